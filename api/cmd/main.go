@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Balyshev/notes-api/internal/blob"
 	"github.com/Balyshev/notes-api/internal/handlers"
-	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/jobs"
+	"github.com/Balyshev/notes-api/internal/loginguard"
+	"github.com/Balyshev/notes-api/internal/mail"
+	"github.com/Balyshev/notes-api/internal/models"
 	"github.com/Balyshev/notes-api/internal/storage"
-	"github.com/go-chi/chi/v5"
-	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/Balyshev/notes-api/pkg/auth"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -22,52 +29,131 @@ func main() {
 		log.Println("Warning: .env file not found")
 	}
 
-	// 2. Подключаемся к БД
-	db, err := initDB()
+	// 2. Создаём storage (postgres по умолчанию, memory — без БД)
+	store, closeStore, err := initStore()
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Close()
+	defer closeStore()
 
-	fmt.Println("✅ Connected to database")
+	// Фоновая очистка корзины
+	retention, err := durationEnv("TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	purgeInterval, err := durationEnv("TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Ключи подписи JWT
+	keys, err := auth.LoadKeySetFromEnv()
+	if err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+	auth.SetKeys(keys)
+	if keys.SigningKey().ID == "ephemeral" {
+		log.Println("Warning: JWT_KEYS and JWT_SECRET are not set, using a random key (tokens will not survive restart)")
+	}
+	fmt.Printf("🔑 Signing JWT with key %q (%s)\n", keys.SigningKey().ID, keys.SigningKey().Method.Alg())
+
+	// Время жизни токенов
+	if auth.AccessTokenTTL, err = durationEnv("ACCESS_TOKEN_TTL", auth.AccessTokenTTL); err != nil {
+		log.Fatal(err)
+	}
+	if auth.RefreshTokenTTL, err = durationEnv("REFRESH_TOKEN_TTL", auth.RefreshTokenTTL); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go jobs.NewTrashPurger(store, retention, purgeInterval).Run(ctx)
 
-	// 3. Создаём storage
-	store := storage.New(db)
+	if auth.PasswordResetTTL, err = durationEnv("PASSWORD_RESET_TTL", auth.PasswordResetTTL); err != nil {
+		log.Fatal(err)
+	}
+
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		auth.TwoFactorIssuer = issuer
+	}
 
-	// 4. Создаём handlers
-	authHandler := handlers.NewAuthHandler(store)
-	userHandler := handlers.NewUserHandler(store)
-	noteHandler := handlers.NewNoteHandler(store)
+	mailer, err := initMailer()
+	if err != nil {
+		log.Fatal("Failed to init mailer:", err)
+	}
 
-	// 5. Настраиваем роутер
-	r := chi.NewRouter()
+	guardConfig, err := loginGuardConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	guard := loginguard.New(store, guardConfig)
 
-	// Middleware (применяются ко всем роутам)
-	r.Use(chimiddleware.Logger)
-	r.Use(chimiddleware.Recoverer)
+	// Rate limiting: корзины в памяти процесса или в PostgreSQL (общие для всех экземпляров)
+	limits, limitPolicies, err := initRateLimits(store)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go jobs.NewBucketSweeper(limits, maxPeriod(limitPolicies), 10*time.Minute).Run(ctx)
 
-	// Serve static files
-	fs := http.FileServer(http.Dir("./static"))
-	r.Handle("/*", http.StripPrefix("/", fs))
+	// Вложения: содержимое в blob хранилище, метаданные в основном
+	blobs, err := initBlobStore()
+	if err != nil {
+		log.Fatal("Failed to init blob store:", err)
+	}
+	maxAttachmentMB, err := positiveIntEnv("ATTACHMENT_MAX_SIZE_MB", 25)
+	if err != nil {
+		log.Fatal(err)
+	}
+	attachmentQuotaMB, err := intEnv("ATTACHMENT_QUOTA_MB", 1024)
+	if err != nil {
+		log.Fatal(err)
+	}
+	blobSweepInterval, err := durationEnv("BLOB_SWEEP_INTERVAL", 10*time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go jobs.NewBlobSweeper(store, blobs, blobSweepInterval).Run(ctx)
 
-	// Публичные роуты (без авторизации)
-	r.Post("/auth/register", authHandler.Register)
-	r.Post("/auth/login", authHandler.Login)
-	r.Post("/users", userHandler.CreateUser) // Deprecated, использовать /auth/register
+	// Миниатюры изображений и удаление координат из EXIF
+	thumbnailSize, err := positiveIntEnv("THUMBNAIL_SIZE", 256)
+	if err != nil {
+		log.Fatal(err)
+	}
+	thumbnailInterval, err := durationEnv("THUMBNAIL_INTERVAL", 5*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go jobs.NewThumbnailer(store, blobs, thumbnailSize, thumbnailInterval).Run(ctx)
 
-	// Защищённые роуты (требуют JWT токен)
-	r.Group(func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware) // Применяем JWT middleware
+	// Импорт архивов markdown заметок (архив ждёт обработки в blob хранилище)
+	maxImportMB, err := positiveIntEnv("IMPORT_MAX_SIZE_MB", 100)
+	if err != nil {
+		log.Fatal(err)
+	}
+	importInterval, err := durationEnv("IMPORT_INTERVAL", 5*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go jobs.NewImporter(store, blobs, importInterval).Run(ctx)
 
-		// Роуты для заметок
-		r.Post("/users/{id}/notes", noteHandler.CreateNote)
-		r.Get("/users/{id}/notes", noteHandler.GetUserNotes)
-		r.Get("/users/{id}/notes/{note_id}", noteHandler.GetNote)
-		r.Put("/users/{id}/notes/{note_id}", noteHandler.UpdateNote)
-		r.Delete("/users/{id}/notes/{note_id}", noteHandler.DeleteNote)
+	// 3. Создаём handlers и роутер
+	r := handlers.NewRouter(handlers.RouterConfig{
+		Store:             store,
+		Mailer:            mailer,
+		Guard:             guard,
+		Keys:              keys,
+		RateLimits:        limits,
+		RateLimitPolicies: limitPolicies,
+		Blobs:             blobs,
+		MaxAttachmentSize: int64(maxAttachmentMB) << 20,
+		AttachmentQuota:   int64(attachmentQuotaMB) << 20,
+		MaxImportSize:     int64(maxImportMB) << 20,
+		TrustProxyHeaders: os.Getenv("TRUST_PROXY_HEADERS") == "true",
+		AdminUsernames:    strings.Split(os.Getenv("ADMIN_USERNAMES"), ","),
+		StaticDir:         "./static",
 	})
 
-	// 6. Запускаем сервер
+	// 4. Запускаем сервер
 	port := os.Getenv("SERVER_PORT")
 	if port == "" {
 		port = "8080"
@@ -77,18 +163,252 @@ func main() {
 	fmt.Println("📝 Public endpoints:")
 	fmt.Println("   POST /auth/register - Register new user")
 	fmt.Println("   POST /auth/login - Login")
+	fmt.Println("   POST /auth/refresh - Rotate refresh token")
+	fmt.Println("   POST /auth/2fa/verify - Second login step with TOTP code")
+	fmt.Println("   POST /auth/password/forgot - Request password reset email")
+	fmt.Println("   POST /auth/password/reset - Set new password with reset token")
+	fmt.Println("   GET  /.well-known/jwks.json - Public JWT signing keys")
+	fmt.Println("   GET  /s/{token} - Public read-only note link")
 	fmt.Println("🔒 Protected endpoints (require JWT token):")
+	fmt.Println("   POST   /auth/logout")
+	fmt.Println("   POST   /auth/logout-all")
+	fmt.Println("   POST   /users/{id}/password")
+	fmt.Println("   DELETE /users/{id}")
+	fmt.Println("   POST   /users/{id}/2fa/setup")
+	fmt.Println("   POST   /users/{id}/2fa/confirm")
+	fmt.Println("   DELETE /users/{id}/2fa")
+	fmt.Println("   POST   /users/{id}/tokens")
+	fmt.Println("   GET    /users/{id}/tokens")
+	fmt.Println("   DELETE /users/{id}/tokens/{token_id}")
 	fmt.Println("   POST   /users/{id}/notes")
 	fmt.Println("   GET    /users/{id}/notes")
+	fmt.Println("   GET    /users/{id}/notes/search?q=...")
 	fmt.Println("   GET    /users/{id}/notes/{note_id}")
 	fmt.Println("   PUT    /users/{id}/notes/{note_id}")
+	fmt.Println("   PATCH  /users/{id}/notes/{note_id}")
 	fmt.Println("   DELETE /users/{id}/notes/{note_id}")
+	fmt.Println("   POST   /users/{id}/notes/{note_id}/move")
+	fmt.Println("   POST   /users/{id}/notebooks")
+	fmt.Println("   GET    /users/{id}/notebooks")
+	fmt.Println("   GET    /users/{id}/notebooks/{notebook_id}")
+	fmt.Println("   PUT    /users/{id}/notebooks/{notebook_id}")
+	fmt.Println("   POST   /users/{id}/notebooks/{notebook_id}/move")
+	fmt.Println("   DELETE /users/{id}/notebooks/{notebook_id}?cascade=true")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/revisions")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/revisions/diff?from=&to=")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/revisions/{revision}")
+	fmt.Println("   POST   /users/{id}/notes/{note_id}/revisions/{revision}/restore")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/outgoing-links")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/backlinks")
+	fmt.Println("   GET    /users/{id}/unresolved-links")
+	fmt.Println("   GET    /users/{id}/graph")
+	fmt.Println("   POST   /users/{id}/notes/{note_id}/shares")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/shares")
+	fmt.Println("   DELETE /users/{id}/notes/{note_id}/shares/{user_id}")
+	fmt.Println("   GET    /shared-with-me")
+	fmt.Println("   POST   /users/{id}/notes/{note_id}/links")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/links")
+	fmt.Println("   DELETE /users/{id}/notes/{note_id}/links/{link_id}")
+	fmt.Println("   POST   /users/{id}/notes/{note_id}/attachments (multipart, field \"file\")")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/attachments")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/attachments/{attachment_id}")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/attachments/{attachment_id}/download")
+	fmt.Println("   GET    /users/{id}/notes/{note_id}/attachments/{attachment_id}/thumbnail")
+	fmt.Println("   DELETE /users/{id}/notes/{note_id}/attachments/{attachment_id}")
+	fmt.Println("   GET    /users/{id}/attachments/usage")
+	fmt.Println("   POST   /users/{id}/imports (multipart zip, field \"file\")")
+	fmt.Println("   GET    /users/{id}/imports")
+	fmt.Println("   GET    /users/{id}/imports/{import_id}")
+	fmt.Println("   GET    /users/{id}/trash")
+	fmt.Println("   POST   /users/{id}/trash/{note_id}/restore")
+	fmt.Println("   DELETE /users/{id}/trash/{note_id}")
+	fmt.Println("   GET    /users/{id}/tags")
+	fmt.Println("   GET    /admin/lockouts (admins only)")
 
 	if err := http.ListenAndServe(":"+port, r); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
 
+// initStore выбирает хранилище по переменной STORAGE_BACKEND
+func initStore() (storage.Store, func(), error) {
+	switch os.Getenv("STORAGE_BACKEND") {
+	case "memory":
+		fmt.Println("✅ Using in-memory storage")
+		return storage.NewMemory(), func() {}, nil
+	case "", "postgres":
+		db, err := initDB()
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Println("✅ Connected to database")
+		timeout, err := durationEnv("DB_QUERY_TIMEOUT", 5*time.Second)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return storage.New(db, timeout), func() { db.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown STORAGE_BACKEND %q", os.Getenv("STORAGE_BACKEND"))
+	}
+}
+
+// rateLimitDefaults - лимиты групп роутов, переопределяются RATE_LIMIT_<ГРУППА>
+var rateLimitDefaults = map[string]string{
+	"auth":   "20/1m",
+	"api":    "300/1m",
+	"search": "30/1m",
+}
+
+// initRateLimits выбирает хранилище лимитов (RATE_LIMIT_BACKEND) и читает политики групп.
+// По умолчанию корзины в памяти: PostgreSQL добавляет к каждому запросу транзакцию с блокировкой строки
+func initRateLimits(store storage.Store) (storage.RateLimitStore, map[string]*models.RateLimitPolicy, error) {
+	policies := make(map[string]*models.RateLimitPolicy)
+	for group, def := range rateLimitDefaults {
+		spec := os.Getenv("RATE_LIMIT_" + strings.ToUpper(group))
+		if spec == "" {
+			spec = def
+		}
+		policy, err := models.ParseRateLimitPolicy(group, spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		policies[group] = policy
+	}
+
+	switch os.Getenv("RATE_LIMIT_BACKEND") {
+	case "", "memory":
+		return storage.NewMemoryRateLimits(), policies, nil
+	case "postgres":
+		if _, ok := store.(*storage.Storage); !ok {
+			return nil, nil, fmt.Errorf("RATE_LIMIT_BACKEND=postgres requires STORAGE_BACKEND=postgres")
+		}
+		return store, policies, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown RATE_LIMIT_BACKEND %q", os.Getenv("RATE_LIMIT_BACKEND"))
+	}
+}
+
+// maxPeriod возвращает, за сколько наполняется самая медленная корзина (не меньше минуты)
+func maxPeriod(policies map[string]*models.RateLimitPolicy) time.Duration {
+	longest := time.Minute
+	for _, p := range policies {
+		if p == nil {
+			continue
+		}
+		if refill := p.Period * time.Duration(p.Burst) / time.Duration(p.Limit); refill > longest {
+			longest = refill
+		}
+	}
+	return longest
+}
+
+// loginGuardConfig читает настройки защиты от подбора пароля
+func loginGuardConfig() (loginguard.Config, error) {
+	config := loginguard.DefaultConfig
+
+	var err error
+	if config.User.FreeAttempts, err = intEnv("LOGIN_MAX_ATTEMPTS", config.User.FreeAttempts); err != nil {
+		return config, err
+	}
+	if config.IP.FreeAttempts, err = intEnv("LOGIN_IP_MAX_ATTEMPTS", config.IP.FreeAttempts); err != nil {
+		return config, err
+	}
+	if config.Window, err = durationEnv("LOGIN_ATTEMPT_WINDOW", config.Window); err != nil {
+		return config, err
+	}
+	maxLockout, err := durationEnv("LOGIN_MAX_LOCKOUT", config.User.MaxDelay)
+	if err != nil {
+		return config, err
+	}
+	config.User.MaxDelay = maxLockout
+	config.IP.MaxDelay = maxLockout
+
+	return config, nil
+}
+
+// intEnv читает целое число из переменной окружения
+func intEnv(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return n, nil
+}
+
+// positiveIntEnv читает целое число больше нуля: для размеров, где 0 не имеет смысла
+func positiveIntEnv(name string, def int) (int, error) {
+	n, err := intEnv(name, def)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("invalid %s: %q must be positive", name, os.Getenv(name))
+	}
+	return n, nil
+}
+
+// initBlobStore выбирает хранилище вложений по переменной BLOB_STORE
+func initBlobStore() (blob.Store, error) {
+	switch os.Getenv("BLOB_STORE") {
+	case "", "local":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "./data/attachments"
+		}
+		fmt.Println("✅ Storing attachments in", dir)
+		return blob.NewLocalStore(dir)
+	case "s3":
+		fmt.Println("✅ Storing attachments in S3 bucket", os.Getenv("S3_BUCKET"))
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", os.Getenv("BLOB_STORE"))
+	}
+}
+
+// initMailer выбирает способ доставки писем по переменной MAILER
+func initMailer() (mail.Mailer, error) {
+	switch os.Getenv("MAILER") {
+	case "", "log":
+		return mail.NewLogMailer(), nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "./mail"
+		}
+		return mail.NewFileMailer(dir)
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", os.Getenv("MAILER"))
+	}
+}
+
+// durationEnv читает длительность из переменной окружения (например "5s", "720h").
+// Длительность должна быть положительной: интервалы фоновых задач идут в time.NewTicker
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q must be positive", name, value)
+	}
+	return d, nil
+}
+
 // initDB инициализирует подключение к БД
 func initDB() (*sql.DB, error) {
 	connStr := fmt.Sprintf(
@@ -0,0 +1,90 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound - объекта с таким ключом нет
+var ErrNotFound = errors.New("blob not found")
+
+// Store хранит содержимое вложений по ключу.
+// Реализации: LocalStore (каталог на диске) и S3Store (S3-совместимое хранилище, например MinIO)
+type Store interface {
+	// Put сохраняет size байт из r под ключом key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get читает length байт начиная с offset (length < 0 — до конца объекта)
+	Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Delete удаляет объект; отсутствующий объект ошибкой не считается
+	Delete(ctx context.Context, key string) error
+}
+
+// Reader - io.ReadSeekCloser поверх Store для http.ServeContent.
+// Объект читается лениво: после каждого Seek открывается новый запрос с нужного смещения,
+// поэтому Range запросы не скачивают объект целиком
+type Reader struct {
+	ctx   context.Context
+	store Store
+	key   string
+	size  int64
+
+	offset int64
+	body   io.ReadCloser
+}
+
+// NewReader создаёт Reader для объекта key размером size
+func NewReader(ctx context.Context, store Store, key string, size int64) *Reader {
+	return &Reader{ctx: ctx, store: store, key: key, size: size}
+}
+
+// Read читает с текущего смещения
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.store.Get(r.ctx, r.key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// Seek меняет смещение; открытый запрос закрывается, если смещение изменилось
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.offset + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("blob: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("blob: negative position")
+	}
+	if abs != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = abs
+	return abs, nil
+}
+
+// Close закрывает открытый запрос
+func (r *Reader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
@@ -0,0 +1,79 @@
+package blob
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+
+	if err := store.Put(ctx, "1/abc", strings.NewReader("hello world"), 11, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "1/short", strings.NewReader("hello"), 11, "text/plain"); err == nil {
+		t.Error("Put accepted fewer bytes than size")
+	}
+
+	tests := []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, -1, "hello world"},
+		{6, -1, "world"},
+		{0, 5, "hello"},
+		{4, 3, "o w"},
+	}
+	for _, tt := range tests {
+		r, err := store.Get(ctx, "1/abc", tt.offset, tt.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		if string(data) != tt.want {
+			t.Errorf("Get(%d, %d) = %q, want %q", tt.offset, tt.length, data, tt.want)
+		}
+	}
+
+	for _, key := range []string{"", "/", "../escape", "1/../../escape"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("Put accepted key %q", key)
+		}
+	}
+
+	if err := store.Delete(ctx, "1/abc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "1/abc", 0, -1); err != ErrNotFound {
+		t.Errorf("Get after Delete: %v", err)
+	}
+	if err := store.Delete(ctx, "1/abc"); err != nil {
+		t.Errorf("Delete of a missing blob: %v", err)
+	}
+}
+
+func TestReaderSeek(t *testing.T) {
+	store, _ := NewLocalStore(t.TempDir())
+	if err := store.Put(t.Context(), "k", strings.NewReader("0123456789"), 10, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(t.Context(), store, "k", 10)
+	defer r.Close()
+	if end, err := r.Seek(0, io.SeekEnd); err != nil || end != 10 {
+		t.Fatalf("Seek end: %d, %v", end, err)
+	}
+	if _, err := r.Seek(7, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	if string(data) != "789" {
+		t.Errorf("read after seek: %q", data)
+	}
+}
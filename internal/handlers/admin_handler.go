@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+// AdminHandler обрабатывает запросы к /admin
+type AdminHandler struct {
+	storage storage.LoginAttemptStore
+}
+
+// NewAdminHandler создаёт новый AdminHandler
+func NewAdminHandler(storage storage.LoginAttemptStore) *AdminHandler {
+	return &AdminHandler{
+		storage: storage,
+	}
+}
+
+// GetLockouts обрабатывает GET /admin/lockouts — журнал блокировок входа
+func (h *AdminHandler) GetLockouts(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetLockouts called ===")
+
+	limit := 50
+	offset := 0
+
+	var err error
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 500 {
+			respondError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid offset parameter")
+			return
+		}
+	}
+
+	events, err := h.storage.GetLockoutEvents(r.Context(), limit, offset)
+	if err != nil {
+		fmt.Println("ERROR: GetLockoutEvents failed:", err)
+		respondStorageError(w, err, "Failed to get lockout events")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, events)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestGetLockouts(t *testing.T) {
+	s := newTestServer(t, func(cfg *RouterConfig) { cfg.AdminUsernames = []string{"admin"} })
+	admin := s.register("admin")
+	alice := s.register("alice")
+
+	for i := 0; i < 6; i++ {
+		s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "wrong-password"})
+	}
+
+	if rec := s.do("GET", "/admin/lockouts", alice.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("not an admin: %d", rec.Code)
+	}
+	// "Admin" — другой аккаунт, не тот, что указан в списке
+	if rec := s.do("GET", "/admin/lockouts", s.register("Admin").Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("admin name in another case: %d", rec.Code)
+	}
+	if rec := s.do("GET", "/admin/lockouts?limit=0", admin.Token, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid limit: %d", rec.Code)
+	}
+
+	rec := s.do("GET", "/admin/lockouts", admin.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("lockouts: %d %s", rec.Code, rec.Body)
+	}
+	var events []models.LockoutEvent
+	decode(t, rec, &events)
+	if len(events) != 1 || events[0].Username != "alice" || events[0].Failures != 6 {
+		t.Errorf("lockouts: %+v", events)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// APITokenHandler обрабатывает запросы к /users/{id}/tokens
+type APITokenHandler struct {
+	storage storage.APITokenStore
+}
+
+// NewAPITokenHandler создаёт новый APITokenHandler
+func NewAPITokenHandler(storage storage.APITokenStore) *APITokenHandler {
+	return &APITokenHandler{
+		storage: storage,
+	}
+}
+
+// CreateToken обрабатывает POST /users/{id}/tokens
+func (h *APITokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== CreateToken called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, tokenHash, err := auth.GenerateAPIToken()
+	if err != nil {
+		fmt.Println("ERROR: Failed to generate api token:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to create token")
+		return
+	}
+
+	created, err := h.storage.CreateAPIToken(r.Context(), userID, req.Name, tokenHash, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		fmt.Println("ERROR: CreateAPIToken failed:", err)
+		respondStorageError(w, err, "Failed to create token")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.CreateAPITokenResponse{
+		Token:    token,
+		APIToken: created,
+	})
+}
+
+// GetTokens обрабатывает GET /users/{id}/tokens
+func (h *APITokenHandler) GetTokens(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetTokens called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.storage.GetUserAPITokens(r.Context(), userID)
+	if err != nil {
+		fmt.Println("ERROR: GetUserAPITokens failed:", err)
+		respondStorageError(w, err, "Failed to get tokens")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+// DeleteToken обрабатывает DELETE /users/{id}/tokens/{token_id}
+func (h *APITokenHandler) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== DeleteToken called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	tokenID, err := strconv.Atoi(chi.URLParam(r, "token_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	if err := h.storage.DeleteAPIToken(r.Context(), userID, tokenID); err != nil {
+		if err == models.ErrAPITokenNotFound {
+			respondError(w, http.StatusNotFound, "Token not found")
+			return
+		}
+		fmt.Println("ERROR: DeleteAPIToken failed:", err)
+		respondStorageError(w, err, "Failed to revoke token")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizedUser проверяет, что пользователь управляет своими токенами
+func (h *APITokenHandler) authorizedUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return 0, false
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only manage your own tokens")
+		return 0, false
+	}
+
+	return authenticatedUserID, true
+}
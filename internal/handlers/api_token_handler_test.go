@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestAPITokens(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	note := s.createNote(alice, "Title", "content")
+
+	tests := []struct {
+		name   string
+		body   interface{}
+		status int
+	}{
+		{"no name", models.CreateAPITokenRequest{Scopes: []string{models.ScopeNotesRead}}, http.StatusBadRequest},
+		{"no scopes", models.CreateAPITokenRequest{Name: "ci"}, http.StatusBadRequest},
+		{"unknown scope", models.CreateAPITokenRequest{Name: "ci", Scopes: []string{"admin"}}, http.StatusBadRequest},
+		{"expiry in the past", `{"name":"ci","scopes":["notes:read"],"expires_at":"2000-01-01T00:00:00Z"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := s.do("POST", userPath(alice, "/tokens"), alice.Token, tt.body); rec.Code != tt.status {
+			t.Errorf("%s: %d %s", tt.name, rec.Code, rec.Body)
+		}
+	}
+
+	rec := s.do("POST", userPath(alice, "/tokens"), alice.Token, models.CreateAPITokenRequest{
+		Name:   "reader",
+		Scopes: []string{models.ScopeNotesRead, models.ScopeNotesRead},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create token: %d %s", rec.Code, rec.Body)
+	}
+	var created models.CreateAPITokenResponse
+	decode(t, rec, &created)
+	if len(created.Token) < 10 || len(created.Scopes) != 1 {
+		t.Fatalf("created token: %+v", created)
+	}
+	reader := created.Token
+
+	access := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"read notes", "GET", userPath(alice, "/notes"), http.StatusOK},
+		{"read note", "GET", notePath(alice, note, ""), http.StatusOK},
+		{"create note", "POST", userPath(alice, "/notes"), http.StatusForbidden},
+		{"delete note", "DELETE", notePath(alice, note, ""), http.StatusForbidden},
+		{"list tokens", "GET", userPath(alice, "/tokens"), http.StatusForbidden},
+		{"logout", "POST", "/auth/logout", http.StatusForbidden},
+	}
+	for _, tt := range access {
+		if rec := s.do(tt.method, tt.path, reader, `{"title":"T","content":"c"}`); rec.Code != tt.status {
+			t.Errorf("%s with read token: %d, want %d", tt.name, rec.Code, tt.status)
+		}
+	}
+
+	var tokens []models.APIToken
+	decode(t, s.do("GET", userPath(alice, "/tokens"), alice.Token, nil), &tokens)
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Errorf("tokens: %+v", tokens)
+	}
+
+	if rec := s.do("DELETE", userPath(alice, "/tokens/"+strconv.Itoa(created.ID)), alice.Token, nil); rec.Code != http.StatusOK && rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("GET", userPath(alice, "/notes"), reader, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: %d", rec.Code)
+	}
+
+	bob := s.register("bob")
+	expires := time.Now().Add(time.Hour)
+	rec = s.do("POST", userPath(bob, "/tokens"), bob.Token, models.CreateAPITokenRequest{
+		Name: "all", Scopes: models.Scopes, ExpiresAt: &expires,
+	})
+	decode(t, rec, &created)
+	if rec := s.do("GET", userPath(alice, "/notes"), created.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("bob's token on alice's notes: %d", rec.Code)
+	}
+	if rec := s.do("DELETE", userPath(bob, "/tokens/"+strconv.Itoa(created.ID)), alice.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("alice revokes bob's token: %d", rec.Code)
+	}
+}
@@ -0,0 +1,492 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Balyshev/notes-api/internal/blob"
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// multipartOverhead - запас на заголовки multipart сверх размера файла
+	multipartOverhead = 64 << 10 // 64 KB
+
+	maxFilenameLength = 255
+)
+
+// inlineContentTypes - типы, которые можно открыть в браузере (?inline=true), а не только скачать
+var inlineContentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// AttachmentHandler обрабатывает запросы к /users/{id}/notes/{note_id}/attachments
+type AttachmentHandler struct {
+	storage storage.NoteAttachmentStore
+	blobs   blob.Store
+	maxSize int64 // максимальный размер одного файла
+	quota   int64 // место на пользователя, 0 — без ограничения
+}
+
+// NewAttachmentHandler создаёт новый AttachmentHandler
+func NewAttachmentHandler(storage storage.NoteAttachmentStore, blobs blob.Store, maxSize, quota int64) *AttachmentHandler {
+	return &AttachmentHandler{
+		storage: storage,
+		blobs:   blobs,
+		maxSize: maxSize,
+		quota:   quota,
+	}
+}
+
+// UploadAttachment обрабатывает POST /users/{id}/notes/{note_id}/attachments
+// (multipart/form-data, файл в поле "file"). Место учитывается в квоте владельца заметки
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== UploadAttachment called ===")
+
+	note, ok := h.attachmentNote(w, r, true)
+	if !ok {
+		return
+	}
+
+	limit := h.maxSize
+	quotaLimited := false
+	if h.quota > 0 {
+		usage, err := h.storage.GetAttachmentUsage(r.Context(), note.UserID)
+		if err != nil {
+			fmt.Println("ERROR: GetAttachmentUsage failed:", err)
+			respondStorageError(w, err, "Failed to upload attachment")
+			return
+		}
+		if remaining := h.quota - usage.Used; remaining < limit {
+			limit = remaining
+			quotaLimited = true
+		}
+		if limit <= 0 {
+			respondError(w, http.StatusRequestEntityTooLarge, models.ErrQuotaExceeded.Error())
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, limit+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Content-Type must be multipart/form-data")
+		return
+	}
+
+	var part io.ReadCloser
+	var filename string
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			h.respondUploadError(w, err, quotaLimited)
+			return
+		}
+		if p.FormName() == "file" {
+			part = p
+			filename = p.FileName()
+			break
+		}
+		p.Close()
+	}
+	if part == nil {
+		respondError(w, http.StatusBadRequest, models.ErrFileRequired.Error())
+		return
+	}
+	defer part.Close()
+
+	// Файл сначала пишется во временный файл: нужны размер и sha256 до загрузки в хранилище
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		fmt.Println("ERROR: Failed to create temp file:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to upload attachment")
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(part, limit+1))
+	if err != nil {
+		h.respondUploadError(w, err, quotaLimited)
+		return
+	}
+	if size > limit {
+		h.respondUploadError(w, models.ErrAttachmentTooLarge, quotaLimited)
+		return
+	}
+
+	contentType, err := detectContentType(tmp, filename)
+	if err != nil {
+		fmt.Println("ERROR: Failed to detect content type:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to upload attachment")
+		return
+	}
+
+	key, err := newBlobKey(note.UserID)
+	if err != nil {
+		fmt.Println("ERROR: Failed to generate blob key:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to upload attachment")
+		return
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		fmt.Println("ERROR: Failed to rewind temp file:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to upload attachment")
+		return
+	}
+	if err := h.blobs.Put(r.Context(), key, tmp, size, contentType); err != nil {
+		fmt.Println("ERROR: Blob Put failed:", err)
+		respondStorageError(w, err, "Failed to store attachment")
+		return
+	}
+
+	imageStatus := ""
+	if models.IsThumbnailable(baseMediaType(contentType)) {
+		imageStatus = models.ImageStatusPending // миниатюру построит фоновая задача
+	}
+
+	attachment, err := h.storage.CreateAttachment(r.Context(), &models.Attachment{
+		NoteID:      note.ID,
+		UserID:      note.UserID,
+		Filename:    sanitizeFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+		ImageStatus: imageStatus,
+	}, h.quota)
+	if err != nil {
+		// Метаданные не сохранились — blob никому не нужен
+		if deleteErr := h.blobs.Delete(context.WithoutCancel(r.Context()), key); deleteErr != nil {
+			fmt.Println("ERROR: Failed to delete blob", key+":", deleteErr)
+		}
+		switch err {
+		case models.ErrQuotaExceeded:
+			respondError(w, http.StatusRequestEntityTooLarge, err.Error())
+		case models.ErrNoteNotFound:
+			respondError(w, http.StatusNotFound, "Note not found")
+		default:
+			fmt.Println("ERROR: CreateAttachment failed:", err)
+			respondStorageError(w, err, "Failed to upload attachment")
+		}
+		return
+	}
+
+	fmt.Printf("Attachment Uploaded: %+v\n", attachment)
+	respondJSON(w, http.StatusCreated, attachment)
+}
+
+// GetAttachments обрабатывает GET /users/{id}/notes/{note_id}/attachments
+func (h *AttachmentHandler) GetAttachments(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetAttachments called ===")
+
+	note, ok := h.attachmentNote(w, r, false)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.storage.GetNoteAttachments(r.Context(), note.ID)
+	if err != nil {
+		fmt.Println("ERROR: GetNoteAttachments failed:", err)
+		respondStorageError(w, err, "Failed to get attachments")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, attachments)
+}
+
+// GetAttachment обрабатывает GET /users/{id}/notes/{note_id}/attachments/{attachment_id} — метаданные
+func (h *AttachmentHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetAttachment called ===")
+
+	attachment, ok := h.attachment(w, r, false)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, attachment)
+}
+
+// DownloadAttachment обрабатывает GET /users/{id}/notes/{note_id}/attachments/{attachment_id}/download.
+// Поддерживает Range и условные запросы (ETag — sha256 содержимого).
+// По умолчанию отдаётся как скачиваемый файл, ?inline=true открывает безопасные типы в браузере
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== DownloadAttachment called ===")
+
+	attachment, ok := h.attachment(w, r, false)
+	if !ok {
+		return
+	}
+
+	disposition := "attachment"
+	if r.URL.Query().Get("inline") == "true" && inlineContentTypes[baseMediaType(attachment.ContentType)] {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("ETag", `"`+attachment.SHA256+`"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox; default-src 'none'")
+
+	content := blob.NewReader(r.Context(), h.blobs, attachment.StorageKey, attachment.Size)
+	defer content.Close()
+
+	http.ServeContent(w, r, "", attachment.CreatedAt, content)
+}
+
+// GetThumbnail обрабатывает GET /users/{id}/notes/{note_id}/attachments/{attachment_id}/thumbnail.
+// Пока изображение обрабатывается — 404 с Retry-After
+func (h *AttachmentHandler) GetThumbnail(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetThumbnail called ===")
+
+	attachment, ok := h.attachment(w, r, false)
+	if !ok {
+		return
+	}
+
+	if !attachment.HasThumbnail() {
+		if attachment.ImageStatus == models.ImageStatusPending || attachment.ImageStatus == models.ImageStatusProcessing {
+			w.Header().Set("Retry-After", "5")
+			respondError(w, http.StatusNotFound, "Thumbnail is not ready yet")
+			return
+		}
+		respondError(w, http.StatusNotFound, "Attachment has no thumbnail")
+		return
+	}
+
+	w.Header().Set("Content-Type", attachment.ThumbnailContentType)
+	w.Header().Set("ETag", `"thumb-`+attachment.SHA256+`"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox; default-src 'none'")
+
+	content := blob.NewReader(r.Context(), h.blobs, attachment.ThumbnailKey, attachment.ThumbnailSize)
+	defer content.Close()
+
+	http.ServeContent(w, r, "", attachment.CreatedAt, content)
+}
+
+// DeleteAttachment обрабатывает DELETE /users/{id}/notes/{note_id}/attachments/{attachment_id}.
+// Сам файл стирает фоновая задача
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== DeleteAttachment called ===")
+
+	attachment, ok := h.attachment(w, r, true)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.DeleteAttachment(r.Context(), attachment.NoteID, attachment.ID); err != nil {
+		if err == models.ErrAttachmentNotFound {
+			respondError(w, http.StatusNotFound, "Attachment not found")
+			return
+		}
+		fmt.Println("ERROR: DeleteAttachment failed:", err)
+		respondStorageError(w, err, "Failed to delete attachment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUsage обрабатывает GET /users/{id}/attachments/usage
+func (h *AttachmentHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetAttachmentUsage called ===")
+
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only view your own usage")
+		return
+	}
+
+	usage, err := h.storage.GetAttachmentUsage(r.Context(), userIDFromURL)
+	if err != nil {
+		fmt.Println("ERROR: GetAttachmentUsage failed:", err)
+		respondStorageError(w, err, "Failed to get usage")
+		return
+	}
+	usage.Quota = h.quota
+
+	respondJSON(w, http.StatusOK, usage)
+}
+
+// attachmentNote находит заметку {note_id} и проверяет доступ к её вложениям:
+// просмотр — любой, кому доступна заметка, изменение (edit) — владелец и editor.
+// При ошибке сам отправляет ответ и возвращает false
+func (h *AttachmentHandler) attachmentNote(w http.ResponseWriter, r *http.Request, edit bool) (*models.Note, bool) {
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return nil, false
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid note ID")
+		return nil, false
+	}
+
+	note, err := h.storage.GetNoteByID(r.Context(), noteID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found")
+			return nil, false
+		}
+		respondStorageError(w, err, "Failed to get note")
+		return nil, false
+	}
+
+	permission, ok := notePermission(w, r, h.storage, note, authenticatedUserID, userIDFromURL)
+	if !ok {
+		return nil, false
+	}
+
+	if edit && !models.CanEdit(permission) {
+		respondError(w, http.StatusForbidden, "You don't have permission to change attachments of this note")
+		return nil, false
+	}
+
+	return note, true
+}
+
+// attachment находит вложение {attachment_id} заметки {note_id} с проверкой доступа как в attachmentNote
+func (h *AttachmentHandler) attachment(w http.ResponseWriter, r *http.Request, edit bool) (*models.Attachment, bool) {
+	note, ok := h.attachmentNote(w, r, edit)
+	if !ok {
+		return nil, false
+	}
+
+	attachmentID, err := strconv.Atoi(chi.URLParam(r, "attachment_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid attachment ID")
+		return nil, false
+	}
+
+	attachment, err := h.storage.GetAttachment(r.Context(), note.ID, attachmentID)
+	if err != nil {
+		if err == models.ErrAttachmentNotFound {
+			respondError(w, http.StatusNotFound, "Attachment not found")
+			return nil, false
+		}
+		respondStorageError(w, err, "Failed to get attachment")
+		return nil, false
+	}
+
+	return attachment, true
+}
+
+// respondUploadError отвечает на ошибку чтения загружаемого файла.
+// Превышение лимита — 413: из-за размера файла или, если лимит урезан квотой, из-за квоты
+func (h *AttachmentHandler) respondUploadError(w http.ResponseWriter, err error, quotaLimited bool) {
+	var maxBytesErr *http.MaxBytesError
+	if err == models.ErrAttachmentTooLarge || errors.As(err, &maxBytesErr) {
+		if quotaLimited {
+			respondError(w, http.StatusRequestEntityTooLarge, models.ErrQuotaExceeded.Error())
+			return
+		}
+		respondError(w, http.StatusRequestEntityTooLarge, models.ErrAttachmentTooLarge.Error())
+		return
+	}
+	fmt.Println("ERROR: Failed to read upload:", err)
+	respondError(w, http.StatusBadRequest, "Failed to read multipart body")
+}
+
+// detectContentType определяет MIME тип по содержимому, а если оно не распознано — по расширению
+func detectContentType(f *os.File, filename string) (string, error) {
+	head := make([]byte, 512)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	if contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))); byExt != "" {
+			contentType = byExt
+		}
+	}
+	return contentType, nil
+}
+
+// sanitizeFilename оставляет от имени файла только базовое имя без управляющих символов
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+// newBlobKey создаёт случайный ключ blob'а в "каталоге" пользователя
+func newBlobKey(userID int) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%s", userID, hex.EncodeToString(b)), nil
+}
+
+func baseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
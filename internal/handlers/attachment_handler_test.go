@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/blob"
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// withBlobs подключает к тестовому серверу LocalStore во временном каталоге
+func withBlobs(t *testing.T, maxSize, quota int64) func(*RouterConfig) {
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(cfg *RouterConfig) {
+		cfg.Blobs = blobs
+		cfg.MaxAttachmentSize = maxSize
+		cfg.AttachmentQuota = quota
+	}
+}
+
+// multipartFile собирает multipart/form-data с файлом в поле "file", возвращает тело и Content-Type
+func multipartFile(t *testing.T, filename string, content []byte) (string, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	mw.Close()
+	return body.String(), mw.FormDataContentType()
+}
+
+// upload загружает файл к заметке
+func (s *testServer) upload(user *models.LoginResponse, note *models.Note, filename string, content []byte) *httptest.ResponseRecorder {
+	s.t.Helper()
+	body, contentType := multipartFile(s.t, filename, content)
+	return s.do("POST", notePath(user, note, "/attachments"), user.Token, body, "Content-Type", contentType)
+}
+
+func TestAttachments(t *testing.T) {
+	s := newTestServer(t, withBlobs(t, 1<<10, 1<<20))
+	alice := s.register("alice")
+	bob := s.register("bob")
+	note := s.createNote(alice, "Title", "content")
+
+	rec := s.upload(alice, note, "../report.txt", []byte("hello attachment"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: %d %s", rec.Code, rec.Body)
+	}
+	var attachment models.Attachment
+	decode(t, rec, &attachment)
+	if attachment.Filename != "report.txt" || attachment.Size != 16 || attachment.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("attachment: %+v", attachment)
+	}
+	attachmentPath := notePath(alice, note, "/attachments/"+strconv.Itoa(attachment.ID))
+
+	rec = s.do("GET", attachmentPath+"/download", alice.Token, nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello attachment" {
+		t.Fatalf("download: %d %q", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("ETag") != `"`+attachment.SHA256+`"` || rec.Header().Get("Content-Disposition") != `attachment; filename=report.txt` {
+		t.Errorf("download headers: %v", rec.Header())
+	}
+
+	rec = s.do("GET", attachmentPath+"/download", alice.Token, nil, "Range", "bytes=6-9")
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "atta" {
+		t.Errorf("range download: %d %q", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		name   string
+		user   *models.LoginResponse
+		method string
+		path   string
+		status int
+	}{
+		{"list", alice, "GET", notePath(alice, note, "/attachments"), http.StatusOK},
+		{"metadata", alice, "GET", attachmentPath, http.StatusOK},
+		{"other user lists", bob, "GET", notePath(alice, note, "/attachments"), http.StatusForbidden},
+		{"other user downloads", bob, "GET", attachmentPath + "/download", http.StatusForbidden},
+		{"other user deletes", bob, "DELETE", attachmentPath, http.StatusForbidden},
+		{"unknown attachment", alice, "GET", notePath(alice, note, "/attachments/999"), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := s.do(tt.method, tt.path, tt.user.Token, nil); rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body)
+		}
+	}
+
+	rec = s.do("GET", userPath(alice, "/attachments/usage"), alice.Token, nil)
+	var usage models.AttachmentUsage
+	decode(t, rec, &usage)
+	if usage.Used != 16 || usage.Count != 1 || usage.Quota != 1<<20 {
+		t.Errorf("usage: %+v", usage)
+	}
+
+	if rec := s.do("DELETE", attachmentPath, alice.Token, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("GET", attachmentPath, alice.Token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("deleted attachment: %d", rec.Code)
+	}
+}
+
+func TestAttachmentLimits(t *testing.T) {
+	s := newTestServer(t, withBlobs(t, 100, 150))
+	alice := s.register("alice")
+	note := s.createNote(alice, "Title", "content")
+
+	rec := s.do("POST", notePath(alice, note, "/attachments"), alice.Token, `{"file":"x"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("json upload: %d", rec.Code)
+	}
+
+	tests := []struct {
+		name   string
+		size   int
+		status int
+	}{
+		{"too large", 101, http.StatusRequestEntityTooLarge},
+		{"fits", 100, http.StatusCreated},
+		{"over quota", 60, http.StatusRequestEntityTooLarge},
+		{"fits remaining quota", 50, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		rec := s.upload(alice, note, "file.bin", bytes.Repeat([]byte{0}, tt.size))
+		if rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body)
+		}
+	}
+}
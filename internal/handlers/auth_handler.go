@@ -4,7 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/Balyshev/notes-api/internal/loginguard"
+	"github.com/Balyshev/notes-api/internal/mail"
+	"github.com/Balyshev/notes-api/internal/middleware"
 	"github.com/Balyshev/notes-api/internal/models"
 	"github.com/Balyshev/notes-api/internal/storage"
 	"github.com/Balyshev/notes-api/pkg/auth"
@@ -12,13 +16,17 @@ import (
 
 // AuthHandler обрабатывает авторизацию
 type AuthHandler struct {
-	storage *storage.Storage
+	storage storage.UserAuthStore
+	mailer  mail.Mailer
+	guard   *loginguard.Guard
 }
 
 // NewAuthHandler создаёт новый AuthHandler
-func NewAuthHandler(storage *storage.Storage) *AuthHandler {
+func NewAuthHandler(storage storage.UserAuthStore, mailer mail.Mailer, guard *loginguard.Guard) *AuthHandler {
 	return &AuthHandler{
 		storage: storage,
+		mailer:  mailer,
+		guard:   guard,
 	}
 }
 
@@ -48,31 +56,25 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4. Создаём пользователя
-	user, err := h.storage.CreateUser(req.Username, passwordHash)
+	user, err := h.storage.CreateUser(r.Context(), req.Username, req.Email, passwordHash)
 	if err != nil {
 		if err == models.ErrUsernameExists {
 			respondError(w, http.StatusBadRequest, "Username already exists")
 			return
 		}
 		fmt.Println("ERROR: Failed to create user:", err)
-		respondError(w, http.StatusInternalServerError, "Failed to create user")
+		respondStorageError(w, err, "Failed to create user")
 		return
 	}
 
-	// 5. Генерируем JWT токен
-	token, err := auth.GenerateToken(user.ID, user.Username)
+	// 5. Открываем сессию и выдаём токены
+	response, err := h.startSession(r, user)
 	if err != nil {
-		fmt.Println("ERROR: Failed to generate token:", err)
-		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		fmt.Println("ERROR: Failed to start session:", err)
+		respondStorageError(w, err, "Failed to generate token")
 		return
 	}
 
-	// 6. Возвращаем токен и пользователя
-	response := models.LoginResponse{
-		Token: token,
-		User:  user,
-	}
-
 	fmt.Printf("User registered: %+v\n", user)
 	respondJSON(w, http.StatusCreated, response)
 }
@@ -94,38 +96,208 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 3. Получаем пользователя по username
-	user, err := h.storage.GetUserByUsername(req.Username)
+	// 3. Не даём подбирать пароль: аккаунт или адрес может быть временно заблокирован
+	ip := middleware.ClientIP(r)
+	wait, err := h.guard.Check(r.Context(), req.Username, ip)
 	if err != nil {
-		if err == models.ErrUserNotFound {
-			respondError(w, http.StatusUnauthorized, "Invalid username or password")
-			return
-		}
+		fmt.Println("ERROR: Failed to check login attempts:", err)
+		respondStorageError(w, err, "Failed to login")
+		return
+	}
+	if wait > 0 {
+		respondTooManyRequests(w, wait, "Too many failed login attempts, try again later")
+		return
+	}
+
+	// 4. Получаем пользователя по username
+	user, err := h.storage.GetUserByUsername(r.Context(), req.Username)
+	if err != nil && err != models.ErrUserNotFound {
 		fmt.Println("ERROR: Failed to get user:", err)
-		respondError(w, http.StatusInternalServerError, "Failed to login")
+		respondStorageError(w, err, "Failed to login")
 		return
 	}
 
-	// 4. Проверяем пароль
-	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+	// 5. Проверяем пароль; для несуществующего пользователя — за то же время
+	valid := false
+	if user != nil {
+		valid = auth.CheckPassword(req.Password, user.PasswordHash)
+	} else {
+		auth.CheckDummyPassword(req.Password)
+	}
+	if !valid {
+		if _, err := h.guard.Fail(r.Context(), req.Username, ip); err != nil {
+			fmt.Println("ERROR: Failed to record login failure:", err)
+			respondStorageError(w, err, "Failed to login")
+			return
+		}
 		respondError(w, http.StatusUnauthorized, "Invalid username or password")
 		return
 	}
 
-	// 5. Генерируем JWT токен
-	token, err := auth.GenerateToken(user.ID, user.Username)
+	if err := h.guard.Succeed(r.Context(), user.Username); err != nil {
+		fmt.Println("ERROR: Failed to reset login attempts:", err)
+	}
+
+	// 6. С включённой 2FA вместо токенов выдаём challenge для /auth/2fa/verify
+	tf, err := h.storage.GetTwoFactor(r.Context(), user.ID)
 	if err != nil {
-		fmt.Println("ERROR: Failed to generate token:", err)
-		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		fmt.Println("ERROR: Failed to get two-factor state:", err)
+		respondStorageError(w, err, "Failed to login")
+		return
+	}
+	if tf.Enabled {
+		challenge, err := auth.GenerateChallengeToken(user.ID, user.Username)
+		if err != nil {
+			fmt.Println("ERROR: Failed to generate challenge token:", err)
+			respondError(w, http.StatusInternalServerError, "Failed to generate token")
+			return
+		}
+		respondJSON(w, http.StatusOK, models.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+			ExpiresIn:         int(auth.ChallengeTTL.Seconds()),
+		})
 		return
 	}
 
-	// 6. Возвращаем токен и пользователя
-	response := models.LoginResponse{
-		Token: token,
-		User:  user,
+	// 7. Открываем сессию и выдаём токены
+	response, err := h.startSession(r, user)
+	if err != nil {
+		fmt.Println("ERROR: Failed to start session:", err)
+		respondStorageError(w, err, "Failed to generate token")
+		return
 	}
 
 	fmt.Printf("User logged in: %s\n", user.Username)
 	respondJSON(w, http.StatusOK, response)
 }
+
+// Refresh обрабатывает POST /auth/refresh — обменивает refresh токен на новую пару токенов.
+// Старый refresh токен становится недействительным; его повторное предъявление отзывает сессию
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== Refresh called ===")
+
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	refreshToken, refreshHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		fmt.Println("ERROR: Failed to generate refresh token:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	session, err := h.storage.RotateRefreshToken(r.Context(), auth.HashToken(req.RefreshToken), refreshHash, time.Now().Add(auth.RefreshTokenTTL))
+	if err != nil {
+		if err == models.ErrInvalidRefreshToken || err == models.ErrRefreshTokenReused {
+			if err == models.ErrRefreshTokenReused {
+				fmt.Println("WARNING: Refresh token reuse detected, session revoked")
+			}
+			respondError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		fmt.Println("ERROR: Failed to rotate refresh token:", err)
+		respondStorageError(w, err, "Failed to refresh token")
+		return
+	}
+
+	user, err := h.storage.GetUserByID(r.Context(), session.UserID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			respondError(w, http.StatusUnauthorized, models.ErrInvalidRefreshToken.Error())
+			return
+		}
+		fmt.Println("ERROR: Failed to get user:", err)
+		respondStorageError(w, err, "Failed to refresh token")
+		return
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Username, session.ID)
+	if err != nil {
+		fmt.Println("ERROR: Failed to generate token:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(auth.AccessTokenTTL.Seconds()),
+		User:         user,
+	})
+}
+
+// Logout обрабатывает POST /auth/logout — отзывает текущую сессию
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== Logout called ===")
+
+	sessionID, ok := middleware.GetSessionIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.storage.RevokeSession(r.Context(), sessionID); err != nil {
+		if err == models.ErrSessionNotFound {
+			respondError(w, http.StatusUnauthorized, "Session already revoked")
+			return
+		}
+		fmt.Println("ERROR: Failed to revoke session:", err)
+		respondStorageError(w, err, "Failed to logout")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll обрабатывает POST /auth/logout-all — отзывает все сессии пользователя
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== LogoutAll called ===")
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.storage.RevokeUserSessions(r.Context(), userID); err != nil {
+		fmt.Println("ERROR: Failed to revoke sessions:", err)
+		respondStorageError(w, err, "Failed to logout")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// startSession создаёт сессию и выдаёт пару access + refresh токенов
+func (h *AuthHandler) startSession(r *http.Request, user *models.User) (*models.LoginResponse, error) {
+	refreshToken, refreshHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := h.storage.CreateSession(r.Context(), user.ID, refreshHash, time.Now().Add(auth.RefreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Username, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(auth.AccessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	if alice.Token == "" || alice.User == nil || alice.User.Username != "alice" {
+		t.Fatalf("register response: %+v", alice)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"login", "/auth/login", `{"username":"alice","password":"password123"}`, http.StatusOK},
+		{"wrong password", "/auth/login", `{"username":"alice","password":"wrong-password"}`, http.StatusUnauthorized},
+		{"unknown user", "/auth/login", `{"username":"nobody","password":"password123"}`, http.StatusUnauthorized},
+		{"no password", "/auth/login", `{"username":"alice"}`, http.StatusBadRequest},
+		{"invalid json", "/auth/login", `{`, http.StatusBadRequest},
+		{"duplicate username", "/auth/register", `{"username":"alice","email":"other@example.com","password":"password123"}`, http.StatusBadRequest},
+		{"short username", "/auth/register", `{"username":"al","email":"al@example.com","password":"password123"}`, http.StatusBadRequest},
+		{"short password", "/auth/register", `{"username":"bob","email":"bob@example.com","password":"123"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do("POST", tt.path, "", tt.body)
+			if rec.Code != tt.status {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestProtectedRoutesRequireToken(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"no header", ""},
+		{"not bearer", "Basic " + alice.Token},
+		{"invalid token", "Bearer not-a-token"},
+		{"tampered token", "Bearer " + alice.Token + "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			if tt.header != "" {
+				headers = []string{"Authorization", tt.header}
+			}
+			rec := s.do("GET", userPath(alice, "/notes"), "", nil, headers...)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status %d, want 401", rec.Code)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	refresh := func(token string) *models.LoginResponse {
+		t.Helper()
+		rec := s.do("POST", "/auth/refresh", "", models.RefreshRequest{RefreshToken: token})
+		if rec.Code != http.StatusOK {
+			return nil
+		}
+		var resp models.LoginResponse
+		decode(t, rec, &resp)
+		return &resp
+	}
+
+	second := refresh(alice.RefreshToken)
+	if second == nil || second.RefreshToken == alice.RefreshToken {
+		t.Fatal("first refresh did not rotate the token")
+	}
+	third := refresh(second.RefreshToken)
+	if third == nil {
+		t.Fatal("rotated token was not accepted")
+	}
+
+	// Повторное использование уже заменённого токена отзывает всю сессию
+	if refresh(alice.RefreshToken) != nil {
+		t.Fatal("reused refresh token was accepted")
+	}
+	if refresh(third.RefreshToken) != nil {
+		t.Error("session survived refresh token reuse")
+	}
+	if rec := s.do("GET", userPath(alice, "/notes"), third.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access token of revoked session: %d", rec.Code)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "password123"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: %d %s", rec.Code, rec.Body)
+	}
+	var second models.LoginResponse
+	decode(t, rec, &second)
+
+	// logout закрывает только текущую сессию
+	if rec := s.do("POST", "/auth/logout", alice.Token, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("logout: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("GET", userPath(alice, "/notes"), alice.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("token after logout: %d", rec.Code)
+	}
+	if rec := s.do("POST", "/auth/refresh", "", models.RefreshRequest{RefreshToken: alice.RefreshToken}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after logout: %d", rec.Code)
+	}
+	if rec := s.do("GET", userPath(alice, "/notes"), second.Token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("other session after logout: %d", rec.Code)
+	}
+
+	// logout-all закрывает все сессии пользователя
+	if rec := s.do("POST", "/auth/logout-all", second.Token, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("logout-all: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("GET", userPath(alice, "/notes"), second.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("token after logout-all: %d", rec.Code)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	s := newTestServer(t)
+	s.register("alice")
+
+	login := func(password string) *http.Response {
+		rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: password})
+		return rec.Result()
+	}
+
+	// DefaultConfig: 5 неудач без блокировки
+	for i := 1; i <= 5; i++ {
+		if resp := login("wrong-password"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("wrong password #%d: %d", i, resp.StatusCode)
+		}
+	}
+	if resp := login("wrong-password"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("sixth wrong password: %d", resp.StatusCode)
+	}
+
+	// Во время блокировки не принимается даже верный пароль
+	resp := login("password123")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "1" {
+		t.Fatalf("valid password while locked: %d, Retry-After %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	// Несуществующий пользователь учитывается так же
+	for i := 0; i < 6; i++ {
+		s.do("POST", "/auth/login", "", models.LoginRequest{Username: "ghost", Password: "password123"})
+	}
+	if rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: "ghost", Password: "password123"}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("unknown user over the limit: %d", rec.Code)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+// PreconditionFailedResponse - ответ 412 с текущей версией заметки, чтобы клиент мог смержить изменения
+type PreconditionFailedResponse struct {
+	Error   string       `json:"error"`
+	Current *models.Note `json:"current"`
+}
+
+// noteETag строит ETag заметки из её версии
+func noteETag(note *models.Note) string {
+	return fmt.Sprintf(`"v%d"`, note.Version)
+}
+
+// ifMatchVersion разбирает If-Match относительно текущей заметки.
+// Возвращает версию для условного обновления в storage (0 — без проверки)
+// и false, если ни один из ETag не совпал с текущим
+func ifMatchVersion(r *http.Request, current *models.Note) (int, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+
+	etag := noteETag(current)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return 0, true
+		}
+		// If-Match использует строгое сравнение, weak ETag (W/"...") не подходит
+		if tag == etag {
+			return current.Version, true
+		}
+	}
+	return 0, false
+}
+
+// ifNoneMatch проверяет If-None-Match (слабое сравнение, как требует RFC 9110)
+func ifNoneMatch(r *http.Request, note *models.Note) bool {
+	return ifNoneMatchETag(r, noteETag(note))
+}
+
+// ifNoneMatchETag проверяет If-None-Match для произвольного ETag
+func ifNoneMatchETag(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// respondPreconditionFailed отвечает 412 и возвращает текущую версию заметки
+func respondPreconditionFailed(w http.ResponseWriter, current *models.Note) {
+	w.Header().Set("ETag", noteETag(current))
+	respondJSON(w, http.StatusPreconditionFailed, PreconditionFailedResponse{
+		Error:   "Note has been modified, If-Match does not match current version",
+		Current: current,
+	})
+}
+
+// respondConflict отвечает на неудачное условное изменение: заметку изменили
+// параллельно (412 с текущей версией) или уже удалили (404)
+func respondConflict(w http.ResponseWriter, r *http.Request, store storage.NoteStore, noteID int) {
+	current, err := store.GetNoteByID(r.Context(), noteID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found")
+			return
+		}
+		respondStorageError(w, err, "Failed to get note")
+		return
+	}
+	respondPreconditionFailed(w, current)
+}
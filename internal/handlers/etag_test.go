@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestUpdateNoteIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch func(note *models.Note) string
+		status  int
+	}{
+		{"without If-Match", func(*models.Note) string { return "" }, http.StatusOK},
+		{"current version", func(n *models.Note) string { return noteETag(n) }, http.StatusOK},
+		{"any version", func(*models.Note) string { return "*" }, http.StatusOK},
+		{"one of several", func(n *models.Note) string { return `"v99", ` + noteETag(n) }, http.StatusOK},
+		{"stale version", func(n *models.Note) string { return `"v` + strconv.Itoa(n.Version-1) + `"` }, http.StatusPreconditionFailed},
+		{"weak etag", func(n *models.Note) string { return "W/" + noteETag(n) }, http.StatusPreconditionFailed},
+	}
+
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note := s.createNote(alice, "Title", "content")
+			var headers []string
+			if h := tt.ifMatch(note); h != "" {
+				headers = []string{"If-Match", h}
+			}
+
+			rec := s.do("PUT", notePath(alice, note, ""), alice.Token, map[string]string{
+				"title":   "New title",
+				"content": "new content",
+			}, headers...)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+
+			// И при успехе, и при 412 ETag указывает на текущую версию
+			var current models.Note
+			decode(t, s.do("GET", notePath(alice, note, ""), alice.Token, nil), &current)
+			if got := rec.Header().Get("ETag"); got != noteETag(&current) {
+				t.Errorf("ETag %s, want %s", got, noteETag(&current))
+			}
+			if changed := current.Title == "New title"; changed != (tt.status == http.StatusOK) {
+				t.Errorf("title %q after status %d", current.Title, rec.Code)
+			}
+		})
+	}
+}
+
+func TestDeleteNoteIfMatch(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	note := s.createNote(alice, "Title", "content")
+	s.do("PUT", notePath(alice, note, ""), alice.Token, map[string]string{"title": "Title", "content": "changed"})
+
+	rec := s.do("DELETE", notePath(alice, note, ""), alice.Token, nil, "If-Match", noteETag(note))
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: %d %s", rec.Code, rec.Body)
+	}
+	var resp PreconditionFailedResponse
+	decode(t, rec, &resp)
+	if resp.Current == nil || resp.Current.Version != 2 || resp.Current.Content != "changed" {
+		t.Errorf("412 body: %+v", resp)
+	}
+
+	if rec := s.do("DELETE", notePath(alice, note, ""), alice.Token, nil, "If-Match", `"v2"`); rec.Code != http.StatusOK {
+		t.Fatalf("current If-Match: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestGetNoteIfNoneMatch(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	note := s.createNote(alice, "Title", "content")
+
+	tests := []struct {
+		ifNoneMatch string
+		status      int
+	}{
+		{"", http.StatusOK},
+		{`"v1"`, http.StatusNotModified},
+		{`W/"v1"`, http.StatusNotModified},
+		{`"v0", "v1"`, http.StatusNotModified},
+		{"*", http.StatusNotModified},
+		{`"v2"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		var headers []string
+		if tt.ifNoneMatch != "" {
+			headers = []string{"If-None-Match", tt.ifNoneMatch}
+		}
+		rec := s.do("GET", notePath(alice, note, ""), alice.Token, nil, headers...)
+		if rec.Code != tt.status {
+			t.Errorf("If-None-Match %s: %d, want %d", tt.ifNoneMatch, rec.Code, tt.status)
+		}
+		if got := rec.Header().Get("ETag"); got != `"v1"` {
+			t.Errorf("If-None-Match %s: ETag %s", tt.ifNoneMatch, got)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/blob"
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/vault"
+	"github.com/go-chi/chi/v5"
+)
+
+// ImportHandler обрабатывает запросы к /users/{id}/imports: загрузку zip архива markdown заметок
+// (например, хранилища Obsidian) и статус его импорта. Сам импорт выполняет фоновая задача
+type ImportHandler struct {
+	storage storage.ImportStore
+	blobs   blob.Store
+	maxSize int64 // максимальный размер архива
+}
+
+// NewImportHandler создаёт новый ImportHandler
+func NewImportHandler(storage storage.ImportStore, blobs blob.Store, maxSize int64) *ImportHandler {
+	return &ImportHandler{
+		storage: storage,
+		blobs:   blobs,
+		maxSize: maxSize,
+	}
+}
+
+// CreateImport обрабатывает POST /users/{id}/imports (multipart/form-data, архив в поле "file").
+// Отвечает 202 Accepted: прогресс и отчёт об ошибках — в GET /users/{id}/imports/{import_id}
+func (h *ImportHandler) CreateImport(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== CreateImport called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Content-Type must be multipart/form-data")
+		return
+	}
+
+	var part io.ReadCloser
+	var filename string
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			h.respondUploadError(w, err)
+			return
+		}
+		if p.FormName() == "file" {
+			part = p
+			filename = p.FileName()
+			break
+		}
+		p.Close()
+	}
+	if part == nil {
+		respondError(w, http.StatusBadRequest, models.ErrFileRequired.Error())
+		return
+	}
+	defer part.Close()
+
+	// Архив сначала пишется во временный файл: zip читается с конца, а файлы нужно посчитать до постановки в очередь
+	tmp, err := os.CreateTemp("", "import-*.zip")
+	if err != nil {
+		fmt.Println("ERROR: Failed to create temp file:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to upload archive")
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, io.LimitReader(part, h.maxSize+1))
+	if err != nil {
+		h.respondUploadError(w, err)
+		return
+	}
+	if size > h.maxSize {
+		h.respondUploadError(w, models.ErrArchiveTooLarge)
+		return
+	}
+
+	archive, err := zip.NewReader(tmp, size)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, models.ErrInvalidArchive.Error())
+		return
+	}
+	files := vault.Files(archive)
+	if len(files) == 0 {
+		respondError(w, http.StatusBadRequest, models.ErrEmptyArchive.Error())
+		return
+	}
+	if err := models.ValidateImportArchive(len(files), vault.UnpackedSize(files)); err != nil {
+		respondError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+
+	key, err := newBlobKey(userID)
+	if err != nil {
+		fmt.Println("ERROR: Failed to generate blob key:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to upload archive")
+		return
+	}
+	key = "imports/" + key
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		fmt.Println("ERROR: Failed to rewind temp file:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to upload archive")
+		return
+	}
+	if err := h.blobs.Put(r.Context(), key, tmp, size, "application/zip"); err != nil {
+		fmt.Println("ERROR: Blob Put failed:", err)
+		respondStorageError(w, err, "Failed to store archive")
+		return
+	}
+
+	imp, err := h.storage.CreateImport(r.Context(), &models.Import{
+		UserID:     userID,
+		Filename:   sanitizeFilename(filename),
+		TotalFiles: len(files),
+		StorageKey: key,
+	})
+	if err != nil {
+		// Импорт не создан — архив никому не нужен
+		if deleteErr := h.blobs.Delete(context.WithoutCancel(r.Context()), key); deleteErr != nil {
+			fmt.Println("ERROR: Failed to delete blob", key+":", deleteErr)
+		}
+		fmt.Println("ERROR: CreateImport failed:", err)
+		respondStorageError(w, err, "Failed to create import")
+		return
+	}
+
+	fmt.Printf("Import Created: %+v\n", imp)
+	respondJSON(w, http.StatusAccepted, imp)
+}
+
+// GetImports обрабатывает GET /users/{id}/imports
+func (h *ImportHandler) GetImports(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetImports called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	imports, err := h.storage.GetUserImports(r.Context(), userID)
+	if err != nil {
+		fmt.Println("ERROR: GetUserImports failed:", err)
+		respondStorageError(w, err, "Failed to get imports")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, imports)
+}
+
+// GetImport обрабатывает GET /users/{id}/imports/{import_id}: статус, прогресс и ошибки по файлам
+func (h *ImportHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetImport called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	importID, err := strconv.Atoi(chi.URLParam(r, "import_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid import ID")
+		return
+	}
+
+	imp, err := h.storage.GetImport(r.Context(), userID, importID)
+	if err != nil {
+		if err == models.ErrImportNotFound {
+			respondError(w, http.StatusNotFound, "Import not found")
+			return
+		}
+		fmt.Println("ERROR: GetImport failed:", err)
+		respondStorageError(w, err, "Failed to get import")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, imp)
+}
+
+// respondUploadError отвечает на ошибку чтения архива: 413 при превышении размера, иначе 400
+func (h *ImportHandler) respondUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if err == models.ErrArchiveTooLarge || errors.As(err, &maxBytesErr) {
+		respondError(w, http.StatusRequestEntityTooLarge, models.ErrArchiveTooLarge.Error())
+		return
+	}
+	fmt.Println("ERROR: Failed to read upload:", err)
+	respondError(w, http.StatusBadRequest, "Failed to read multipart body")
+}
+
+// authorizedUser проверяет, что пользователь работает со своими импортами
+func (h *ImportHandler) authorizedUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return 0, false
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only access your own imports")
+		return 0, false
+	}
+
+	return authenticatedUserID, true
+}
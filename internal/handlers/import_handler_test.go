@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func zipArchive(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("# " + name))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCreateImport(t *testing.T) {
+	s := newTestServer(t, withBlobs(t, 0, 0), func(cfg *RouterConfig) {
+		cfg.MaxImportSize = 4 << 10
+	})
+	alice := s.register("alice")
+	bob := s.register("bob")
+
+	upload := func(user *models.LoginResponse, filename string, content []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		body, contentType := multipartFile(t, filename, content)
+		return s.do("POST", userPath(user, "/imports"), user.Token, body, "Content-Type", contentType)
+	}
+
+	rec := upload(alice, "vault.zip", zipArchive(t, "a.md", "notes/b.md", "image.png"))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("create import: %d %s", rec.Code, rec.Body)
+	}
+	var imp models.Import
+	decode(t, rec, &imp)
+	if imp.Status != models.ImportStatusPending || imp.TotalFiles != 2 || imp.Filename != "vault.zip" {
+		t.Errorf("import: %+v", imp)
+	}
+	importPath := userPath(alice, "/imports/"+strconv.Itoa(imp.ID))
+
+	tests := []struct {
+		name   string
+		user   *models.LoginResponse
+		path   string
+		status int
+	}{
+		{"get own import", alice, importPath, http.StatusOK},
+		{"list own imports", alice, userPath(alice, "/imports"), http.StatusOK},
+		{"unknown import", alice, userPath(alice, "/imports/999"), http.StatusNotFound},
+		{"other user's import", bob, importPath, http.StatusForbidden},
+		{"import of another user by id", bob, userPath(bob, "/imports/"+strconv.Itoa(imp.ID)), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := s.do("GET", tt.path, tt.user.Token, nil); rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body)
+		}
+	}
+
+	uploads := []struct {
+		name    string
+		content []byte
+		status  int
+	}{
+		{"not a zip", []byte("plain text"), http.StatusBadRequest},
+		{"no markdown files", zipArchive(t, "image.png", ".obsidian/app.md"), http.StatusBadRequest},
+		{"too large", bytes.Repeat([]byte{0}, 5<<10), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range uploads {
+		if rec := upload(alice, "vault.zip", tt.content); rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body)
+		}
+	}
+	if rec := s.do("POST", userPath(alice, "/imports"), alice.Token, `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("json body: %d", rec.Code)
+	}
+}
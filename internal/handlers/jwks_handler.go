@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Balyshev/notes-api/pkg/auth"
+)
+
+// JWKSHandler публикует публичные ключи подписи JWT
+type JWKSHandler struct {
+	keys *auth.KeySet
+}
+
+// NewJWKSHandler создаёт новый JWKSHandler
+func NewJWKSHandler(keys *auth.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+// GetJWKS обрабатывает GET /.well-known/jwks.json
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetJWKS called ===")
+
+	// Ключи меняются только при ротации, кешировать можно недолго
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondJSON(w, http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"testing"
+
+	"github.com/Balyshev/notes-api/pkg/auth"
+)
+
+func TestGetJWKS(t *testing.T) {
+	// HS256 ключ тестов не публикуется
+	s := newTestServer(t)
+	rec := s.do("GET", "/.well-known/jwks.json", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("jwks: %d %s", rec.Code, rec.Body)
+	}
+	var set auth.JWKS
+	decode(t, rec, &set)
+	if len(set.Keys) != 0 {
+		t.Errorf("HMAC key published: %+v", set.Keys)
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := auth.NewKeySet([]*auth.Key{auth.NewHMACKey("test", []byte("test-secret")), pemKey(t, "ed", priv)}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = newTestServer(t, func(cfg *RouterConfig) { cfg.Keys = keys })
+	rec = s.do("GET", "/.well-known/jwks.json", "", nil)
+	decode(t, rec, &set)
+	if len(set.Keys) != 1 || set.Keys[0].Kid != "ed" || set.Keys[0].Kty != "OKP" || set.Keys[0].X == "" {
+		t.Errorf("jwks: %+v", set.Keys)
+	}
+	if rec.Header().Get("Cache-Control") == "" {
+		t.Error("no Cache-Control")
+	}
+}
+
+// pemKey оборачивает приватный ключ в auth.Key через PKCS#8 PEM
+func pemKey(t *testing.T, id string, priv interface{}) *auth.Key {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := auth.ParseKeyPEM(id, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// LinkHandler обрабатывает запросы к ссылкам между заметками: [[Заголовок]] и note:42 в тексте.
+// Ссылки ведут на заметки того же владельца, поэтому доступны только ему
+type LinkHandler struct {
+	storage storage.NoteLinkStore
+}
+
+// NewLinkHandler создаёт новый LinkHandler
+func NewLinkHandler(storage storage.NoteLinkStore) *LinkHandler {
+	return &LinkHandler{
+		storage: storage,
+	}
+}
+
+// GetOutgoingLinks обрабатывает GET /users/{id}/notes/{note_id}/outgoing-links
+func (h *LinkHandler) GetOutgoingLinks(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetOutgoingLinks called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.storage.GetOutgoingLinks(r.Context(), note.UserID, note.ID)
+	if err != nil {
+		fmt.Println("ERROR: GetOutgoingLinks failed:", err)
+		respondStorageError(w, err, "Failed to get links")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, links)
+}
+
+// GetBacklinks обрабатывает GET /users/{id}/notes/{note_id}/backlinks
+func (h *LinkHandler) GetBacklinks(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetBacklinks called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.storage.GetBacklinks(r.Context(), note.UserID, note.ID)
+	if err != nil {
+		fmt.Println("ERROR: GetBacklinks failed:", err)
+		respondStorageError(w, err, "Failed to get backlinks")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, links)
+}
+
+// GetUnresolvedLinks обрабатывает GET /users/{id}/unresolved-links
+func (h *LinkHandler) GetUnresolvedLinks(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetUnresolvedLinks called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.storage.GetUnresolvedLinks(r.Context(), userID)
+	if err != nil {
+		fmt.Println("ERROR: GetUnresolvedLinks failed:", err)
+		respondStorageError(w, err, "Failed to get unresolved links")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, links)
+}
+
+// GetLinkGraph обрабатывает GET /users/{id}/graph
+func (h *LinkHandler) GetLinkGraph(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetLinkGraph called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	graph, err := h.storage.GetLinkGraph(r.Context(), userID)
+	if err != nil {
+		fmt.Println("ERROR: GetLinkGraph failed:", err)
+		respondStorageError(w, err, "Failed to get link graph")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, graph)
+}
+
+// ownedNote проверяет токен, user_id из URL и владельца заметки {note_id}.
+// При ошибке сам отправляет ответ и возвращает false
+func (h *LinkHandler) ownedNote(w http.ResponseWriter, r *http.Request) (*models.Note, bool) {
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid note ID")
+		return nil, false
+	}
+
+	note, err := h.storage.GetNoteByID(r.Context(), noteID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found")
+			return nil, false
+		}
+		respondStorageError(w, err, "Failed to get note")
+		return nil, false
+	}
+
+	if note.UserID != userID {
+		respondError(w, http.StatusForbidden, "You don't have permission to access this note")
+		return nil, false
+	}
+
+	return note, true
+}
+
+// authorizedUser проверяет, что пользователь запрашивает ссылки своих заметок
+func (h *LinkHandler) authorizedUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return 0, false
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only access your own notes")
+		return 0, false
+	}
+
+	return authenticatedUserID, true
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestNoteLinks(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+
+	plan := s.createNote(alice, "Plan", "goals")
+	bobNote := s.createNote(bob, "Secret", "bob's note")
+	source := s.createNote(alice, "Journal",
+		"see [[plan]], [[Missing]] and note:"+strconv.Itoa(plan.ID)+" but not note:"+strconv.Itoa(bobNote.ID))
+
+	links := func(user *models.LoginResponse, path string) []models.NoteLink {
+		t.Helper()
+		rec := s.do("GET", path, user.Token, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", path, rec.Code, rec.Body)
+		}
+		var result []models.NoteLink
+		decode(t, rec, &result)
+		return result
+	}
+
+	outgoing := links(alice, notePath(alice, source, "/outgoing-links"))
+	resolved := 0
+	for _, l := range outgoing {
+		if l.TargetID != nil {
+			resolved++
+			if *l.TargetID != plan.ID {
+				t.Errorf("link %s resolved to %d", l.Link, *l.TargetID)
+			}
+		}
+	}
+	// Чужая заметка по note:ID не находится
+	if len(outgoing) != 4 || resolved != 2 {
+		t.Errorf("outgoing links: %+v", outgoing)
+	}
+
+	backlinks := links(alice, notePath(alice, plan, "/backlinks"))
+	if len(backlinks) == 0 || backlinks[0].SourceID != source.ID {
+		t.Errorf("backlinks: %+v", backlinks)
+	}
+	if got := links(bob, notePath(bob, bobNote, "/backlinks")); len(got) != 0 {
+		t.Errorf("backlinks from another user: %+v", got)
+	}
+
+	unresolved := links(alice, userPath(alice, "/unresolved-links"))
+	if len(unresolved) != 2 {
+		t.Errorf("unresolved links: %+v", unresolved)
+	}
+
+	// Заметка с нужным заголовком разрешает ссылку
+	missing := s.createNote(alice, "Missing", "now exists")
+	backlinks = links(alice, notePath(alice, missing, "/backlinks"))
+	if len(backlinks) != 1 || backlinks[0].SourceID != source.ID {
+		t.Errorf("backlinks of a new note: %+v", backlinks)
+	}
+
+	rec := s.do("GET", userPath(alice, "/graph"), alice.Token, nil)
+	var graph models.LinkGraph
+	decode(t, rec, &graph)
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 {
+		t.Errorf("graph: %d nodes, %d edges", len(graph.Nodes), len(graph.Edges))
+	}
+
+	if rec := s.do("GET", notePath(alice, plan, "/backlinks"), bob.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("other user reads backlinks: %d", rec.Code)
+	}
+	if rec := s.do("GET", userPath(alice, "/graph"), bob.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("other user reads graph: %d", rec.Code)
+	}
+}
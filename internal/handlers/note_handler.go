@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Balyshev/notes-api/internal/middleware"
 	"github.com/Balyshev/notes-api/internal/models"
@@ -14,12 +16,14 @@ import (
 
 // NoteHandler обрабатывает запросы к /users/{id}/notes
 type NoteHandler struct {
-	storage *storage.Storage
+	storage storage.NoteStore
+	shares  storage.ShareStore
 }
 
-func NewNoteHandler(storage *storage.Storage) *NoteHandler {
+func NewNoteHandler(storage storage.NoteStore, shares storage.ShareStore) *NoteHandler {
 	return &NoteHandler{
 		storage: storage,
+		shares:  shares,
 	}
 }
 
@@ -63,14 +67,19 @@ func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Создаём заметку (используем authenticatedUserID из токена, а не из URL!)
-	note, err := h.storage.CreateNote(authenticatedUserID, req.Title, req.Content)
+	note, err := h.storage.CreateNote(r.Context(), authenticatedUserID, req.NotebookID, req.Title, req.Content, req.Format, req.Tags)
 	if err != nil {
+		if err == models.ErrNotebookNotFound {
+			respondError(w, http.StatusNotFound, "Notebook not found")
+			return
+		}
 		fmt.Println("ERROR: CreateNote failed:", err)
-		respondError(w, http.StatusInternalServerError, "Failed to create note")
+		respondStorageError(w, err, "Failed to create note")
 		return
 	}
 
 	fmt.Printf("Note created: %+v\n", note)
+	w.Header().Set("ETag", noteETag(note))
 	respondJSON(w, http.StatusCreated, note)
 }
 
@@ -101,13 +110,9 @@ func (h *NoteHandler) GetUserNotes(w http.ResponseWriter, r *http.Request) {
 	// Парсим query параметры
 	limitStr := r.URL.Query().Get("limit")
 	offsetStr := r.URL.Query().Get("offset")
-	sortOrder := r.URL.Query().Get("sort")
 
 	limit := 10
 	offset := 0
-	if sortOrder == "" {
-		sortOrder = "desc"
-	}
 
 	if limitStr != "" {
 		limit, err = strconv.Atoi(limitStr)
@@ -125,28 +130,186 @@ func (h *NoteHandler) GetUserNotes(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if sortOrder != "asc" && sortOrder != "desc" {
-		respondError(w, http.StatusBadRequest, "Invalid sort parameter (must be 'asc' or 'desc')")
+	// Сортировка: ?sort=desc (по created_at) или ?sort=updated_at:desc,title
+	sortKeys, err := models.ParseNoteSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Фильтр по тегам: ?tag=work&tag=go или ?tag=work,go
+	var tags []string
+	for _, value := range r.URL.Query()["tag"] {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+	tags, err = models.NormalizeTags(tags)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tagMode := r.URL.Query().Get("tag_mode")
+	if tagMode == "" {
+		tagMode = "and"
+	}
+	if tagMode != "and" && tagMode != "or" {
+		respondError(w, http.StatusBadRequest, models.ErrInvalidTagMode.Error())
+		return
+	}
+
+	// Фильтры по датам, префиксу заголовка и длине содержимого
+	opts := models.NoteListOptions{
+		Limit:       limit + 1, // лишняя заметка показывает, есть ли следующая страница
+		Offset:      offset,
+		Sort:        sortKeys,
+		Tags:        tags,
+		TagMode:     tagMode,
+		TitlePrefix: r.URL.Query().Get("title_prefix"),
+	}
+
+	dateFilters := []struct {
+		param string
+		end   bool
+		dest  **time.Time
+	}{
+		{"created_from", false, &opts.CreatedFrom},
+		{"created_to", true, &opts.CreatedTo},
+		{"updated_from", false, &opts.UpdatedFrom},
+		{"updated_to", true, &opts.UpdatedTo},
+	}
+	for _, f := range dateFilters {
+		*f.dest, err = models.ParseDateBound(r.URL.Query().Get(f.param), f.end)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	for param, dest := range map[string]*int{"min_length": &opts.MinLength, "max_length": &opts.MaxLength} {
+		if value := r.URL.Query().Get(param); value != "" {
+			*dest, err = strconv.Atoi(value)
+			if err != nil || *dest < 0 {
+				respondError(w, http.StatusBadRequest, models.ErrInvalidLength.Error())
+				return
+			}
+		}
+	}
+	if opts.MaxLength > 0 && opts.MinLength > opts.MaxLength {
+		respondError(w, http.StatusBadRequest, models.ErrInvalidLength.Error())
 		return
 	}
 
-	fmt.Printf("Query params: limit=%d, offset=%d, sort=%s\n", limit, offset, sortOrder)
+	// Содержимое блокнота: ?notebook_id=5 (&recursive=true — с вложенными), ?notebook_id=none — вне блокнотов
+	switch notebook := r.URL.Query().Get("notebook_id"); notebook {
+	case "":
+	case "none":
+		opts.Unfiled = true
+	default:
+		notebookID, err := strconv.Atoi(notebook)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid notebook_id parameter")
+			return
+		}
+		opts.NotebookID = &notebookID
+		opts.Recursive = r.URL.Query().Get("recursive") == "true"
+	}
+
+	// Keyset пагинация: ?cursor=<token>, первая страница — ?pagination=cursor
+	cursorMode := r.URL.Query().Has("cursor") || r.URL.Query().Get("pagination") == "cursor"
+	var cursor *models.NoteCursor
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		cursor, err = models.DecodeNoteCursor(token, sortKeys)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid cursor parameter (or cursor was issued for a different sort)")
+			return
+		}
+	}
+	opts.Cursor = cursor
+	if cursorMode && offsetStr != "" {
+		respondError(w, http.StatusBadRequest, "offset cannot be combined with cursor pagination")
+		return
+	}
+
+	includeTotal := r.URL.Query().Get("include_total") == "true"
+
+	fmt.Printf("Query params: limit=%d, offset=%d, sort=%s, tags=%v (%s), cursor=%v\n", limit, offset, models.SortString(sortKeys), tags, tagMode, cursor != nil)
 
 	// Получаем заметки
-	notes, err := h.storage.GetUserNotes(authenticatedUserID, limit, offset, sortOrder)
+	notes, err := h.storage.GetUserNotes(r.Context(), authenticatedUserID, opts)
 	if err != nil {
 		fmt.Println("ERROR: GetUserNotes failed:", err)
-		respondError(w, http.StatusInternalServerError, "Failed to get notes")
+		respondStorageError(w, err, "Failed to get notes")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, notes)
+	backward := cursor != nil && cursor.Before
+	hasMore := len(notes) > limit
+	if hasMore {
+		if backward {
+			notes = notes[1:]
+		} else {
+			notes = notes[:limit]
+		}
+	}
+
+	var total *int
+	if includeTotal {
+		count, err := h.storage.CountUserNotes(r.Context(), authenticatedUserID, opts)
+		if err != nil {
+			fmt.Println("ERROR: CountUserNotes failed:", err)
+			respondStorageError(w, err, "Failed to count notes")
+			return
+		}
+		total = &count
+	}
+
+	// Старый формат: массив заметок, ссылки на страницы — в заголовке Link
+	if !cursorMode {
+		links := map[string]string{}
+		if hasMore {
+			links["next"] = pageURL(r, "offset", strconv.Itoa(offset+limit))
+		}
+		if offset > 0 {
+			links["prev"] = pageURL(r, "offset", strconv.Itoa(max(offset-limit, 0)))
+		}
+		setLinkHeader(w, links)
+		if total != nil {
+			w.Header().Set("X-Total-Count", strconv.Itoa(*total))
+		}
+		respondJSON(w, http.StatusOK, notes)
+		return
+	}
+
+	page := models.NotePage{Data: notes, Total: total}
+	if page.Data == nil {
+		page.Data = []*models.Note{}
+	}
+
+	links := map[string]string{}
+	if len(notes) > 0 {
+		// Назад мы пришли со следующей страницы, значит она существует
+		if hasMore || backward {
+			next := models.CursorFor(notes[len(notes)-1], sortKeys, false).Encode()
+			page.NextCursor = &next
+			links["next"] = pageURL(r, "cursor", next)
+		}
+		if (cursor != nil && !backward) || (backward && hasMore) {
+			prev := models.CursorFor(notes[0], sortKeys, true).Encode()
+			page.PrevCursor = &prev
+			links["prev"] = pageURL(r, "cursor", prev)
+		}
+	}
+	links["first"] = pageURL(r, "cursor", "")
+	setLinkHeader(w, links)
+
+	respondJSON(w, http.StatusOK, page)
 }
 
-func (h *NoteHandler) GetNote(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("=== GetNote called ===")
+// SearchNotes обрабатывает GET /users/{id}/notes/search?q=...
+// Поддерживает фразы ("..."), префиксы (слово*) и отрицание (-слово)
+func (h *NoteHandler) SearchNotes(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== SearchNotes called ===")
 
-	// Получаем user_id из JWT токена
 	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
 		respondError(w, http.StatusUnauthorized, "Unauthorized")
@@ -160,7 +323,60 @@ func (h *NoteHandler) GetNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if authenticatedUserID != userIDFromURL {
-		respondError(w, http.StatusForbidden, "You can only view your own notes")
+		respondError(w, http.StatusForbidden, "You can only search your own notes")
+		return
+	}
+
+	query, err := models.ParseSearchQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit := 10
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid offset parameter")
+			return
+		}
+	}
+
+	fmt.Printf("Search: q=%q, limit=%d, offset=%d\n", query.Raw, limit, offset)
+
+	results, err := h.storage.SearchNotes(r.Context(), authenticatedUserID, query, limit, offset)
+	if err != nil {
+		fmt.Println("ERROR: SearchNotes failed:", err)
+		respondStorageError(w, err, "Failed to search notes")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, results)
+}
+
+func (h *NoteHandler) GetNote(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetNote called ===")
+
+	// Получаем user_id из JWT токена
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
@@ -172,19 +388,36 @@ func (h *NoteHandler) GetNote(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("UserID: %d, NoteID: %d\n", authenticatedUserID, noteID)
 
-	note, err := h.storage.GetNoteByID(noteID)
+	note, err := h.storage.GetNoteByID(r.Context(), noteID)
 	if err != nil {
 		if err == models.ErrNoteNotFound {
 			respondError(w, http.StatusNotFound, "Note not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "Failed to get note")
+		respondStorageError(w, err, "Failed to get note")
+		return
+	}
+
+	// Проверяем, что заметка принадлежит {id} и текущему пользователю она доступна
+	if _, ok := notePermission(w, r, h.shares, note, authenticatedUserID, userIDFromURL); !ok {
+		return
+	}
+
+	// Accept: text/html — отрисованное содержимое заметки вместо JSON
+	w.Header().Set("Vary", "Accept")
+	if wantsHTML(r) {
+		w.Header().Set("ETag", noteHTMLETag(note))
+		if ifNoneMatchETag(r, noteHTMLETag(note)) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		respondNoteHTML(w, http.StatusOK, noteHTML(r.Context(), h.storage, note))
 		return
 	}
 
-	// Проверяем ownership
-	if note.UserID != authenticatedUserID {
-		respondError(w, http.StatusForbidden, "You don't have permission to access this note")
+	w.Header().Set("ETag", noteETag(note))
+	if ifNoneMatch(r, note) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -207,11 +440,6 @@ func (h *NoteHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if authenticatedUserID != userIDFromURL {
-		respondError(w, http.StatusForbidden, "You can only update your own notes")
-		return
-	}
-
 	noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid note ID")
@@ -220,21 +448,33 @@ func (h *NoteHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("USER ID: %d, Note ID: %d\n", authenticatedUserID, noteID)
 
-	existingNote, err := h.storage.GetNoteByID(noteID)
+	existingNote, err := h.storage.GetNoteByID(r.Context(), noteID)
 	if err != nil {
 		if err == models.ErrNoteNotFound {
 			respondError(w, http.StatusNotFound, "Note not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "Failed to get note")
+		respondStorageError(w, err, "Failed to get note")
+		return
+	}
+
+	permission, ok := notePermission(w, r, h.shares, existingNote, authenticatedUserID, userIDFromURL)
+	if !ok {
 		return
 	}
 
-	if existingNote.UserID != authenticatedUserID {
+	if !models.CanEdit(permission) {
 		respondError(w, http.StatusForbidden, "You don't have permission to update this note")
 		return
 	}
 
+	// Optimistic concurrency: If-Match должен совпадать с текущим ETag
+	expectedVersion, ok := ifMatchVersion(r, existingNote)
+	if !ok {
+		respondPreconditionFailed(w, existingNote)
+		return
+	}
+
 	var req models.UdateNoteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid JSON")
@@ -248,18 +488,23 @@ func (h *NoteHandler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	note, err := h.storage.UpdateNote(noteID, req.Title, req.Content)
+	note, err := h.storage.UpdateNote(r.Context(), noteID, req.Title, req.Content, req.Format, req.Tags, expectedVersion)
 	if err != nil {
+		if err == models.ErrVersionMismatch || err == models.ErrNoteNotFound {
+			respondConflict(w, r, h.storage, noteID)
+			return
+		}
 		fmt.Println("ERROR: UpdateNote failed:", err)
-		respondError(w, http.StatusInternalServerError, "Failed to update note")
+		respondStorageError(w, err, "Failed to update note")
 		return
 	}
 
 	fmt.Printf("Note Updated: %+v\n", note)
+	w.Header().Set("ETag", noteETag(note))
 	respondJSON(w, http.StatusOK, note)
 }
 
-// DeleteNote обрабатывает DELETE /users/{id}/notes/{note_id}
+// DeleteNote обрабатывает DELETE /users/{id}/notes/{note_id} (перенос в корзину)
 func (h *NoteHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("=== DeleteNote called ===")
 
@@ -275,11 +520,6 @@ func (h *NoteHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if authenticatedUserID != userIDFromURL {
-		respondError(w, http.StatusForbidden, "You can only delete your own notes")
-		return
-	}
-
 	noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid note ID")
@@ -288,26 +528,69 @@ func (h *NoteHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("User ID: %d, Note ID: %d\n", authenticatedUserID, noteID)
 
-	existingNote, err := h.storage.GetNoteByID(noteID)
+	existingNote, err := h.storage.GetNoteByID(r.Context(), noteID)
 	if err != nil {
 		if err == models.ErrNoteNotFound {
 			respondError(w, http.StatusNotFound, "Note not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "Failed to get note")
+		respondStorageError(w, err, "Failed to get note")
+		return
+	}
+
+	permission, ok := notePermission(w, r, h.shares, existingNote, authenticatedUserID, userIDFromURL)
+	if !ok {
+		return
+	}
+
+	// Перенести заметку в корзину может только владелец, editor — нет
+	if permission != models.PermissionOwner {
+		respondError(w, http.StatusForbidden, "Only the owner can delete this note")
 		return
 	}
 
-	if existingNote.UserID != authenticatedUserID {
-		respondError(w, http.StatusForbidden, "You don't have permission to delete this note")
+	expectedVersion, ok := ifMatchVersion(r, existingNote)
+	if !ok {
+		respondPreconditionFailed(w, existingNote)
 		return
 	}
 
-	if err := h.storage.DeleteNote(noteID); err != nil {
+	if err := h.storage.DeleteNote(r.Context(), noteID, expectedVersion); err != nil {
+		if err == models.ErrVersionMismatch || err == models.ErrNoteNotFound {
+			respondConflict(w, r, h.storage, noteID)
+			return
+		}
 		fmt.Println("ERROR: DeleteNote failed:", err)
-		respondError(w, http.StatusInternalServerError, "Failed to delete note")
+		respondStorageError(w, err, "Failed to delete note")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{"message": "Note deleted successfully"})
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Note moved to trash"})
+}
+
+// notePermission проверяет, что заметка принадлежит пользователю {id} из URL,
+// и возвращает право текущего пользователя на неё: владелец или выданный доступ.
+// При ошибке сам отправляет ответ и возвращает false
+func notePermission(w http.ResponseWriter, r *http.Request, shares storage.ShareStore, note *models.Note, userID, ownerID int) (string, bool) {
+	if note.UserID != ownerID {
+		respondError(w, http.StatusNotFound, "Note not found")
+		return "", false
+	}
+
+	if note.UserID == userID {
+		return models.PermissionOwner, true
+	}
+
+	permission, err := shares.GetNotePermission(r.Context(), note.ID, userID)
+	if err != nil {
+		if err == models.ErrShareNotFound {
+			respondError(w, http.StatusForbidden, "You don't have permission to access this note")
+			return "", false
+		}
+		fmt.Println("ERROR: GetNotePermission failed:", err)
+		respondStorageError(w, err, "Failed to check note permission")
+		return "", false
+	}
+
+	return permission, true
 }
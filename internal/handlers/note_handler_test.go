@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestNoteCRUD(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	note := s.createNote(alice, "Title", "content")
+	if note.ID == 0 || note.UserID != alice.User.ID || note.Title != "Title" || note.Content != "content" {
+		t.Fatalf("created note: %+v", note)
+	}
+
+	var got models.Note
+	rec := s.do("GET", notePath(alice, note, ""), alice.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: %d %s", rec.Code, rec.Body)
+	}
+	decode(t, rec, &got)
+	if got.Title != "Title" || got.Content != "content" {
+		t.Errorf("got note: %+v", got)
+	}
+
+	rec = s.do("PUT", notePath(alice, note, ""), alice.Token, map[string]string{"title": "New", "content": "changed"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body)
+	}
+	decode(t, rec, &got)
+	if got.Title != "New" || got.Content != "changed" {
+		t.Errorf("updated note: %+v", got)
+	}
+
+	rec = s.do("DELETE", notePath(alice, note, ""), alice.Token, nil)
+	if rec.Code != http.StatusOK && rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("GET", notePath(alice, note, ""), alice.Token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("get after delete: %d", rec.Code)
+	}
+}
+
+func TestCreateNoteValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"valid", `{"title":"T","content":"c"}`, http.StatusCreated},
+		{"no title", `{"content":"c"}`, http.StatusBadRequest},
+		{"no content", `{"title":"T"}`, http.StatusBadRequest},
+		{"long title", `{"title":"` + strings.Repeat("a", 256) + `","content":"c"}`, http.StatusBadRequest},
+		{"invalid json", `{"title":`, http.StatusBadRequest},
+		{"markdown", `{"title":"T","content":"c","content_format":"markdown"}`, http.StatusCreated},
+		{"unknown format", `{"title":"T","content":"c","content_format":"html"}`, http.StatusBadRequest},
+		{"nul in title", `{"title":"T\u0000","content":"c"}`, http.StatusBadRequest},
+		{"nul in content", `{"title":"T","content":"c\u0000"}`, http.StatusBadRequest},
+		{"nul in tag", `{"title":"T","content":"c","tags":["a\u0000"]}`, http.StatusBadRequest},
+		{"content too long", `{"title":"T","content":"` + strings.Repeat("a", models.MaxContentLength+1) + `"}`, http.StatusBadRequest},
+	}
+
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do("POST", userPath(alice, "/notes"), alice.Token, tt.body)
+			if rec.Code != tt.status {
+				t.Errorf("status %d, want %d: %.200s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestNotesOfOtherUsers(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	note := s.createNote(alice, "Title", "content")
+
+	update := map[string]string{"title": "Hacked", "content": "hacked"}
+	bobsNote := "/users/" + strconv.Itoa(bob.User.ID) + "/notes/" + strconv.Itoa(note.ID)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"list through alice's path", "GET", userPath(alice, "/notes"), nil},
+		{"create for alice", "POST", userPath(alice, "/notes"), update},
+		{"get through alice's path", "GET", notePath(alice, note, ""), nil},
+		{"update through alice's path", "PUT", notePath(alice, note, ""), update},
+		{"delete through alice's path", "DELETE", notePath(alice, note, ""), nil},
+		{"get through own path", "GET", bobsNote, nil},
+		{"update through own path", "PUT", bobsNote, update},
+		{"delete through own path", "DELETE", bobsNote, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(tt.method, tt.path, bob.Token, tt.body)
+			if rec.Code != http.StatusForbidden && rec.Code != http.StatusNotFound {
+				t.Errorf("status %d, want 403 or 404: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+
+	var got models.Note
+	decode(t, s.do("GET", notePath(alice, note, ""), alice.Token, nil), &got)
+	if got.Title != "Title" {
+		t.Errorf("note changed by another user: %+v", got)
+	}
+}
+
+func TestGetUserNotesPagination(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	for i := 1; i <= 5; i++ {
+		s.createNote(alice, "Note "+strconv.Itoa(i), "content")
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		titles []string
+	}{
+		{"?sort=asc&limit=2", http.StatusOK, []string{"Note 1", "Note 2"}},
+		{"?sort=asc&limit=2&offset=4", http.StatusOK, []string{"Note 5"}},
+		{"?sort=desc&limit=1", http.StatusOK, []string{"Note 5"}},
+		{"?limit=-1", http.StatusBadRequest, nil},
+		{"?offset=x", http.StatusBadRequest, nil},
+		{"?sort=sideways", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := s.do("GET", userPath(alice, "/notes"+tt.query), alice.Token, nil)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var notes []models.Note
+			decode(t, rec, &notes)
+			var titles []string
+			for _, n := range notes {
+				titles = append(titles, n.Title)
+			}
+			if !slices.Equal(titles, tt.titles) {
+				t.Errorf("titles %q, want %q", titles, tt.titles)
+			}
+		})
+	}
+}
+
+func TestSearchNotes(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	s.createNote(alice, "Shopping list", "milk and bread")
+	s.createNote(alice, "Plan", "buy milk")
+	s.createNote(alice, "Other", "nothing")
+	s.createNote(bob, "Shopping", "milk")
+
+	tests := []struct {
+		name   string
+		user   *models.LoginResponse
+		query  string
+		status int
+		titles []string
+	}{
+		{"equal rank, newest first", alice, "?q=milk", http.StatusOK, []string{"Plan", "Shopping list"}},
+		{"title ranks first", alice, "?q=shopping", http.StatusOK, []string{"Shopping list"}},
+		{"limit", alice, "?q=milk&limit=1", http.StatusOK, []string{"Plan"}},
+		{"offset", alice, "?q=milk&offset=1", http.StatusOK, []string{"Shopping list"}},
+		{"no query", alice, "", http.StatusBadRequest, nil},
+		{"empty query", alice, "?q=%20", http.StatusBadRequest, nil},
+		{"invalid limit", alice, "?q=milk&limit=0", http.StatusBadRequest, nil},
+		{"other user", bob, "?q=milk", http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do("GET", userPath(alice, "/notes/search"+tt.query), tt.user.Token, nil)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var results []models.NoteSearchResult
+			decode(t, rec, &results)
+			var titles []string
+			for _, r := range results {
+				titles = append(titles, r.Title)
+			}
+			if !slices.Equal(titles, tt.titles) {
+				t.Errorf("titles %q, want %q", titles, tt.titles)
+			}
+		})
+	}
+}
+
+func TestGetUserNotesSortAndFilters(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	for _, n := range []struct{ title, content string }{
+		{"Beta", "short"},
+		{"alpha", "a longer content"},
+		{"Alpine", "medium text"},
+	} {
+		s.createNote(alice, n.title, n.content)
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+
+	tests := []struct {
+		query  string
+		status int
+		want   string
+	}{
+		{"sort=title", http.StatusOK, "Alpine,Beta,alpha"},
+		{"sort=-created_at", http.StatusOK, "Alpine,alpha,Beta"},
+		{"sort=asc", http.StatusOK, "Beta,alpha,Alpine"},
+		{"sort=title_prefix", http.StatusBadRequest, ""},
+		{"sort=asc&title_prefix=AL", http.StatusOK, "alpha,Alpine"},
+		{"sort=asc&min_length=6", http.StatusOK, "alpha,Alpine"},
+		{"sort=asc&max_length=11", http.StatusOK, "Beta,Alpine"},
+		{"sort=asc&min_length=6&max_length=11", http.StatusOK, "Alpine"},
+		{"min_length=5&max_length=4", http.StatusBadRequest, ""},
+		{"min_length=-1", http.StatusBadRequest, ""},
+		{"sort=asc&created_from=" + today + "&created_to=" + today, http.StatusOK, "Beta,alpha,Alpine"},
+		{"created_to=2000-01-01", http.StatusOK, ""},
+		{"updated_from=yesterday", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		rec := s.do("GET", userPath(alice, "/notes?"+tt.query), alice.Token, nil)
+		if rec.Code != tt.status {
+			t.Errorf("%s: %d %s", tt.query, rec.Code, rec.Body)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var notes []models.Note
+		decode(t, rec, &notes)
+		var titles []string
+		for _, n := range notes {
+			titles = append(titles, n.Title)
+		}
+		if got := strings.Join(titles, ","); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchSnippetsEscaped(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	s.createNote(alice, `<b>needle</b>`, `<img src=x onerror=alert(1)> needle & more`)
+
+	rec := s.do("GET", userPath(alice, "/notes/search?q=needle"), alice.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("search: %d %s", rec.Code, rec.Body)
+	}
+	var results []models.NoteSearchResult
+	decode(t, rec, &results)
+	if len(results) != 1 {
+		t.Fatalf("got %d results", len(results))
+	}
+
+	for _, snippet := range []string{results[0].TitleSnippet, results[0].ContentSnippet} {
+		if strings.Contains(snippet, "<img") || strings.Contains(snippet, "<b>") {
+			t.Errorf("snippet is not escaped: %s", snippet)
+		}
+		if !strings.Contains(snippet, "<mark>needle</mark>") {
+			t.Errorf("snippet has no highlight: %s", snippet)
+		}
+	}
+	if !strings.Contains(results[0].ContentSnippet, "&lt;img") || !strings.Contains(results[0].ContentSnippet, "&amp; more") {
+		t.Errorf("content snippet: %s", results[0].ContentSnippet)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/markdown"
+	"github.com/Balyshev/notes-api/pkg/sanitize"
+)
+
+// htmlRendererVersion - версия рендерера HTML заметок. Её нужно увеличить при изменении
+// pkg/markdown или pkg/sanitize: HTML ревизий, отрисованный старой версией, построится заново
+const htmlRendererVersion = "1"
+
+// noteHTMLETag строит ETag HTML представления заметки, он отличается от ETag JSON
+func noteHTMLETag(note *models.Note) string {
+	return fmt.Sprintf(`"v%d-html%s"`, note.Version, htmlRendererVersion)
+}
+
+// noteHTML возвращает HTML содержимого заметки. HTML кэшируется в последней ревизии:
+// ревизии неизменяемы, поэтому отрисовка происходит один раз на ревизию
+func noteHTML(ctx context.Context, store storage.NoteStore, note *models.Note) string {
+	rev, err := store.GetLatestRevision(ctx, note.ID)
+	if err != nil {
+		fmt.Println("ERROR: GetLatestRevision failed:", err)
+		return renderContentHTML(note.Content, note.Format)
+	}
+
+	// Заметку могли обновить между чтениями — тогда ревизия уже не соответствует ей
+	if rev.Content != note.Content || rev.Format != note.Format {
+		return renderContentHTML(note.Content, note.Format)
+	}
+
+	if rev.HTMLRenderer == htmlRendererVersion {
+		return rev.HTML
+	}
+
+	rendered := renderContentHTML(rev.Content, rev.Format)
+	if err := store.SaveRevisionHTML(ctx, rev.ID, htmlRendererVersion, rendered); err != nil {
+		fmt.Println("ERROR: SaveRevisionHTML failed:", err)
+	}
+	return rendered
+}
+
+// renderContentHTML отрисовывает содержимое заметки: markdown — как CommonMark с расширениями GFM,
+// plain — абзацами по пустым строкам. Результат всегда проходит через санитайзер
+func renderContentHTML(content, format string) string {
+	if format == models.FormatMarkdown {
+		return sanitize.HTML(markdown.Render(content))
+	}
+
+	var b strings.Builder
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	for _, paragraph := range strings.Split(content, "\n\n") {
+		paragraph = strings.Trim(paragraph, "\n")
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		lines := strings.Split(html.EscapeString(paragraph), "\n")
+		b.WriteString("<p>" + strings.Join(lines, "<br />\n") + "</p>\n")
+	}
+	return sanitize.HTML(b.String())
+}
+
+// respondNoteHTML отдаёт содержимое заметки HTML фрагментом
+func respondNoteHTML(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src https: http:; style-src 'unsafe-inline'")
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(body)); err != nil {
+		fmt.Println("ERROR: Failed to write note HTML:", err)
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestNoteHTMLSanitized(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string // фрагмент, который должен остаться
+	}{
+		{"script block", "<script>alert(1)</script>\n\ntext", "<p>text</p>"},
+		{"inline script", "a <script>alert(1)</script> b", "a "},
+		{"event handler", `<img src="x.png" onerror="alert(1)">`, `<img src="x.png"`},
+		{"javascript link", "[click](javascript:alert(1))", ">click</a>"},
+		{"javascript autolink", "<javascript:alert(1)>", "<a rel="},
+		{"encoded javascript", "[click](jav&#x09;ascript:alert(1))", ">click</a>"},
+		{"javascript image", "![x](javascript:alert(1))", ""},
+		{"iframe", `<iframe src="https://evil.example"></iframe>`, ""},
+		{"style attribute", `<p style="background:url(javascript:alert(1))">x</p>`, "x</p>"},
+		{"svg", `<svg onload="alert(1)"><circle/></svg>`, ""},
+		{"data uri", `<a href="data:text/html,<script>alert(1)</script>">x</a>`, "x</a>"},
+		{"raw html in table", "| a |\n|---|\n| <script>alert(1)</script> |", "<table>"},
+		{"safe markdown", "**bold** and [link](https://example.com)", `<a href="https://example.com"`},
+	}
+	// javascript: в тексте ссылки безопасен, опасен только в атрибутах
+	forbidden := []string{"<script", `="javascript:`, `=javascript:`, "onerror", "onload", "<iframe", "style=", `="data:`, "<svg"}
+
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do("POST", userPath(alice, "/notes"), alice.Token, map[string]string{
+				"title":          "XSS",
+				"content":        tt.content,
+				"content_format": "markdown",
+			})
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("create: %d %s", rec.Code, rec.Body)
+			}
+			var note models.Note
+			decode(t, rec, &note)
+
+			rec = s.do("GET", notePath(alice, &note, ""), alice.Token, nil, "Accept", "text/html")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("get html: %d %s", rec.Code, rec.Body)
+			}
+			html := rec.Body.String()
+			lower := strings.ToLower(html)
+			for _, f := range forbidden {
+				if strings.Contains(lower, f) {
+					t.Errorf("HTML contains %q: %s", f, html)
+				}
+			}
+			if !strings.Contains(html, tt.want) {
+				t.Errorf("HTML %q does not contain %q", html, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainNoteHTML(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"one\ntwo\n\nthree", "<p>one<br />\ntwo</p>\n<p>three</p>\n"},
+		{"<b>not bold</b> & **not markdown**", "<p>&lt;b&gt;not bold&lt;/b&gt; &amp; **not markdown**</p>\n"},
+		{"\r\n\r\n  \n\nlast", "<p>last</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := renderContentHTML(tt.content, models.FormatPlain); got != tt.want {
+			t.Errorf("renderContentHTML(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
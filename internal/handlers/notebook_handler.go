@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// NotebookHandler обрабатывает запросы к /users/{id}/notebooks и перенос заметок между блокнотами
+type NotebookHandler struct {
+	storage storage.NotebookStore
+	notes   storage.NoteStore
+}
+
+// NewNotebookHandler создаёт новый NotebookHandler
+func NewNotebookHandler(storage storage.NotebookStore, notes storage.NoteStore) *NotebookHandler {
+	return &NotebookHandler{
+		storage: storage,
+		notes:   notes,
+	}
+}
+
+// CreateNotebook обрабатывает POST /users/{id}/notebooks
+func (h *NotebookHandler) CreateNotebook(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== CreateNotebook called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateNotebookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	notebook, err := h.storage.CreateNotebook(r.Context(), userID, req.ParentID, req.Name)
+	if err != nil {
+		if err == models.ErrNotebookNotFound {
+			respondError(w, http.StatusNotFound, "Parent notebook not found")
+			return
+		}
+		fmt.Println("ERROR: CreateNotebook failed:", err)
+		respondStorageError(w, err, "Failed to create notebook")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, notebook)
+}
+
+// GetNotebooks обрабатывает GET /users/{id}/notebooks — все блокноты плоским списком
+func (h *NotebookHandler) GetNotebooks(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetNotebooks called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	notebooks, err := h.storage.GetUserNotebooks(r.Context(), userID)
+	if err != nil {
+		fmt.Println("ERROR: GetUserNotebooks failed:", err)
+		respondStorageError(w, err, "Failed to get notebooks")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, notebooks)
+}
+
+// GetNotebook обрабатывает GET /users/{id}/notebooks/{notebook_id}
+func (h *NotebookHandler) GetNotebook(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetNotebook called ===")
+
+	notebook, ok := h.ownedNotebook(w, r)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, notebook)
+}
+
+// RenameNotebook обрабатывает PUT /users/{id}/notebooks/{notebook_id}
+func (h *NotebookHandler) RenameNotebook(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== RenameNotebook called ===")
+
+	notebook, ok := h.ownedNotebook(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RenameNotebookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	renamed, err := h.storage.RenameNotebook(r.Context(), notebook.ID, req.Name)
+	if err != nil {
+		if err == models.ErrNotebookNotFound {
+			respondError(w, http.StatusNotFound, "Notebook not found")
+			return
+		}
+		fmt.Println("ERROR: RenameNotebook failed:", err)
+		respondStorageError(w, err, "Failed to rename notebook")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, renamed)
+}
+
+// MoveNotebook обрабатывает POST /users/{id}/notebooks/{notebook_id}/move — перенос вместе с содержимым
+func (h *NotebookHandler) MoveNotebook(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== MoveNotebook called ===")
+
+	notebook, ok := h.ownedNotebook(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MoveNotebookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	moved, err := h.storage.MoveNotebook(r.Context(), notebook.ID, req.ParentID)
+	if err != nil {
+		switch err {
+		case models.ErrNotebookNotFound:
+			respondError(w, http.StatusNotFound, "Notebook not found")
+		case models.ErrNotebookCycle:
+			respondError(w, http.StatusConflict, err.Error())
+		default:
+			fmt.Println("ERROR: MoveNotebook failed:", err)
+			respondStorageError(w, err, "Failed to move notebook")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, moved)
+}
+
+// DeleteNotebook обрабатывает DELETE /users/{id}/notebooks/{notebook_id}.
+// Непустой блокнот удаляется только с ?cascade=true, его заметки переносятся в корзину
+func (h *NotebookHandler) DeleteNotebook(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== DeleteNotebook called ===")
+
+	notebook, ok := h.ownedNotebook(w, r)
+	if !ok {
+		return
+	}
+
+	cascade := r.URL.Query().Get("cascade") == "true"
+
+	result, err := h.storage.DeleteNotebook(r.Context(), notebook.ID, cascade)
+	if err != nil {
+		switch err {
+		case models.ErrNotebookNotFound:
+			respondError(w, http.StatusNotFound, "Notebook not found")
+		case models.ErrNotebookNotEmpty:
+			respondError(w, http.StatusConflict, err.Error())
+		default:
+			fmt.Println("ERROR: DeleteNotebook failed:", err)
+			respondStorageError(w, err, "Failed to delete notebook")
+		}
+		return
+	}
+
+	fmt.Printf("Notebook %d deleted: %+v\n", notebook.ID, result)
+	respondJSON(w, http.StatusOK, result)
+}
+
+// MoveNote обрабатывает POST /users/{id}/notes/{note_id}/move (поддерживает If-Match)
+func (h *NotebookHandler) MoveNote(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== MoveNote called ===")
+
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid note ID")
+		return
+	}
+
+	existingNote, err := h.notes.GetNoteByID(r.Context(), noteID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found")
+			return
+		}
+		respondStorageError(w, err, "Failed to get note")
+		return
+	}
+
+	if existingNote.UserID != userID {
+		respondError(w, http.StatusForbidden, "You don't have permission to move this note")
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(r, existingNote)
+	if !ok {
+		respondPreconditionFailed(w, existingNote)
+		return
+	}
+
+	var req models.MoveNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	note, err := h.storage.MoveNote(r.Context(), noteID, req.NotebookID, expectedVersion)
+	if err != nil {
+		switch err {
+		case models.ErrNoteNotFound:
+			respondError(w, http.StatusNotFound, "Note not found")
+		case models.ErrNotebookNotFound:
+			respondError(w, http.StatusNotFound, "Notebook not found")
+		case models.ErrVersionMismatch:
+			current, err := h.notes.GetNoteByID(r.Context(), noteID)
+			if err != nil {
+				respondStorageError(w, err, "Failed to get note")
+				return
+			}
+			respondPreconditionFailed(w, current)
+		default:
+			fmt.Println("ERROR: MoveNote failed:", err)
+			respondStorageError(w, err, "Failed to move note")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", noteETag(note))
+	respondJSON(w, http.StatusOK, note)
+}
+
+// ownedNotebook получает блокнот {notebook_id} текущего пользователя.
+// Чужой блокнот выглядит как несуществующий. При ошибке сам отправляет ответ и возвращает false
+func (h *NotebookHandler) ownedNotebook(w http.ResponseWriter, r *http.Request) (*models.Notebook, bool) {
+	userID, ok := h.authorizedUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	notebookID, err := strconv.Atoi(chi.URLParam(r, "notebook_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid notebook ID")
+		return nil, false
+	}
+
+	notebook, err := h.storage.GetNotebook(r.Context(), notebookID)
+	if err != nil {
+		if err == models.ErrNotebookNotFound {
+			respondError(w, http.StatusNotFound, "Notebook not found")
+			return nil, false
+		}
+		respondStorageError(w, err, "Failed to get notebook")
+		return nil, false
+	}
+
+	if notebook.UserID != userID {
+		respondError(w, http.StatusNotFound, "Notebook not found")
+		return nil, false
+	}
+
+	return notebook, true
+}
+
+// authorizedUser проверяет, что пользователь работает со своими блокнотами
+func (h *NotebookHandler) authorizedUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return 0, false
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only manage your own notebooks")
+		return 0, false
+	}
+
+	return authenticatedUserID, true
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestNotebooks(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+
+	createNotebook := func(name string, parentID *int) *models.Notebook {
+		t.Helper()
+		rec := s.do("POST", userPath(alice, "/notebooks"), alice.Token, models.CreateNotebookRequest{Name: name, ParentID: parentID})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create notebook %q: %d %s", name, rec.Code, rec.Body)
+		}
+		var nb models.Notebook
+		decode(t, rec, &nb)
+		return &nb
+	}
+	work := createNotebook("Work", nil)
+	projects := createNotebook("Projects", &work.ID)
+	notebookPath := func(nb *models.Notebook, path string) string {
+		return userPath(alice, "/notebooks/"+strconv.Itoa(nb.ID)+path)
+	}
+
+	rec := s.do("POST", userPath(alice, "/notes"), alice.Token, map[string]interface{}{
+		"title": "Plan", "content": "content", "notebook_id": projects.ID,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create note in notebook: %d %s", rec.Code, rec.Body)
+	}
+	var planned models.Note
+	decode(t, rec, &planned)
+	loose := s.createNote(alice, "Loose", "content")
+
+	listed := func(query string) []int {
+		t.Helper()
+		rec := s.do("GET", userPath(alice, "/notes?"+query), alice.Token, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list %q: %d %s", query, rec.Code, rec.Body)
+		}
+		var notes []models.Note
+		decode(t, rec, &notes)
+		ids := make([]int, len(notes))
+		for i, n := range notes {
+			ids[i] = n.ID
+		}
+		return ids
+	}
+	filters := []struct {
+		query string
+		want  []int
+	}{
+		{"notebook_id=" + strconv.Itoa(projects.ID), []int{planned.ID}},
+		{"notebook_id=" + strconv.Itoa(work.ID), []int{}},
+		{"notebook_id=" + strconv.Itoa(work.ID) + "&recursive=true", []int{planned.ID}},
+		{"notebook_id=none", []int{loose.ID}},
+	}
+	for _, tt := range filters {
+		if got := listed(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		user   *models.LoginResponse
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"empty name", alice, "POST", userPath(alice, "/notebooks"), models.CreateNotebookRequest{Name: " "}, http.StatusBadRequest},
+		{"unknown parent", alice, "POST", userPath(alice, "/notebooks"), map[string]interface{}{"name": "Orphan", "parent_id": 999}, http.StatusNotFound},
+		{"rename", alice, "PUT", notebookPath(work, ""), models.RenameNotebookRequest{Name: "Job"}, http.StatusOK},
+		{"move into descendant", alice, "POST", notebookPath(work, "/move"), models.MoveNotebookRequest{ParentID: &projects.ID}, http.StatusConflict},
+		{"other user reads", bob, "GET", notebookPath(work, ""), nil, http.StatusForbidden},
+		{"move note to top level", alice, "POST", notePath(alice, &planned, "/move"), models.MoveNoteRequest{}, http.StatusOK},
+		{"move note to unknown notebook", alice, "POST", notePath(alice, loose, "/move"), map[string]int{"notebook_id": 999}, http.StatusNotFound},
+		{"move note to notebook", alice, "POST", notePath(alice, loose, "/move"), models.MoveNoteRequest{NotebookID: &projects.ID}, http.StatusOK},
+		{"delete non-empty", alice, "DELETE", notebookPath(work, ""), nil, http.StatusConflict},
+		{"delete with cascade", alice, "DELETE", notebookPath(work, "?cascade=true"), nil, http.StatusOK},
+		{"deleted notebook", alice, "GET", notebookPath(projects, ""), nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := s.do(tt.method, tt.path, tt.user.Token, tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body)
+		}
+	}
+
+	// Заметка из удалённого блокнота ушла в корзину, вынутая — осталась
+	if got := listed("notebook_id=none"); !slices.Equal(got, []int{planned.ID}) {
+		t.Errorf("notes after cascade delete: %v", got)
+	}
+}
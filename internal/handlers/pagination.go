@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// pageURL возвращает URL текущего запроса с заменённым параметром пагинации
+func pageURL(r *http.Request, param, value string) string {
+	query := r.URL.Query()
+	query.Set(param, value)
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// setLinkHeader выставляет заголовок Link (RFC 8288) для next/prev/first
+func setLinkHeader(w http.ResponseWriter, links map[string]string) {
+	var parts []string
+	for _, rel := range []string{"next", "prev", "first"} {
+		if url, ok := links[rel]; ok {
+			parts = append(parts, "<"+url+`>; rel="`+rel+`"`)
+		}
+	}
+	if len(parts) > 0 {
+		w.Header().Set("Link", strings.Join(parts, ", "))
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestGetUserNotesCursorPagination(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	s.createNote(bob, "Not alice's", "content")
+
+	for i := 1; i <= 5; i++ {
+		s.createNote(alice, "Note "+strconv.Itoa(i), "content")
+	}
+
+	page := func(query string) models.NotePage {
+		t.Helper()
+		rec := s.do("GET", userPath(alice, "/notes?limit=2&sort=title:asc&"+query), alice.Token, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", query, rec.Code, rec.Body)
+		}
+		var p models.NotePage
+		decode(t, rec, &p)
+		return p
+	}
+	titles := func(p models.NotePage) string {
+		var ts []string
+		for _, n := range p.Data {
+			ts = append(ts, strings.TrimPrefix(n.Title, "Note "))
+		}
+		return strings.Join(ts, ",")
+	}
+
+	first := page("pagination=cursor")
+	if titles(first) != "1,2" || first.NextCursor == nil || first.PrevCursor != nil {
+		t.Fatalf("first page: %s next=%v prev=%v", titles(first), first.NextCursor, first.PrevCursor)
+	}
+
+	second := page("cursor=" + *first.NextCursor)
+	if titles(second) != "3,4" || second.NextCursor == nil || second.PrevCursor == nil {
+		t.Fatalf("second page: %s", titles(second))
+	}
+
+	last := page("cursor=" + *second.NextCursor)
+	if titles(last) != "5" || last.NextCursor != nil || last.PrevCursor == nil {
+		t.Fatalf("last page: %s next=%v", titles(last), last.NextCursor)
+	}
+
+	back := page("cursor=" + *last.PrevCursor)
+	if titles(back) != "3,4" || back.NextCursor == nil || back.PrevCursor == nil {
+		t.Fatalf("previous page: %s", titles(back))
+	}
+
+	// Заметка, созданная между запросами, не сдвигает следующую страницу
+	s.createNote(alice, "Note 0", "content")
+	if again := page("cursor=" + *first.NextCursor); titles(again) != "3,4" {
+		t.Errorf("second page after insert: %s", titles(again))
+	}
+
+	rec := s.do("GET", userPath(alice, "/notes?sort=updated_at:desc&cursor="+*first.NextCursor), alice.Token, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("cursor for a different sort: %d", rec.Code)
+	}
+	rec = s.do("GET", userPath(alice, "/notes?offset=2&cursor="+*first.NextCursor), alice.Token, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("cursor with offset: %d", rec.Code)
+	}
+}
+
+func TestGetUserNotesLinkHeader(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	for i := 1; i <= 5; i++ {
+		s.createNote(alice, "Note "+strconv.Itoa(i), "content")
+	}
+
+	tests := []struct {
+		query string
+		links []string
+		total string
+	}{
+		{"limit=2", []string{`offset=2>; rel="next"`}, ""},
+		{"limit=2&offset=2", []string{`offset=4>; rel="next"`, `offset=0>; rel="prev"`}, ""},
+		{"limit=2&offset=4&include_total=true", []string{`offset=2>; rel="prev"`}, "5"},
+		{"limit=10", nil, ""},
+	}
+	for _, tt := range tests {
+		rec := s.do("GET", userPath(alice, "/notes?"+tt.query), alice.Token, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", tt.query, rec.Code, rec.Body)
+		}
+		link := rec.Header().Get("Link")
+		if len(tt.links) == 0 && link != "" {
+			t.Errorf("%s: Link %q, want none", tt.query, link)
+		}
+		for _, want := range tt.links {
+			if !strings.Contains(link, want) {
+				t.Errorf("%s: Link %q, want %q", tt.query, link, want)
+			}
+		}
+		if got := rec.Header().Get("X-Total-Count"); got != tt.total {
+			t.Errorf("%s: X-Total-Count %q, want %q", tt.query, got, tt.total)
+		}
+	}
+
+	rec := s.do("GET", userPath(alice, "/notes?pagination=cursor&limit=2&include_total=true"), alice.Token, nil)
+	var page models.NotePage
+	decode(t, rec, &page)
+	if page.Total == nil || *page.Total != 5 || len(page.Data) != 2 {
+		t.Errorf("cursor page with total: %d notes, total %v", len(page.Data), page.Total)
+	}
+	if rec := s.do("GET", userPath(alice, "/notes?cursor=garbage"), alice.Token, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid cursor: %d", rec.Code)
+	}
+}
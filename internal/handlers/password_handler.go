@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/mail"
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/pkg/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// ChangePassword обрабатывает POST /users/{id}/password.
+// Все сессии пользователя отзываются, вызывающему выдаётся новая. Неверный current_password
+// считается неудачным входом: иначе украденным access токеном можно было бы подбирать пароль
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== ChangePassword called ===")
+
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only change your own password")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.storage.GetUserByID(r.Context(), authenticatedUserID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			respondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		fmt.Println("ERROR: Failed to get user:", err)
+		respondStorageError(w, err, "Failed to change password")
+		return
+	}
+
+	ip := middleware.ClientIP(r)
+	wait, err := h.guard.Check(r.Context(), user.Username, ip)
+	if err != nil {
+		fmt.Println("ERROR: Failed to check login attempts:", err)
+		respondStorageError(w, err, "Failed to change password")
+		return
+	}
+	if wait > 0 {
+		respondTooManyRequests(w, wait, "Too many failed password attempts, try again later")
+		return
+	}
+
+	if !auth.CheckPassword(req.CurrentPassword, user.PasswordHash) {
+		if _, err := h.guard.Fail(r.Context(), user.Username, ip); err != nil {
+			fmt.Println("ERROR: Failed to record login failure:", err)
+			respondStorageError(w, err, "Failed to change password")
+			return
+		}
+		respondError(w, http.StatusForbidden, models.ErrCurrentPasswordWrong.Error())
+		return
+	}
+
+	if err := h.guard.Succeed(r.Context(), user.Username); err != nil {
+		fmt.Println("ERROR: Failed to reset login attempts:", err)
+	}
+
+	passwordHash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		fmt.Println("ERROR: Failed to hash password:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	// Пароль меняется вместе с отзывом сессий одной транзакцией
+	if err := h.storage.UpdatePassword(r.Context(), user.ID, passwordHash); err != nil {
+		fmt.Println("ERROR: Failed to update password:", err)
+		respondStorageError(w, err, "Failed to change password")
+		return
+	}
+
+	response, err := h.startSession(r, user)
+	if err != nil {
+		fmt.Println("ERROR: Failed to start session:", err)
+		respondStorageError(w, err, "Failed to generate token")
+		return
+	}
+
+	fmt.Printf("Password changed: %s\n", user.Username)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// ForgotPassword обрабатывает POST /auth/password/forgot — отправляет письмо с токеном сброса.
+// Ответ всегда 202, чтобы по нему нельзя было узнать, существует ли пользователь
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== ForgotPassword called ===")
+
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	accepted := map[string]string{"message": "If the account exists and has an email, a reset token has been sent"}
+
+	user, err := h.storage.GetUserByUsername(r.Context(), req.Username)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			respondJSON(w, http.StatusAccepted, accepted)
+			return
+		}
+		fmt.Println("ERROR: Failed to get user:", err)
+		respondStorageError(w, err, "Failed to request password reset")
+		return
+	}
+
+	if user.Email == "" {
+		fmt.Printf("Password reset requested for %s without email\n", user.Username)
+		respondJSON(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	token, tokenHash, err := auth.GenerateResetToken()
+	if err != nil {
+		fmt.Println("ERROR: Failed to generate reset token:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	if err := h.storage.CreatePasswordReset(r.Context(), user.ID, tokenHash, time.Now().Add(auth.PasswordResetTTL)); err != nil {
+		fmt.Println("ERROR: Failed to create password reset:", err)
+		respondStorageError(w, err, "Failed to request password reset")
+		return
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse this token to set a new password via POST /auth/password/reset:\n\n%s\n\nThe token expires in %s. If you did not request a reset, ignore this email.\n",
+			user.Username, token, auth.PasswordResetTTL,
+		),
+	}
+	if err := h.mailer.Send(r.Context(), msg); err != nil {
+		fmt.Println("ERROR: Failed to send reset email:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to send email")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, accepted)
+}
+
+// ResetPassword обрабатывает POST /auth/password/reset — задаёт новый пароль по токену из письма
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== ResetPassword called ===")
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		fmt.Println("ERROR: Failed to hash password:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	userID, err := h.storage.ResetPassword(r.Context(), auth.HashToken(req.Token), passwordHash)
+	if err != nil {
+		if err == models.ErrInvalidResetToken {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		fmt.Println("ERROR: Failed to reset password:", err)
+		respondStorageError(w, err, "Failed to reset password")
+		return
+	}
+
+	fmt.Printf("Password reset for user %d\n", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestChangePassword(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	tests := []struct {
+		name   string
+		req    models.ChangePasswordRequest
+		status int
+	}{
+		{"wrong current password", models.ChangePasswordRequest{CurrentPassword: "wrong-password", NewPassword: "new-password"}, http.StatusForbidden},
+		{"short new password", models.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "123"}, http.StatusBadRequest},
+		{"no current password", models.ChangePasswordRequest{NewPassword: "new-password"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := s.do("POST", userPath(alice, "/password"), alice.Token, tt.req); rec.Code != tt.status {
+			t.Errorf("%s: %d %s", tt.name, rec.Code, rec.Body)
+		}
+	}
+
+	rec := s.do("POST", userPath(alice, "/password"), alice.Token, models.ChangePasswordRequest{
+		CurrentPassword: "password123",
+		NewPassword:     "new-password",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("change password: %d %s", rec.Code, rec.Body)
+	}
+	var resp models.LoginResponse
+	decode(t, rec, &resp)
+
+	// Старые сессии отозваны, вызывающему выдана новая
+	if rec := s.do("GET", userPath(alice, "/notes"), alice.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("old token: %d", rec.Code)
+	}
+	if rec := s.do("GET", userPath(alice, "/notes"), resp.Token, nil); rec.Code != http.StatusOK {
+		t.Errorf("new token: %d", rec.Code)
+	}
+	if rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "password123"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("login with old password: %d", rec.Code)
+	}
+	if rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "new-password"}); rec.Code != http.StatusOK {
+		t.Errorf("login with new password: %d", rec.Code)
+	}
+}
+
+func TestChangePasswordLockout(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	change := func(current string) *httptest.ResponseRecorder {
+		return s.do("POST", userPath(alice, "/password"), alice.Token, models.ChangePasswordRequest{
+			CurrentPassword: current,
+			NewPassword:     "new-password",
+		})
+	}
+
+	// Неверный current_password считается неудачным входом: DefaultConfig — 5 неудач без блокировки
+	for i := 1; i <= 6; i++ {
+		if rec := change("wrong-password"); rec.Code != http.StatusForbidden {
+			t.Fatalf("wrong password #%d: %d", i, rec.Code)
+		}
+	}
+	rec := change("password123")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("valid password while locked: %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "password123"}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("login while locked: %d", rec.Code)
+	}
+}
+
+func TestDeleteUserLockout(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	remove := func(password string) *httptest.ResponseRecorder {
+		return s.do("DELETE", userPath(alice, ""), alice.Token, models.DeleteUserRequest{Password: password})
+	}
+
+	// Пароль подтверждения удаления считается так же, как вход и смена пароля
+	for i := 1; i <= 4; i++ {
+		if rec := remove("wrong-password"); rec.Code != http.StatusForbidden {
+			t.Fatalf("wrong password #%d: %d", i, rec.Code)
+		}
+	}
+	// Верный пароль сбрасывает счётчик
+	if rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "password123"}); rec.Code != http.StatusOK {
+		t.Fatalf("login: %d %s", rec.Code, rec.Body)
+	}
+	for i := 1; i <= 6; i++ {
+		if rec := remove("wrong-password"); rec.Code != http.StatusForbidden {
+			t.Fatalf("wrong password #%d after reset: %d", i, rec.Code)
+		}
+	}
+	rec := remove("password123")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("valid password while locked: %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if _, err := s.store.GetUserByID(t.Context(), alice.User.ID); err != nil {
+		t.Errorf("user deleted while locked: %v", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if rec := remove("password123"); rec.Code != http.StatusNoContent {
+		t.Errorf("valid password after the lock: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	// Ответ не выдаёт, существует ли пользователь
+	if rec := s.do("POST", "/auth/password/forgot", "", models.ForgotPasswordRequest{Username: "nobody"}); rec.Code != http.StatusAccepted {
+		t.Fatalf("forgot for unknown user: %d %s", rec.Code, rec.Body)
+	}
+	if len(s.mailer.sent()) != 0 {
+		t.Fatal("mail sent for unknown user")
+	}
+
+	if rec := s.do("POST", "/auth/password/forgot", "", models.ForgotPasswordRequest{Username: "alice"}); rec.Code != http.StatusAccepted {
+		t.Fatalf("forgot: %d %s", rec.Code, rec.Body)
+	}
+	sent := s.mailer.sent()
+	if len(sent) != 1 || sent[0].To != "alice@example.com" {
+		t.Fatalf("sent mail: %+v", sent)
+	}
+	token := strings.Split(sent[0].Body, "\n\n")[2]
+
+	tests := []struct {
+		name   string
+		req    models.ResetPasswordRequest
+		status int
+	}{
+		{"wrong token", models.ResetPasswordRequest{Token: token + "x", NewPassword: "new-password"}, http.StatusBadRequest},
+		{"short password", models.ResetPasswordRequest{Token: token, NewPassword: "123"}, http.StatusBadRequest},
+		{"valid", models.ResetPasswordRequest{Token: token, NewPassword: "new-password"}, http.StatusNoContent},
+		{"token reuse", models.ResetPasswordRequest{Token: token, NewPassword: "other-password"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := s.do("POST", "/auth/password/reset", "", tt.req); rec.Code != tt.status {
+			t.Errorf("%s: %d %s", tt.name, rec.Code, rec.Body)
+		}
+	}
+
+	if rec := s.do("GET", userPath(alice, "/notes"), alice.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("session after reset: %d", rec.Code)
+	}
+	if rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "new-password"}); rec.Code != http.StatusOK {
+		t.Errorf("login with new password: %d", rec.Code)
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/pkg/patch"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+
+	maxPatchSize = 1 << 20 // 1 MB
+)
+
+// patchDocument - представление заметки, к которому применяется патч
+type patchDocument struct {
+	Title   *string   `json:"title"`
+	Content *string   `json:"content"`
+	Format  *string   `json:"content_format"`
+	Tags    *[]string `json:"tags"`
+}
+
+// PatchNote обрабатывает PATCH /users/{id}/notes/{note_id}
+// Content-Type: application/merge-patch+json (RFC 7396, также application/json)
+// или application/json-patch+json (RFC 6902). Меняются только затронутые патчем поля
+func (h *NoteHandler) PatchNote(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== PatchNote called ===")
+
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid note ID")
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != contentTypeMergePatch && mediaType != contentTypeJSONPatch && mediaType != "application/json") {
+		w.Header().Set("Accept-Patch", contentTypeMergePatch+", "+contentTypeJSONPatch)
+		respondError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+contentTypeMergePatch+" or "+contentTypeJSONPatch)
+		return
+	}
+
+	existingNote, err := h.storage.GetNoteByID(r.Context(), noteID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found")
+			return
+		}
+		respondStorageError(w, err, "Failed to get note")
+		return
+	}
+
+	permission, ok := notePermission(w, r, h.shares, existingNote, authenticatedUserID, userIDFromURL)
+	if !ok {
+		return
+	}
+
+	if !models.CanEdit(permission) {
+		respondError(w, http.StatusForbidden, "You don't have permission to update this note")
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(r, existingNote)
+	if !ok {
+		respondPreconditionFailed(w, existingNote)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(w, http.StatusRequestEntityTooLarge, models.ErrPatchTooLarge.Error())
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	notePatch, status, err := buildNotePatch(existingNote, mediaType, body)
+	if err != nil {
+		respondError(w, status, err.Error())
+		return
+	}
+
+	fmt.Printf("Parsed patch: title=%v content=%v format=%v tags=%v\n", notePatch.Title != nil, notePatch.Content != nil, notePatch.Format != nil, notePatch.Tags != nil)
+
+	if err := notePatch.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Патч, который ничего не меняет, успешен (RFC 6902, RFC 7396): новая версия не создаётся
+	if notePatch.Empty() {
+		w.Header().Set("ETag", noteETag(existingNote))
+		respondJSON(w, http.StatusOK, existingNote)
+		return
+	}
+
+	note, err := h.storage.PatchNote(r.Context(), noteID, *notePatch, expectedVersion)
+	if err != nil {
+		if err == models.ErrVersionMismatch || err == models.ErrNoteNotFound {
+			respondConflict(w, r, h.storage, noteID)
+			return
+		}
+		fmt.Println("ERROR: PatchNote failed:", err)
+		respondStorageError(w, err, "Failed to update note")
+		return
+	}
+
+	fmt.Printf("Note Patched: %+v\n", note)
+	w.Header().Set("ETag", noteETag(note))
+	respondJSON(w, http.StatusOK, note)
+}
+
+// buildNotePatch применяет патч к текущей заметке и возвращает изменившиеся поля.
+// При ошибке возвращает HTTP статус для ответа
+func buildNotePatch(note *models.Note, mediaType string, body []byte) (*models.NotePatch, int, error) {
+	tags := note.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	current, err := json.Marshal(patchDocument{Title: &note.Title, Content: &note.Content, Format: &note.Format, Tags: &tags})
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	var patched []byte
+	if mediaType == contentTypeJSONPatch {
+		patched, err = patch.JSONPatch(current, body)
+	} else {
+		patched, err = patch.MergePatch(current, body)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, patch.ErrTestFailed):
+			return nil, http.StatusConflict, err
+		case errors.Is(err, patch.ErrInvalidPatch):
+			return nil, http.StatusBadRequest, err
+		default:
+			return nil, http.StatusUnprocessableEntity, err
+		}
+	}
+
+	var result patchDocument
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("patch produces invalid note: %w", err)
+	}
+
+	// Удалённые патчем title/content — это пустые значения, их отклонит Validate
+	empty := ""
+	if result.Title == nil {
+		result.Title = &empty
+	}
+	if result.Content == nil {
+		result.Content = &empty
+	}
+	if result.Tags == nil {
+		result.Tags = &[]string{}
+	}
+	// Удалённый формат возвращается к значению по умолчанию
+	if result.Format == nil {
+		plain := models.FormatPlain
+		result.Format = &plain
+	}
+
+	notePatch := &models.NotePatch{}
+	if *result.Title != note.Title {
+		notePatch.Title = result.Title
+	}
+	if *result.Content != note.Content {
+		notePatch.Content = result.Content
+	}
+	if *result.Format != note.Format {
+		notePatch.Format = result.Format
+	}
+	if !equalTags(*result.Tags, tags) {
+		notePatch.Tags = result.Tags
+	}
+	return notePatch, http.StatusOK, nil
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestPatchNote(t *testing.T) {
+	const (
+		merge     = "application/merge-patch+json"
+		jsonPatch = "application/json-patch+json"
+	)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		ifMatch     string
+		status      int
+		version     int // версия заметки после запроса
+		title       string
+		content     string
+	}{
+		{
+			name: "merge patch", contentType: merge, body: `{"title":"Patched"}`,
+			status: http.StatusOK, version: 2, title: "Patched", content: "content",
+		},
+		{
+			name: "json patch", contentType: jsonPatch, body: `[{"op":"test","path":"/title","value":"Title"},{"op":"replace","path":"/content","value":"patched"}]`,
+			status: http.StatusOK, version: 2, title: "Title", content: "patched",
+		},
+		{
+			name: "plain json is merge patch", contentType: "application/json", body: `{"content":"patched"}`,
+			status: http.StatusOK, version: 2, title: "Title", content: "patched",
+		},
+		{
+			name: "no-op json patch", contentType: jsonPatch, body: `[{"op":"test","path":"/title","value":"Title"}]`,
+			status: http.StatusOK, version: 1, title: "Title", content: "content",
+		},
+		{
+			name: "no-op merge patch", contentType: merge, body: `{"title":"Title"}`,
+			status: http.StatusOK, version: 1, title: "Title", content: "content",
+		},
+		{
+			name: "failed test", contentType: jsonPatch, body: `[{"op":"test","path":"/title","value":"Other"},{"op":"replace","path":"/title","value":"X"}]`,
+			status: http.StatusConflict, version: 1, title: "Title", content: "content",
+		},
+		{
+			name: "empty title", contentType: merge, body: `{"title":""}`,
+			status: http.StatusBadRequest, version: 1, title: "Title", content: "content",
+		},
+		{
+			name: "nul in content", contentType: merge, body: `{"content":"a\u0000b"}`,
+			status: http.StatusBadRequest, version: 1, title: "Title", content: "content",
+		},
+		{
+			name: "stale If-Match", contentType: merge, body: `{"title":"Patched"}`, ifMatch: `"v0"`,
+			status: http.StatusPreconditionFailed, version: 1, title: "Title", content: "content",
+		},
+		{
+			name: "current If-Match", contentType: merge, body: `{"title":"Patched"}`, ifMatch: `"v1"`,
+			status: http.StatusOK, version: 2, title: "Patched", content: "content",
+		},
+		{
+			name: "unsupported media type", contentType: "text/plain", body: `{"title":"Patched"}`,
+			status: http.StatusUnsupportedMediaType, version: 1, title: "Title", content: "content",
+		},
+		{
+			name: "body too large", contentType: merge, body: `{"content":"` + strings.Repeat("a", maxPatchSize) + `"}`,
+			status: http.StatusRequestEntityTooLarge, version: 1, title: "Title", content: "content",
+		},
+	}
+
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note := s.createNote(alice, "Title", "content")
+			headers := []string{"Content-Type", tt.contentType}
+			if tt.ifMatch != "" {
+				headers = append(headers, "If-Match", tt.ifMatch)
+			}
+
+			rec := s.do("PATCH", notePath(alice, note, ""), alice.Token, tt.body, headers...)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if rec.Code == http.StatusOK {
+				if got, want := rec.Header().Get("ETag"), `"v`+strconv.Itoa(tt.version)+`"`; got != want {
+					t.Errorf("ETag %s, want %s", got, want)
+				}
+			}
+
+			var current models.Note
+			decode(t, s.do("GET", notePath(alice, note, ""), alice.Token, nil), &current)
+			if current.Version != tt.version || current.Title != tt.title || current.Content != tt.content {
+				t.Errorf("note after patch: version %d, title %q, content %q", current.Version, current.Title, current.Content)
+			}
+		})
+	}
+}
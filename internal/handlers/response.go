@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type ErrorResponse struct {
@@ -19,3 +23,28 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, ErrorResponse{Error: message})
 }
+
+// respondStorageError отвечает на ошибку хранилища:
+// таймаут запроса — 504, отмена (клиент ушёл) — 503, остальное — 500 с message
+func respondStorageError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		respondError(w, http.StatusGatewayTimeout, "Database request timed out")
+	case errors.Is(err, context.Canceled):
+		respondError(w, http.StatusServiceUnavailable, "Request canceled")
+	default:
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// respondTooManyRequests отвечает 429 с Retry-After
+func respondTooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	setRetryAfter(w, retryAfter)
+	respondError(w, http.StatusTooManyRequests, message)
+}
+
+// setRetryAfter выставляет заголовок Retry-After в секундах (округляется вверх)
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/diff"
+	"github.com/go-chi/chi/v5"
+)
+
+// RevisionHandler обрабатывает запросы к /users/{id}/notes/{note_id}/revisions
+type RevisionHandler struct {
+	storage storage.NoteStore
+}
+
+// NewRevisionHandler создаёт новый RevisionHandler
+func NewRevisionHandler(storage storage.NoteStore) *RevisionHandler {
+	return &RevisionHandler{
+		storage: storage,
+	}
+}
+
+// GetRevisions обрабатывает GET /users/{id}/notes/{note_id}/revisions
+func (h *RevisionHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetRevisions called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.storage.GetNoteRevisions(r.Context(), note.ID)
+	if err != nil {
+		fmt.Println("ERROR: GetNoteRevisions failed:", err)
+		respondStorageError(w, err, "Failed to get revisions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, revisions)
+}
+
+// GetRevision обрабатывает GET /users/{id}/notes/{note_id}/revisions/{revision}
+func (h *RevisionHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetRevision called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	revisionNumber, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid revision")
+		return
+	}
+
+	revision, ok := h.revision(w, r, note.ID, revisionNumber)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, revision)
+}
+
+// DiffRevisions обрабатывает GET /users/{id}/notes/{note_id}/revisions/diff?from=1&to=3
+// По умолчанию to — последняя ревизия, from — предыдущая
+func (h *RevisionHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== DiffRevisions called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	to := 0
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		var err error
+		to, err = strconv.Atoi(toStr)
+		if err != nil || to < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid to parameter")
+			return
+		}
+	} else {
+		revisions, err := h.storage.GetNoteRevisions(r.Context(), note.ID)
+		if err != nil {
+			fmt.Println("ERROR: GetNoteRevisions failed:", err)
+			respondStorageError(w, err, "Failed to get revisions")
+			return
+		}
+		if len(revisions) == 0 {
+			respondError(w, http.StatusNotFound, "Revision not found")
+			return
+		}
+		to = revisions[0].Revision
+	}
+
+	from := to - 1
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		var err error
+		from, err = strconv.Atoi(fromStr)
+		if err != nil || from < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid from parameter")
+			return
+		}
+	}
+	if from < 1 {
+		respondError(w, http.StatusBadRequest, "Note has only one revision, specify from and to")
+		return
+	}
+
+	fromRevision, ok := h.revision(w, r, note.ID, from)
+	if !ok {
+		return
+	}
+	toRevision, ok := h.revision(w, r, note.ID, to)
+	if !ok {
+		return
+	}
+
+	unified, err := diff.Unified(
+		fromRevision.Content,
+		toRevision.Content,
+		fmt.Sprintf("revision %d", fromRevision.Revision),
+		fmt.Sprintf("revision %d", toRevision.Revision),
+		3,
+	)
+	if err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.RevisionDiff{
+		From:      fromRevision.Revision,
+		To:        toRevision.Revision,
+		TitleFrom: fromRevision.Title,
+		TitleTo:   toRevision.Title,
+		Diff:      unified,
+	})
+}
+
+// RestoreRevision обрабатывает POST /users/{id}/notes/{note_id}/revisions/{revision}/restore
+// Старая ревизия становится текущей версией (и записывается как новая ревизия).
+// Как и PUT, учитывает If-Match: при параллельном изменении заметки — 412
+func (h *RevisionHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== RestoreRevision called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	revisionNumber, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid revision")
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(r, note)
+	if !ok {
+		respondPreconditionFailed(w, note)
+		return
+	}
+
+	revision, ok := h.revision(w, r, note.ID, revisionNumber)
+	if !ok {
+		return
+	}
+
+	restored, err := h.storage.UpdateNote(r.Context(), note.ID, revision.Title, revision.Content, revision.Format, nil, expectedVersion)
+	if err != nil {
+		if err == models.ErrVersionMismatch || err == models.ErrNoteNotFound {
+			respondConflict(w, r, h.storage, note.ID)
+			return
+		}
+		fmt.Println("ERROR: UpdateNote failed:", err)
+		respondStorageError(w, err, "Failed to restore revision")
+		return
+	}
+
+	fmt.Printf("Note %d restored to revision %d\n", note.ID, revision.Revision)
+	w.Header().Set("ETag", noteETag(restored))
+	respondJSON(w, http.StatusOK, restored)
+}
+
+// ownedNote проверяет токен, user_id из URL и владельца заметки {note_id}.
+// При ошибке сам отправляет ответ и возвращает false
+func (h *RevisionHandler) ownedNote(w http.ResponseWriter, r *http.Request) (*models.Note, bool) {
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return nil, false
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only access your own notes")
+		return nil, false
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid note ID")
+		return nil, false
+	}
+
+	note, err := h.storage.GetNoteByID(r.Context(), noteID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found")
+			return nil, false
+		}
+		respondStorageError(w, err, "Failed to get note")
+		return nil, false
+	}
+
+	if note.UserID != authenticatedUserID {
+		respondError(w, http.StatusForbidden, "You don't have permission to access this note")
+		return nil, false
+	}
+
+	return note, true
+}
+
+// revision получает ревизию, при ошибке отправляет ответ и возвращает false
+func (h *RevisionHandler) revision(w http.ResponseWriter, r *http.Request, noteID, number int) (*models.NoteRevision, bool) {
+	revision, err := h.storage.GetNoteRevision(r.Context(), noteID, number)
+	if err != nil {
+		if err == models.ErrRevisionNotFound {
+			respondError(w, http.StatusNotFound, "Revision not found")
+			return nil, false
+		}
+		fmt.Println("ERROR: GetNoteRevision failed:", err)
+		respondStorageError(w, err, "Failed to get revision")
+		return nil, false
+	}
+	return revision, true
+}
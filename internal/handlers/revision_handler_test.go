@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/pkg/diff"
+)
+
+func TestRevisions(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+
+	note := s.createNote(alice, "v1", "a\nb\n")
+	for _, content := range []string{"a\nB\n", "a\nB\nc\n"} {
+		if rec := s.do("PUT", notePath(alice, note, ""), alice.Token, map[string]string{"title": "v", "content": content}); rec.Code != http.StatusOK {
+			t.Fatalf("update: %d %s", rec.Code, rec.Body)
+		}
+	}
+
+	var revisions []models.NoteRevision
+	rec := s.do("GET", notePath(alice, note, "/revisions"), alice.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revisions: %d %s", rec.Code, rec.Body)
+	}
+	decode(t, rec, &revisions)
+	if len(revisions) != 3 || revisions[0].Revision != 3 || revisions[2].Title != "v1" {
+		t.Fatalf("revisions: %+v", revisions)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		diff   string
+	}{
+		{"/revisions/diff", http.StatusOK, "@@ -1,2 +1,3 @@\n a\n B\n+c\n"},
+		{"/revisions/diff?from=1&to=2", http.StatusOK, "@@ -1,2 +1,2 @@\n a\n-b\n+B\n"},
+		{"/revisions/diff?from=2&to=2", http.StatusOK, ""},
+		{"/revisions/diff?from=1&to=9", http.StatusNotFound, ""},
+		{"/revisions/diff?from=0", http.StatusBadRequest, ""},
+		{"/revisions/diff?to=x", http.StatusBadRequest, ""},
+		{"/revisions/diff?to=1", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		rec := s.do("GET", notePath(alice, note, tt.path), alice.Token, nil)
+		if rec.Code != tt.status {
+			t.Errorf("%s: %d %s", tt.path, rec.Code, rec.Body)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var d models.RevisionDiff
+		decode(t, rec, &d)
+		if !strings.HasSuffix(d.Diff, tt.diff) {
+			t.Errorf("%s: diff %q, want suffix %q", tt.path, d.Diff, tt.diff)
+		}
+	}
+
+	if rec := s.do("GET", notePath(alice, note, "/revisions/9"), alice.Token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown revision: %d", rec.Code)
+	}
+	if rec := s.do("GET", notePath(alice, note, "/revisions"), bob.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("other user's revisions: %d", rec.Code)
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	note := s.createNote(alice, "v1", "first")
+	s.do("PUT", notePath(alice, note, ""), alice.Token, map[string]string{"title": "v2", "content": "second"})
+
+	rec := s.do("POST", notePath(alice, note, "/revisions/1/restore"), alice.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", rec.Code, rec.Body)
+	}
+	var restored models.Note
+	decode(t, rec, &restored)
+	if restored.Title != "v1" || restored.Content != "first" {
+		t.Errorf("restored note: %+v", restored)
+	}
+
+	// Восстановление записывается новой ревизией, история не теряется
+	var revisions []models.NoteRevision
+	decode(t, s.do("GET", notePath(alice, note, "/revisions"), alice.Token, nil), &revisions)
+	if len(revisions) != 3 || revisions[0].Content != "first" || revisions[1].Content != "second" {
+		t.Errorf("revisions after restore: %+v", revisions)
+	}
+
+	if rec := s.do("POST", notePath(alice, note, "/revisions/9/restore"), alice.Token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("restore unknown revision: %d", rec.Code)
+	}
+}
+
+func TestRevisionDiffTooLarge(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	note := s.createNote(alice, "Title", "start")
+
+	var b strings.Builder
+	for i := 0; i <= diff.MaxEdits; i++ {
+		b.WriteString(strconv.Itoa(i) + "\n")
+	}
+	if rec := s.do("PUT", notePath(alice, note, ""), alice.Token, map[string]string{"title": "Title", "content": b.String()}); rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body)
+	}
+
+	rec := s.do("GET", notePath(alice, note, "/revisions/diff?from=1&to=2"), alice.Token, nil)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), diff.ErrTooLarge.Error()) {
+		t.Errorf("oversized diff: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestRestoreRevisionIfMatch(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	note := s.createNote(alice, "Title", "first")
+
+	rec := s.do("PUT", notePath(alice, note, ""), alice.Token, map[string]string{"title": "Title", "content": "second"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = s.do("POST", notePath(alice, note, "/revisions/1/restore"), alice.Token, nil, "If-Match", noteETag(note))
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = s.do("POST", notePath(alice, note, "/revisions/1/restore"), alice.Token, nil, "If-Match", `"v2"`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("current If-Match: %d %s", rec.Code, rec.Body)
+	}
+	var restored models.Note
+	decode(t, rec, &restored)
+	if restored.Content != "first" || restored.Version != 3 {
+		t.Errorf("restored note: content %q, version %d", restored.Content, restored.Version)
+	}
+	if got := rec.Header().Get("ETag"); got != `"v3"` {
+		t.Errorf("ETag %s", got)
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Balyshev/notes-api/internal/blob"
+	"github.com/Balyshev/notes-api/internal/loginguard"
+	"github.com/Balyshev/notes-api/internal/mail"
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/auth"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// RouterConfig - зависимости роутера API
+type RouterConfig struct {
+	Store             storage.Store
+	Mailer            mail.Mailer                        // письма сброса пароля
+	Guard             *loginguard.Guard                  // защита от подбора пароля
+	Keys              *auth.KeySet                       // ключи подписи JWT для /.well-known/jwks.json
+	RateLimits        storage.RateLimitStore             // корзины rate limit
+	RateLimitPolicies map[string]*models.RateLimitPolicy // лимиты групп роутов, нет группы — без лимита
+	Blobs             blob.Store                         // blob хранилище вложений
+	MaxAttachmentSize int64                              // максимальный размер вложения в байтах
+	AttachmentQuota   int64                              // место под вложения на пользователя в байтах
+	MaxImportSize     int64                              // максимальный размер архива импорта в байтах
+	TrustProxyHeaders bool                               // адрес клиента из X-Forwarded-For / X-Real-IP
+	AdminUsernames    []string                           // пользователи с доступом к /admin
+	StaticDir         string                             // каталог веб-интерфейса, пусто — не отдавать
+}
+
+// NewRouter создаёт handlers и роутер API со всеми middleware.
+// Один и тот же роутер запускает main и используют тесты обработчиков
+func NewRouter(cfg RouterConfig) chi.Router {
+	authHandler := NewAuthHandler(cfg.Store, cfg.Mailer, cfg.Guard)
+	userHandler := NewUserHandler(cfg.Store, cfg.Guard)
+	noteHandler := NewNoteHandler(cfg.Store, cfg.Store)
+	tagHandler := NewTagHandler(cfg.Store)
+	revisionHandler := NewRevisionHandler(cfg.Store)
+	trashHandler := NewTrashHandler(cfg.Store)
+	jwksHandler := NewJWKSHandler(cfg.Keys)
+	apiTokenHandler := NewAPITokenHandler(cfg.Store)
+	adminHandler := NewAdminHandler(cfg.Store)
+	shareHandler := NewShareHandler(cfg.Store, cfg.Guard)
+	notebookHandler := NewNotebookHandler(cfg.Store, cfg.Store)
+	linkHandler := NewLinkHandler(cfg.Store)
+	attachmentHandler := NewAttachmentHandler(cfg.Store, cfg.Blobs, cfg.MaxAttachmentSize, cfg.AttachmentQuota)
+	importHandler := NewImportHandler(cfg.Store, cfg.Blobs, cfg.MaxImportSize)
+
+	r := chi.NewRouter()
+
+	// Middleware (применяются ко всем роутам)
+	r.Use(chimiddleware.Logger)
+	r.Use(chimiddleware.Recoverer)
+	if cfg.TrustProxyHeaders {
+		// Адрес клиента из X-Forwarded-For / X-Real-IP (только за доверенным прокси)
+		r.Use(chimiddleware.RealIP)
+	}
+
+	// Serve static files
+	if cfg.StaticDir != "" {
+		fs := http.FileServer(http.Dir(cfg.StaticDir))
+		r.Handle("/*", http.StripPrefix("/", fs))
+	}
+
+	// Публичные роуты (без авторизации), лимит по IP
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RateLimit(cfg.RateLimits, cfg.RateLimitPolicies["auth"]))
+
+		r.Post("/auth/register", authHandler.Register)
+		r.Post("/auth/login", authHandler.Login)
+		r.Post("/auth/refresh", authHandler.Refresh)
+		r.Post("/auth/2fa/verify", authHandler.VerifyTwoFactor)
+		r.Post("/auth/password/forgot", authHandler.ForgotPassword)
+		r.Post("/auth/password/reset", authHandler.ResetPassword)
+		r.Post("/users", userHandler.CreateUser) // Deprecated, использовать /auth/register
+
+		// Публичные ссылки на заметки (POST — ввод пароля из HTML формы)
+		r.Get("/s/{token}", shareHandler.GetPublicNote)
+		r.Post("/s/{token}", shareHandler.GetPublicNote)
+	})
+	r.Get("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
+	// Защищённые роуты (требуют JWT токен), лимит по пользователю
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(cfg.Store, cfg.Store)) // Применяем JWT middleware
+		r.Use(middleware.RateLimit(cfg.RateLimits, cfg.RateLimitPolicies["api"]))
+
+		// Права personal access токенов (для JWT все права есть)
+		read := middleware.RequireScope(models.ScopeNotesRead)
+		write := middleware.RequireScope(models.ScopeNotesWrite)
+		remove := middleware.RequireScope(models.ScopeNotesDelete)
+
+		// Сессии и токены — только после входа по паролю
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSession)
+
+			r.Post("/auth/logout", authHandler.Logout)
+			r.Post("/auth/logout-all", authHandler.LogoutAll)
+
+			// Аккаунт
+			r.Post("/users/{id}/password", authHandler.ChangePassword)
+			r.Delete("/users/{id}", userHandler.DeleteUser)
+			r.Post("/users/{id}/2fa/setup", authHandler.SetupTwoFactor)
+			r.Post("/users/{id}/2fa/confirm", authHandler.ConfirmTwoFactor)
+			r.Delete("/users/{id}/2fa", authHandler.DisableTwoFactor)
+
+			r.Post("/users/{id}/tokens", apiTokenHandler.CreateToken)
+			r.Get("/users/{id}/tokens", apiTokenHandler.GetTokens)
+			r.Delete("/users/{id}/tokens/{token_id}", apiTokenHandler.DeleteToken)
+		})
+
+		// Администрирование (ADMIN_USERNAMES)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSession)
+			r.Use(middleware.RequireAdmin(cfg.Store, cfg.AdminUsernames))
+
+			r.Get("/admin/lockouts", adminHandler.GetLockouts)
+		})
+
+		// Роуты для заметок
+		r.With(write).Post("/users/{id}/notes", noteHandler.CreateNote)
+		r.With(read).Get("/users/{id}/notes", noteHandler.GetUserNotes)
+		r.With(read, middleware.RateLimit(cfg.RateLimits, cfg.RateLimitPolicies["search"])).Get("/users/{id}/notes/search", noteHandler.SearchNotes)
+		r.With(read).Get("/users/{id}/notes/{note_id}", noteHandler.GetNote)
+		r.With(write).Put("/users/{id}/notes/{note_id}", noteHandler.UpdateNote)
+		r.With(write).Patch("/users/{id}/notes/{note_id}", noteHandler.PatchNote)
+		r.With(remove).Delete("/users/{id}/notes/{note_id}", noteHandler.DeleteNote)
+		r.With(write).Post("/users/{id}/notes/{note_id}/move", notebookHandler.MoveNote)
+
+		// Блокноты
+		r.With(write).Post("/users/{id}/notebooks", notebookHandler.CreateNotebook)
+		r.With(read).Get("/users/{id}/notebooks", notebookHandler.GetNotebooks)
+		r.With(read).Get("/users/{id}/notebooks/{notebook_id}", notebookHandler.GetNotebook)
+		r.With(write).Put("/users/{id}/notebooks/{notebook_id}", notebookHandler.RenameNotebook)
+		r.With(write).Post("/users/{id}/notebooks/{notebook_id}/move", notebookHandler.MoveNotebook)
+		r.With(remove).Delete("/users/{id}/notebooks/{notebook_id}", notebookHandler.DeleteNotebook)
+
+		// История изменений заметки
+		r.With(read).Get("/users/{id}/notes/{note_id}/revisions", revisionHandler.GetRevisions)
+		r.With(read).Get("/users/{id}/notes/{note_id}/revisions/diff", revisionHandler.DiffRevisions)
+		r.With(read).Get("/users/{id}/notes/{note_id}/revisions/{revision}", revisionHandler.GetRevision)
+		r.With(write).Post("/users/{id}/notes/{note_id}/revisions/{revision}/restore", revisionHandler.RestoreRevision)
+
+		// Ссылки между заметками ([[Заголовок]], note:42) — только владельцу
+		r.With(read).Get("/users/{id}/notes/{note_id}/outgoing-links", linkHandler.GetOutgoingLinks)
+		r.With(read).Get("/users/{id}/notes/{note_id}/backlinks", linkHandler.GetBacklinks)
+		r.With(read).Get("/users/{id}/unresolved-links", linkHandler.GetUnresolvedLinks)
+		r.With(read).Get("/users/{id}/graph", linkHandler.GetLinkGraph)
+
+		// Совместный доступ (управляет только владелец заметки)
+		r.With(write).Post("/users/{id}/notes/{note_id}/shares", shareHandler.ShareNote)
+		r.With(read).Get("/users/{id}/notes/{note_id}/shares", shareHandler.GetShares)
+		r.With(write).Delete("/users/{id}/notes/{note_id}/shares/{user_id}", shareHandler.RevokeShare)
+		r.With(read).Get("/shared-with-me", shareHandler.GetSharedWithMe)
+		r.With(write).Post("/users/{id}/notes/{note_id}/links", shareHandler.CreateLink)
+		r.With(read).Get("/users/{id}/notes/{note_id}/links", shareHandler.GetLinks)
+		r.With(write).Delete("/users/{id}/notes/{note_id}/links/{link_id}", shareHandler.RevokeLink)
+
+		// Вложения (просмотр — всем, кому доступна заметка, изменение — владельцу и editor)
+		r.With(write).Post("/users/{id}/notes/{note_id}/attachments", attachmentHandler.UploadAttachment)
+		r.With(read).Get("/users/{id}/notes/{note_id}/attachments", attachmentHandler.GetAttachments)
+		r.With(read).Get("/users/{id}/notes/{note_id}/attachments/{attachment_id}", attachmentHandler.GetAttachment)
+		r.With(read).Get("/users/{id}/notes/{note_id}/attachments/{attachment_id}/download", attachmentHandler.DownloadAttachment)
+		r.With(read).Get("/users/{id}/notes/{note_id}/attachments/{attachment_id}/thumbnail", attachmentHandler.GetThumbnail)
+		r.With(remove).Delete("/users/{id}/notes/{note_id}/attachments/{attachment_id}", attachmentHandler.DeleteAttachment)
+		r.With(read).Get("/users/{id}/attachments/usage", attachmentHandler.GetUsage)
+
+		// Импорт архива markdown заметок (выполняется фоновой задачей)
+		r.With(write).Post("/users/{id}/imports", importHandler.CreateImport)
+		r.With(read).Get("/users/{id}/imports", importHandler.GetImports)
+		r.With(read).Get("/users/{id}/imports/{import_id}", importHandler.GetImport)
+
+		// Корзина
+		r.With(read).Get("/users/{id}/trash", trashHandler.GetTrash)
+		r.With(write).Post("/users/{id}/trash/{note_id}/restore", trashHandler.RestoreNote)
+		r.With(remove).Delete("/users/{id}/trash/{note_id}", trashHandler.PurgeNote)
+
+		// Теги
+		r.With(read).Get("/users/{id}/tags", tagHandler.GetUserTags)
+	})
+
+	return r
+}
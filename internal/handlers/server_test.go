@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/loginguard"
+	"github.com/Balyshev/notes-api/internal/mail"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/auth"
+)
+
+// testKeys - HS256 ключ, которым подписываются токены в тестах
+var testKeys *auth.KeySet
+
+func TestMain(m *testing.M) {
+	keys, err := auth.NewKeySet([]*auth.Key{auth.NewHMACKey("test", []byte("test-secret"))}, "")
+	if err != nil {
+		panic(err)
+	}
+	auth.SetKeys(keys)
+	testKeys = keys
+
+	os.Exit(m.Run())
+}
+
+// testServer - роутер API из NewRouter поверх MemoryStorage
+type testServer struct {
+	t      *testing.T
+	store  *storage.MemoryStorage
+	mailer *testMailer
+	router http.Handler
+}
+
+// testMailer запоминает отправленные письма
+type testMailer struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+// Send сохраняет письмо
+func (m *testMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// sent возвращает отправленные письма
+func (m *testMailer) sent() []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mail.Message(nil), m.messages...)
+}
+
+// newTestServer поднимает роутер; configure может поменять конфиг перед созданием
+func newTestServer(t *testing.T, configure ...func(*RouterConfig)) *testServer {
+	t.Helper()
+
+	store := storage.NewMemory()
+	mailer := &testMailer{}
+	cfg := RouterConfig{
+		Store:  store,
+		Mailer: mailer,
+		Guard:  loginguard.New(store, loginguard.DefaultConfig),
+		Keys:   testKeys,
+	}
+	for _, c := range configure {
+		c(&cfg)
+	}
+
+	return &testServer{t: t, store: store, mailer: mailer, router: NewRouter(cfg)}
+}
+
+// do выполняет запрос; body — структура для JSON или готовая строка
+func (s *testServer) do(method, path, token string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var reader *bytes.Reader
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// register создаёт пользователя и возвращает ответ входа
+func (s *testServer) register(username string) *models.LoginResponse {
+	s.t.Helper()
+
+	rec := s.do("POST", "/auth/register", "", map[string]string{
+		"username": username,
+		"email":    username + "@example.com",
+		"password": "password123",
+	})
+	if rec.Code != http.StatusCreated {
+		s.t.Fatalf("register %s: %d %s", username, rec.Code, rec.Body)
+	}
+	var resp models.LoginResponse
+	decode(s.t, rec, &resp)
+	return &resp
+}
+
+// createNote создаёт заметку пользователя
+func (s *testServer) createNote(user *models.LoginResponse, title, content string) *models.Note {
+	s.t.Helper()
+
+	rec := s.do("POST", userPath(user, "/notes"), user.Token, map[string]string{
+		"title":   title,
+		"content": content,
+	})
+	if rec.Code != http.StatusCreated {
+		s.t.Fatalf("create note: %d %s", rec.Code, rec.Body)
+	}
+	var note models.Note
+	decode(s.t, rec, &note)
+	return &note
+}
+
+func userPath(user *models.LoginResponse, path string) string {
+	return "/users/" + strconv.Itoa(user.User.ID) + path
+}
+
+func notePath(user *models.LoginResponse, note *models.Note, path string) string {
+	return userPath(user, "/notes/"+strconv.Itoa(note.ID)+path)
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(strings.NewReader(rec.Body.String())).Decode(v); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+}
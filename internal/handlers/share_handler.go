@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/loginguard"
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// ShareHandler обрабатывает запросы к /users/{id}/notes/{note_id}/shares и /shared-with-me
+type ShareHandler struct {
+	storage storage.NoteShareStore
+	guard   *loginguard.Guard // неверные пароли публичных ссылок
+}
+
+// NewShareHandler создаёт новый ShareHandler
+func NewShareHandler(storage storage.NoteShareStore, guard *loginguard.Guard) *ShareHandler {
+	return &ShareHandler{
+		storage: storage,
+		guard:   guard,
+	}
+}
+
+// ShareNote обрабатывает POST /users/{id}/notes/{note_id}/shares.
+// Повторный вызов для того же пользователя меняет право доступа
+func (h *ShareHandler) ShareNote(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== ShareNote called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ShareNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.storage.GetUserByUsername(r.Context(), req.Username)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			respondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		respondStorageError(w, err, "Failed to get user")
+		return
+	}
+
+	if user.ID == note.UserID {
+		respondError(w, http.StatusBadRequest, models.ErrShareWithSelf.Error())
+		return
+	}
+
+	share, err := h.storage.ShareNote(r.Context(), note.ID, user.ID, req.Permission)
+	if err != nil {
+		if err == models.ErrNoteNotFound || err == models.ErrUserNotFound {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		fmt.Println("ERROR: ShareNote failed:", err)
+		respondStorageError(w, err, "Failed to share note")
+		return
+	}
+
+	fmt.Printf("Note %d shared with user %d as %s\n", note.ID, user.ID, share.Permission)
+	respondJSON(w, http.StatusOK, share)
+}
+
+// GetShares обрабатывает GET /users/{id}/notes/{note_id}/shares
+func (h *ShareHandler) GetShares(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetShares called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	shares, err := h.storage.GetNoteShares(r.Context(), note.ID)
+	if err != nil {
+		fmt.Println("ERROR: GetNoteShares failed:", err)
+		respondStorageError(w, err, "Failed to get shares")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, shares)
+}
+
+// RevokeShare обрабатывает DELETE /users/{id}/notes/{note_id}/shares/{user_id}
+func (h *ShareHandler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== RevokeShare called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.storage.DeleteNoteShare(r.Context(), note.ID, userID); err != nil {
+		if err == models.ErrShareNotFound {
+			respondError(w, http.StatusNotFound, "Share not found")
+			return
+		}
+		fmt.Println("ERROR: DeleteNoteShare failed:", err)
+		respondStorageError(w, err, "Failed to revoke share")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSharedWithMe обрабатывает GET /shared-with-me — чужие заметки, доступные текущему пользователю
+func (h *ShareHandler) GetSharedWithMe(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetSharedWithMe called ===")
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	limit := 10
+	offset := 0
+
+	var err error
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid offset parameter")
+			return
+		}
+	}
+
+	notes, err := h.storage.GetSharedNotes(r.Context(), userID, limit, offset)
+	if err != nil {
+		fmt.Println("ERROR: GetSharedNotes failed:", err)
+		respondStorageError(w, err, "Failed to get shared notes")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, notes)
+}
+
+// ownedNote проверяет, что доступами к заметке {note_id} управляет её владелец.
+// При ошибке сам отправляет ответ и возвращает false
+func (h *ShareHandler) ownedNote(w http.ResponseWriter, r *http.Request) (*models.Note, bool) {
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return nil, false
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "Only the owner can manage note shares")
+		return nil, false
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid note ID")
+		return nil, false
+	}
+
+	note, err := h.storage.GetNoteByID(r.Context(), noteID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found")
+			return nil, false
+		}
+		respondStorageError(w, err, "Failed to get note")
+		return nil, false
+	}
+
+	if note.UserID != authenticatedUserID {
+		respondError(w, http.StatusForbidden, "Only the owner can manage note shares")
+		return nil, false
+	}
+
+	return note, true
+}
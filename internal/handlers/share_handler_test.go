@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestSharePermissions(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	viewer := s.register("viewer")
+	editor := s.register("editor")
+	stranger := s.register("stranger")
+	note := s.createNote(alice, "Title", "content")
+
+	for _, share := range []models.ShareNoteRequest{
+		{Username: "viewer", Permission: models.PermissionViewer},
+		{Username: "editor", Permission: models.PermissionEditor},
+	} {
+		if rec := s.do("POST", notePath(alice, note, "/shares"), alice.Token, share); rec.Code != http.StatusOK {
+			t.Fatalf("share with %s: %d %s", share.Username, rec.Code, rec.Body)
+		}
+	}
+
+	update := map[string]string{"title": "Title", "content": "changed"}
+	tests := []struct {
+		name   string
+		user   *models.LoginResponse
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"viewer reads", viewer, "GET", "", nil, http.StatusOK},
+		{"viewer cannot update", viewer, "PUT", "", update, http.StatusForbidden},
+		{"viewer cannot patch", viewer, "PATCH", "", `{"title":"X"}`, http.StatusForbidden},
+		{"viewer cannot share", viewer, "POST", "/shares", models.ShareNoteRequest{Username: "stranger", Permission: models.PermissionViewer}, http.StatusForbidden},
+		{"editor reads", editor, "GET", "", nil, http.StatusOK},
+		{"editor updates", editor, "PUT", "", update, http.StatusOK},
+		{"editor cannot delete", editor, "DELETE", "", nil, http.StatusForbidden},
+		{"editor cannot list shares", editor, "GET", "/shares", nil, http.StatusForbidden},
+		{"stranger cannot read", stranger, "GET", "", nil, http.StatusForbidden},
+		{"stranger cannot update", stranger, "PUT", "", update, http.StatusForbidden},
+		{"owner cannot be shared", alice, "POST", "/shares", models.ShareNoteRequest{Username: "alice", Permission: models.PermissionViewer}, http.StatusBadRequest},
+		{"owner permission cannot be granted", alice, "POST", "/shares", models.ShareNoteRequest{Username: "stranger", Permission: models.PermissionOwner}, http.StatusBadRequest},
+		{"unknown user", alice, "POST", "/shares", models.ShareNoteRequest{Username: "nobody", Permission: models.PermissionViewer}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(tt.method, notePath(alice, note, tt.path), tt.user.Token, tt.body,
+				"Content-Type", "application/json")
+			if rec.Code != tt.status {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+
+	rec := s.do("GET", "/shared-with-me", viewer.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("shared with me: %d %s", rec.Code, rec.Body)
+	}
+	var shared []models.SharedNote
+	decode(t, rec, &shared)
+	if len(shared) != 1 || shared[0].ID != note.ID || shared[0].OwnerUsername != "alice" || shared[0].Permission != models.PermissionViewer {
+		t.Errorf("shared with me: %+v", shared)
+	}
+
+	// После отзыва доступа заметка снова недоступна
+	rec = s.do("DELETE", notePath(alice, note, "/shares/"+strconv.Itoa(viewer.User.ID)), alice.Token, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("GET", notePath(alice, note, ""), viewer.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("viewer after revoke: %d", rec.Code)
+	}
+}
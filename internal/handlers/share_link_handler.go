@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/pkg/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxSharePasswordForm - ограничение тела формы с паролем публичной ссылки
+const maxSharePasswordForm = 4 << 10 // 4 KB
+
+// CreateLink обрабатывает POST /users/{id}/notes/{note_id}/links
+func (h *ShareHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== CreateLink called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateShareLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	passwordHash := ""
+	if req.Password != "" {
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			fmt.Println("ERROR: Failed to hash share link password:", err)
+			respondError(w, http.StatusInternalServerError, "Failed to create link")
+			return
+		}
+		passwordHash = hash
+	}
+
+	token, tokenHash, err := auth.GenerateShareLinkToken()
+	if err != nil {
+		fmt.Println("ERROR: Failed to generate share link token:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to create link")
+		return
+	}
+
+	link, err := h.storage.CreateShareLink(r.Context(), note.ID, note.UserID, tokenHash, passwordHash, req.ExpiresAt)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found")
+			return
+		}
+		fmt.Println("ERROR: CreateShareLink failed:", err)
+		respondStorageError(w, err, "Failed to create link")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.CreateShareLinkResponse{
+		Token:     token,
+		Path:      "/s/" + token,
+		ShareLink: link,
+	})
+}
+
+// GetLinks обрабатывает GET /users/{id}/notes/{note_id}/links
+func (h *ShareHandler) GetLinks(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetLinks called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.storage.GetNoteShareLinks(r.Context(), note.ID)
+	if err != nil {
+		fmt.Println("ERROR: GetNoteShareLinks failed:", err)
+		respondStorageError(w, err, "Failed to get links")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, links)
+}
+
+// RevokeLink обрабатывает DELETE /users/{id}/notes/{note_id}/links/{link_id}
+func (h *ShareHandler) RevokeLink(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== RevokeLink called ===")
+
+	note, ok := h.ownedNote(w, r)
+	if !ok {
+		return
+	}
+
+	linkID, err := strconv.Atoi(chi.URLParam(r, "link_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid link ID")
+		return
+	}
+
+	if err := h.storage.DeleteShareLink(r.Context(), note.ID, linkID); err != nil {
+		if err == models.ErrShareLinkNotFound {
+			respondError(w, http.StatusNotFound, "Link not found")
+			return
+		}
+		fmt.Println("ERROR: DeleteShareLink failed:", err)
+		respondStorageError(w, err, "Failed to revoke link")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPublicNote обрабатывает GET и POST /s/{token} — заметка по публичной ссылке, без авторизации.
+// Пароль ссылки передаётся в заголовке X-Share-Password или полем password (форма или JSON в POST).
+// Неверные пароли считаются по ссылке: после лимита — 429 с Retry-After.
+// Ответ в HTML, если клиент просит text/html или ?format=html, иначе JSON
+func (h *ShareHandler) GetPublicNote(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetPublicNote called ===")
+
+	// Токен в адресе: страницу не кешируем, не индексируем и не отдаём в Referer
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	html := wantsHTML(r)
+
+	link, err := h.storage.GetShareLink(r.Context(), auth.HashToken(chi.URLParam(r, "token")))
+	if err != nil {
+		if err == models.ErrShareLinkNotFound {
+			respondPublicError(w, html, http.StatusNotFound, "Link not found or expired")
+			return
+		}
+		respondStorageError(w, err, "Failed to get link")
+		return
+	}
+
+	if link.HasPassword {
+		password := sharePassword(w, r)
+		if password != "" {
+			wait, err := h.guard.CheckShareLink(r.Context(), link.ID)
+			if err != nil {
+				fmt.Println("ERROR: Failed to check share link attempts:", err)
+				respondStorageError(w, err, "Failed to get link")
+				return
+			}
+			if wait > 0 {
+				setRetryAfter(w, wait)
+				respondPublicError(w, html, http.StatusTooManyRequests, models.ErrShareLinkLocked.Error())
+				return
+			}
+		}
+
+		if password == "" || !auth.CheckPassword(password, link.PasswordHash) {
+			if password != "" {
+				wait, err := h.guard.FailShareLink(r.Context(), link.ID, middleware.ClientIP(r))
+				if err != nil {
+					fmt.Println("ERROR: Failed to record share link failure:", err)
+					respondStorageError(w, err, "Failed to get link")
+					return
+				}
+				if wait > 0 {
+					setRetryAfter(w, wait)
+					respondPublicError(w, html, http.StatusTooManyRequests, models.ErrShareLinkLocked.Error())
+					return
+				}
+			}
+			if html {
+				renderPublicPage(w, http.StatusUnauthorized, publicPage{
+					PasswordRequired: true,
+					Error:            password != "",
+				})
+				return
+			}
+			respondError(w, http.StatusUnauthorized, models.ErrShareLinkPasswordInvalid.Error())
+			return
+		}
+
+		if err := h.guard.SucceedShareLink(r.Context(), link.ID); err != nil {
+			fmt.Println("ERROR: Failed to reset share link attempts:", err)
+		}
+	}
+
+	note, err := h.storage.GetNoteByID(r.Context(), link.NoteID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondPublicError(w, html, http.StatusNotFound, "Link not found or expired")
+			return
+		}
+		respondStorageError(w, err, "Failed to get note")
+		return
+	}
+
+	public := &models.PublicNote{
+		Title:     note.Title,
+		Content:   note.Content,
+		Tags:      note.Tags,
+		UpdatedAt: note.UpdatedAt,
+	}
+
+	if html {
+		page := publicPage{Note: public}
+		// Markdown показывается отрисованным: noteHTML уже прошёл санитайзер и кэшируется в ревизии
+		if note.Format == models.FormatMarkdown {
+			page.HTML = template.HTML(noteHTML(r.Context(), h.storage, note))
+		}
+		renderPublicPage(w, http.StatusOK, page)
+		return
+	}
+	respondJSON(w, http.StatusOK, public)
+}
+
+// wantsHTML выбирает формат ответа: HTML, если клиент просит text/html или ?format=html
+func wantsHTML(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// sharePassword достаёт пароль публичной ссылки из заголовка или тела POST запроса
+func sharePassword(w http.ResponseWriter, r *http.Request) string {
+	if password := r.Header.Get("X-Share-Password"); password != "" {
+		return password
+	}
+	if r.Method != http.MethodPost {
+		return ""
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSharePasswordForm)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var body struct {
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return ""
+		}
+		return body.Password
+	}
+	return r.PostFormValue("password")
+}
+
+// publicPage - данные HTML страницы публичной ссылки
+type publicPage struct {
+	Note             *models.PublicNote
+	HTML             template.HTML // отрисованный markdown; пусто — текст выводится как есть
+	PasswordRequired bool
+	Error            bool
+	Message          string
+}
+
+var publicPageTemplate = template.Must(template.New("public").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>{{if .Note}}{{.Note.Title}}{{else}}Notes{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; max-width: 760px; margin: 40px auto; padding: 0 16px; color: #222; }
+.content { white-space: pre-wrap; word-wrap: break-word; line-height: 1.5; }
+.markdown { white-space: normal; }
+.markdown pre { background: #f6f6f6; padding: 8px; overflow-x: auto; }
+.tag { display: inline-block; background: #eef; border-radius: 4px; padding: 2px 8px; margin-right: 4px; font-size: 13px; }
+.meta { color: #888; font-size: 13px; }
+.error { color: #c00; }
+</style>
+</head>
+<body>
+{{if .Note}}
+<h1>{{.Note.Title}}</h1>
+<p>{{range .Note.Tags}}<span class="tag">{{.}}</span>{{end}}</p>
+{{if .HTML}}<div class="content markdown">{{.HTML}}</div>{{else}}<div class="content">{{.Note.Content}}</div>{{end}}
+<p class="meta">Обновлено {{.Note.UpdatedAt.Format "02.01.2006 15:04"}}</p>
+{{else if .PasswordRequired}}
+<h1>Заметка защищена паролем</h1>
+{{if .Error}}<p class="error">Неверный пароль</p>{{end}}
+<form method="post">
+<input type="password" name="password" autofocus required>
+<button type="submit">Открыть</button>
+</form>
+{{else}}
+<h1>{{.Message}}</h1>
+{{end}}
+</body>
+</html>
+`))
+
+// renderPublicPage отдаёт HTML страницу публичной ссылки
+func renderPublicPage(w http.ResponseWriter, status int, page publicPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'")
+	w.WriteHeader(status)
+	if err := publicPageTemplate.Execute(w, page); err != nil {
+		fmt.Println("ERROR: Failed to render public page:", err)
+	}
+}
+
+// respondPublicError отвечает на ошибку публичной ссылки в нужном формате
+func respondPublicError(w http.ResponseWriter, html bool, status int, message string) {
+	if html {
+		renderPublicPage(w, status, publicPage{Message: message})
+		return
+	}
+	respondError(w, status, message)
+}
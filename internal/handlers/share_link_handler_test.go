@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestPublicShareLink(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	note := s.createNote(alice, "<script>alert(1)</script>", "<img src=x onerror=alert(1)>")
+
+	create := func(req models.CreateShareLinkRequest) *models.CreateShareLinkResponse {
+		t.Helper()
+		rec := s.do("POST", notePath(alice, note, "/links"), alice.Token, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create link: %d %s", rec.Code, rec.Body)
+		}
+		var link models.CreateShareLinkResponse
+		decode(t, rec, &link)
+		return &link
+	}
+
+	open := create(models.CreateShareLinkRequest{})
+	rec := s.do("GET", open.Path, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("public note: %d %s", rec.Code, rec.Body)
+	}
+	var public models.PublicNote
+	decode(t, rec, &public)
+	if public.Title != note.Title || public.Content != note.Content {
+		t.Errorf("public note: %+v", public)
+	}
+
+	rec = s.do("GET", open.Path+"?format=html", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("public page: %d", rec.Code)
+	}
+	if page := rec.Body.String(); strings.Contains(page, "<script>alert") || strings.Contains(page, "<img src=x") {
+		t.Errorf("public page is not escaped: %s", page)
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" || rec.Header().Get("Referrer-Policy") != "no-referrer" {
+		t.Errorf("public page headers: %v", rec.Header())
+	}
+
+	protected := create(models.CreateShareLinkRequest{Password: "secret123"})
+	tests := []struct {
+		name    string
+		method  string
+		body    interface{}
+		headers []string
+		status  int
+	}{
+		{"no password", "GET", nil, nil, http.StatusUnauthorized},
+		{"wrong password", "GET", nil, []string{"X-Share-Password", "wrong"}, http.StatusUnauthorized},
+		{"password header", "GET", nil, []string{"X-Share-Password", "secret123"}, http.StatusOK},
+		{"password in json", "POST", map[string]string{"password": "secret123"}, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(tt.method, protected.Path, "", tt.body, tt.headers...)
+			if rec.Code != tt.status {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+
+	rec = s.do("DELETE", notePath(alice, note, "/links/"+strconv.Itoa(open.ID)), alice.Token, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke link: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("GET", open.Path, "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("revoked link: %d", rec.Code)
+	}
+	if rec := s.do("GET", "/s/nsl_unknown", "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown link: %d", rec.Code)
+	}
+}
+
+func TestShareLinkAccess(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	editor := s.register("editor")
+	note := s.createNote(alice, "Title", "content")
+
+	share := models.ShareNoteRequest{Username: "editor", Permission: models.PermissionEditor}
+	if rec := s.do("POST", notePath(alice, note, "/shares"), alice.Token, share); rec.Code != http.StatusOK {
+		t.Fatalf("share: %d %s", rec.Code, rec.Body)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name   string
+		user   *models.LoginResponse
+		body   models.CreateShareLinkRequest
+		status int
+	}{
+		{"editor cannot create link", editor, models.CreateShareLinkRequest{}, http.StatusForbidden},
+		{"expiry in the past", alice, models.CreateShareLinkRequest{ExpiresAt: &past}, http.StatusBadRequest},
+		{"short password", alice, models.CreateShareLinkRequest{Password: "123"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := s.do("POST", notePath(alice, note, "/links"), tt.user.Token, tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body)
+		}
+	}
+
+	if rec := s.do("GET", notePath(alice, note, "/links"), editor.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("editor lists links: %d", rec.Code)
+	}
+}
+
+func TestShareLinkPasswordLockout(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	note := s.createNote(alice, "Title", "content")
+
+	create := func() *models.CreateShareLinkResponse {
+		t.Helper()
+		rec := s.do("POST", notePath(alice, note, "/links"), alice.Token, models.CreateShareLinkRequest{Password: "secret123"})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create link: %d %s", rec.Code, rec.Body)
+		}
+		var link models.CreateShareLinkResponse
+		decode(t, rec, &link)
+		return &link
+	}
+	link, other := create(), create()
+	open := func(link *models.CreateShareLinkResponse, password string) *httptest.ResponseRecorder {
+		return s.do("GET", link.Path, "", nil, "X-Share-Password", password)
+	}
+
+	// Запрос без пароля только показывает форму и не считается неудачей
+	for i := 0; i < 10; i++ {
+		if rec := s.do("GET", link.Path, "", nil); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("no password: %d", rec.Code)
+		}
+	}
+	for i := 1; i <= 5; i++ {
+		if rec := open(link, "wrong-password"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong password #%d: %d %s", i, rec.Code, rec.Body)
+		}
+	}
+	rec := open(link, "wrong-password")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Fatalf("wrong password over the limit: %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	// Во время блокировки не принимается даже верный пароль, в том числе из формы
+	if rec := open(link, "secret123"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("valid password while locked: %d %s", rec.Code, rec.Body)
+	}
+	form := "password=secret123"
+	rec = s.do("POST", link.Path+"?format=html", "", form, "Content-Type", "application/x-www-form-urlencoded")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" ||
+		!strings.Contains(rec.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("form while locked: %d %v", rec.Code, rec.Header())
+	}
+
+	// Блокировка относится только к этой ссылке
+	if rec := open(other, "secret123"); rec.Code != http.StatusOK {
+		t.Errorf("other link: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPublicShareLinkMarkdown(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	content := "# Heading\n\n**bold** <script>alert(1)</script> [x](javascript:alert(1))"
+	rec := s.do("POST", userPath(alice, "/notes"), alice.Token, models.CreateNoteRequest{
+		Title:   "Markdown",
+		Content: content,
+		Format:  models.FormatMarkdown,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create note: %d %s", rec.Code, rec.Body)
+	}
+	var note models.Note
+	decode(t, rec, &note)
+
+	rec = s.do("POST", notePath(alice, &note, "/links"), alice.Token, models.CreateShareLinkRequest{})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create link: %d %s", rec.Code, rec.Body)
+	}
+	var link models.CreateShareLinkResponse
+	decode(t, rec, &link)
+
+	rec = s.do("GET", link.Path+"?format=html", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("public page: %d", rec.Code)
+	}
+	page := rec.Body.String()
+	for _, want := range []string{"<h1>Heading</h1>", "<strong>bold</strong>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("public page has no %q: %s", want, page)
+		}
+	}
+	for _, bad := range []string{"<script>alert", "javascript:", "# Heading"} {
+		if strings.Contains(page, bad) {
+			t.Errorf("public page contains %q: %s", bad, page)
+		}
+	}
+
+	// HTML взят из кэша ревизии, JSON по-прежнему отдаёт исходный текст
+	rev, err := s.store.GetLatestRevision(t.Context(), note.ID)
+	if err != nil || rev.HTMLRenderer != htmlRendererVersion {
+		t.Errorf("revision HTML is not cached: %+v, %v", rev, err)
+	}
+	rec = s.do("GET", link.Path, "", nil)
+	var public models.PublicNote
+	decode(t, rec, &public)
+	if public.Content != content {
+		t.Errorf("public note content: %q", public.Content)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// TagHandler обрабатывает запросы к /users/{id}/tags
+type TagHandler struct {
+	storage storage.NoteStore
+}
+
+// NewTagHandler создаёт новый TagHandler
+func NewTagHandler(storage storage.NoteStore) *TagHandler {
+	return &TagHandler{
+		storage: storage,
+	}
+}
+
+// GetUserTags обрабатывает GET /users/{id}/tags
+func (h *TagHandler) GetUserTags(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetUserTags called ===")
+
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only view your own tags")
+		return
+	}
+
+	tags, err := h.storage.GetUserTags(r.Context(), authenticatedUserID)
+	if err != nil {
+		fmt.Println("ERROR: GetUserTags failed:", err)
+		respondStorageError(w, err, "Failed to get tags")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tags)
+}
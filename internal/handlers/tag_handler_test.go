@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestNoteTags(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+
+	for _, body := range []string{
+		`{"title":"a","content":"c","tags":["Go"," work "]}`,
+		`{"title":"b","content":"c","tags":["go","go"]}`,
+		`{"title":"c","content":"c","tags":["home"]}`,
+	} {
+		if rec := s.do("POST", userPath(alice, "/notes"), alice.Token, body); rec.Code != http.StatusCreated {
+			t.Fatalf("create: %d %s", rec.Code, rec.Body)
+		}
+	}
+
+	var tags []models.TagCount
+	rec := s.do("GET", userPath(alice, "/tags"), alice.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("tags: %d %s", rec.Code, rec.Body)
+	}
+	decode(t, rec, &tags)
+	if len(tags) != 3 || tags[0] != (models.TagCount{Name: "go", Count: 2}) {
+		t.Errorf("tags: %+v", tags)
+	}
+	if rec := s.do("GET", userPath(alice, "/tags"), bob.Token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("other user's tags: %d", rec.Code)
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		want   string
+	}{
+		{"", http.StatusOK, "abc"},
+		{"tag=GO", http.StatusOK, "ab"},
+		{"tag=go&tag=work", http.StatusOK, "a"},
+		{"tag=go,work", http.StatusOK, "a"},
+		{"tag=work,home&tag_mode=or", http.StatusOK, "ac"},
+		{"tag=go&tag_mode=xor", http.StatusBadRequest, ""},
+		{"tag=" + strings.Repeat("x", 51), http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		rec := s.do("GET", userPath(alice, "/notes?sort=asc&"+tt.query), alice.Token, nil)
+		if rec.Code != tt.status {
+			t.Errorf("%s: %d %s", tt.query, rec.Code, rec.Body)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var notes []models.Note
+		decode(t, rec, &notes)
+		got := ""
+		for _, n := range notes {
+			got += n.Title
+		}
+		if got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateNoteTags(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	rec := s.do("POST", userPath(alice, "/notes"), alice.Token, `{"title":"T","content":"c","tags":["go"]}`)
+	var note models.Note
+	decode(t, rec, &note)
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"title":"T","content":"c"}`, 1},           // поле не передано — теги не меняются
+		{`{"title":"T","content":"c","tags":[]}`, 0}, // [] — теги удаляются
+	}
+	for _, tt := range tests {
+		rec := s.do("PUT", notePath(alice, &note, ""), alice.Token, tt.body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", tt.body, rec.Code, rec.Body)
+		}
+		var got models.Note
+		decode(t, rec, &got)
+		if len(got.Tags) != tt.want {
+			t.Errorf("%s: tags %v", tt.body, got.Tags)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// TrashHandler обрабатывает запросы к /users/{id}/trash
+type TrashHandler struct {
+	storage storage.NoteStore
+}
+
+// NewTrashHandler создаёт новый TrashHandler
+func NewTrashHandler(storage storage.NoteStore) *TrashHandler {
+	return &TrashHandler{
+		storage: storage,
+	}
+}
+
+// GetTrash обрабатывает GET /users/{id}/trash
+func (h *TrashHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== GetTrash called ===")
+
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only view your own trash")
+		return
+	}
+
+	limit := 10
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid offset parameter")
+			return
+		}
+	}
+
+	notes, err := h.storage.GetTrashedNotes(r.Context(), authenticatedUserID, limit, offset)
+	if err != nil {
+		fmt.Println("ERROR: GetTrashedNotes failed:", err)
+		respondStorageError(w, err, "Failed to get trash")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, notes)
+}
+
+// RestoreNote обрабатывает POST /users/{id}/trash/{note_id}/restore
+func (h *TrashHandler) RestoreNote(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== RestoreNote called ===")
+
+	trashed, ok := h.trashedNote(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.storage.RestoreNote(r.Context(), trashed.ID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found in trash")
+			return
+		}
+		fmt.Println("ERROR: RestoreNote failed:", err)
+		respondStorageError(w, err, "Failed to restore note")
+		return
+	}
+
+	fmt.Printf("Note restored from trash: %d\n", note.ID)
+	respondJSON(w, http.StatusOK, note)
+}
+
+// PurgeNote обрабатывает DELETE /users/{id}/trash/{note_id} (удаление навсегда)
+func (h *TrashHandler) PurgeNote(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== PurgeNote called ===")
+
+	trashed, ok := h.trashedNote(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.PurgeNote(r.Context(), trashed.ID); err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found in trash")
+			return
+		}
+		fmt.Println("ERROR: PurgeNote failed:", err)
+		respondStorageError(w, err, "Failed to delete note")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Note deleted permanently"})
+}
+
+// trashedNote проверяет токен, user_id из URL и владельца заметки {note_id} в корзине.
+// При ошибке сам отправляет ответ и возвращает false
+func (h *TrashHandler) trashedNote(w http.ResponseWriter, r *http.Request) (*models.Note, bool) {
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return nil, false
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only manage your own trash")
+		return nil, false
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid note ID")
+		return nil, false
+	}
+
+	note, err := h.storage.GetTrashedNote(r.Context(), noteID)
+	if err != nil {
+		if err == models.ErrNoteNotFound {
+			respondError(w, http.StatusNotFound, "Note not found in trash")
+			return nil, false
+		}
+		respondStorageError(w, err, "Failed to get note")
+		return nil, false
+	}
+
+	if note.UserID != authenticatedUserID {
+		respondError(w, http.StatusForbidden, "You don't have permission to access this note")
+		return nil, false
+	}
+
+	return note, true
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestTrash(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	note := s.createNote(alice, "Title", "content")
+	trashPath := userPath(alice, "/trash/"+strconv.Itoa(note.ID))
+
+	if rec := s.do("DELETE", notePath(alice, note, ""), alice.Token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body)
+	}
+
+	var trash []models.Note
+	rec := s.do("GET", userPath(alice, "/trash"), alice.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("trash: %d %s", rec.Code, rec.Body)
+	}
+	decode(t, rec, &trash)
+	if len(trash) != 1 || trash[0].ID != note.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("trash: %+v", trash)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"other user's trash", "GET", userPath(alice, "/trash"), bob.Token, http.StatusForbidden},
+		{"invalid limit", "GET", userPath(alice, "/trash?limit=x"), alice.Token, http.StatusBadRequest},
+		{"other user restores", "POST", trashPath + "/restore", bob.Token, http.StatusForbidden},
+		{"unknown note", "POST", userPath(alice, "/trash/999/restore"), alice.Token, http.StatusNotFound},
+		{"trashed note is hidden", "GET", notePath(alice, note, ""), alice.Token, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := s.do(tt.method, tt.path, tt.token, nil); rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d", tt.name, rec.Code, tt.status)
+		}
+	}
+
+	if rec := s.do("POST", trashPath+"/restore", alice.Token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("GET", notePath(alice, note, ""), alice.Token, nil); rec.Code != http.StatusOK {
+		t.Errorf("get after restore: %d", rec.Code)
+	}
+	if rec := s.do("DELETE", trashPath, alice.Token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("purge of a live note: %d", rec.Code)
+	}
+
+	s.do("DELETE", notePath(alice, note, ""), alice.Token, nil)
+	if rec := s.do("DELETE", trashPath, alice.Token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("purge: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("POST", trashPath+"/restore", alice.Token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("restore after purge: %d", rec.Code)
+	}
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/middleware"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/pkg/auth"
+	"github.com/Balyshev/notes-api/pkg/totp"
+	"github.com/go-chi/chi/v5"
+)
+
+// SetupTwoFactor обрабатывает POST /users/{id}/2fa/setup — выдаёт новый секрет TOTP.
+// 2FA включается только после подтверждения кодом
+func (h *AuthHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== SetupTwoFactor called ===")
+
+	userID, ok := h.accountUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.storage.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			respondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		fmt.Println("ERROR: Failed to get user:", err)
+		respondStorageError(w, err, "Failed to set up two-factor authentication")
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		fmt.Println("ERROR: Failed to generate totp secret:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to set up two-factor authentication")
+		return
+	}
+
+	if err := h.storage.SetTOTPSecret(r.Context(), user.ID, secret); err != nil {
+		if err == models.ErrTwoFactorAlreadyEnabled {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		fmt.Println("ERROR: Failed to save totp secret:", err)
+		respondStorageError(w, err, "Failed to set up two-factor authentication")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.TwoFactorSetupResponse{
+		Secret: secret,
+		URI:    totp.URI(auth.TwoFactorIssuer, user.Username, secret),
+	})
+}
+
+// ConfirmTwoFactor обрабатывает POST /users/{id}/2fa/confirm — включает 2FA по первому коду
+// и возвращает коды восстановления
+func (h *AuthHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== ConfirmTwoFactor called ===")
+
+	userID, ok := h.accountUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tf, err := h.storage.GetTwoFactor(r.Context(), userID)
+	if err != nil {
+		fmt.Println("ERROR: Failed to get two-factor state:", err)
+		respondStorageError(w, err, "Failed to confirm two-factor authentication")
+		return
+	}
+	if tf.Enabled {
+		respondError(w, http.StatusConflict, models.ErrTwoFactorAlreadyEnabled.Error())
+		return
+	}
+	if tf.Secret == "" {
+		respondError(w, http.StatusBadRequest, models.ErrTwoFactorNotSetUp.Error())
+		return
+	}
+
+	counter, valid := totp.Validate(tf.Secret, req.Code, time.Now())
+	if !valid {
+		respondError(w, http.StatusBadRequest, models.ErrInvalidTwoFactorCode.Error())
+		return
+	}
+
+	codes, hashes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		fmt.Println("ERROR: Failed to generate recovery codes:", err)
+		respondError(w, http.StatusInternalServerError, "Failed to confirm two-factor authentication")
+		return
+	}
+
+	if err := h.storage.EnableTwoFactor(r.Context(), userID, counter, hashes); err != nil {
+		if err == models.ErrTwoFactorAlreadyEnabled {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		fmt.Println("ERROR: Failed to enable two-factor:", err)
+		respondStorageError(w, err, "Failed to confirm two-factor authentication")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.TwoFactorConfirmResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor обрабатывает DELETE /users/{id}/2fa — нужен пароль и код (или код восстановления)
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== DisableTwoFactor called ===")
+
+	userID, ok := h.accountUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.DisableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.storage.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			respondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		fmt.Println("ERROR: Failed to get user:", err)
+		respondStorageError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	ip := middleware.ClientIP(r)
+	wait, err := h.guard.Check(r.Context(), user.Username, ip)
+	if err != nil {
+		fmt.Println("ERROR: Failed to check login attempts:", err)
+		respondStorageError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+	if wait > 0 {
+		respondTooManyRequests(w, wait, "Too many failed password attempts, try again later")
+		return
+	}
+
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		if _, err := h.guard.Fail(r.Context(), user.Username, ip); err != nil {
+			fmt.Println("ERROR: Failed to record login failure:", err)
+			respondStorageError(w, err, "Failed to disable two-factor authentication")
+			return
+		}
+		respondError(w, http.StatusForbidden, models.ErrCurrentPasswordWrong.Error())
+		return
+	}
+
+	if wait, err := h.checkSecondFactor(r, user, req.Code, req.RecoveryCode, time.Time{}); err != nil {
+		h.respondSecondFactorError(w, err, wait, "Failed to disable two-factor authentication")
+		return
+	}
+
+	if err := h.storage.DisableTwoFactor(r.Context(), user.ID); err != nil {
+		fmt.Println("ERROR: Failed to disable two-factor:", err)
+		respondStorageError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyTwoFactor обрабатывает POST /auth/2fa/verify — второй шаг входа
+func (h *AuthHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== VerifyTwoFactor called ===")
+
+	var req models.TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	claims, err := auth.ValidateChallengeToken(req.ChallengeToken)
+	if err != nil || claims.IssuedAt == nil {
+		respondError(w, http.StatusUnauthorized, "Invalid or expired challenge token")
+		return
+	}
+
+	user, err := h.storage.GetUserByID(r.Context(), claims.UserID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			respondError(w, http.StatusUnauthorized, "Invalid or expired challenge token")
+			return
+		}
+		fmt.Println("ERROR: Failed to get user:", err)
+		respondStorageError(w, err, "Failed to login")
+		return
+	}
+
+	if wait, err := h.checkSecondFactor(r, user, req.Code, req.RecoveryCode, claims.IssuedAt.Time); err != nil {
+		h.respondSecondFactorError(w, err, wait, "Failed to verify code")
+		return
+	}
+
+	response, err := h.startSession(r, user)
+	if err != nil {
+		fmt.Println("ERROR: Failed to start session:", err)
+		respondStorageError(w, err, "Failed to generate token")
+		return
+	}
+
+	fmt.Printf("User logged in with 2FA: %s\n", user.Username)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// checkSecondFactor проверяет код TOTP или погашает код восстановления.
+// Неверные коды считает loginguard по аккаунту: после лимита — models.ErrTwoFactorLocked и время ожидания,
+// а challenge, выданный до блокировки (challengeIssuedAt), — models.ErrChallengeRevoked
+func (h *AuthHandler) checkSecondFactor(r *http.Request, user *models.User, code, recoveryCode string, challengeIssuedAt time.Time) (time.Duration, error) {
+	ctx := r.Context()
+	tf, err := h.storage.GetTwoFactor(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+	if !tf.Enabled {
+		return 0, models.ErrTwoFactorNotEnabled
+	}
+
+	wait, err := h.guard.CheckTwoFactor(ctx, user.ID, challengeIssuedAt)
+	if err != nil {
+		return 0, err
+	}
+	if wait > 0 {
+		return wait, models.ErrTwoFactorLocked
+	}
+
+	if code != "" {
+		counter, valid := totp.Validate(tf.Secret, code, time.Now())
+		if valid {
+			err = h.storage.UseTOTPCounter(ctx, user.ID, counter)
+		} else {
+			err = models.ErrInvalidTwoFactorCode
+		}
+	} else {
+		err = h.storage.UseRecoveryCode(ctx, user.ID, auth.HashRecoveryCode(recoveryCode))
+	}
+
+	switch err {
+	case nil:
+		if err := h.guard.SucceedTwoFactor(ctx, user.ID); err != nil {
+			fmt.Println("ERROR: Failed to reset two-factor attempts:", err)
+		}
+		return 0, nil
+	case models.ErrInvalidTwoFactorCode:
+		lock, failErr := h.guard.FailTwoFactor(ctx, user.ID, user.Username, middleware.ClientIP(r))
+		if failErr != nil {
+			return 0, failErr
+		}
+		if lock > 0 {
+			return lock, models.ErrTwoFactorLocked
+		}
+		return 0, err
+	default:
+		return 0, err
+	}
+}
+
+// respondSecondFactorError отвечает на ошибку проверки второго фактора; wait — для 429
+func (h *AuthHandler) respondSecondFactorError(w http.ResponseWriter, err error, wait time.Duration, message string) {
+	switch err {
+	case models.ErrInvalidTwoFactorCode, models.ErrTwoFactorCodeReused, models.ErrChallengeRevoked:
+		respondError(w, http.StatusUnauthorized, err.Error())
+	case models.ErrTwoFactorLocked:
+		respondTooManyRequests(w, wait, err.Error())
+	case models.ErrTwoFactorNotEnabled:
+		respondError(w, http.StatusBadRequest, err.Error())
+	case models.ErrUserNotFound:
+		respondError(w, http.StatusNotFound, "User not found")
+	default:
+		fmt.Println("ERROR: Second factor check failed:", err)
+		respondStorageError(w, err, message)
+	}
+}
+
+// accountUserID проверяет, что пользователь управляет своим аккаунтом
+func (h *AuthHandler) accountUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return 0, false
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only manage your own account")
+		return 0, false
+	}
+
+	return authenticatedUserID, true
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/pkg/totp"
+)
+
+// totpCode возвращает код для интервала, сдвинутого на delta от текущего
+func totpCode(t *testing.T, secret string, delta int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Counter(time.Now())+delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enableTwoFactor подключает 2FA и возвращает секрет и коды восстановления
+func (s *testServer) enableTwoFactor(user *models.LoginResponse) (string, []string) {
+	s.t.Helper()
+
+	rec := s.do("POST", userPath(user, "/2fa/setup"), user.Token, nil)
+	if rec.Code != http.StatusOK {
+		s.t.Fatalf("setup: %d %s", rec.Code, rec.Body)
+	}
+	var setup models.TwoFactorSetupResponse
+	decode(s.t, rec, &setup)
+
+	rec = s.do("POST", userPath(user, "/2fa/confirm"), user.Token, models.TwoFactorCodeRequest{Code: totpCode(s.t, setup.Secret, 0)})
+	if rec.Code != http.StatusOK {
+		s.t.Fatalf("confirm: %d %s", rec.Code, rec.Body)
+	}
+	var confirm models.TwoFactorConfirmResponse
+	decode(s.t, rec, &confirm)
+	return setup.Secret, confirm.RecoveryCodes
+}
+
+// loginChallenge входит по паролю и возвращает challenge токен второго шага
+func (s *testServer) loginChallenge(username string) string {
+	s.t.Helper()
+
+	rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: username, Password: "password123"})
+	if rec.Code != http.StatusOK {
+		s.t.Fatalf("login: %d %s", rec.Code, rec.Body)
+	}
+	var challenge models.TwoFactorChallengeResponse
+	decode(s.t, rec, &challenge)
+	if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+		s.t.Fatalf("login did not ask for a second factor: %+v", challenge)
+	}
+	return challenge.ChallengeToken
+}
+
+func TestTwoFactorSetup(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+
+	if rec := s.do("POST", userPath(alice, "/2fa/confirm"), alice.Token, models.TwoFactorCodeRequest{Code: "123456"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("confirm before setup: %d", rec.Code)
+	}
+
+	rec := s.do("POST", userPath(alice, "/2fa/setup"), alice.Token, nil)
+	var setup models.TwoFactorSetupResponse
+	decode(t, rec, &setup)
+	if setup.Secret == "" || setup.URI == "" {
+		t.Fatalf("setup: %+v", setup)
+	}
+
+	// Пока 2FA не подтверждена, вход идёт по паролю
+	var login models.LoginResponse
+	decode(t, s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "password123"}), &login)
+	if login.Token == "" {
+		t.Fatal("login before confirm asked for a second factor")
+	}
+
+	if rec := s.do("POST", userPath(alice, "/2fa/confirm"), alice.Token, models.TwoFactorCodeRequest{Code: totpCode(t, setup.Secret, 5)}); rec.Code != http.StatusBadRequest {
+		t.Errorf("confirm with wrong code: %d", rec.Code)
+	}
+	rec = s.do("POST", userPath(alice, "/2fa/confirm"), alice.Token, models.TwoFactorCodeRequest{Code: totpCode(t, setup.Secret, 0)})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("confirm: %d %s", rec.Code, rec.Body)
+	}
+	var confirm models.TwoFactorConfirmResponse
+	decode(t, rec, &confirm)
+	if len(confirm.RecoveryCodes) == 0 {
+		t.Error("no recovery codes")
+	}
+
+	if rec := s.do("POST", userPath(alice, "/2fa/setup"), alice.Token, nil); rec.Code != http.StatusConflict {
+		t.Errorf("setup when enabled: %d", rec.Code)
+	}
+}
+
+func TestVerifyTwoFactor(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	secret, recovery := s.enableTwoFactor(alice)
+
+	verify := func(req models.TwoFactorVerifyRequest) int {
+		return s.do("POST", "/auth/2fa/verify", "", req).Code
+	}
+
+	challenge := s.loginChallenge("alice")
+	tests := []struct {
+		name   string
+		req    models.TwoFactorVerifyRequest
+		status int
+	}{
+		{"no code", models.TwoFactorVerifyRequest{ChallengeToken: challenge}, http.StatusBadRequest},
+		{"bad challenge", models.TwoFactorVerifyRequest{ChallengeToken: "garbage", Code: totpCode(t, secret, 1)}, http.StatusUnauthorized},
+		{"access token as challenge", models.TwoFactorVerifyRequest{ChallengeToken: alice.Token, Code: totpCode(t, secret, 1)}, http.StatusUnauthorized},
+		{"wrong code", models.TwoFactorVerifyRequest{ChallengeToken: challenge, Code: totpCode(t, secret, 5)}, http.StatusUnauthorized},
+		// Код интервала, принятого при подтверждении, повторно не принимается
+		{"replayed code", models.TwoFactorVerifyRequest{ChallengeToken: challenge, Code: totpCode(t, secret, -1)}, http.StatusUnauthorized},
+		{"next code", models.TwoFactorVerifyRequest{ChallengeToken: challenge, Code: totpCode(t, secret, 1)}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := verify(tt.req); got != tt.status {
+			t.Errorf("%s: %d, want %d", tt.name, got, tt.status)
+		}
+	}
+
+	// Код восстановления одноразовый
+	challenge = s.loginChallenge("alice")
+	if got := verify(models.TwoFactorVerifyRequest{ChallengeToken: challenge, RecoveryCode: recovery[0]}); got != http.StatusOK {
+		t.Fatalf("recovery code: %d", got)
+	}
+	if got := verify(models.TwoFactorVerifyRequest{ChallengeToken: challenge, RecoveryCode: recovery[0]}); got != http.StatusUnauthorized {
+		t.Errorf("reused recovery code: %d", got)
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	_, recovery := s.enableTwoFactor(alice)
+
+	tests := []struct {
+		name   string
+		req    models.DisableTwoFactorRequest
+		status int
+	}{
+		{"no code", models.DisableTwoFactorRequest{Password: "password123"}, http.StatusBadRequest},
+		{"wrong password", models.DisableTwoFactorRequest{Password: "wrong-password", RecoveryCode: recovery[0]}, http.StatusForbidden},
+		{"wrong recovery code", models.DisableTwoFactorRequest{Password: "password123", RecoveryCode: "nope"}, http.StatusUnauthorized},
+		{"recovery code", models.DisableTwoFactorRequest{Password: "password123", RecoveryCode: recovery[0]}, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		if rec := s.do("DELETE", userPath(alice, "/2fa"), alice.Token, tt.req); rec.Code != tt.status {
+			t.Errorf("%s: %d %s", tt.name, rec.Code, rec.Body)
+		}
+	}
+
+	var login models.LoginResponse
+	decode(t, s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "password123"}), &login)
+	if login.Token == "" {
+		t.Error("login after disable asked for a second factor")
+	}
+}
+
+func TestVerifyTwoFactorLockout(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	secret, _ := s.enableTwoFactor(alice)
+	challenge := s.loginChallenge("alice")
+
+	// Неверный код: верный с изменённой первой цифрой
+	valid := totpCode(t, secret, 0)
+	wrong := strconv.Itoa((int(valid[0]-'0')+5)%10) + valid[1:]
+	verify := func(code string) *httptest.ResponseRecorder {
+		return s.do("POST", "/auth/2fa/verify", "", models.TwoFactorVerifyRequest{
+			ChallengeToken: challenge,
+			Code:           code,
+		})
+	}
+
+	for i := 1; i <= 5; i++ {
+		if rec := verify(wrong); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong code #%d: %d %s", i, rec.Code, rec.Body)
+		}
+	}
+	rec := verify(wrong)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("wrong code over the limit: %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	// Во время блокировки не принимается даже верный код, в том числе по новому challenge
+	if rec := verify(valid); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("valid code while locked: %d %s", rec.Code, rec.Body)
+	}
+	challenge = s.loginChallenge("alice")
+	if rec := verify(valid); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("new challenge while locked: %d %s", rec.Code, rec.Body)
+	}
+}
@@ -4,21 +4,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
+	"github.com/Balyshev/notes-api/internal/loginguard"
+	"github.com/Balyshev/notes-api/internal/middleware"
 	"github.com/Balyshev/notes-api/internal/models"
 	"github.com/Balyshev/notes-api/internal/storage"
 	"github.com/Balyshev/notes-api/pkg/auth"
+	"github.com/go-chi/chi/v5"
 )
 
 // UserHandler обрабатывает запросы к /users
 type UserHandler struct {
-	storage *storage.Storage
+	storage storage.UserStore
+	guard   *loginguard.Guard
 }
 
 // NewUserHandler создаёт новый UserHandler
-func NewUserHandler(storage *storage.Storage) *UserHandler {
+func NewUserHandler(storage storage.UserStore, guard *loginguard.Guard) *UserHandler {
 	return &UserHandler{
 		storage: storage,
+		guard:   guard,
 	}
 }
 
@@ -45,7 +51,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.storage.CreateUser(req.Username, passwordHash)
+	user, err := h.storage.CreateUser(r.Context(), req.Username, req.Email, passwordHash)
 	if err != nil {
 		fmt.Println("ERROR: storage.CreateUser failed:", err)
 
@@ -53,10 +59,94 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "Username already exists")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "Failed to create user")
+		respondStorageError(w, err, "Failed to create user")
 		return
 	}
 
 	fmt.Printf("User created successfully: %+v\n", user)
 	respondJSON(w, http.StatusCreated, user)
 }
+
+// DeleteUser обрабатывает DELETE /users/{id} — удаляет аккаунт вместе со всеми заметками.
+// Требует подтверждения паролем; неверный пароль считается неудачным входом, как в ChangePassword
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("=== DeleteUser called ===")
+
+	authenticatedUserID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userIDFromURL, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if authenticatedUserID != userIDFromURL {
+		respondError(w, http.StatusForbidden, "You can only delete your own account")
+		return
+	}
+
+	var req models.DeleteUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.storage.GetUserByID(r.Context(), authenticatedUserID)
+	if err != nil {
+		if err == models.ErrUserNotFound {
+			respondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		fmt.Println("ERROR: storage.GetUserByID failed:", err)
+		respondStorageError(w, err, "Failed to delete user")
+		return
+	}
+
+	ip := middleware.ClientIP(r)
+	wait, err := h.guard.Check(r.Context(), user.Username, ip)
+	if err != nil {
+		fmt.Println("ERROR: Failed to check login attempts:", err)
+		respondStorageError(w, err, "Failed to delete user")
+		return
+	}
+	if wait > 0 {
+		respondTooManyRequests(w, wait, "Too many failed password attempts, try again later")
+		return
+	}
+
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		if _, err := h.guard.Fail(r.Context(), user.Username, ip); err != nil {
+			fmt.Println("ERROR: Failed to record login failure:", err)
+			respondStorageError(w, err, "Failed to delete user")
+			return
+		}
+		respondError(w, http.StatusForbidden, models.ErrCurrentPasswordWrong.Error())
+		return
+	}
+
+	if err := h.guard.Succeed(r.Context(), user.Username); err != nil {
+		fmt.Println("ERROR: Failed to reset login attempts:", err)
+	}
+
+	if err := h.storage.DeleteUser(r.Context(), user.ID); err != nil {
+		if err == models.ErrUserNotFound {
+			respondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		fmt.Println("ERROR: storage.DeleteUser failed:", err)
+		respondStorageError(w, err, "Failed to delete user")
+		return
+	}
+
+	fmt.Printf("User deleted: %s\n", user.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
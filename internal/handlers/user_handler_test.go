@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestDeleteUser(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.register("alice")
+	bob := s.register("bob")
+	note := s.createNote(alice, "Title", "content")
+
+	tests := []struct {
+		name   string
+		token  string
+		body   interface{}
+		status int
+	}{
+		{"other user", bob.Token, models.DeleteUserRequest{Password: "password123"}, http.StatusForbidden},
+		{"no password", alice.Token, models.DeleteUserRequest{}, http.StatusBadRequest},
+		{"wrong password", alice.Token, models.DeleteUserRequest{Password: "wrong-password"}, http.StatusForbidden},
+		{"invalid json", alice.Token, `{"password":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := s.do("DELETE", userPath(alice, ""), tt.token, tt.body); rec.Code != tt.status {
+			t.Errorf("%s: %d %s", tt.name, rec.Code, rec.Body)
+		}
+	}
+
+	if rec := s.do("DELETE", userPath(alice, ""), alice.Token, models.DeleteUserRequest{Password: "password123"}); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do("POST", "/auth/login", "", models.LoginRequest{Username: "alice", Password: "password123"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("login after delete: %d", rec.Code)
+	}
+	if _, err := s.store.GetNoteByID(t.Context(), note.ID); err != models.ErrNoteNotFound {
+		t.Errorf("note after delete: %v", err)
+	}
+	if rec := s.do("GET", userPath(bob, "/notes"), bob.Token, nil); rec.Code != http.StatusOK {
+		t.Errorf("other user after delete: %d", rec.Code)
+	}
+}
@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/blob"
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+// blobSweepBatch - сколько blob'ов стирается за один проход
+const blobSweepBatch = 100
+
+// BlobSweeper периодически стирает из blob хранилища файлы удалённых вложений
+// (удалённых вручную или вместе с заметкой)
+type BlobSweeper struct {
+	storage  storage.AttachmentStore
+	blobs    blob.Store
+	interval time.Duration
+}
+
+// NewBlobSweeper создаёт новый BlobSweeper
+func NewBlobSweeper(storage storage.AttachmentStore, blobs blob.Store, interval time.Duration) *BlobSweeper {
+	return &BlobSweeper{
+		storage:  storage,
+		blobs:    blobs,
+		interval: interval,
+	}
+}
+
+// Run запускает очистку сразу и затем каждые interval, пока не отменён ctx
+func (s *BlobSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *BlobSweeper) sweep(ctx context.Context) {
+	for {
+		orphaned, err := s.storage.GetOrphanedBlobs(ctx, blobSweepBatch)
+		if err != nil {
+			fmt.Println("ERROR: GetOrphanedBlobs failed:", err)
+			return
+		}
+		if len(orphaned) == 0 {
+			return
+		}
+
+		// Не стёртые blob'ы остаются в очереди до следующего прохода
+		ids := make([]int, 0, len(orphaned))
+		for _, b := range orphaned {
+			if err := s.blobs.Delete(ctx, b.StorageKey); err != nil {
+				fmt.Println("ERROR: Failed to delete blob", b.StorageKey+":", err)
+				continue
+			}
+			ids = append(ids, b.ID)
+		}
+		if len(ids) == 0 {
+			return
+		}
+
+		if err := s.storage.DeleteOrphanedBlobs(ctx, ids); err != nil {
+			fmt.Println("ERROR: DeleteOrphanedBlobs failed:", err)
+			return
+		}
+		fmt.Printf("🧹 Deleted %d orphaned blobs\n", len(ids))
+
+		if len(orphaned) < blobSweepBatch {
+			return
+		}
+	}
+}
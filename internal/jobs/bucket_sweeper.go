@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+// BucketSweeper периодически удаляет корзины rate limit, простаивающие дольше idle
+type BucketSweeper struct {
+	storage  storage.RateLimitStore
+	idle     time.Duration
+	interval time.Duration
+}
+
+// NewBucketSweeper создаёт новый BucketSweeper.
+// idle должен быть не меньше самого длинного окна лимита: такие корзины уже полные
+func NewBucketSweeper(storage storage.RateLimitStore, idle, interval time.Duration) *BucketSweeper {
+	return &BucketSweeper{
+		storage:  storage,
+		idle:     idle,
+		interval: interval,
+	}
+}
+
+// Run запускает очистку каждые interval, пока не отменён ctx
+func (s *BucketSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := s.storage.PurgeRateLimitBuckets(ctx, time.Now().Add(-s.idle))
+		if err != nil {
+			fmt.Println("ERROR: PurgeRateLimitBuckets failed:", err)
+			continue
+		}
+		if purged > 0 {
+			fmt.Printf("🧹 Removed %d idle rate limit buckets\n", purged)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package jobs
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/blob"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/vault"
+)
+
+const (
+	// importBatch - сколько файлов архива записывается одной транзакцией
+	importBatch = 50
+	// importClaimTimeout - через сколько импорт без новых порций считается зависшим
+	importClaimTimeout = 10 * time.Minute
+	// importMaxFileSize - markdown файлы больше этого размера не импортируются
+	importMaxFileSize = 1 << 20 // 1 MB
+	// importMaxAttempts - сколько раз подряд импорт может упасть без прогресса, прежде чем он завершится с ошибкой
+	importMaxAttempts = 5
+)
+
+// Importer выполняет импорт zip архивов markdown заметок: папки становятся блокнотами,
+// заголовок берётся из первого заголовка или имени файла, теги и даты — из YAML front matter
+type Importer struct {
+	storage  storage.ImportStore
+	blobs    blob.Store
+	interval time.Duration
+}
+
+// NewImporter создаёт новый Importer
+func NewImporter(storage storage.ImportStore, blobs blob.Store, interval time.Duration) *Importer {
+	return &Importer{
+		storage:  storage,
+		blobs:    blobs,
+		interval: interval,
+	}
+}
+
+// Run обрабатывает очередь сразу и затем каждые interval, пока не отменён ctx
+func (im *Importer) Run(ctx context.Context) {
+	ticker := time.NewTicker(im.interval)
+	defer ticker.Stop()
+
+	for {
+		im.processPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processPending берёт импорты по одному: каждый может занять много времени
+func (im *Importer) processPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		imports, err := im.storage.ClaimImports(ctx, 1, now, now.Add(-importClaimTimeout))
+		if err != nil {
+			fmt.Println("ERROR: ClaimImports failed:", err)
+			return
+		}
+		if len(imports) == 0 {
+			return
+		}
+
+		im.process(ctx, imports[0])
+	}
+}
+
+// process импортирует архив порциями, начиная с первого незаписанного файла. Ошибки хранилища
+// оставляют импорт в обработке: через importClaimTimeout он будет продолжен, но после importMaxAttempts
+// попыток подряд без записанной порции — failed. Повреждённый или слишком большой архив — тоже failed
+func (im *Importer) process(ctx context.Context, imp *models.Import) {
+	if imp.Attempts > importMaxAttempts {
+		fmt.Printf("Import %d failed %d times in a row, giving up\n", imp.ID, imp.Attempts-1)
+		im.finish(ctx, imp, models.ImportStatusFailed, models.ErrImportAttemptsExceeded.Error())
+		return
+	}
+
+	archive, err := im.download(ctx, imp)
+	if err != nil {
+		fmt.Println("ERROR: Failed to read archive", imp.StorageKey+":", err)
+		return
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	info, err := archive.Stat()
+	if err != nil {
+		fmt.Println("ERROR: Failed to stat archive", imp.StorageKey+":", err)
+		return
+	}
+	reader, err := zip.NewReader(archive, info.Size())
+	if err != nil {
+		im.finish(ctx, imp, models.ImportStatusFailed, models.ErrInvalidArchive.Error())
+		return
+	}
+
+	files := vault.Files(reader)
+	if err := models.ValidateImportArchive(len(files), vault.UnpackedSize(files)); err != nil {
+		im.finish(ctx, imp, models.ImportStatusFailed, err.Error())
+		return
+	}
+
+	for offset := imp.ProcessedFiles; offset < len(files); offset += importBatch {
+		end := offset + importBatch
+		if end > len(files) {
+			end = len(files)
+		}
+
+		batch := &models.ImportBatch{Offset: offset, Files: end - offset}
+		for _, f := range files[offset:end] {
+			note, err := readNote(f)
+			if err != nil {
+				batch.Errors = append(batch.Errors, models.ImportFileError{File: reportName(f.Name), Error: err.Error()})
+				continue
+			}
+			batch.Notes = append(batch.Notes, note)
+		}
+
+		if err := im.storage.SaveImportBatch(ctx, imp.ID, batch); err != nil {
+			if err == models.ErrImportNotFound {
+				fmt.Printf("Import %d was deleted or taken over by another worker\n", imp.ID)
+				return
+			}
+			fmt.Println("ERROR: SaveImportBatch failed:", err)
+			return
+		}
+		imp.ImportedNotes += len(batch.Notes)
+		imp.FailedFiles += len(batch.Errors)
+	}
+
+	im.finish(ctx, imp, models.ImportStatusCompleted, "")
+	fmt.Printf("📦 Processed import %d: %d notes imported, %d files failed\n", imp.ID, imp.ImportedNotes, imp.FailedFiles)
+}
+
+func (im *Importer) finish(ctx context.Context, imp *models.Import, status, message string) {
+	if err := im.storage.FinishImport(ctx, imp.ID, status, message); err != nil && err != models.ErrImportNotFound {
+		fmt.Println("ERROR: FinishImport failed:", err)
+	}
+}
+
+// download копирует архив во временный файл: zip читается с произвольного места
+func (im *Importer) download(ctx context.Context, imp *models.Import) (*os.File, error) {
+	body, err := im.blobs.Get(ctx, imp.StorageKey, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp("", "import-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// reportName приводит имя файла архива к виду, который можно сохранить в отчёте:
+// имя может быть не в UTF-8 или содержать NUL
+func reportName(name string) string {
+	return strings.ToValidUTF8(strings.ReplaceAll(name, "\x00", ""), "\uFFFD")
+}
+
+// readNote читает заметку из файла архива и проверяет её теми же правилами, что и POST заметки
+func readNote(f *zip.File) (*models.ImportedNote, error) {
+	if f.UncompressedSize64 > importMaxFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", importMaxFileSize)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// Размер в заголовке zip может не совпадать с содержимым
+	data, err := io.ReadAll(io.LimitReader(rc, importMaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > importMaxFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", importMaxFileSize)
+	}
+
+	parsed, err := vault.Parse(f.Name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	folders := make([]string, len(parsed.Folders))
+	for i, folder := range parsed.Folders {
+		notebook := &models.CreateNotebookRequest{Name: folder}
+		if err := notebook.Validate(); err != nil {
+			return nil, fmt.Errorf("folder %q: %w", folder, err)
+		}
+		folders[i] = notebook.Name
+	}
+
+	req := &models.CreateNoteRequest{
+		Title:   parsed.Title,
+		Content: parsed.Content,
+		Format:  models.FormatMarkdown,
+		Tags:    parsed.Tags,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &models.ImportedNote{
+		Folders:   folders,
+		Title:     req.Title,
+		Content:   req.Content,
+		Tags:      req.Tags,
+		CreatedAt: parsed.CreatedAt,
+		UpdatedAt: parsed.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/blob"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+// testArchive собирает zip архив из файлов имя -> содержимое
+func testArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImporter(t *testing.T) {
+	ctx := t.Context()
+	store := storage.NewMemory()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, _ := store.CreateUser(ctx, "alice", "", "hash")
+
+	files := map[string]string{
+		"work/plan.md":       "---\ntags: [go, notes]\ncreated: 2023-01-02\n---\n# Project Plan\ntext",
+		"work/projects/a.md": "nested",
+		"root.md":            "in root",
+		"bad.md":             "not utf-8 \xff",
+		".obsidian/app.md":   "skipped",
+		"image.png":          "skipped",
+	}
+	// Больше importBatch файлов — импорт пишется несколькими порциями
+	for i := 0; i < importBatch+5; i++ {
+		files[fmt.Sprintf("bulk/%02d.md", i)] = "bulk note"
+	}
+	archive := testArchive(t, files)
+	if err := blobs.Put(ctx, "imports/1.zip", bytes.NewReader(archive), int64(len(archive)), "application/zip"); err != nil {
+		t.Fatal(err)
+	}
+
+	imp, err := store.CreateImport(ctx, &models.Import{
+		UserID:     user.ID,
+		Filename:   "vault.zip",
+		TotalFiles: importBatch + 9,
+		StorageKey: "imports/1.zip",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	NewImporter(store, blobs, time.Hour).processPending(ctx)
+
+	done, err := store.GetImport(ctx, user.ID, imp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != models.ImportStatusCompleted || done.ImportedNotes != importBatch+8 || done.FailedFiles != 1 ||
+		done.ProcessedFiles != importBatch+9 || done.CreatedNotebooks != 3 || done.FinishedAt == nil {
+		t.Errorf("import: %+v", done)
+	}
+	if len(done.Errors) != 1 || done.Errors[0].File != "bad.md" {
+		t.Errorf("import errors: %+v", done.Errors)
+	}
+
+	notes, _ := store.GetUserNotes(ctx, user.ID, models.NoteListOptions{Limit: 100, Tags: []string{"go"}})
+	if len(notes) != 1 {
+		t.Fatalf("notes tagged go: %d", len(notes))
+	}
+	plan := notes[0]
+	if plan.Title != "Project Plan" || plan.Format != models.FormatMarkdown || plan.NotebookID == nil ||
+		!plan.CreatedAt.Equal(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("imported note: %+v", plan)
+	}
+
+	notebooks, _ := store.GetUserNotebooks(ctx, user.ID)
+	if len(notebooks) != 3 {
+		t.Errorf("notebooks: %d", len(notebooks))
+	}
+}
+
+func TestImporterInvalidArchive(t *testing.T) {
+	ctx := t.Context()
+	store := storage.NewMemory()
+	blobs, _ := blob.NewLocalStore(t.TempDir())
+	user, _ := store.CreateUser(ctx, "alice", "", "hash")
+
+	if err := blobs.Put(ctx, "imports/1.zip", bytes.NewReader([]byte("not a zip")), 9, "application/zip"); err != nil {
+		t.Fatal(err)
+	}
+	imp, _ := store.CreateImport(ctx, &models.Import{UserID: user.ID, Filename: "vault.zip", StorageKey: "imports/1.zip"})
+
+	NewImporter(store, blobs, time.Hour).processPending(ctx)
+
+	failed, _ := store.GetImport(ctx, user.ID, imp.ID)
+	if failed.Status != models.ImportStatusFailed || failed.Error != models.ErrInvalidArchive.Error() {
+		t.Errorf("import of a broken archive: %+v", failed)
+	}
+}
+
+func TestImporterAttempts(t *testing.T) {
+	ctx := t.Context()
+	store := storage.NewMemory()
+	blobs, _ := blob.NewLocalStore(t.TempDir())
+	user, _ := store.CreateUser(ctx, "alice", "", "hash")
+
+	// Архива нет в хранилище: каждая попытка падает без прогресса
+	imp, _ := store.CreateImport(ctx, &models.Import{UserID: user.ID, Filename: "vault.zip", StorageKey: "imports/1.zip"})
+	im := NewImporter(store, blobs, time.Hour)
+
+	var claimed []*models.Import
+	for i := 0; i <= importMaxAttempts; i++ {
+		claimed, _ = store.ClaimImports(ctx, 10, time.Now(), time.Now().Add(time.Hour))
+		if len(claimed) != 1 {
+			t.Fatalf("claim %d: %d imports", i+1, len(claimed))
+		}
+		if i < importMaxAttempts {
+			im.process(ctx, claimed[0])
+			if got, _ := store.GetImport(ctx, user.ID, imp.ID); got.Status != models.ImportStatusProcessing {
+				t.Fatalf("attempt %d: status %s", i+1, got.Status)
+			}
+		}
+	}
+	im.process(ctx, claimed[0])
+
+	failed, _ := store.GetImport(ctx, user.ID, imp.ID)
+	if failed.Status != models.ImportStatusFailed || failed.Error != models.ErrImportAttemptsExceeded.Error() {
+		t.Errorf("import after %d attempts: %+v", importMaxAttempts+1, failed)
+	}
+}
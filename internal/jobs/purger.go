@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+// TrashPurger периодически удаляет из корзины заметки старше retention
+type TrashPurger struct {
+	storage   storage.NoteStore
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewTrashPurger создаёт новый TrashPurger
+func NewTrashPurger(storage storage.NoteStore, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		storage:   storage,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run запускает очистку сразу и затем каждые interval, пока не отменён ctx
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	before := time.Now().Add(-p.retention)
+
+	purged, err := p.storage.PurgeDeletedNotes(ctx, before)
+	if err != nil {
+		fmt.Println("ERROR: PurgeDeletedNotes failed:", err)
+		return
+	}
+
+	if purged > 0 {
+		fmt.Printf("🗑️  Purged %d notes deleted before %s\n", purged, before.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/blob"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/imaging"
+)
+
+const (
+	// thumbnailBatch - сколько изображений забирается в обработку за раз
+	thumbnailBatch = 10
+	// thumbnailClaimTimeout - через сколько изображение, взятое в обработку, считается зависшим
+	thumbnailClaimTimeout = 10 * time.Minute
+)
+
+// Thumbnailer обрабатывает загруженные изображения: удаляет из EXIF координаты,
+// записывает размеры и строит миниатюру, вписанную в квадрат size×size
+type Thumbnailer struct {
+	storage  storage.AttachmentStore
+	blobs    blob.Store
+	size     int
+	interval time.Duration
+}
+
+// NewThumbnailer создаёт новый Thumbnailer
+func NewThumbnailer(storage storage.AttachmentStore, blobs blob.Store, size int, interval time.Duration) *Thumbnailer {
+	return &Thumbnailer{
+		storage:  storage,
+		blobs:    blobs,
+		size:     size,
+		interval: interval,
+	}
+}
+
+// Run обрабатывает очередь сразу и затем каждые interval, пока не отменён ctx
+func (t *Thumbnailer) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		t.processPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Thumbnailer) processPending(ctx context.Context) {
+	for {
+		now := time.Now()
+		attachments, err := t.storage.ClaimImageAttachments(ctx, thumbnailBatch, now, now.Add(-thumbnailClaimTimeout))
+		if err != nil {
+			fmt.Println("ERROR: ClaimImageAttachments failed:", err)
+			return
+		}
+
+		for _, attachment := range attachments {
+			t.process(ctx, attachment)
+		}
+
+		if len(attachments) < thumbnailBatch {
+			return
+		}
+	}
+}
+
+// process обрабатывает одно изображение. Ошибки чтения/записи хранилища оставляют его в обработке:
+// через thumbnailClaimTimeout оно будет взято повторно. Нераспознанное изображение помечается failed
+func (t *Thumbnailer) process(ctx context.Context, attachment *models.Attachment) {
+	data, err := t.read(ctx, attachment)
+	if err != nil {
+		fmt.Println("ERROR: Failed to read image", attachment.StorageKey+":", err)
+		return
+	}
+
+	result := &models.ImageResult{}
+
+	// Координаты удаляются из самого оригинала, чтобы их не получили те, с кем поделились заметкой.
+	// Очищенный файл пишется под новым ключом: прежний blob отдаётся, пока FinishImageAttachment
+	// не переключит вложение, и уходит в очередь на удаление вместе с переключением
+	if stripped, changed := imaging.StripLocation(data); changed {
+		key := attachment.StorageKey + ".nogps"
+		if err := t.blobs.Put(ctx, key, bytes.NewReader(stripped), int64(len(stripped)), attachment.ContentType); err != nil {
+			fmt.Println("ERROR: Failed to store stripped image", key+":", err)
+			return
+		}
+		sum := sha256.Sum256(stripped)
+		result.StorageKey = key
+		result.Size = int64(len(stripped))
+		result.SHA256 = hex.EncodeToString(sum[:])
+		data = stripped
+		fmt.Printf("📍 Removed location from attachment %d\n", attachment.ID)
+	}
+
+	status := models.ImageStatusReady
+	thumb, err := imaging.Thumbnail(data, t.size)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to build thumbnail for attachment %d: %v\n", attachment.ID, err)
+		status = models.ImageStatusFailed
+	} else {
+		key := attachment.StorageKey + ".thumb"
+		if err := t.blobs.Put(ctx, key, bytes.NewReader(thumb.Thumbnail), int64(len(thumb.Thumbnail)), thumb.ContentType); err != nil {
+			fmt.Println("ERROR: Failed to store thumbnail", key+":", err)
+			return
+		}
+		result.Width = thumb.Width
+		result.Height = thumb.Height
+		result.ThumbnailKey = key
+		result.ThumbnailContentType = thumb.ContentType
+		result.ThumbnailSize = int64(len(thumb.Thumbnail))
+	}
+
+	if err := t.storage.FinishImageAttachment(ctx, attachment.ID, status, result); err != nil {
+		if err == models.ErrAttachmentNotFound {
+			t.cleanupDeleted(ctx, attachment, result)
+			return
+		}
+		fmt.Println("ERROR: FinishImageAttachment failed:", err)
+		return
+	}
+
+	fmt.Printf("🖼️  Processed attachment %d (%dx%d, %s)\n", attachment.ID, result.Width, result.Height, status)
+}
+
+func (t *Thumbnailer) read(ctx context.Context, attachment *models.Attachment) ([]byte, error) {
+	body, err := t.blobs.Get(ctx, attachment.StorageKey, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(io.LimitReader(body, attachment.Size))
+}
+
+// cleanupDeleted стирает то, что записано для вложения, удалённого во время обработки:
+// при удалении в очередь попал только исходный blob, о новых ключах хранилище не знает
+func (t *Thumbnailer) cleanupDeleted(ctx context.Context, attachment *models.Attachment, result *models.ImageResult) {
+	if _, err := t.storage.GetAttachment(ctx, attachment.NoteID, attachment.ID); err != models.ErrAttachmentNotFound {
+		return // вложение на месте, его обработал другой экземпляр
+	}
+
+	keys := []string{}
+	if result.StorageKey != "" {
+		keys = append(keys, result.StorageKey)
+	}
+	if result.ThumbnailKey != "" {
+		keys = append(keys, result.ThumbnailKey)
+	}
+	for _, key := range keys {
+		if err := t.blobs.Delete(ctx, key); err != nil {
+			fmt.Println("ERROR: Failed to delete blob", key+":", err)
+		}
+	}
+}
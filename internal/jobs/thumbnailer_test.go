@@ -0,0 +1,186 @@
+package jobs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/jpeg"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/blob"
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/imaging"
+)
+
+// exifWithGPS - EXIF (little endian): ориентация 6 и GPS IFD с широтой 0x5EC2E7/1
+var exifWithGPS = []byte{
+	'I', 'I', 42, 0, 8, 0, 0, 0,
+	2, 0,
+	0x12, 0x01, 3, 0, 1, 0, 0, 0, 6, 0, 0, 0,
+	0x25, 0x88, 4, 0, 1, 0, 0, 0, 38, 0, 0, 0,
+	0, 0, 0, 0,
+	1, 0,
+	2, 0, 5, 0, 1, 0, 0, 0, 56, 0, 0, 0,
+	0, 0, 0, 0,
+	0xE7, 0xC2, 0x5E, 0, 1, 0, 0, 0,
+}
+
+// photoWithGPS - JPEG 40×20 с EXIF из exifWithGPS
+func photoWithGPS(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	segment := []byte{0xFF, 0xE1, 0, byte(2 + 6 + len(exifWithGPS))}
+	segment = append(segment, "Exif\x00\x00"...)
+	segment = append(segment, exifWithGPS...)
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestThumbnailer(t *testing.T) {
+	ctx := t.Context()
+	store := storage.NewMemory()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, _ := store.CreateUser(ctx, "alice", "", "hash")
+	note, _ := store.CreateNote(ctx, user.ID, nil, "Title", "content", "plain", nil)
+
+	upload := func(key, contentType string, data []byte) *models.Attachment {
+		t.Helper()
+		if err := blobs.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(data)
+		attachment, err := store.CreateAttachment(ctx, &models.Attachment{
+			NoteID:      note.ID,
+			UserID:      user.ID,
+			Filename:    key,
+			ContentType: contentType,
+			Size:        int64(len(data)),
+			SHA256:      hex.EncodeToString(sum[:]),
+			StorageKey:  key,
+			ImageStatus: models.ImageStatusPending,
+		}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return attachment
+	}
+	uploaded := photoWithGPS(t)
+	photo := upload("photo.jpg", "image/jpeg", uploaded)
+	broken := upload("broken.png", "image/png", []byte("not a png"))
+
+	NewThumbnailer(store, blobs, 10, time.Hour).processPending(ctx)
+
+	processed, err := store.GetAttachment(ctx, note.ID, photo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ориентация 6: изображение 40×20 показывается повёрнутым
+	if processed.ImageStatus != models.ImageStatusReady || processed.Width != 20 || processed.Height != 40 {
+		t.Errorf("processed photo: %+v", processed)
+	}
+	if processed.SHA256 == photo.SHA256 {
+		t.Error("sha256 was not updated after stripping location")
+	}
+
+	original := readBlob(t, blobs, processed.StorageKey)
+	if bytes.Contains(original, []byte{0xE7, 0xC2, 0x5E, 0}) {
+		t.Error("GPS data left in stored original")
+	}
+	if sum := sha256.Sum256(original); hex.EncodeToString(sum[:]) != processed.SHA256 || int64(len(original)) != processed.Size {
+		t.Error("stored original does not match attachment metadata")
+	}
+	if imaging.Orientation(original) != 6 {
+		t.Errorf("orientation after stripping: %d", imaging.Orientation(original))
+	}
+
+	// Очищенный оригинал записан под новым ключом, прежний blob не перезаписан и ждёт удаления
+	if processed.StorageKey == photo.StorageKey {
+		t.Errorf("stripped original overwrote %s", photo.StorageKey)
+	}
+	if !bytes.Equal(readBlob(t, blobs, photo.StorageKey), uploaded) {
+		t.Error("previous blob was modified before it was released")
+	}
+	orphaned, _ := store.GetOrphanedBlobs(ctx, 10)
+	if len(orphaned) != 1 || orphaned[0].StorageKey != photo.StorageKey {
+		t.Errorf("orphaned blobs: %+v", orphaned)
+	}
+	NewBlobSweeper(store, blobs, time.Hour).sweep(ctx)
+	if _, err := blobs.Get(ctx, photo.StorageKey, 0, -1); err == nil {
+		t.Error("previous blob was not swept")
+	}
+
+	thumb, _, err := image.DecodeConfig(bytes.NewReader(readBlob(t, blobs, processed.ThumbnailKey)))
+	if err != nil || thumb.Width != 5 || thumb.Height != 10 || processed.ThumbnailContentType != "image/jpeg" {
+		t.Errorf("thumbnail: %dx%d %s, %v", thumb.Width, thumb.Height, processed.ThumbnailContentType, err)
+	}
+
+	failed, _ := store.GetAttachment(ctx, note.ID, broken.ID)
+	if failed.ImageStatus != models.ImageStatusFailed || failed.HasThumbnail() {
+		t.Errorf("broken image: %+v", failed)
+	}
+}
+
+func readBlob(t *testing.T, blobs blob.Store, key string) []byte {
+	t.Helper()
+	body, err := blobs.Get(t.Context(), key, 0, -1)
+	if err != nil {
+		t.Fatalf("get %s: %v", key, err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestThumbnailerDeletedDuringProcessing(t *testing.T) {
+	ctx := t.Context()
+	store := storage.NewMemory()
+	blobs, _ := blob.NewLocalStore(t.TempDir())
+	user, _ := store.CreateUser(ctx, "alice", "", "hash")
+	note, _ := store.CreateNote(ctx, user.ID, nil, "Title", "content", "plain", nil)
+
+	data := photoWithGPS(t)
+	blobs.Put(ctx, "photo.jpg", bytes.NewReader(data), int64(len(data)), "image/jpeg")
+	store.CreateAttachment(ctx, &models.Attachment{
+		NoteID:      note.ID,
+		UserID:      user.ID,
+		Filename:    "photo.jpg",
+		ContentType: "image/jpeg",
+		Size:        int64(len(data)),
+		StorageKey:  "photo.jpg",
+		ImageStatus: models.ImageStatusPending,
+	}, 0)
+
+	claimed, _ := store.ClaimImageAttachments(ctx, 10, time.Now(), time.Now().Add(-time.Hour))
+	if len(claimed) != 1 {
+		t.Fatalf("claimed: %d", len(claimed))
+	}
+	if err := store.DeleteAttachment(ctx, note.ID, claimed[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	NewThumbnailer(store, blobs, 10, time.Hour).process(ctx, claimed[0])
+
+	// Новые blob'ы стёрты сразу, исходный — в очереди на удаление
+	for _, key := range []string{"photo.jpg.nogps", "photo.jpg.thumb"} {
+		if _, err := blobs.Get(ctx, key, 0, -1); err == nil {
+			t.Errorf("%s is left after the attachment was deleted", key)
+		}
+	}
+	orphaned, _ := store.GetOrphanedBlobs(ctx, 10)
+	if len(orphaned) != 1 || orphaned[0].StorageKey != "photo.jpg" {
+		t.Errorf("orphaned blobs: %+v", orphaned)
+	}
+}
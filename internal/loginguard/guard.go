@@ -0,0 +1,213 @@
+package loginguard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+// Policy - правила блокировки для одного вида ключа.
+// Первые FreeAttempts неудач не блокируют, дальше блокировка растёт
+// экспоненциально от BaseDelay до MaxDelay
+type Policy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+}
+
+// LockDuration возвращает длительность блокировки после failures неудач подряд
+func (p Policy) LockDuration(failures int) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// Config - настройки Guard
+type Config struct {
+	User      Policy        // по имени пользователя — подбор пароля к одному аккаунту
+	IP        Policy        // по адресу клиента — перебор многих аккаунтов с одного адреса
+	TwoFactor Policy        // по аккаунту для кодов 2FA — подбор кода, когда пароль уже известен
+	ShareLink Policy        // по публичной ссылке — подбор пароля ссылки с любых адресов
+	Window    time.Duration // после такой паузы без неудач счётчик начинается заново
+}
+
+// DefaultConfig - 5 попыток на аккаунт и 20 на адрес, блокировка от 1 секунды до 15 минут;
+// 5 кодов 2FA и 5 паролей публичной ссылки, блокировка от 30 секунд до 15 минут
+var DefaultConfig = Config{
+	User:      Policy{FreeAttempts: 5, BaseDelay: time.Second, MaxDelay: 15 * time.Minute},
+	IP:        Policy{FreeAttempts: 20, BaseDelay: time.Second, MaxDelay: 15 * time.Minute},
+	TwoFactor: Policy{FreeAttempts: 5, BaseDelay: 30 * time.Second, MaxDelay: 15 * time.Minute},
+	ShareLink: Policy{FreeAttempts: 5, BaseDelay: 30 * time.Second, MaxDelay: 15 * time.Minute},
+	Window:    15 * time.Minute,
+}
+
+// Guard отслеживает неудачные входы по имени пользователя и по IP
+type Guard struct {
+	storage storage.LoginAttemptStore
+	config  Config
+}
+
+// New создаёт новый Guard
+func New(storage storage.LoginAttemptStore, config Config) *Guard {
+	return &Guard{
+		storage: storage,
+		config:  config,
+	}
+}
+
+// Check возвращает, сколько ещё ждать до следующей попытки (0 — можно входить)
+func (g *Guard) Check(ctx context.Context, username, ip string) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+	for _, key := range keys(username, ip) {
+		attempts, err := g.storage.GetLoginAttempts(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if attempts.LockedUntil != nil && attempts.LockedUntil.After(now) {
+			if d := attempts.LockedUntil.Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+	return wait, nil
+}
+
+// Fail записывает неудачный вход; при превышении лимита блокирует ключ
+// и пишет событие в журнал. Возвращает длительность блокировки (0 — без блокировки)
+func (g *Guard) Fail(ctx context.Context, username, ip string) (time.Duration, error) {
+	policies := []Policy{g.config.User, g.config.IP}
+
+	var wait time.Duration
+	for i, key := range keys(username, ip) {
+		lock, err := g.fail(ctx, key, policies[i], username, ip)
+		if err != nil {
+			return 0, err
+		}
+		if lock > wait {
+			wait = lock
+		}
+	}
+	return wait, nil
+}
+
+// fail увеличивает счётчик ключа и блокирует его, если неудач больше, чем разрешает policy
+func (g *Guard) fail(ctx context.Context, key string, policy Policy, username, ip string) (time.Duration, error) {
+	now := time.Now()
+	failures, err := g.storage.RecordLoginFailure(ctx, key, now, now.Add(-g.config.Window))
+	if err != nil {
+		return 0, err
+	}
+
+	lock := policy.LockDuration(failures)
+	if lock == 0 {
+		return 0, nil
+	}
+	until := now.Add(lock)
+	if err := g.storage.LockLogin(ctx, key, until); err != nil {
+		return 0, err
+	}
+	event := &models.LockoutEvent{
+		Key:         key,
+		Username:    username,
+		IP:          ip,
+		Failures:    failures,
+		LockedUntil: until,
+	}
+	if err := g.storage.CreateLockoutEvent(ctx, event); err != nil {
+		return 0, err
+	}
+	fmt.Printf("WARNING: Login locked for %s until %s after %d failures\n", key, until.Format(time.RFC3339), failures)
+	return lock, nil
+}
+
+// Succeed сбрасывает счётчик аккаунта после успешного входа.
+// Счётчик адреса не сбрасывается: иначе перебор чередовал бы чужие аккаунты со своим
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	return g.storage.ResetLoginAttempts(ctx, userKey(username))
+}
+
+// CheckTwoFactor возвращает, сколько ещё ждать до следующей проверки кода 2FA (0 — можно проверять).
+// Неудачи считаются по аккаунту, а не по challenge: новый вход с паролем счётчик не сбрасывает.
+// Challenge, выданный до последней неудачи сверх лимита, больше не принимается —
+// models.ErrChallengeRevoked. Нулевой challengeIssuedAt — проверка без challenge (отключение 2FA)
+func (g *Guard) CheckTwoFactor(ctx context.Context, userID int, challengeIssuedAt time.Time) (time.Duration, error) {
+	attempts, err := g.storage.GetLoginAttempts(ctx, twoFactorKey(userID))
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	if attempts.LockedUntil != nil && attempts.LockedUntil.After(now) {
+		return attempts.LockedUntil.Sub(now), nil
+	}
+	overLimit := attempts.Failures > g.config.TwoFactor.FreeAttempts &&
+		attempts.LastFailureAt.After(now.Add(-g.config.Window))
+	if overLimit && !challengeIssuedAt.IsZero() && !challengeIssuedAt.After(attempts.LastFailureAt) {
+		return 0, models.ErrChallengeRevoked
+	}
+	return 0, nil
+}
+
+// FailTwoFactor записывает неверный код 2FA. Возвращает длительность блокировки (0 — без блокировки)
+func (g *Guard) FailTwoFactor(ctx context.Context, userID int, username, ip string) (time.Duration, error) {
+	return g.fail(ctx, twoFactorKey(userID), g.config.TwoFactor, username, ip)
+}
+
+// SucceedTwoFactor сбрасывает счётчик кодов 2FA после верного кода
+func (g *Guard) SucceedTwoFactor(ctx context.Context, userID int) error {
+	return g.storage.ResetLoginAttempts(ctx, twoFactorKey(userID))
+}
+
+// CheckShareLink возвращает, сколько ещё ждать до следующей проверки пароля публичной ссылки (0 — можно проверять)
+func (g *Guard) CheckShareLink(ctx context.Context, linkID int) (time.Duration, error) {
+	attempts, err := g.storage.GetLoginAttempts(ctx, shareLinkKey(linkID))
+	if err != nil {
+		return 0, err
+	}
+	if now := time.Now(); attempts.LockedUntil != nil && attempts.LockedUntil.After(now) {
+		return attempts.LockedUntil.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// FailShareLink записывает неверный пароль публичной ссылки. Неудачи считаются по ссылке,
+// а не по адресу: ссылку может перебирать множество адресов. Возвращает длительность блокировки
+func (g *Guard) FailShareLink(ctx context.Context, linkID int, ip string) (time.Duration, error) {
+	return g.fail(ctx, shareLinkKey(linkID), g.config.ShareLink, "", ip)
+}
+
+// SucceedShareLink сбрасывает счётчик ссылки после верного пароля
+func (g *Guard) SucceedShareLink(ctx context.Context, linkID int) error {
+	return g.storage.ResetLoginAttempts(ctx, shareLinkKey(linkID))
+}
+
+func keys(username, ip string) []string {
+	return []string{userKey(username), "ip:" + ip}
+}
+
+// userKey - ключ аккаунта. Имена пользователей чувствительны к регистру: "Alice" и "alice" —
+// разные аккаунты, и неудачи одного не должны блокировать другой
+func userKey(username string) string {
+	return "user:" + username
+}
+
+func twoFactorKey(userID int) string {
+	return "2fa:" + strconv.Itoa(userID)
+}
+
+func shareLinkKey(linkID int) string {
+	return "link:" + strconv.Itoa(linkID)
+}
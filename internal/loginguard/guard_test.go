@@ -0,0 +1,236 @@
+package loginguard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+func TestLockDuration(t *testing.T) {
+	p := Policy{FreeAttempts: 5, BaseDelay: time.Second, MaxDelay: 15 * time.Minute}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{5, 0},
+		{6, time.Second},
+		{7, 2 * time.Second},
+		{8, 4 * time.Second},
+		{15, 512 * time.Second},
+		{16, 15 * time.Minute}, // 1024s больше MaxDelay
+		{1000, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.LockDuration(tt.failures); got != tt.want {
+			t.Errorf("%d failures: %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestGuardUserLockout(t *testing.T) {
+	store := storage.NewMemory()
+	g := New(store, Config{
+		User:   Policy{FreeAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		IP:     Policy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		Window: time.Hour,
+	})
+	ctx := t.Context()
+
+	for i := 1; i <= 2; i++ {
+		if wait, err := g.Fail(ctx, "alice", "10.0.0.1"); err != nil || wait != 0 {
+			t.Fatalf("failure %d: %s, %v", i, wait, err)
+		}
+	}
+	if wait, _ := g.Check(ctx, "alice", "10.0.0.1"); wait != 0 {
+		t.Fatalf("locked after free attempts: %s", wait)
+	}
+
+	if wait, _ := g.Fail(ctx, "alice", "10.0.0.1"); wait != time.Minute {
+		t.Fatalf("third failure: lock %s", wait)
+	}
+	if wait, _ := g.Fail(ctx, "alice", "10.0.0.2"); wait != 2*time.Minute {
+		t.Fatalf("fourth failure: lock %s", wait)
+	}
+
+	// Аккаунт заблокирован с любого адреса, другие аккаунты — нет
+	if wait, _ := g.Check(ctx, "alice", "10.0.0.9"); wait <= time.Minute || wait > 2*time.Minute {
+		t.Errorf("alice from another address: %s", wait)
+	}
+	if wait, _ := g.Check(ctx, "bob", "10.0.0.1"); wait != 0 {
+		t.Errorf("bob: %s", wait)
+	}
+
+	events, _ := store.GetLockoutEvents(ctx, 10, 0)
+	if len(events) != 2 || events[0].Username != "alice" || events[0].Failures != 4 || events[0].IP != "10.0.0.2" {
+		t.Errorf("lockout events: %+v", events)
+	}
+
+	if err := g.Succeed(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := g.Check(ctx, "alice", "10.0.0.1"); wait != 0 {
+		t.Errorf("after success: %s", wait)
+	}
+}
+
+func TestGuardIPLockout(t *testing.T) {
+	g := New(storage.NewMemory(), Config{
+		User:   Policy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		IP:     Policy{FreeAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		Window: time.Hour,
+	})
+	ctx := t.Context()
+
+	// Перебор разных аккаунтов с одного адреса
+	for _, name := range []string{"a", "b", "c"} {
+		if wait, _ := g.Fail(ctx, name, "10.0.0.1"); wait != 0 {
+			t.Fatalf("%s: lock %s", name, wait)
+		}
+	}
+	// Успешный вход своим аккаунтом не сбрасывает счётчик адреса
+	g.Succeed(ctx, "a")
+	if wait, _ := g.Fail(ctx, "d", "10.0.0.1"); wait != time.Minute {
+		t.Fatalf("fourth account: lock %s", wait)
+	}
+
+	if wait, _ := g.Check(ctx, "someone", "10.0.0.1"); wait == 0 {
+		t.Error("address is not locked")
+	}
+	if wait, _ := g.Check(ctx, "someone", "10.0.0.2"); wait != 0 {
+		t.Errorf("other address: %s", wait)
+	}
+}
+
+func TestGuardWindow(t *testing.T) {
+	// Окно нулевой длины: каждая неудача начинает счёт заново
+	g := New(storage.NewMemory(), Config{
+		User: Policy{FreeAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		IP:   Policy{FreeAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour},
+	})
+	for i := 0; i < 5; i++ {
+		time.Sleep(time.Millisecond)
+		if wait, _ := g.Fail(t.Context(), "alice", "10.0.0.1"); wait != 0 {
+			t.Fatalf("failure %d outside the window: lock %s", i+1, wait)
+		}
+	}
+}
+
+func TestGuardTwoFactor(t *testing.T) {
+	g := New(storage.NewMemory(), Config{
+		User:      Policy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		IP:        Policy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		TwoFactor: Policy{FreeAttempts: 2, BaseDelay: 20 * time.Millisecond, MaxDelay: time.Second},
+		Window:    time.Hour,
+	})
+	ctx := t.Context()
+	issued := time.Now()
+
+	for i := 1; i <= 2; i++ {
+		if wait, err := g.FailTwoFactor(ctx, 1, "alice", "10.0.0.1"); err != nil || wait != 0 {
+			t.Fatalf("failure %d: %s, %v", i, wait, err)
+		}
+	}
+	if wait, _ := g.FailTwoFactor(ctx, 1, "alice", "10.0.0.1"); wait != 20*time.Millisecond {
+		t.Fatalf("third failure: lock %s", wait)
+	}
+	if wait, _ := g.CheckTwoFactor(ctx, 1, issued); wait == 0 {
+		t.Fatal("account is not locked")
+	}
+	// Счётчик кодов не затрагивает пароль и другие аккаунты
+	if wait, _ := g.Check(ctx, "alice", "10.0.0.1"); wait != 0 {
+		t.Errorf("password check: %s", wait)
+	}
+	if wait, _ := g.CheckTwoFactor(ctx, 2, issued); wait != 0 {
+		t.Errorf("other account: %s", wait)
+	}
+
+	// После блокировки старый challenge не принимается, новый — принимается
+	time.Sleep(30 * time.Millisecond)
+	if _, err := g.CheckTwoFactor(ctx, 1, issued); err != models.ErrChallengeRevoked {
+		t.Errorf("stale challenge: %v", err)
+	}
+	if wait, err := g.CheckTwoFactor(ctx, 1, time.Now()); err != nil || wait != 0 {
+		t.Errorf("new challenge: %s, %v", wait, err)
+	}
+
+	if err := g.SucceedTwoFactor(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.CheckTwoFactor(ctx, 1, issued); err != nil {
+		t.Errorf("after success: %v", err)
+	}
+}
+
+func TestGuardShareLink(t *testing.T) {
+	store := storage.NewMemory()
+	g := New(store, Config{
+		User:      Policy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		IP:        Policy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		ShareLink: Policy{FreeAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		Window:    time.Hour,
+	})
+	ctx := t.Context()
+
+	// Неудачи с разных адресов складываются в счётчик ссылки
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		if wait, err := g.FailShareLink(ctx, 7, ip); err != nil || wait != 0 {
+			t.Fatalf("failure %d: %s, %v", i+1, wait, err)
+		}
+	}
+	if wait, _ := g.FailShareLink(ctx, 7, "10.0.0.3"); wait != time.Minute {
+		t.Fatalf("third failure: lock %s", wait)
+	}
+	if wait, _ := g.CheckShareLink(ctx, 7); wait <= 0 || wait > time.Minute {
+		t.Errorf("locked link: %s", wait)
+	}
+	if wait, _ := g.CheckShareLink(ctx, 8); wait != 0 {
+		t.Errorf("other link: %s", wait)
+	}
+	if wait, _ := g.Check(ctx, "", "10.0.0.3"); wait != 0 {
+		t.Errorf("address is locked by link failures: %s", wait)
+	}
+
+	events, _ := store.GetLockoutEvents(ctx, 10, 0)
+	if len(events) != 1 || events[0].Key != "link:7" || events[0].IP != "10.0.0.3" {
+		t.Errorf("lockout events: %+v", events)
+	}
+
+	if err := g.SucceedShareLink(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := g.CheckShareLink(ctx, 7); wait != 0 {
+		t.Errorf("after success: %s", wait)
+	}
+}
+
+func TestGuardUsernameCase(t *testing.T) {
+	g := New(storage.NewMemory(), Config{
+		User:   Policy{FreeAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		IP:     Policy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		Window: time.Hour,
+	})
+	ctx := t.Context()
+
+	// "Alice" и "alice" — разные аккаунты: подбор пароля к одному не блокирует другой
+	for i := 0; i < 2; i++ {
+		g.Fail(ctx, "Alice", "10.0.0.1")
+	}
+	if wait, _ := g.Check(ctx, "Alice", "10.0.0.1"); wait == 0 {
+		t.Error("Alice is not locked")
+	}
+	if wait, _ := g.Check(ctx, "alice", "10.0.0.1"); wait != 0 {
+		t.Errorf("alice is locked by failures of Alice: %s", wait)
+	}
+
+	g.Fail(ctx, "alice", "10.0.0.1")
+	if err := g.Succeed(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := g.Check(ctx, "Alice", "10.0.0.1"); wait == 0 {
+		t.Error("success of alice reset the counter of Alice")
+	}
+}
@@ -0,0 +1,72 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Message - письмо пользователю
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма (сброс пароля и т.п.).
+// Для продакшена подключается SMTP/API провайдер, для локального запуска — LogMailer и FileMailer
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer печатает письма в лог вместо отправки
+type LogMailer struct{}
+
+// NewLogMailer создаёт LogMailer
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Send печатает письмо в лог
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("📧 Mail to %s: %s\n%s\n", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer сохраняет каждое письмо в отдельный .eml файл в каталоге
+type FileMailer struct {
+	dir string
+	seq atomic.Int64
+}
+
+// NewFileMailer создаёт FileMailer, каталог создаётся при необходимости
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir}, nil
+}
+
+// Send записывает письмо в файл вида 20250101T120000-1.eml
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%d.eml", now.UTC().Format("20060102T150405"), m.seq.Add(1))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(b.String()), 0o600)
+}
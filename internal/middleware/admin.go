@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+// RequireAdmin пропускает только пользователей из списка администраторов (ADMIN_USERNAMES).
+// Имена сравниваются с учётом регистра, как и при входе
+func RequireAdmin(users storage.UserStore, admins []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool)
+	for _, name := range admins {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed[name] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := GetUserIDFromContext(r.Context())
+			if !ok {
+				respondError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			user, err := users.GetUserByID(r.Context(), userID)
+			if err != nil || !allowed[user.Username] {
+				respondError(w, http.StatusForbidden, "Admin access required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -5,43 +5,86 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
 	"github.com/Balyshev/notes-api/pkg/auth"
 )
 
 type contextKey string
 
-const UserContextKey contextKey = "user"
-
-// AuthMiddleware проверяет JWT токен
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Получаем токен из заголовка Authorization
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			respondError(w, http.StatusUnauthorized, "Missing authorization header")
-			return
-		}
-
-		// Формат: "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			respondError(w, http.StatusUnauthorized, "Invalid authorization header format")
-			return
-		}
-
-		tokenString := parts[1]
-
-		// Валидируем токен
-		claims, err := auth.ValidateToken(tokenString)
-		if err != nil {
-			respondError(w, http.StatusUnauthorized, "Invalid or expired token")
-			return
-		}
-
-		// Сохраняем user_id в контексте запроса
-		ctx := context.WithValue(r.Context(), UserContextKey, claims.UserID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+const (
+	UserContextKey     contextKey = "user"
+	SessionContextKey  contextKey = "session"
+	APITokenContextKey contextKey = "api_token"
+)
+
+// AuthMiddleware проверяет JWT токен и что его сессия не отозвана.
+// Вместо JWT можно передать personal access токен (префикс nat_)
+func AuthMiddleware(sessions storage.SessionStore, tokens storage.APITokenStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Получаем токен из заголовка Authorization
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				respondError(w, http.StatusUnauthorized, "Missing authorization header")
+				return
+			}
+
+			// Формат: "Bearer <token>"
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				respondError(w, http.StatusUnauthorized, "Invalid authorization header format")
+				return
+			}
+
+			tokenString := parts[1]
+
+			// Personal access токен: ищем по хешу, права проверяет RequireScope
+			if auth.IsAPIToken(tokenString) {
+				token, err := tokens.UseAPIToken(r.Context(), auth.HashToken(tokenString))
+				if err != nil {
+					if err == models.ErrAPITokenNotFound {
+						respondError(w, http.StatusUnauthorized, "Invalid or expired token")
+						return
+					}
+					respondError(w, http.StatusServiceUnavailable, "Failed to check token")
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), UserContextKey, token.UserID)
+				ctx = context.WithValue(ctx, APITokenContextKey, token)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// Валидируем токен
+			claims, err := auth.ValidateToken(tokenString)
+			if err != nil {
+				respondError(w, http.StatusUnauthorized, "Invalid or expired token")
+				return
+			}
+
+			// Токен должен принадлежать активной сессии (logout отзывает её)
+			if claims.SessionID <= 0 {
+				respondError(w, http.StatusUnauthorized, "Invalid or expired token")
+				return
+			}
+			active, err := sessions.IsSessionActive(r.Context(), claims.SessionID)
+			if err != nil {
+				respondError(w, http.StatusServiceUnavailable, "Failed to check session")
+				return
+			}
+			if !active {
+				respondError(w, http.StatusUnauthorized, "Session has been revoked")
+				return
+			}
+
+			// Сохраняем user_id и сессию в контексте запроса
+			ctx := context.WithValue(r.Context(), UserContextKey, claims.UserID)
+			ctx = context.WithValue(ctx, SessionContextKey, claims.SessionID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 // GetUserIDFromContext извлекает user_id из контекста
@@ -50,6 +93,12 @@ func GetUserIDFromContext(ctx context.Context) (int, bool) {
 	return userID, ok
 }
 
+// GetSessionIDFromContext извлекает id сессии из контекста
+func GetSessionIDFromContext(ctx context.Context) (int, bool) {
+	sessionID, ok := ctx.Value(SessionContextKey).(int)
+	return sessionID, ok
+}
+
 func respondError(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
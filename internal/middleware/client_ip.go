@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP возвращает адрес клиента без порта.
+// За прокси RemoteAddr заранее подменяет chi middleware.RealIP (TRUST_PROXY_HEADERS=true)
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
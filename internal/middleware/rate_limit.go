@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+// RateLimit ограничивает частоту запросов по token bucket из policy.
+// Ключ — id пользователя, если запрос уже прошёл AuthMiddleware, иначе IP клиента.
+// Ответ содержит заголовки RateLimit-Limit/Remaining/Reset и RateLimit-Policy,
+// при превышении — 429 с Retry-After. policy == nil — лимит отключён
+func RateLimit(limits storage.RateLimitStore, policy *models.RateLimitPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if policy == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := policy.Name + ":ip:" + ClientIP(r)
+			if userID, ok := GetUserIDFromContext(r.Context()); ok {
+				key = policy.Name + ":user:" + strconv.Itoa(userID)
+			}
+
+			bucket, allowed, err := limits.TakeRateLimitToken(r.Context(), key, policy, time.Now())
+			if err != nil {
+				// Недоступность хранилища лимитов не должна ронять API
+				fmt.Println("ERROR: Rate limit check failed:", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", policy.Limit, int(policy.Period.Seconds()), policy.Burst))
+			h.Set("RateLimit-Limit", strconv.Itoa(policy.Burst))
+			h.Set("RateLimit-Remaining", strconv.Itoa(bucket.Remaining()))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(bucket.ResetAfter(policy))))
+
+			if !allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(bucket.RetryAfter(policy))))
+				respondError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+)
+
+func TestRateLimit(t *testing.T) {
+	policy := &models.RateLimitPolicy{Name: "api", Limit: 2, Period: time.Minute, Burst: 2}
+	handler := RateLimit(storage.NewMemory(), policy)(ok)
+
+	request := func(addr string, userID int) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = addr
+		if userID != 0 {
+			r = r.WithContext(context.WithValue(r.Context(), UserContextKey, userID))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	tests := []struct {
+		name       string
+		addr       string
+		userID     int // 0 — анонимный запрос, лимит по IP
+		status     int
+		remaining  string
+		retryAfter string
+	}{
+		{"first ip request", "10.0.0.1:1000", 0, http.StatusNoContent, "1", ""},
+		{"same ip, other port", "10.0.0.1:2000", 0, http.StatusNoContent, "0", ""},
+		{"ip limit exceeded", "10.0.0.1:3000", 0, http.StatusTooManyRequests, "0", "30"},
+		{"other ip has own bucket", "10.0.0.2:1000", 0, http.StatusNoContent, "1", ""},
+		{"user has own bucket", "10.0.0.1:1000", 7, http.StatusNoContent, "1", ""},
+		{"user from other ip", "10.0.0.3:1000", 7, http.StatusNoContent, "0", ""},
+		{"user limit exceeded", "10.0.0.4:1000", 7, http.StatusTooManyRequests, "0", "30"},
+	}
+	for _, tt := range tests {
+		rec := request(tt.addr, tt.userID)
+		if rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		h := rec.Header()
+		if h.Get("RateLimit-Policy") != "2;w=60;burst=2" || h.Get("RateLimit-Limit") != "2" {
+			t.Errorf("%s: policy headers %q %q", tt.name, h.Get("RateLimit-Policy"), h.Get("RateLimit-Limit"))
+		}
+		if got := h.Get("RateLimit-Remaining"); got != tt.remaining {
+			t.Errorf("%s: RateLimit-Remaining = %q, want %q", tt.name, got, tt.remaining)
+		}
+		if got := h.Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("%s: Retry-After = %q, want %q", tt.name, got, tt.retryAfter)
+		}
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	handler := RateLimit(storage.NewMemory(), nil)(ok)
+	for range 100 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Code != http.StatusNoContent || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("nil policy: %d %v", rec.Code, rec.Header())
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// RequireScope пропускает personal access токен, только если у него есть право scope.
+// Вход по JWT (сессия пользователя) даёт все права
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token, ok := GetAPITokenFromContext(r.Context()); ok && !token.HasScope(scope) {
+				respondError(w, http.StatusForbidden, "Token does not have the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession пропускает только вход по JWT: personal access токены
+// не могут управлять сессиями и выпускать новые токены
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := GetAPITokenFromContext(r.Context()); ok {
+			respondError(w, http.StatusForbidden, "This endpoint requires a login session, not an API token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetAPITokenFromContext извлекает personal access токен, если запрос авторизован им
+func GetAPITokenFromContext(ctx context.Context) (*models.APIToken, bool) {
+	token, ok := ctx.Value(APITokenContextKey).(*models.APIToken)
+	return token, ok
+}
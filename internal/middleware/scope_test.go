@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/internal/storage"
+	"github.com/Balyshev/notes-api/pkg/auth"
+)
+
+// ok - конечный обработчик, до которого доходит пропущенный запрос
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+})
+
+func TestRequireScope(t *testing.T) {
+	readOnly := &models.APIToken{Scopes: []string{models.ScopeNotesRead}}
+	all := &models.APIToken{Scopes: models.Scopes}
+
+	tests := []struct {
+		name   string
+		token  *models.APIToken // nil — вход по JWT
+		scope  string
+		status int
+	}{
+		{"session has every scope", nil, models.ScopeNotesDelete, http.StatusNoContent},
+		{"token with scope", readOnly, models.ScopeNotesRead, http.StatusNoContent},
+		{"token without write", readOnly, models.ScopeNotesWrite, http.StatusForbidden},
+		{"token without delete", readOnly, models.ScopeNotesDelete, http.StatusForbidden},
+		{"token with all scopes", all, models.ScopeNotesDelete, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.token != nil {
+			r = r.WithContext(context.WithValue(r.Context(), APITokenContextKey, tt.token))
+		}
+		rec := httptest.NewRecorder()
+		RequireScope(tt.scope)(ok).ServeHTTP(rec, r)
+		if rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d", tt.name, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	RequireSession(ok).ServeHTTP(rec, r)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("session: %d", rec.Code)
+	}
+
+	all := &models.APIToken{Scopes: models.Scopes}
+	rec = httptest.NewRecorder()
+	RequireSession(ok).ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), APITokenContextKey, all)))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("api token: %d", rec.Code)
+	}
+}
+
+func TestAuthMiddlewareAPIToken(t *testing.T) {
+	store := storage.NewMemory()
+	user, err := store.CreateUser(t.Context(), "alice", "", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid, hash, _ := auth.GenerateAPIToken()
+	store.CreateAPIToken(t.Context(), user.ID, "valid", hash, []string{models.ScopeNotesRead}, nil)
+	expired, hash, _ := auth.GenerateAPIToken()
+	past := time.Now().Add(-time.Minute)
+	store.CreateAPIToken(t.Context(), user.ID, "expired", hash, []string{models.ScopeNotesRead}, &past)
+	unknown, _, _ := auth.GenerateAPIToken()
+
+	var gotUser int
+	var gotToken *models.APIToken
+	handler := AuthMiddleware(store, store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _ = GetUserIDFromContext(r.Context())
+		gotToken, _ = GetAPITokenFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"valid token", "Bearer " + valid, http.StatusNoContent},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized},
+		{"unknown token", "Bearer " + unknown, http.StatusUnauthorized},
+		{"no header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Token " + valid, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code != tt.status {
+			t.Errorf("%s: %d, want %d", tt.name, rec.Code, tt.status)
+		}
+	}
+
+	if gotUser != user.ID || gotToken == nil || gotToken.Name != "valid" {
+		t.Errorf("context: user %d, token %+v", gotUser, gotToken)
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Права personal access токенов
+const (
+	ScopeNotesRead   = "notes:read"
+	ScopeNotesWrite  = "notes:write"
+	ScopeNotesDelete = "notes:delete"
+)
+
+// Scopes - все поддерживаемые права
+var Scopes = []string{ScopeNotesRead, ScopeNotesWrite, ScopeNotesDelete}
+
+// APIToken - personal access токен для скриптов и интеграций (сам токен не хранится, только хеш)
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope проверяет, что у токена есть право scope
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired сообщает, истёк ли срок действия токена
+func (t *APIToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// CreateAPITokenRequest - данные для создания токена
+type CreateAPITokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil — бессрочный
+}
+
+// CreateAPITokenResponse - созданный токен; Token показывается только один раз
+type CreateAPITokenResponse struct {
+	Token string `json:"token"`
+	*APIToken
+}
+
+// Validate проверяет CreateAPITokenRequest и убирает дубликаты прав
+func (r *CreateAPITokenRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return ErrTokenNameRequired
+	}
+	if utf8.RuneCountInString(r.Name) > 100 {
+		return ErrTokenNameTooLong
+	}
+
+	if len(r.Scopes) == 0 {
+		return ErrScopesRequired
+	}
+	seen := make(map[string]bool)
+	scopes := []string{}
+	for _, scope := range r.Scopes {
+		if !validScope(scope) {
+			return ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	r.Scopes = scopes
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return ErrTokenExpiryPast
+	}
+	return nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
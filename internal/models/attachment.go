@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Статусы обработки изображений (миниатюра, размеры, удаление GPS из EXIF)
+const (
+	ImageStatusPending    = "pending"
+	ImageStatusProcessing = "processing"
+	ImageStatusReady      = "ready"
+	ImageStatusFailed     = "failed"
+)
+
+// Attachment - файл, прикреплённый к заметке. Содержимое лежит в blob хранилище под StorageKey
+type Attachment struct {
+	ID          int       `json:"id"`
+	NoteID      int       `json:"note_id"`
+	UserID      int       `json:"user_id"` // владелец заметки, место учитывается в его квоте
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Только для изображений
+	ImageStatus          string `json:"image_status,omitempty"`
+	Width                int    `json:"width,omitempty"`
+	Height               int    `json:"height,omitempty"`
+	ThumbnailKey         string `json:"-"`
+	ThumbnailContentType string `json:"-"`
+	ThumbnailSize        int64  `json:"-"`
+}
+
+// HasThumbnail сообщает, готова ли миниатюра
+func (a *Attachment) HasThumbnail() bool {
+	return a.ImageStatus == ImageStatusReady && a.ThumbnailKey != ""
+}
+
+// ImageResult - результат обработки изображения
+type ImageResult struct {
+	Width                int
+	Height               int
+	ThumbnailKey         string
+	ThumbnailContentType string
+	ThumbnailSize        int64
+	StorageKey           string // ключ оригинала без GPS (пусто — не менялся); прежний blob уходит в очередь на удаление
+	Size                 int64  // новый размер оригинала, если из него удалён GPS (0 — не менялся)
+	SHA256               string // новый sha256 оригинала (пусто — не менялся)
+}
+
+// IsThumbnailable сообщает, можно ли построить миниатюру для файла такого типа
+func IsThumbnailable(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// AttachmentUsage - занятое вложениями место и квота пользователя
+type AttachmentUsage struct {
+	Used  int64 `json:"used"`
+	Quota int64 `json:"quota"` // 0 — без ограничения
+	Count int   `json:"count"`
+}
+
+// OrphanedBlob - blob, метаданные которого удалены (вместе с заметкой или вложением) и который пора стереть
+type OrphanedBlob struct {
+	ID         int
+	StorageKey string
+}
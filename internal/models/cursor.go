@@ -0,0 +1,142 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// NoteCursor - позиция в списке заметок для keyset пагинации:
+// значения полей сортировки и id заметки. Клиенту отдаётся как непрозрачная строка (Encode)
+type NoteCursor struct {
+	Sort   string   `json:"s"` // SortString сортировки, для которой выдан курсор
+	Values []string `json:"v"` // значения полей сортировки
+	ID     int      `json:"i"`
+	Before bool     `json:"b,omitempty"` // true — страница перед позицией (prev)
+
+	keys  []SortKey
+	typed []interface{}
+}
+
+// NotePage - страница заметок с курсорами соседних страниц
+type NotePage struct {
+	Data       []*Note `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+	Total      *int    `json:"total,omitempty"`
+}
+
+// Encode превращает курсор в base64url строку
+func (c *NoteCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeNoteCursor разбирает строку курсора для сортировки keys
+func DecodeNoteCursor(s string, keys []SortKey) (*NoteCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &NoteCursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.ID < 1 || c.Sort != SortString(keys) || len(c.Values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+
+	c.keys = keys
+	c.typed = make([]interface{}, len(keys))
+	for i, key := range keys {
+		if key.Field == SortTitle {
+			c.typed[i] = c.Values[i]
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, c.Values[i])
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		c.typed[i] = t
+	}
+	return c, nil
+}
+
+// CursorFor строит курсор на заметку для сортировки keys
+func CursorFor(note *Note, keys []SortKey, before bool) *NoteCursor {
+	c := &NoteCursor{Sort: SortString(keys), ID: note.ID, Before: before, keys: keys}
+	for _, key := range keys {
+		value := SortValue(note, key.Field)
+		c.typed = append(c.typed, value)
+		if t, ok := value.(time.Time); ok {
+			c.Values = append(c.Values, t.Format(time.RFC3339Nano))
+		} else {
+			c.Values = append(c.Values, value.(string))
+		}
+	}
+	return c
+}
+
+// TypedValues возвращает значения полей сортировки (time.Time или string) для SQL параметров
+func (c *NoteCursor) TypedValues() []interface{} {
+	return c.typed
+}
+
+// After проверяет, идёт ли заметка после курсора в порядке сортировки (id — последний ключ)
+func (c *NoteCursor) After(note *Note) bool {
+	return CompareNotes(note, c.typed, c.ID, c.keys) > 0
+}
+
+// Precedes проверяет, идёт ли заметка перед курсором
+func (c *NoteCursor) Precedes(note *Note) bool {
+	return CompareNotes(note, c.typed, c.ID, c.keys) < 0
+}
+
+// CompareNotes сравнивает заметку с позицией (values, id) в порядке сортировки keys:
+// -1 — заметка раньше, 1 — позже, 0 — та же позиция
+func CompareNotes(note *Note, values []interface{}, id int, keys []SortKey) int {
+	for i, key := range keys {
+		cmp := compareValues(SortValue(note, key.Field), values[i])
+		if cmp != 0 {
+			if key.Desc {
+				return -cmp
+			}
+			return cmp
+		}
+	}
+
+	cmp := 0
+	switch {
+	case note.ID < id:
+		cmp = -1
+	case note.ID > id:
+		cmp = 1
+	}
+	if len(keys) > 0 && keys[len(keys)-1].Desc {
+		return -cmp
+	}
+	return cmp
+}
+
+func compareValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case time.Time:
+		bv := b.(time.Time)
+		switch {
+		case av.Before(bv):
+			return -1
+		case av.After(bv):
+			return 1
+		}
+	case string:
+		bv := b.(string)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+	}
+	return 0
+}
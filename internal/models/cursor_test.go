@@ -0,0 +1,58 @@
+package models
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestNoteCursor(t *testing.T) {
+	keys := []SortKey{{Field: SortTitle}, {Field: SortUpdatedAt, Desc: true}}
+	note := &Note{ID: 7, Title: "b", UpdatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123, time.UTC)}
+	cursor := CursorFor(note, keys, true)
+
+	got, err := DecodeNoteCursor(cursor.Encode(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != 7 || !got.Before || got.Sort != "title:asc,updated_at:desc" {
+		t.Errorf("decoded cursor: %+v", got)
+	}
+
+	tests := []struct {
+		name  string
+		note  Note
+		after bool
+	}{
+		{"next title", Note{ID: 1, Title: "c"}, true},
+		{"previous title", Note{ID: 9, Title: "a"}, false},
+		{"same title, older", Note{ID: 1, Title: "b", UpdatedAt: note.UpdatedAt.Add(-time.Second)}, true},
+		{"same title, newer", Note{ID: 9, Title: "b", UpdatedAt: note.UpdatedAt.Add(time.Second)}, false},
+		// При равенстве всех полей решает id в направлении последнего ключа
+		{"same values, smaller id", Note{ID: 6, Title: "b", UpdatedAt: note.UpdatedAt}, true},
+		{"same values, bigger id", Note{ID: 8, Title: "b", UpdatedAt: note.UpdatedAt}, false},
+	}
+	for _, tt := range tests {
+		if after := got.After(&tt.note); after != tt.after {
+			t.Errorf("%s: After %v", tt.name, after)
+		}
+		if precedes := got.Precedes(&tt.note); precedes == tt.after {
+			t.Errorf("%s: Precedes %v", tt.name, precedes)
+		}
+	}
+
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, token := range []string{
+		"",
+		"not base64!",
+		encode("not json"),
+		CursorFor(note, DefaultNoteSort, false).Encode(), // другая сортировка
+		encode(`{"s":"title:asc,updated_at:desc","v":["b","2024-05-01T12:00:00Z"],"i":0}`),
+		encode(`{"s":"title:asc,updated_at:desc","v":["b"],"i":1}`),
+		encode(`{"s":"title:asc,updated_at:desc","v":["b","yesterday"],"i":1}`),
+	} {
+		if _, err := DecodeNoteCursor(token, keys); err != ErrInvalidCursor {
+			t.Errorf("DecodeNoteCursor(%q): %v", token, err)
+		}
+	}
+}
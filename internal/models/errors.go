@@ -15,9 +15,119 @@ var (
 )
 
 var (
-	ErrTitleRequired   = errors.New("title is required")
-	ErrTitleTooLong    = errors.New("title must be at most 255 characters")
-	ErrContentRequired = errors.New("content is required")
-	ErrNoteNotFound    = errors.New("note not found")
-	ErrForbidden       = errors.New("you don't have permission to access this note")
+	ErrTitleRequired        = errors.New("title is required")
+	ErrTitleTooLong         = errors.New("title must be at most 255 characters")
+	ErrContentRequired      = errors.New("content is required")
+	ErrContentTooLong       = errors.New("content must be at most 1 MB")
+	ErrNULCharacter         = errors.New("title and content must not contain NUL characters")
+	ErrInvalidContentFormat = errors.New("content_format must be plain or markdown")
+	ErrNoteNotFound         = errors.New("note not found")
+	ErrForbidden            = errors.New("you don't have permission to access this note")
+)
+
+var (
+	ErrSearchQueryRequired = errors.New("search query is required")
+	ErrSearchQueryTooLong  = errors.New("search query must be at most 256 characters")
+)
+
+var (
+	ErrTagTooLong      = errors.New("tag must be at most 50 characters")
+	ErrTagNULCharacter = errors.New("tag must not contain NUL characters")
+	ErrTooManyTags     = errors.New("note can have at most 20 tags")
+	ErrInvalidTagMode  = errors.New("tag_mode must be 'and' or 'or'")
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+var (
+	ErrVersionMismatch = errors.New("note has been modified by another request")
+)
+
+var (
+	ErrPatchTooLarge = errors.New("patch body is too large")
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+var (
+	ErrInvalidSort       = errors.New("sort must be a comma-separated list of created_at, updated_at, title with optional :asc/:desc")
+	ErrInvalidDateFilter = errors.New("date filters must be RFC 3339 timestamps or YYYY-MM-DD dates")
+	ErrInvalidLength     = errors.New("min_length and max_length must be non-negative integers, min_length <= max_length")
+)
+
+var (
+	ErrRefreshTokenRequired = errors.New("refresh_token is required")
+	ErrInvalidRefreshToken  = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused   = errors.New("refresh token has already been used, session revoked")
+	ErrSessionNotFound      = errors.New("session not found")
+)
+
+var (
+	ErrTokenNameRequired = errors.New("token name is required")
+	ErrTokenNameTooLong  = errors.New("token name must be at most 100 characters")
+	ErrScopesRequired    = errors.New("at least one scope is required")
+	ErrInvalidScope      = errors.New("scopes must be notes:read, notes:write or notes:delete")
+	ErrTokenExpiryPast   = errors.New("expires_at must be in the future")
+	ErrAPITokenNotFound  = errors.New("api token not found")
+)
+
+var (
+	ErrInvalidEmail         = errors.New("email is not a valid address")
+	ErrCurrentPasswordWrong = errors.New("current password is incorrect")
+	ErrResetTokenRequired   = errors.New("token is required")
+	ErrInvalidResetToken    = errors.New("invalid or expired password reset token")
+	ErrSamePassword         = errors.New("new password must differ from the current one")
+)
+
+var (
+	ErrTwoFactorCodeRequired   = errors.New("code or recovery_code is required")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorCodeReused     = errors.New("two-factor code has already been used")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp       = errors.New("call 2fa/setup before confirming")
+	ErrChallengeTokenRequired  = errors.New("challenge_token is required")
+	ErrTwoFactorLocked         = errors.New("too many invalid two-factor codes, try again later")
+	ErrChallengeRevoked        = errors.New("too many invalid two-factor codes, log in again")
+)
+
+var (
+	ErrInvalidPermission = errors.New("permission must be viewer or editor")
+	ErrShareWithSelf     = errors.New("you cannot share a note with yourself")
+	ErrShareNotFound     = errors.New("share not found")
+)
+
+var (
+	ErrShareLinkNotFound        = errors.New("share link not found or expired")
+	ErrShareLinkPasswordInvalid = errors.New("share link password is missing or incorrect")
+	ErrShareLinkLocked          = errors.New("too many wrong passwords for this link, try again later")
+)
+
+var (
+	ErrNotebookNameRequired = errors.New("notebook name is required")
+	ErrNotebookNameTooLong  = errors.New("notebook name must be at most 100 characters")
+	ErrNotebookNotFound     = errors.New("notebook not found")
+	ErrNotebookCycle        = errors.New("notebook cannot be moved into itself or its descendant")
+	ErrNotebookNotEmpty     = errors.New("notebook is not empty, pass cascade=true to delete it with its contents")
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentTooLarge = errors.New("attachment exceeds the maximum file size")
+	ErrQuotaExceeded      = errors.New("attachment storage quota exceeded")
+	ErrFileRequired       = errors.New("multipart field \"file\" is required")
+)
+
+var (
+	ErrImportNotFound          = errors.New("import not found")
+	ErrInvalidArchive          = errors.New("file must be a zip archive")
+	ErrEmptyArchive            = errors.New("archive contains no markdown (.md) files")
+	ErrArchiveTooLarge         = errors.New("archive exceeds the maximum import size")
+	ErrArchiveTooManyFiles     = errors.New("archive contains more than 10000 markdown files")
+	ErrArchiveUnpackedTooLarge = errors.New("markdown files in the archive exceed 256 MB unpacked")
+	ErrImportAttemptsExceeded  = errors.New("import failed repeatedly and was stopped")
 )
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Статусы импорта архива: pending -> processing -> completed / failed
+const (
+	ImportStatusPending    = "pending"
+	ImportStatusProcessing = "processing"
+	ImportStatusCompleted  = "completed"
+	ImportStatusFailed     = "failed"
+)
+
+// Import - импорт zip архива markdown заметок, выполняемый фоновой задачей. Архив лежит в blob хранилище
+// под StorageKey, пока импорт не завершён
+type Import struct {
+	ID               int               `json:"id"`
+	UserID           int               `json:"user_id"`
+	Filename         string            `json:"filename"`
+	Status           string            `json:"status"`
+	TotalFiles       int               `json:"total_files"`     // markdown файлов в архиве
+	ProcessedFiles   int               `json:"processed_files"` // импортировано + с ошибкой
+	ImportedNotes    int               `json:"imported_notes"`
+	FailedFiles      int               `json:"failed_files"`
+	CreatedNotebooks int               `json:"created_notebooks"`
+	Attempts         int               `json:"-"`                // забран в обработку подряд без прогресса
+	Errors           []ImportFileError `json:"errors,omitempty"` // отчёт по файлам, только в GET одного импорта
+	Error            string            `json:"error,omitempty"`  // почему импорт прерван (status failed)
+	StorageKey       string            `json:"-"`
+	CreatedAt        time.Time         `json:"created_at"`
+	FinishedAt       *time.Time        `json:"finished_at"`
+}
+
+// Ограничения архива импорта: файлы по отдельности не больше 1 MB, но маленький zip
+// может распаковываться в огромный объём, поэтому ограничены и их число, и суммарный размер
+const (
+	ImportMaxFiles       = 10000
+	ImportMaxUnpackedMB  = 256
+	importMaxUnpackedLen = ImportMaxUnpackedMB << 20
+)
+
+// ValidateImportArchive проверяет число markdown файлов архива и их размер после распаковки
+func ValidateImportArchive(files int, unpacked uint64) error {
+	if files > ImportMaxFiles {
+		return ErrArchiveTooManyFiles
+	}
+	if unpacked > importMaxUnpackedLen {
+		return ErrArchiveUnpackedTooLarge
+	}
+	return nil
+}
+
+// ImportFileError - файл архива, который не удалось импортировать
+type ImportFileError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// ImportedNote - заметка из архива, прошедшая проверки CreateNoteRequest
+type ImportedNote struct {
+	Folders   []string // блокноты от верхнего уровня, пусто — вне блокнотов
+	Title     string
+	Content   string
+	Tags      []string
+	CreatedAt *time.Time // nil — время импорта
+	UpdatedAt *time.Time // nil — CreatedAt
+}
+
+// ImportBatch - очередная порция файлов архива. Записывается одной транзакцией вместе с прогрессом,
+// поэтому прерванный импорт продолжается с первого незаписанного файла
+type ImportBatch struct {
+	Offset int // номер первого файла порции, должен совпасть с ProcessedFiles
+	Files  int // файлов в порции: len(Notes) + len(Errors)
+	Notes  []*ImportedNote
+	Errors []ImportFileError
+}
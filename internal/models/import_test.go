@@ -0,0 +1,22 @@
+package models
+
+import "testing"
+
+func TestValidateImportArchive(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    int
+		unpacked uint64
+		err      error
+	}{
+		{"empty", 0, 0, nil},
+		{"at the limits", ImportMaxFiles, ImportMaxUnpackedMB << 20, nil},
+		{"too many files", ImportMaxFiles + 1, 0, ErrArchiveTooManyFiles},
+		{"unpacked too large", 1, ImportMaxUnpackedMB<<20 + 1, ErrArchiveUnpackedTooLarge},
+	}
+	for _, tt := range tests {
+		if err := ValidateImportArchive(tt.files, tt.unpacked); err != tt.err {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
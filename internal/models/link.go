@@ -0,0 +1,29 @@
+package models
+
+// NoteLink - ссылка из одной заметки пользователя на другую
+type NoteLink struct {
+	SourceID    int     `json:"source_id"`
+	SourceTitle string  `json:"source_title"`
+	Link        string  `json:"link"`         // как ссылка записана в тексте: [[Заголовок]] или note:42
+	TargetID    *int    `json:"target_id"`    // nil — ссылка не разрешена: заметки нет или она в корзине
+	TargetTitle *string `json:"target_title"` // заголовок найденной заметки
+}
+
+// LinkGraph - граф ссылок пользователя: все его заметки и разрешённые ссылки между ними
+type LinkGraph struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+}
+
+// GraphNode - заметка в графе ссылок
+type GraphNode struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	NotebookID *int   `json:"notebook_id"`
+}
+
+// GraphEdge - ссылка из заметки Source на заметку Target (несколько ссылок между парой заметок — одно ребро)
+type GraphEdge struct {
+	Source int `json:"source"`
+	Target int `json:"target"`
+}
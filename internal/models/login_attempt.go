@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// LoginAttempts - неудачные попытки входа по ключу (user:<имя> или ip:<адрес>)
+type LoginAttempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// LockoutEvent - запись о временной блокировке входа для просмотра администратором
+type LockoutEvent struct {
+	ID          int       `json:"id"`
+	Key         string    `json:"key"`
+	Username    string    `json:"username"`
+	IP          string    `json:"ip"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+	CreatedAt   time.Time `json:"created_at"`
+}
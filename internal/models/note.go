@@ -1,27 +1,95 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 //Данные заметки
 type Note struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	NotebookID *int       `json:"notebook_id"` // nil — заметка вне блокнотов
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	Format     string     `json:"content_format"` // plain или markdown
+	Tags       []string   `json:"tags"`
+	Version    int        `json:"version"` // растёт при каждом обновлении, из него строится ETag
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"` // заметка в корзине
+}
+
+// Форматы содержимого заметки
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+)
+
+// MaxContentLength - максимальный размер content в байтах. Markdown рендерится в HTML
+// и кешируется в ревизиях, поэтому размер текста ограничен
+const MaxContentLength = 1 << 20 // 1 MB
+
+// hasNUL проверяет строку на символ NUL: Postgres не хранит его в текстовых полях
+func hasNUL(s string) bool {
+	return strings.IndexByte(s, 0) >= 0
+}
+
+// ValidFormat проверяет формат содержимого заметки
+func ValidFormat(format string) bool {
+	return format == FormatPlain || format == FormatMarkdown
 }
 
 //createNoteRequest - данные для создания заметки
 type CreateNoteRequest struct {
-	Title   string `json:"title"`
-	Content string `jsson:"content"`
+	Title      string   `json:"title"`
+	Content    string   `jsson:"content"`
+	Format     string   `json:"content_format"` // пусто — plain
+	Tags       []string `json:"tags"`
+	NotebookID *int     `json:"notebook_id"` // nil — вне блокнотов
 }
 
 //updateNoteRequest - данные для обновления заметки
+//Tags == nil (поле не передано) — теги не меняются, [] — теги удаляются. Пустой Format — формат не меняется
 type UdateNoteRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Format  string   `json:"content_format"`
+	Tags    []string `json:"tags"`
+}
+
+//NotePatch - частичное обновление заметки (PATCH), nil — поле не меняется
+type NotePatch struct {
+	Title   *string
+	Content *string
+	Format  *string
+	Tags    *[]string
+}
+
+//NoteListOptions - параметры выборки заметок пользователя
+type NoteListOptions struct {
+	Limit  int
+	Offset int
+	Sort   []SortKey // поля сортировки, при равенстве всех — по id
+
+	Tags    []string // фильтр по тегам
+	TagMode string   // and — все теги, or — любой из тегов
+
+	CreatedFrom *time.Time // границы включительно
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	TitlePrefix string // без учёта регистра
+	MinLength   int    // длина content в символах, 0 — без ограничения
+	MaxLength   int
+
+	NotebookID *int // только заметки блокнота
+	Recursive  bool // вместе с NotebookID — и всех вложенных блокнотов
+	Unfiled    bool // только заметки вне блокнотов
+
+	// Cursor включает keyset пагинацию вместо Offset: заметки после курсора
+	// (или до него, если Cursor.Before) в порядке Sort
+	Cursor *NoteCursor
 }
 
 //Validate проверяет createNoteRequest
@@ -35,6 +103,23 @@ func (c *CreateNoteRequest) Validate() error {
 	if c.Content == "" {
 		return ErrContentRequired
 	}
+	if len(c.Content) > MaxContentLength {
+		return ErrContentTooLong
+	}
+	if hasNUL(c.Title) || hasNUL(c.Content) {
+		return ErrNULCharacter
+	}
+	if c.Format == "" {
+		c.Format = FormatPlain
+	}
+	if !ValidFormat(c.Format) {
+		return ErrInvalidContentFormat
+	}
+	tags, err := NormalizeTags(c.Tags)
+	if err != nil {
+		return err
+	}
+	c.Tags = tags
 	return nil
 }
 
@@ -48,5 +133,64 @@ func (r *UdateNoteRequest) Validate() error {
 	if r.Content == "" {
 		return ErrContentRequired
 	}
+	if len(r.Content) > MaxContentLength {
+		return ErrContentTooLong
+	}
+	if hasNUL(r.Title) || hasNUL(r.Content) {
+		return ErrNULCharacter
+	}
+	if r.Format != "" && !ValidFormat(r.Format) {
+		return ErrInvalidContentFormat
+	}
+	tags, err := NormalizeTags(r.Tags)
+	if err != nil {
+		return err
+	}
+	r.Tags = tags
+	return nil
+}
+
+//Empty сообщает, что патч не меняет ни одного поля (например, JSON Patch из одних test)
+func (p *NotePatch) Empty() bool {
+	return p.Title == nil && p.Content == nil && p.Format == nil && p.Tags == nil
+}
+
+//Validate проверяет NotePatch теми же правилами, что и полное обновление
+func (p *NotePatch) Validate() error {
+	if p.Title != nil {
+		if *p.Title == "" {
+			return ErrTitleRequired
+		}
+		if len(*p.Title) > 255 {
+			return ErrTitleTooLong
+		}
+		if hasNUL(*p.Title) {
+			return ErrNULCharacter
+		}
+	}
+	if p.Content != nil {
+		if *p.Content == "" {
+			return ErrContentRequired
+		}
+		if len(*p.Content) > MaxContentLength {
+			return ErrContentTooLong
+		}
+		if hasNUL(*p.Content) {
+			return ErrNULCharacter
+		}
+	}
+	if p.Format != nil && !ValidFormat(*p.Format) {
+		return ErrInvalidContentFormat
+	}
+	if p.Tags != nil {
+		tags, err := NormalizeTags(*p.Tags)
+		if err != nil {
+			return err
+		}
+		if tags == nil {
+			tags = []string{}
+		}
+		p.Tags = &tags
+	}
 	return nil
 }
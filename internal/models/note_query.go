@@ -0,0 +1,146 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Поля заметки, по которым разрешена сортировка (whitelist для SQL)
+const (
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortTitle     = "title"
+)
+
+// SortKey - одно поле сортировки списка заметок
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// DefaultNoteSort - сортировка по умолчанию: новые заметки первыми
+var DefaultNoteSort = []SortKey{{Field: SortCreatedAt, Desc: true}}
+
+// ParseNoteSort разбирает параметр sort:
+//   - "asc" / "desc" — по created_at (старый формат)
+//   - "updated_at:desc,title" или "-updated_at,title" — несколько полей по порядку
+func ParseNoteSort(value string) ([]SortKey, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "":
+		return DefaultNoteSort, nil
+	case "asc":
+		return []SortKey{{Field: SortCreatedAt}}, nil
+	case "desc":
+		return DefaultNoteSort, nil
+	}
+
+	var keys []SortKey
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		key := SortKey{}
+
+		if strings.HasPrefix(part, "-") {
+			key.Desc = true
+			part = part[1:]
+		} else if field, dir, ok := strings.Cut(part, ":"); ok {
+			switch dir {
+			case "asc":
+			case "desc":
+				key.Desc = true
+			default:
+				return nil, ErrInvalidSort
+			}
+			part = field
+		}
+
+		if part != SortCreatedAt && part != SortUpdatedAt && part != SortTitle {
+			return nil, ErrInvalidSort
+		}
+		if seen[part] {
+			return nil, ErrInvalidSort
+		}
+		seen[part] = true
+
+		key.Field = part
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// SortString возвращает каноническую запись сортировки ("created_at:desc,title:asc")
+func SortString(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		dir := "asc"
+		if key.Desc {
+			dir = "desc"
+		}
+		parts[i] = key.Field + ":" + dir
+	}
+	return strings.Join(parts, ",")
+}
+
+// SortValue возвращает значение поля сортировки заметки (time.Time или string)
+func SortValue(note *Note, field string) interface{} {
+	switch field {
+	case SortUpdatedAt:
+		return note.UpdatedAt
+	case SortTitle:
+		return note.Title
+	default:
+		return note.CreatedAt
+	}
+}
+
+// ParseDateBound разбирает границу фильтра по дате: RFC 3339 или YYYY-MM-DD.
+// Для верхней границы (end) дата без времени означает конец этого дня
+func ParseDateBound(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, ErrInvalidDateFilter
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Microsecond)
+	}
+	return &t, nil
+}
+
+// Matches проверяет заметку по фильтрам NoteListOptions (кроме тегов и пагинации).
+// Используется in-memory хранилищем, в Postgres те же условия строятся в SQL
+func (o *NoteListOptions) Matches(note *Note) bool {
+	if o.CreatedFrom != nil && note.CreatedAt.Before(*o.CreatedFrom) {
+		return false
+	}
+	if o.CreatedTo != nil && note.CreatedAt.After(*o.CreatedTo) {
+		return false
+	}
+	if o.UpdatedFrom != nil && note.UpdatedAt.Before(*o.UpdatedFrom) {
+		return false
+	}
+	if o.UpdatedTo != nil && note.UpdatedAt.After(*o.UpdatedTo) {
+		return false
+	}
+	if o.TitlePrefix != "" && !strings.HasPrefix(strings.ToLower(note.Title), strings.ToLower(o.TitlePrefix)) {
+		return false
+	}
+	length := utf8.RuneCountInString(note.Content)
+	if o.MinLength > 0 && length < o.MinLength {
+		return false
+	}
+	if o.MaxLength > 0 && length > o.MaxLength {
+		return false
+	}
+	return true
+}
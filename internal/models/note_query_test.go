@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNoteSort(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		err   error
+	}{
+		{"", "created_at:desc", nil},
+		{"desc", "created_at:desc", nil},
+		{"asc", "created_at:asc", nil},
+		{"title", "title:asc", nil},
+		{"updated_at:desc,title", "updated_at:desc,title:asc", nil},
+		{"-updated_at, title:desc", "updated_at:desc,title:desc", nil},
+		{"content", "", ErrInvalidSort},
+		{"title:up", "", ErrInvalidSort},
+		{"title,-title", "", ErrInvalidSort},
+		{"title,", "", ErrInvalidSort},
+		{"id; DROP TABLE notes", "", ErrInvalidSort},
+	}
+	for _, tt := range tests {
+		keys, err := ParseNoteSort(tt.value)
+		if err != tt.err {
+			t.Errorf("%q: error %v, want %v", tt.value, err, tt.err)
+			continue
+		}
+		if got := SortString(keys); err == nil && got != tt.want {
+			t.Errorf("%q: %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseDateBound(t *testing.T) {
+	tests := []struct {
+		value string
+		end   bool
+		want  string
+		err   error
+	}{
+		{"2024-05-01", false, "2024-05-01T00:00:00Z", nil},
+		{"2024-05-01", true, "2024-05-01T23:59:59.999999Z", nil},
+		{"2024-05-01T10:00:00+03:00", true, "2024-05-01T07:00:00Z", nil},
+		{"01.05.2024", false, "", ErrInvalidDateFilter},
+	}
+	for _, tt := range tests {
+		got, err := ParseDateBound(tt.value, tt.end)
+		if err != tt.err {
+			t.Errorf("%q: error %v, want %v", tt.value, err, tt.err)
+			continue
+		}
+		if err == nil && got.Format(time.RFC3339Nano) != tt.want {
+			t.Errorf("%q end=%v: %s, want %s", tt.value, tt.end, got.Format(time.RFC3339Nano), tt.want)
+		}
+	}
+	if got, err := ParseDateBound("", false); got != nil || err != nil {
+		t.Errorf("empty bound: %v, %v", got, err)
+	}
+}
+
+func TestNoteListOptionsMatches(t *testing.T) {
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC)
+		return &t
+	}
+	note := &Note{Title: "Go notes", Content: "привет", CreatedAt: *day(10), UpdatedAt: *day(20)}
+
+	tests := []struct {
+		name string
+		opts NoteListOptions
+		want bool
+	}{
+		{"no filters", NoteListOptions{}, true},
+		{"created range", NoteListOptions{CreatedFrom: day(10), CreatedTo: day(10)}, true},
+		{"created before range", NoteListOptions{CreatedFrom: day(11)}, false},
+		{"updated after range", NoteListOptions{UpdatedTo: day(19)}, false},
+		{"title prefix ignores case", NoteListOptions{TitlePrefix: "go N"}, true},
+		{"other title prefix", NoteListOptions{TitlePrefix: "notes"}, false},
+		{"length in runes", NoteListOptions{MinLength: 6, MaxLength: 6}, true},
+		{"too short", NoteListOptions{MinLength: 7}, false},
+		{"too long", NoteListOptions{MaxLength: 5}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.Matches(note); got != tt.want {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Notebook - блокнот (папка) для заметок; блокноты вкладываются друг в друга через ParentID
+type Notebook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	ParentID  *int      `json:"parent_id"` // nil — блокнот верхнего уровня
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateNotebookRequest - данные для создания блокнота
+type CreateNotebookRequest struct {
+	Name     string `json:"name"`
+	ParentID *int   `json:"parent_id"`
+}
+
+// RenameNotebookRequest - новое имя блокнота
+type RenameNotebookRequest struct {
+	Name string `json:"name"`
+}
+
+// MoveNotebookRequest - перенос блокнота вместе со всем содержимым; ParentID nil — на верхний уровень
+type MoveNotebookRequest struct {
+	ParentID *int `json:"parent_id"`
+}
+
+// MoveNoteRequest - перенос заметки; NotebookID nil — вынуть из блокнота
+type MoveNoteRequest struct {
+	NotebookID *int `json:"notebook_id"`
+}
+
+// DeleteNotebookResponse - результат удаления блокнота
+type DeleteNotebookResponse struct {
+	DeletedNotebooks int `json:"deleted_notebooks"`
+	TrashedNotes     int `json:"trashed_notes"` // заметки из удалённых блокнотов перенесены в корзину
+}
+
+// Validate проверяет CreateNotebookRequest
+func (r *CreateNotebookRequest) Validate() error {
+	name, err := validNotebookName(r.Name)
+	r.Name = name
+	return err
+}
+
+// Validate проверяет RenameNotebookRequest
+func (r *RenameNotebookRequest) Validate() error {
+	name, err := validNotebookName(r.Name)
+	r.Name = name
+	return err
+}
+
+func validNotebookName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return name, ErrNotebookNameRequired
+	}
+	if utf8.RuneCountInString(name) > 100 {
+		return name, ErrNotebookNameTooLong
+	}
+	return name, nil
+}
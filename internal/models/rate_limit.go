@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitPolicy - лимит token bucket для группы роутов: Limit запросов за Period,
+// корзина вмещает Burst токенов и пополняется равномерно
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// ParseRateLimitPolicy разбирает лимит вида "300/1m" или "300/1m,burst=50".
+// "off" — без лимита (возвращается nil)
+func ParseRateLimitPolicy(name, spec string) (*RateLimitPolicy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "off" {
+		return nil, nil
+	}
+
+	rate, burst, hasBurst := strings.Cut(spec, ",")
+	limitStr, periodStr, ok := strings.Cut(rate, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid rate limit %q for %s, expected N/period", spec, name)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit < 1 {
+		return nil, fmt.Errorf("invalid rate limit %q for %s", spec, name)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodStr))
+	if err != nil || period <= 0 {
+		return nil, fmt.Errorf("invalid rate limit period %q for %s", periodStr, name)
+	}
+
+	policy := &RateLimitPolicy{Name: name, Limit: limit, Period: period, Burst: limit}
+	if hasBurst {
+		value, ok := strings.CutPrefix(strings.TrimSpace(burst), "burst=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit option %q for %s", burst, name)
+		}
+		if policy.Burst, err = strconv.Atoi(value); err != nil || policy.Burst < 1 {
+			return nil, fmt.Errorf("invalid burst %q for %s", value, name)
+		}
+	}
+	return policy, nil
+}
+
+// RefillRate - сколько токенов добавляется в секунду
+func (p *RateLimitPolicy) RefillRate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// RateLimitBucket - состояние token bucket одного клиента
+type RateLimitBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Take пополняет корзину на момент now и забирает один токен, если он есть.
+// Новая корзина (UpdatedAt нулевой) считается полной
+func (b *RateLimitBucket) Take(policy *RateLimitPolicy, now time.Time) bool {
+	capacity := float64(policy.Burst)
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = capacity
+	} else if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed*policy.RefillRate())
+	}
+	b.UpdatedAt = now
+
+	if b.Tokens < 1 {
+		return false
+	}
+	b.Tokens--
+	return true
+}
+
+// Remaining - сколько целых запросов ещё можно сделать
+func (b *RateLimitBucket) Remaining() int {
+	return int(math.Floor(b.Tokens))
+}
+
+// RetryAfter - через сколько появится следующий токен
+func (b *RateLimitBucket) RetryAfter(policy *RateLimitPolicy) time.Duration {
+	if b.Tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.Tokens) / policy.RefillRate() * float64(time.Second))
+}
+
+// ResetAfter - через сколько корзина снова будет полной
+func (b *RateLimitBucket) ResetAfter(policy *RateLimitPolicy) time.Duration {
+	missing := float64(policy.Burst) - b.Tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / policy.RefillRate() * float64(time.Second))
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitPolicy(t *testing.T) {
+	tests := []struct {
+		spec   string
+		want   *RateLimitPolicy // nil — лимит отключён
+		hasErr bool
+	}{
+		{"300/1m", &RateLimitPolicy{Name: "api", Limit: 300, Period: time.Minute, Burst: 300}, false},
+		{" 20/10s , burst=5 ", &RateLimitPolicy{Name: "api", Limit: 20, Period: 10 * time.Second, Burst: 5}, false},
+		{"off", nil, false},
+		{"300", nil, true},
+		{"0/1m", nil, true},
+		{"abc/1m", nil, true},
+		{"10/0s", nil, true},
+		{"10/soon", nil, true},
+		{"10/1m,size=5", nil, true},
+		{"10/1m,burst=0", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRateLimitPolicy("api", tt.spec)
+		if (err != nil) != tt.hasErr {
+			t.Errorf("%q: err = %v, want error %v", tt.spec, err, tt.hasErr)
+			continue
+		}
+		if tt.want == nil {
+			if got != nil && !tt.hasErr {
+				t.Errorf("%q: %+v, want nil", tt.spec, got)
+			}
+			continue
+		}
+		if *got != *tt.want {
+			t.Errorf("%q: %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitBucket(t *testing.T) {
+	// 60 запросов в минуту — токен в секунду, корзина на 3
+	policy := &RateLimitPolicy{Name: "api", Limit: 60, Period: time.Minute, Burst: 3}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		at        time.Duration // время запроса от start
+		allowed   bool
+		remaining int
+	}{
+		{0, true, 2},
+		{0, true, 1},
+		{0, true, 0},
+		{0, false, 0},
+		{500 * time.Millisecond, false, 0},
+		{time.Second, true, 0},
+		{10 * time.Second, true, 2}, // корзина не копит больше Burst
+	}
+
+	var b RateLimitBucket
+	for i, tt := range tests {
+		if allowed := b.Take(policy, start.Add(tt.at)); allowed != tt.allowed {
+			t.Errorf("request %d at %v: allowed = %v, want %v", i, tt.at, allowed, tt.allowed)
+		}
+		if b.Remaining() != tt.remaining {
+			t.Errorf("request %d at %v: remaining = %d, want %d", i, tt.at, b.Remaining(), tt.remaining)
+		}
+	}
+
+	var empty RateLimitBucket
+	for range policy.Burst {
+		empty.Take(policy, start)
+	}
+	if d := empty.RetryAfter(policy); d != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", d)
+	}
+	if d := empty.ResetAfter(policy); d != 3*time.Second {
+		t.Errorf("ResetAfter = %v, want 3s", d)
+	}
+}
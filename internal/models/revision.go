@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// NoteRevision - неизменяемый снимок заметки после создания или обновления
+type NoteRevision struct {
+	ID        int       `json:"id"`
+	NoteID    int       `json:"note_id"`
+	Revision  int       `json:"revision"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Format    string    `json:"content_format"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Кэш отрисованного HTML: HTML построен рендерером версии HTMLRenderer, пусто — ещё не отрисован
+	HTML         string `json:"-"`
+	HTMLRenderer string `json:"-"`
+}
+
+// RevisionDiff - построчный diff содержимого двух ревизий
+type RevisionDiff struct {
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	TitleFrom string `json:"title_from"`
+	TitleTo   string `json:"title_to"`
+	Diff      string `json:"diff"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchTerm - одно условие поискового запроса:
+// слово, фраза в кавычках, префикс (слово*) или отрицание (-слово)
+type SearchTerm struct {
+	Words  []string
+	Prefix bool
+	Negate bool
+}
+
+// SearchQuery - разобранный поисковый запрос (все условия объединяются через AND)
+type SearchQuery struct {
+	Raw   string
+	Terms []SearchTerm
+}
+
+// NoteSearchResult - заметка с рангом и подсвеченными фрагментами
+type NoteSearchResult struct {
+	Note
+	Rank           float64 `json:"rank"`
+	TitleSnippet   string  `json:"title_snippet"`
+	ContentSnippet string  `json:"content_snippet"`
+}
+
+// ParseSearchQuery разбирает строку вида: заметка "список покупок" прог* -черновик
+func ParseSearchQuery(raw string) (*SearchQuery, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, ErrSearchQueryRequired
+	}
+	if len(raw) > 256 {
+		return nil, ErrSearchQueryTooLong
+	}
+
+	q := &SearchQuery{Raw: raw}
+	runes := []rune(raw)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		term := SearchTerm{}
+		if runes[i] == '-' {
+			term.Negate = true
+			i++
+		}
+
+		var chunk string
+		if i < len(runes) && runes[i] == '"' {
+			// Фраза до закрывающей кавычки (или до конца строки)
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			chunk = string(runes[i+1 : end])
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			chunk = string(runes[i:end])
+			i = end
+			if strings.HasSuffix(chunk, "*") {
+				term.Prefix = true
+			}
+		}
+
+		term.Words = SearchWords(chunk)
+		if len(term.Words) > 0 {
+			q.Terms = append(q.Terms, term)
+		}
+	}
+
+	if len(q.Terms) == 0 {
+		return nil, ErrSearchQueryRequired
+	}
+	return q, nil
+}
+
+// SearchWords разбивает текст на слова в нижнем регистре (буквы и цифры)
+func SearchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
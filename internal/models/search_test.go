@@ -0,0 +1,37 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		raw   string
+		terms []SearchTerm
+		err   error
+	}{
+		{"заметка", []SearchTerm{{Words: []string{"заметка"}}}, nil},
+		{"Hello, World", []SearchTerm{{Words: []string{"hello"}}, {Words: []string{"world"}}}, nil},
+		{`"список покупок" молоко`, []SearchTerm{{Words: []string{"список", "покупок"}}, {Words: []string{"молоко"}}}, nil},
+		{"прог*", []SearchTerm{{Words: []string{"прог"}, Prefix: true}}, nil},
+		{"-черновик план", []SearchTerm{{Words: []string{"черновик"}, Negate: true}, {Words: []string{"план"}}}, nil},
+		{`-"old plan"`, []SearchTerm{{Words: []string{"old", "plan"}, Negate: true}}, nil},
+		{`"unclosed phrase`, []SearchTerm{{Words: []string{"unclosed", "phrase"}}}, nil},
+		{"", nil, ErrSearchQueryRequired},
+		{"   ", nil, ErrSearchQueryRequired},
+		{"- * !!", nil, ErrSearchQueryRequired},
+		{strings.Repeat("a", 257), nil, ErrSearchQueryTooLong},
+	}
+	for _, tt := range tests {
+		q, err := ParseSearchQuery(tt.raw)
+		if err != tt.err {
+			t.Errorf("%q: error %v, want %v", tt.raw, err, tt.err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(q.Terms, tt.terms) {
+			t.Errorf("%q: terms %+v, want %+v", tt.raw, q.Terms, tt.terms)
+		}
+	}
+}
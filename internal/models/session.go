@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Session - сессия входа пользователя; к ней привязаны refresh токены и access токены (claim sid)
+type Session struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// RefreshRequest - данные для обновления access токена
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Validate проверяет RefreshRequest
+func (r *RefreshRequest) Validate() error {
+	if r.RefreshToken == "" {
+		return ErrRefreshTokenRequired
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Права доступа к заметке
+const (
+	PermissionOwner  = "owner"  // владелец, выдать нельзя
+	PermissionViewer = "viewer" // только чтение
+	PermissionEditor = "editor" // чтение и изменение
+)
+
+// NoteShare - доступ пользователя к чужой заметке
+type NoteShare struct {
+	NoteID     int       `json:"note_id"`
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SharedNote - заметка, которой поделились с пользователем
+type SharedNote struct {
+	*Note
+	OwnerUsername string    `json:"owner_username"`
+	Permission    string    `json:"permission"`
+	SharedAt      time.Time `json:"shared_at"`
+}
+
+// ShareNoteRequest - данные для выдачи доступа к заметке
+type ShareNoteRequest struct {
+	Username   string `json:"username"`
+	Permission string `json:"permission"`
+}
+
+// Validate проверяет ShareNoteRequest
+func (r *ShareNoteRequest) Validate() error {
+	r.Username = strings.TrimSpace(r.Username)
+	if r.Username == "" {
+		return ErrUsernameRequired
+	}
+	if r.Permission != PermissionViewer && r.Permission != PermissionEditor {
+		return ErrInvalidPermission
+	}
+	return nil
+}
+
+// CanEdit сообщает, разрешает ли право изменять заметку
+func CanEdit(permission string) bool {
+	return permission == PermissionOwner || permission == PermissionEditor
+}
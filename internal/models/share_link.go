@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// ShareLink - публичная ссылка на заметку только для чтения (сам токен не хранится, только хеш)
+type ShareLink struct {
+	ID           int        `json:"id"`
+	NoteID       int        `json:"note_id"`
+	UserID       int        `json:"user_id"`
+	PasswordHash string     `json:"-"`
+	HasPassword  bool       `json:"has_password"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Expired сообщает, истёк ли срок действия ссылки
+func (l *ShareLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// CreateShareLinkRequest - данные для создания публичной ссылки
+type CreateShareLinkRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"` // nil — бессрочная
+	Password  string     `json:"password"`   // пусто — без пароля
+}
+
+// CreateShareLinkResponse - созданная ссылка; Token показывается только один раз
+type CreateShareLinkResponse struct {
+	Token string `json:"token"`
+	Path  string `json:"path"` // публичный адрес, например /s/nsl_...
+	*ShareLink
+}
+
+// PublicNote - то, что видит получатель публичной ссылки
+type PublicNote struct {
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Tags      []string  `json:"tags"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate проверяет CreateShareLinkRequest
+func (r *CreateShareLinkRequest) Validate() error {
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return ErrTokenExpiryPast
+	}
+	if r.Password != "" && len(r.Password) < 6 {
+		return ErrPasswordTooShort
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxTagLength   = 50
+	maxTagsPerNote = 20
+)
+
+// TagCount - тег пользователя с количеством заметок
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// NormalizeTags приводит теги к нижнему регистру, убирает пустые и дубликаты.
+// nil остаётся nil (в UpdateNote это значит «теги не менять»)
+func NormalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, ErrTagTooLong
+		}
+		if hasNUL(tag) {
+			return nil, ErrTagNULCharacter
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	if len(result) > maxTagsPerNote {
+		return nil, ErrTooManyTags
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
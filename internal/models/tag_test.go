@@ -0,0 +1,45 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	many := make([]string, maxTagsPerNote+1)
+	for i := range many {
+		many[i] = "tag" + strings.Repeat("x", i)
+	}
+
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+		err  error
+	}{
+		{"nil stays nil", nil, nil, nil},
+		{"empty list", []string{}, []string{}, nil},
+		{"lowercase and trim", []string{" Go ", "WORK"}, []string{"go", "work"}, nil},
+		{"duplicates and blanks", []string{"go", "Go", "", "  ", "go"}, []string{"go"}, nil},
+		{"sorted", []string{"b", "c", "a"}, []string{"a", "b", "c"}, nil},
+		{"max length", []string{strings.Repeat("я", maxTagLength)}, []string{strings.Repeat("я", maxTagLength)}, nil},
+		{"too long", []string{strings.Repeat("я", maxTagLength+1)}, nil, ErrTagTooLong},
+		{"nul character", []string{"a\x00b"}, nil, ErrTagNULCharacter},
+		{"too many", many, nil, ErrTooManyTags},
+		{"duplicates do not count", append(many[:maxTagsPerNote:maxTagsPerNote], many[0]), nil, nil},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeTags(tt.in)
+		if err != tt.err {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err == nil && tt.want != nil && !slices.Equal(got, tt.want) {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+		if tt.in == nil && got != nil {
+			t.Errorf("%s: %q, want nil", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package models
+
+import "strings"
+
+// TwoFactor - состояние TOTP у пользователя. Secret задан, но Enabled == false — подключение не подтверждено
+type TwoFactor struct {
+	Secret      string
+	Enabled     bool
+	LastCounter int64 // последний принятый интервал TOTP (защита от повторного кода)
+}
+
+// TwoFactorSetupResponse - секрет для приложения-аутентификатора
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest - код из приложения-аутентификатора
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// Validate проверяет TwoFactorCodeRequest
+func (r *TwoFactorCodeRequest) Validate() error {
+	r.Code = strings.TrimSpace(r.Code)
+	if r.Code == "" {
+		return ErrTwoFactorCodeRequired
+	}
+	return nil
+}
+
+// TwoFactorConfirmResponse - одноразовые коды восстановления, показываются один раз
+type TwoFactorConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// DisableTwoFactorRequest - отключение 2FA: пароль и код (или код восстановления)
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// Validate проверяет DisableTwoFactorRequest
+func (r *DisableTwoFactorRequest) Validate() error {
+	if r.Password == "" {
+		return ErrPasswordRequired
+	}
+	r.Code = strings.TrimSpace(r.Code)
+	r.RecoveryCode = strings.TrimSpace(r.RecoveryCode)
+	if r.Code == "" && r.RecoveryCode == "" {
+		return ErrTwoFactorCodeRequired
+	}
+	return nil
+}
+
+// TwoFactorChallengeResponse - ответ Login при включённой 2FA вместо токенов
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+// TwoFactorVerifyRequest - второй шаг входа: challenge токен и код (или код восстановления)
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// Validate проверяет TwoFactorVerifyRequest
+func (r *TwoFactorVerifyRequest) Validate() error {
+	if r.ChallengeToken == "" {
+		return ErrChallengeTokenRequired
+	}
+	r.Code = strings.TrimSpace(r.Code)
+	r.RecoveryCode = strings.TrimSpace(r.RecoveryCode)
+	if r.Code == "" && r.RecoveryCode == "" {
+		return ErrTwoFactorCodeRequired
+	}
+	return nil
+}
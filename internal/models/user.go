@@ -1,11 +1,16 @@
 package models
 
-import "time"
+import (
+	"net/mail"
+	"strings"
+	"time"
+)
 
 //user представляет пользователя в системе
 type User struct {
 	ID           int       `json:"id"`
 	Username     string    `json:"username"`
+	Email        string    `json:"email,omitempty"`
 	PasswordHash string    `json:"-"`
 	CreatedAt    time.Time `json:"created_at"`
 }
@@ -13,6 +18,7 @@ type User struct {
 //CreateUserRequest - данные для создания пользователя
 type CreateUserRequest struct {
 	Username string `json:"username"`
+	Email    string `json:"email"` // необязателен, нужен для сброса пароля
 	Password string `json:"password"`
 }
 
@@ -23,9 +29,12 @@ type LoginRequest struct {
 }
 
 //ответ с JWT токеном
+//Token — короткоживущий access токен, RefreshToken — для POST /auth/refresh
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // время жизни access токена в секундах
+	User         *User  `json:"user"`
 }
 
 //Validate - проверяет корректность данных
@@ -45,6 +54,12 @@ func (v *CreateUserRequest) Validate() error {
 	if len(v.Password) < 6 {
 		return ErrPasswordTooShort
 	}
+	v.Email = strings.TrimSpace(v.Email)
+	if v.Email != "" {
+		if _, err := mail.ParseAddress(v.Email); err != nil {
+			return ErrInvalidEmail
+		}
+	}
 	return nil
 }
 
@@ -58,3 +73,69 @@ func (r *LoginRequest) Validate() error {
 	}
 	return nil
 }
+
+//ChangePasswordRequest - смена пароля авторизованным пользователем
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+//Validate проверяет ChangePasswordRequest
+func (r *ChangePasswordRequest) Validate() error {
+	if r.CurrentPassword == "" || r.NewPassword == "" {
+		return ErrPasswordRequired
+	}
+	if len(r.NewPassword) < 6 {
+		return ErrPasswordTooShort
+	}
+	if r.NewPassword == r.CurrentPassword {
+		return ErrSamePassword
+	}
+	return nil
+}
+
+//ForgotPasswordRequest - запрос письма со ссылкой для сброса пароля
+type ForgotPasswordRequest struct {
+	Username string `json:"username"`
+}
+
+//Validate проверяет ForgotPasswordRequest
+func (r *ForgotPasswordRequest) Validate() error {
+	if r.Username == "" {
+		return ErrUsernameRequired
+	}
+	return nil
+}
+
+//ResetPasswordRequest - новый пароль по токену из письма
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+//Validate проверяет ResetPasswordRequest
+func (r *ResetPasswordRequest) Validate() error {
+	if r.Token == "" {
+		return ErrResetTokenRequired
+	}
+	if r.NewPassword == "" {
+		return ErrPasswordRequired
+	}
+	if len(r.NewPassword) < 6 {
+		return ErrPasswordTooShort
+	}
+	return nil
+}
+
+//DeleteUserRequest - подтверждение удаления аккаунта паролем
+type DeleteUserRequest struct {
+	Password string `json:"password"`
+}
+
+//Validate проверяет DeleteUserRequest
+func (r *DeleteUserRequest) Validate() error {
+	if r.Password == "" {
+		return ErrPasswordRequired
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/lib/pq"
+)
+
+const apiTokenColumns = "id, user_id, name, scopes, expires_at, last_used_at, created_at"
+
+// CreateAPIToken сохраняет personal access токен (только хеш)
+func (s *Storage) CreateAPIToken(ctx context.Context, userID int, name, tokenHash string, scopes []string, expiresAt *time.Time) (*models.APIToken, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO api_tokens (user_id, name, token_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING ` + apiTokenColumns
+
+	var expires interface{}
+	if expiresAt != nil {
+		expires = expiresAt.UTC()
+	}
+
+	token, err := scanAPIToken(s.db.QueryRowContext(ctx, query, userID, name, tokenHash, pq.Array(scopes), expires))
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return token, nil
+}
+
+// GetUserAPITokens возвращает токены пользователя, новые первыми
+func (s *Storage) GetUserAPITokens(ctx context.Context, userID int) ([]*models.APIToken, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + apiTokenColumns + `
+		FROM api_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	tokens := []*models.APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return tokens, nil
+}
+
+// UseAPIToken находит действующий токен по хешу и отмечает время использования.
+// Неизвестный или истёкший токен — models.ErrAPITokenNotFound
+func (s *Storage) UseAPIToken(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE api_tokens SET last_used_at = NOW()
+		WHERE token_hash = $1 AND (expires_at IS NULL OR expires_at > $2)
+		RETURNING ` + apiTokenColumns
+
+	token, err := scanAPIToken(s.db.QueryRowContext(ctx, query, tokenHash, time.Now().UTC()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrAPITokenNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return token, nil
+}
+
+// DeleteAPIToken отзывает токен пользователя
+func (s *Storage) DeleteAPIToken(ctx context.Context, userID, tokenID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM api_tokens WHERE id = $1 AND user_id = $2`
+
+	result, err := s.db.ExecContext(ctx, query, tokenID, userID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrAPITokenNotFound
+	}
+
+	return nil
+}
+
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	token := &models.APIToken{}
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		pq.Array(&token.Scopes),
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
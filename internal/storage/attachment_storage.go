@@ -0,0 +1,370 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/lib/pq"
+)
+
+const attachmentColumns = `id, note_id, user_id, filename, content_type, size, sha256, storage_key, created_at,
+	image_status, width, height, thumbnail_key, thumbnail_content_type, thumbnail_size`
+
+// CreateAttachment сохраняет метаданные загруженного вложения.
+// Строка пользователя блокируется, чтобы параллельные загрузки не превысили quota (0 — без ограничения).
+// Заметка должна существовать и не лежать в корзине, иначе models.ErrNoteNotFound
+func (s *Storage) CreateAttachment(ctx context.Context, attachment *models.Attachment, quota int64) (*models.Attachment, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var locked int
+	err = tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, attachment.UserID).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	if quota > 0 {
+		var used int64
+		err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1`, attachment.UserID).Scan(&used)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		if used+attachment.Size > quota {
+			return nil, models.ErrQuotaExceeded
+		}
+	}
+
+	query := `
+		INSERT INTO attachments (note_id, user_id, filename, content_type, size, sha256, storage_key, image_status, created_at)
+		SELECT id, $2, $3, $4, $5, $6, $7, $8, NOW()
+		FROM notes
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING ` + attachmentColumns
+
+	created, err := scanAttachment(tx.QueryRowContext(ctx, query,
+		attachment.NoteID,
+		attachment.UserID,
+		attachment.Filename,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.SHA256,
+		attachment.StorageKey,
+		nullString(attachment.ImageStatus),
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoteNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return created, nil
+}
+
+// GetNoteAttachments возвращает вложения заметки в порядке загрузки
+func (s *Storage) GetNoteAttachments(ctx context.Context, noteID int) ([]*models.Attachment, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE note_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	attachments := []*models.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment возвращает вложение заметки по ID
+func (s *Storage) GetAttachment(ctx context.Context, noteID, attachmentID int) (*models.Attachment, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE id = $1 AND note_id = $2
+	`
+
+	attachment, err := scanAttachment(s.db.QueryRowContext(ctx, query, attachmentID, noteID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrAttachmentNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return attachment, nil
+}
+
+// DeleteAttachment удаляет метаданные вложения; blob попадает в orphaned_blobs (триггер)
+func (s *Storage) DeleteAttachment(ctx context.Context, noteID, attachmentID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM attachments WHERE id = $1 AND note_id = $2`
+
+	result, err := s.db.ExecContext(ctx, query, attachmentID, noteID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrAttachmentNotFound
+	}
+
+	return nil
+}
+
+// GetAttachmentUsage возвращает занятое вложениями пользователя место (Quota не заполняется)
+func (s *Storage) GetAttachmentUsage(ctx context.Context, userID int) (*models.AttachmentUsage, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	usage := &models.AttachmentUsage{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(size), 0), COUNT(*) FROM attachments WHERE user_id = $1
+	`, userID).Scan(&usage.Used, &usage.Count)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return usage, nil
+}
+
+// ClaimImageAttachments забирает в обработку до limit изображений: ожидающих и тех,
+// чья обработка началась раньше staleBefore (обработчик упал). SKIP LOCKED позволяет работать нескольким экземплярам
+func (s *Storage) ClaimImageAttachments(ctx context.Context, limit int, now, staleBefore time.Time) ([]*models.Attachment, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE attachments SET image_status = 'processing', image_claimed_at = $2
+		WHERE id IN (
+			SELECT id FROM attachments
+			WHERE image_status = 'pending' OR (image_status = 'processing' AND image_claimed_at < $3)
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + attachmentColumns
+
+	rows, err := s.db.QueryContext(ctx, query, limit, now.UTC(), staleBefore.UTC())
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	attachments := []*models.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return attachments, nil
+}
+
+// FinishImageAttachment сохраняет результат обработки изображения (status — ready или failed).
+// Если оригинал записан под новым ключом, прежний blob ставится в очередь на удаление.
+// Если вложение успели удалить — models.ErrAttachmentNotFound
+func (s *Storage) FinishImageAttachment(ctx context.Context, attachmentID int, status string, result *models.ImageResult) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var storageKey string
+	err = tx.QueryRowContext(ctx, `
+		SELECT storage_key FROM attachments WHERE id = $1 AND image_status = 'processing' FOR UPDATE
+	`, attachmentID).Scan(&storageKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrAttachmentNotFound
+		}
+		return ctxError(ctx, err)
+	}
+
+	query := `
+		UPDATE attachments SET
+			image_status = $2,
+			image_claimed_at = NULL,
+			width = $3,
+			height = $4,
+			thumbnail_key = $5,
+			thumbnail_content_type = $6,
+			thumbnail_size = $7,
+			size = CASE WHEN $8::bigint > 0 THEN $8::bigint ELSE size END,
+			sha256 = COALESCE($9, sha256),
+			storage_key = COALESCE($10, storage_key)
+		WHERE id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		attachmentID,
+		status,
+		nullInt(result.Width),
+		nullInt(result.Height),
+		nullString(result.ThumbnailKey),
+		nullString(result.ThumbnailContentType),
+		nullInt64(result.ThumbnailSize),
+		result.Size,
+		nullString(result.SHA256),
+		nullString(result.StorageKey),
+	)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if result.StorageKey != "" && result.StorageKey != storageKey {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO orphaned_blobs (storage_key) VALUES ($1)`, storageKey); err != nil {
+			return ctxError(ctx, err)
+		}
+	}
+
+	return ctxError(ctx, tx.Commit())
+}
+
+// GetOrphanedBlobs возвращает до limit blob'ов из очереди на удаление, старые первыми
+func (s *Storage) GetOrphanedBlobs(ctx context.Context, limit int) ([]*models.OrphanedBlob, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, storage_key FROM orphaned_blobs ORDER BY id LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	blobs := []*models.OrphanedBlob{}
+	for rows.Next() {
+		blob := &models.OrphanedBlob{}
+		if err := rows.Scan(&blob.ID, &blob.StorageKey); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		blobs = append(blobs, blob)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return blobs, nil
+}
+
+// DeleteOrphanedBlobs убирает из очереди blob'ы, которые уже стёрты из хранилища
+func (s *Storage) DeleteOrphanedBlobs(ctx context.Context, ids []int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM orphaned_blobs WHERE id = ANY($1)`, pq.Array(ids))
+	return ctxError(ctx, err)
+}
+
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	var imageStatus, thumbnailKey, thumbnailContentType sql.NullString
+	var width, height, thumbnailSize sql.NullInt64
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.NoteID,
+		&attachment.UserID,
+		&attachment.Filename,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.SHA256,
+		&attachment.StorageKey,
+		&attachment.CreatedAt,
+		&imageStatus,
+		&width,
+		&height,
+		&thumbnailKey,
+		&thumbnailContentType,
+		&thumbnailSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	attachment.ImageStatus = imageStatus.String
+	attachment.Width = int(width.Int64)
+	attachment.Height = int(height.Int64)
+	attachment.ThumbnailKey = thumbnailKey.String
+	attachment.ThumbnailContentType = thumbnailContentType.String
+	attachment.ThumbnailSize = thumbnailSize.Int64
+	return attachment, nil
+}
+
+// nullString, nullInt и nullInt64 превращают нулевые значения в NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullInt(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
+
+func nullInt64(n int64) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
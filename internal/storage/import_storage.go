@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+const importColumns = `id, user_id, filename, status, COALESCE(storage_key, ''), total_files, processed_files,
+	imported_notes, failed_files, created_notebooks, attempts, COALESCE(error, ''), created_at, finished_at`
+
+// CreateImport ставит импорт архива в очередь фоновой задачи
+func (s *Storage) CreateImport(ctx context.Context, imp *models.Import) (*models.Import, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO imports (user_id, filename, status, storage_key, total_files, created_at)
+		VALUES ($1, $2, 'pending', $3, $4, NOW())
+		RETURNING ` + importColumns
+
+	created, err := scanImport(s.db.QueryRowContext(ctx, query, imp.UserID, imp.Filename, imp.StorageKey, imp.TotalFiles))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return created, nil
+}
+
+// GetImport возвращает импорт пользователя вместе с отчётом об ошибках по файлам
+func (s *Storage) GetImport(ctx context.Context, userID, importID int) (*models.Import, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + importColumns + ` FROM imports WHERE id = $1 AND user_id = $2`
+
+	imp, err := scanImport(s.db.QueryRowContext(ctx, query, importID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrImportNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT file, error FROM import_errors WHERE import_id = $1 ORDER BY id`, importID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fileErr models.ImportFileError
+		if err := rows.Scan(&fileErr.File, &fileErr.Error); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		imp.Errors = append(imp.Errors, fileErr)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return imp, nil
+}
+
+// GetUserImports возвращает импорты пользователя, новые первыми (без отчётов об ошибках)
+func (s *Storage) GetUserImports(ctx context.Context, userID int) ([]*models.Import, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + importColumns + ` FROM imports WHERE user_id = $1 ORDER BY id DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	imports := []*models.Import{}
+	for rows.Next() {
+		imp, err := scanImport(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		imports = append(imports, imp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return imports, nil
+}
+
+// ClaimImports забирает в обработку до limit импортов: ожидающих и тех, по которым
+// с staleBefore не было прогресса (обработчик упал), и увеличивает их attempts.
+// SKIP LOCKED позволяет работать нескольким экземплярам
+func (s *Storage) ClaimImports(ctx context.Context, limit int, now, staleBefore time.Time) ([]*models.Import, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE imports SET status = 'processing', claimed_at = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM imports
+			WHERE status = 'pending' OR (status = 'processing' AND claimed_at < $3)
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + importColumns
+
+	rows, err := s.db.QueryContext(ctx, query, limit, now.UTC(), staleBefore.UTC())
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	imports := []*models.Import{}
+	for rows.Next() {
+		imp, err := scanImport(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		imports = append(imports, imp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return imports, nil
+}
+
+// SaveImportBatch одной транзакцией создаёт заметки порции (с тегами, ревизиями и ссылками),
+// недостающие блокноты и записывает ошибки и прогресс импорта (порция обнуляет attempts). Если импорт удалён или порцию
+// уже записал другой экземпляр (processed_files не равен batch.Offset) — models.ErrImportNotFound.
+// Таймаут — DB_QUERY_TIMEOUT на каждую заметку и ошибку порции плюс на блокировку и прогресс импорта
+func (s *Storage) SaveImportBatch(ctx context.Context, importID int, batch *models.ImportBatch) error {
+	ctx, cancel := s.withBatchTimeout(ctx, len(batch.Notes)+len(batch.Errors)+2)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id FROM imports
+		WHERE id = $1 AND status = 'processing' AND processed_files = $2
+		FOR UPDATE
+	`, importID, batch.Offset).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrImportNotFound
+		}
+		return ctxError(ctx, err)
+	}
+
+	notebooks := &importNotebooks{ids: make(map[string]int)}
+	for _, n := range batch.Notes {
+		notebookID, err := notebooks.resolve(ctx, tx, userID, n.Folders)
+		if err != nil {
+			return ctxError(ctx, err)
+		}
+
+		query := `
+			INSERT INTO notes (user_id, notebook_id, title, content, content_format, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, 'markdown', COALESCE($5, NOW()), COALESCE($6, $5, NOW()))
+			RETURNING ` + noteColumns
+
+		note, err := scanNote(tx.QueryRowContext(ctx, query, userID, notebookID, n.Title, n.Content, n.CreatedAt, n.UpdatedAt))
+		if err != nil {
+			return ctxError(ctx, err)
+		}
+
+		if err := setNoteTags(ctx, tx, userID, note.ID, n.Tags); err != nil {
+			return ctxError(ctx, err)
+		}
+
+		if err := insertRevision(ctx, tx, note); err != nil {
+			return ctxError(ctx, err)
+		}
+
+		if err := setNoteLinks(ctx, tx, note); err != nil {
+			return ctxError(ctx, err)
+		}
+	}
+
+	for _, fileErr := range batch.Errors {
+		_, err := tx.ExecContext(ctx, `INSERT INTO import_errors (import_id, file, error) VALUES ($1, $2, $3)`,
+			importID, fileErr.File, fileErr.Error)
+		if err != nil {
+			return ctxError(ctx, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE imports SET
+			processed_files = processed_files + $2,
+			imported_notes = imported_notes + $3,
+			failed_files = failed_files + $4,
+			created_notebooks = created_notebooks + $5,
+			attempts = 0,
+			claimed_at = NOW()
+		WHERE id = $1
+	`, importID, batch.Files, len(batch.Notes), len(batch.Errors), notebooks.created)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return ctxError(ctx, tx.Commit())
+}
+
+// FinishImport завершает импорт (status — completed или failed, message — причина ошибки)
+// и ставит архив в очередь на удаление. Если импорт удалён или уже завершён — models.ErrImportNotFound
+func (s *Storage) FinishImport(ctx context.Context, importID int, status, message string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var storageKey sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT storage_key FROM imports WHERE id = $1 AND status = 'processing' FOR UPDATE
+	`, importID).Scan(&storageKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrImportNotFound
+		}
+		return ctxError(ctx, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE imports SET status = $2, error = $3, storage_key = NULL, claimed_at = NULL, finished_at = NOW()
+		WHERE id = $1
+	`, importID, status, nullString(message))
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if storageKey.Valid {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO orphaned_blobs (storage_key) VALUES ($1)`, storageKey.String); err != nil {
+			return ctxError(ctx, err)
+		}
+	}
+
+	return ctxError(ctx, tx.Commit())
+}
+
+// importNotebooks находит блокноты по пути папок архива и создаёт недостающие.
+// Папка с тем же именем, что и существующий блокнот на том же уровне, попадает в него
+type importNotebooks struct {
+	ids     map[string]int // путь папки -> id блокнота
+	created int
+}
+
+func (nb *importNotebooks) resolve(ctx context.Context, q querier, userID int, folders []string) (*int, error) {
+	var parentID *int
+	for i, name := range folders {
+		key := strings.Join(folders[:i+1], "/")
+		if id, ok := nb.ids[key]; ok {
+			parentID = &id
+			continue
+		}
+
+		var id int
+		err := q.QueryRowContext(ctx, `
+			SELECT id FROM notebooks
+			WHERE user_id = $1 AND parent_id IS NOT DISTINCT FROM $2 AND name = $3
+			ORDER BY id
+			LIMIT 1
+		`, userID, parentID, name).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = q.QueryRowContext(ctx, `
+				INSERT INTO notebooks (user_id, parent_id, name, created_at, updated_at)
+				VALUES ($1, $2, $3, NOW(), NOW())
+				RETURNING id
+			`, userID, parentID, name).Scan(&id)
+			nb.created++
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		nb.ids[key] = id
+		parentID = &id
+	}
+	return parentID, nil
+}
+
+func scanImport(row rowScanner) (*models.Import, error) {
+	imp := &models.Import{}
+	err := row.Scan(
+		&imp.ID,
+		&imp.UserID,
+		&imp.Filename,
+		&imp.Status,
+		&imp.StorageKey,
+		&imp.TotalFiles,
+		&imp.ProcessedFiles,
+		&imp.ImportedNotes,
+		&imp.FailedFiles,
+		&imp.CreatedNotebooks,
+		&imp.Attempts,
+		&imp.Error,
+		&imp.CreatedAt,
+		&imp.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/pkg/wikilink"
+	"github.com/lib/pq"
+)
+
+// resolvedLinksQuery - ссылки из заметок пользователя $1 (кроме заметок в корзине) вместе с найденными целями.
+// [[Заголовок]] указывает на самую старую заметку пользователя с таким заголовком (без учёта регистра),
+// note:42 — на заметку 42, если она того же пользователя и не в корзине
+const resolvedLinksQuery = `
+	WITH resolved AS (
+		SELECT l.id, l.source_id, s.title AS source_title,
+			l.target_id AS link_id, l.target_title AS link_title,
+			t.id AS target_id, t.title AS target_title
+		FROM note_links l
+		JOIN notes s ON s.id = l.source_id
+		LEFT JOIN LATERAL (
+			SELECT n.id, n.title
+			FROM notes n
+			WHERE n.user_id = s.user_id AND n.deleted_at IS NULL
+				AND (n.id = l.target_id OR (l.target_id IS NULL AND LOWER(n.title) = LOWER(l.target_title)))
+			ORDER BY n.id
+			LIMIT 1
+		) t ON TRUE
+		WHERE s.user_id = $1 AND s.deleted_at IS NULL
+	)
+`
+
+// GetOutgoingLinks возвращает ссылки из заметки в порядке их появления в тексте
+func (s *Storage) GetOutgoingLinks(ctx context.Context, userID, noteID int) ([]*models.NoteLink, error) {
+	return s.queryLinks(ctx, resolvedLinksQuery+`
+		SELECT source_id, source_title, link_id, link_title, target_id, target_title
+		FROM resolved
+		WHERE source_id = $2
+		ORDER BY id
+	`, userID, noteID)
+}
+
+// GetBacklinks возвращает ссылки других заметок пользователя (и самой заметки) на заметку noteID
+func (s *Storage) GetBacklinks(ctx context.Context, userID, noteID int) ([]*models.NoteLink, error) {
+	return s.queryLinks(ctx, resolvedLinksQuery+`
+		SELECT source_id, source_title, link_id, link_title, target_id, target_title
+		FROM resolved
+		WHERE target_id = $2
+		ORDER BY source_id, id
+	`, userID, noteID)
+}
+
+// GetUnresolvedLinks возвращает ссылки из заметок пользователя, для которых не нашлось заметки
+func (s *Storage) GetUnresolvedLinks(ctx context.Context, userID int) ([]*models.NoteLink, error) {
+	return s.queryLinks(ctx, resolvedLinksQuery+`
+		SELECT source_id, source_title, link_id, link_title, target_id, target_title
+		FROM resolved
+		WHERE target_id IS NULL
+		ORDER BY source_id, id
+	`, userID)
+}
+
+// GetLinkGraph возвращает граф ссылок пользователя: заметки вне корзины и ссылки между ними
+func (s *Storage) GetLinkGraph(ctx context.Context, userID int) (*models.LinkGraph, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	graph := &models.LinkGraph{Nodes: []*models.GraphNode{}, Edges: []*models.GraphEdge{}}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, title, notebook_id
+		FROM notes
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		node := &models.GraphNode{}
+		if err := rows.Scan(&node.ID, &node.Title, &node.NotebookID); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	edges, err := s.db.QueryContext(ctx, resolvedLinksQuery+`
+		SELECT DISTINCT source_id, target_id
+		FROM resolved
+		WHERE target_id IS NOT NULL
+		ORDER BY source_id, target_id
+	`, userID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer edges.Close()
+
+	for edges.Next() {
+		edge := &models.GraphEdge{}
+		if err := edges.Scan(&edge.Source, &edge.Target); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+	if err = edges.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return graph, nil
+}
+
+func (s *Storage) queryLinks(ctx context.Context, query string, args ...interface{}) ([]*models.NoteLink, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	links := []*models.NoteLink{}
+	for rows.Next() {
+		link := &models.NoteLink{}
+		var linkID, targetID sql.NullInt64
+		var linkTitle, targetTitle sql.NullString
+		err := rows.Scan(
+			&link.SourceID,
+			&link.SourceTitle,
+			&linkID,
+			&linkTitle,
+			&targetID,
+			&targetTitle,
+		)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+
+		link.Link = wikilink.Target{NoteID: int(linkID.Int64), Title: linkTitle.String}.String()
+		if targetID.Valid {
+			id := int(targetID.Int64)
+			link.TargetID = &id
+			link.TargetTitle = &targetTitle.String
+		}
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return links, nil
+}
+
+// setNoteLinks заменяет ссылки заметки ссылками из её текущего содержимого
+func setNoteLinks(ctx context.Context, q querier, note *models.Note) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM note_links WHERE source_id = $1`, note.ID); err != nil {
+		return err
+	}
+
+	targets := wikilink.Parse(note.Content)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	// Параллельные массивы: у ссылки на заголовок id = 0, у ссылки на id заголовок пустой
+	ids := make([]int64, len(targets))
+	titles := make([]string, len(targets))
+	for i, t := range targets {
+		ids[i] = int64(t.NoteID)
+		titles[i] = t.Title
+	}
+
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO note_links (source_id, target_id, target_title)
+		SELECT $1, NULLIF(t.id, 0), NULLIF(t.title, '')
+		FROM UNNEST($2::integer[], $3::text[]) AS t(id, title)
+	`, note.ID, pq.Array(ids), pq.Array(titles))
+	return err
+}
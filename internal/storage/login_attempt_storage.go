@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// GetLoginAttempts возвращает счётчик неудачных входов по ключу; нет записи — пустой счётчик
+func (s *Storage) GetLoginAttempts(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT key, failures, last_failure_at, locked_until
+		FROM login_attempts
+		WHERE key = $1
+	`
+
+	attempts := &models.LoginAttempts{}
+	err := s.db.QueryRowContext(ctx, query, key).Scan(
+		&attempts.Key,
+		&attempts.Failures,
+		&attempts.LastFailureAt,
+		&attempts.LockedUntil,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.LoginAttempts{Key: key}, nil
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return attempts, nil
+}
+
+// RecordLoginFailure увеличивает счётчик неудач; если последняя неудача была раньше
+// windowStart, счёт начинается заново. Возвращает новое число неудач
+func (s *Storage) RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = $2
+		RETURNING failures
+	`
+
+	var failures int
+	if err := s.db.QueryRowContext(ctx, query, key, now.UTC(), windowStart.UTC()).Scan(&failures); err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	return failures, nil
+}
+
+// LockLogin блокирует вход по ключу до until
+func (s *Storage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE login_attempts SET locked_until = $1 WHERE key = $2`
+
+	if _, err := s.db.ExecContext(ctx, query, until.UTC(), key); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return nil
+}
+
+// ResetLoginAttempts сбрасывает счётчик после успешного входа
+func (s *Storage) ResetLoginAttempts(ctx context.Context, key string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM login_attempts WHERE key = $1`
+
+	if _, err := s.db.ExecContext(ctx, query, key); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return nil
+}
+
+// CreateLockoutEvent записывает блокировку входа в журнал
+func (s *Storage) CreateLockoutEvent(ctx context.Context, event *models.LockoutEvent) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO lockout_events (key, username, ip, failures, locked_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, created_at
+	`
+
+	err := s.db.QueryRowContext(ctx, query,
+		event.Key,
+		event.Username,
+		event.IP,
+		event.Failures,
+		event.LockedUntil.UTC(),
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return nil
+}
+
+// GetLockoutEvents возвращает журнал блокировок, новые первыми
+func (s *Storage) GetLockoutEvents(ctx context.Context, limit, offset int) ([]*models.LockoutEvent, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, key, username, ip, failures, locked_until, created_at
+		FROM lockout_events
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	events := []*models.LockoutEvent{}
+	for rows.Next() {
+		event := &models.LockoutEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.Key,
+			&event.Username,
+			&event.IP,
+			&event.Failures,
+			&event.LockedUntil,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return events, nil
+}
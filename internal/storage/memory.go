@@ -0,0 +1,488 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/pkg/wikilink"
+)
+
+// MemoryStorage хранит данные в памяти (для тестов и локального запуска без БД)
+type MemoryStorage struct {
+	mu sync.RWMutex
+
+	users      map[int]*models.User
+	nextUserID int
+
+	notes      map[int]*models.Note
+	nextNoteID int
+
+	revisions      map[int][]*models.NoteRevision // note_id -> ревизии по возрастанию
+	nextRevisionID int
+
+	sessions      map[int]*models.Session
+	nextSessionID int
+	refreshTokens map[string]*memoryRefreshToken // хеш токена -> токен
+
+	apiTokens      map[int]*memoryAPIToken
+	nextAPITokenID int
+
+	passwordResets map[string]*memoryPasswordReset // хеш токена -> сброс
+
+	twoFactor map[int]*memoryTwoFactor // user_id -> TOTP
+
+	loginAttempts map[string]*models.LoginAttempts
+	lockoutEvents []*models.LockoutEvent
+
+	rateLimits *MemoryRateLimits
+
+	shares map[int]map[int]*models.NoteShare // note_id -> user_id -> доступ
+
+	shareLinks      map[int]*memoryShareLink
+	nextShareLinkID int
+
+	notebooks      map[int]*models.Notebook
+	nextNotebookID int
+
+	attachments      map[int]*models.Attachment
+	nextAttachmentID int
+	imageClaims      map[int]time.Time // attachment_id -> когда изображение взято в обработку
+
+	orphanedBlobs      []*models.OrphanedBlob // очередь blob'ов на удаление
+	nextOrphanedBlobID int
+
+	links map[int][]wikilink.Target // note_id -> ссылки из заметки в порядке появления
+
+	imports      map[int]*models.Import
+	nextImportID int
+	importClaims map[int]time.Time // import_id -> когда импорт взят в обработку или записана последняя порция
+}
+
+// NewMemory создаёт пустое in-memory хранилище
+func NewMemory() *MemoryStorage {
+	return &MemoryStorage{
+		users:      make(map[int]*models.User),
+		nextUserID: 1,
+		notes:      make(map[int]*models.Note),
+		nextNoteID: 1,
+
+		revisions:      make(map[int][]*models.NoteRevision),
+		nextRevisionID: 1,
+
+		sessions:      make(map[int]*models.Session),
+		nextSessionID: 1,
+		refreshTokens: make(map[string]*memoryRefreshToken),
+
+		apiTokens:      make(map[int]*memoryAPIToken),
+		nextAPITokenID: 1,
+
+		passwordResets: make(map[string]*memoryPasswordReset),
+
+		twoFactor: make(map[int]*memoryTwoFactor),
+
+		loginAttempts: make(map[string]*models.LoginAttempts),
+
+		rateLimits: NewMemoryRateLimits(),
+
+		shares: make(map[int]map[int]*models.NoteShare),
+
+		shareLinks:      make(map[int]*memoryShareLink),
+		nextShareLinkID: 1,
+
+		notebooks:      make(map[int]*models.Notebook),
+		nextNotebookID: 1,
+
+		attachments:      make(map[int]*models.Attachment),
+		nextAttachmentID: 1,
+		imageClaims:      make(map[int]time.Time),
+
+		nextOrphanedBlobID: 1,
+
+		links: make(map[int][]wikilink.Target),
+
+		imports:      make(map[int]*models.Import),
+		nextImportID: 1,
+		importClaims: make(map[int]time.Time),
+	}
+}
+
+// CreateUser создаёт нового пользователя
+func (m *MemoryStorage) CreateUser(ctx context.Context, username, email, passwordHash string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range m.users {
+		if u.Username == username {
+			return nil, models.ErrUsernameExists
+		}
+	}
+
+	user := &models.User{
+		ID:           m.nextUserID,
+		Username:     username,
+		Email:        email,
+		PasswordHash: passwordHash,
+		CreatedAt:    time.Now(),
+	}
+	m.users[user.ID] = user
+	m.nextUserID++
+
+	// Как и в Postgres, хеш пароля при создании не возвращаем
+	created := *user
+	created.PasswordHash = ""
+	return &created, nil
+}
+
+// GetUserByUsername получает пользователя по username (для логина)
+func (m *MemoryStorage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if u.Username == username {
+			user := *u
+			return &user, nil
+		}
+	}
+	return nil, models.ErrUserNotFound
+}
+
+// GetUserByID получает пользователя по ID (с хешем пароля — для его проверки при смене)
+func (m *MemoryStorage) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+	user := *u
+	return &user, nil
+}
+
+// CreateNote создаёт новую заметку вместе со ссылками и первой ревизией
+func (m *MemoryStorage) CreateNote(ctx context.Context, userID int, notebookID *int, title, content, format string, tags []string) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, models.ErrUserNotFound
+	}
+	if notebookID != nil {
+		if nb, ok := m.notebooks[*notebookID]; !ok || nb.UserID != userID {
+			return nil, models.ErrNotebookNotFound
+		}
+	}
+
+	now := time.Now()
+	note := &models.Note{
+		ID:         m.nextNoteID,
+		UserID:     userID,
+		NotebookID: copyID(notebookID),
+		Title:      title,
+		Content:    content,
+		Format:     format,
+		Tags:       copyTags(tags),
+		Version:    1,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	m.notes[note.ID] = note
+	m.nextNoteID++
+	m.addRevision(note)
+	m.setNoteLinks(note)
+
+	return cloneNote(note), nil
+}
+
+// GetNoteByID получает заметку по ID
+func (m *MemoryStorage) GetNoteByID(ctx context.Context, noteID int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n, ok := m.notes[noteID]
+	if !ok || n.DeletedAt != nil {
+		return nil, models.ErrNoteNotFound
+	}
+	return cloneNote(n), nil
+}
+
+// GetUserNotes получает заметки пользователя с пагинацией, сортировкой и фильтрами.
+// С opts.Cursor вместо Offset используется позиция курсора
+func (m *MemoryStorage) GetUserNotes(ctx context.Context, userID int, opts models.NoteListOptions) ([]*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := opts.Sort
+	if len(keys) == 0 {
+		keys = models.DefaultNoteSort
+	}
+
+	all := m.filterNotes(userID, opts)
+	sort.Slice(all, func(i, j int) bool {
+		values := make([]interface{}, len(keys))
+		for k, key := range keys {
+			values[k] = models.SortValue(all[j], key.Field)
+		}
+		return models.CompareNotes(all[i], values, all[j].ID, keys) < 0
+	})
+
+	if c := opts.Cursor; c != nil {
+		var page []*models.Note
+		for _, n := range all {
+			if (c.Before && c.Precedes(n)) || (!c.Before && c.After(n)) {
+				page = append(page, n)
+			}
+		}
+		// До курсора берём ближайшие к нему заметки, после — первые
+		if c.Before && len(page) > opts.Limit {
+			return page[len(page)-opts.Limit:], nil
+		}
+		if len(page) > opts.Limit {
+			page = page[:opts.Limit]
+		}
+		return page, nil
+	}
+
+	var notes []*models.Note
+	for i := opts.Offset; i < len(all) && len(notes) < opts.Limit; i++ {
+		notes = append(notes, all[i])
+	}
+	return notes, nil
+}
+
+// CountUserNotes считает заметки пользователя с теми же фильтрами, что и GetUserNotes
+func (m *MemoryStorage) CountUserNotes(ctx context.Context, userID int, opts models.NoteListOptions) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.filterNotes(userID, opts)), nil
+}
+
+// filterNotes возвращает копии заметок пользователя, подходящих под фильтры (вызывается под m.mu)
+func (m *MemoryStorage) filterNotes(userID int, opts models.NoteListOptions) []*models.Note {
+	var notebooks map[int]bool
+	if opts.NotebookID != nil {
+		notebooks = map[int]bool{*opts.NotebookID: true}
+		if opts.Recursive {
+			notebooks = m.notebookSubtree(*opts.NotebookID)
+		}
+	}
+
+	var notes []*models.Note
+	for _, n := range m.notes {
+		if n.UserID != userID || n.DeletedAt != nil || !matchTags(n.Tags, opts.Tags, opts.TagMode) || !opts.Matches(n) {
+			continue
+		}
+		if opts.Unfiled && n.NotebookID != nil {
+			continue
+		}
+		if notebooks != nil && (n.NotebookID == nil || !notebooks[*n.NotebookID]) {
+			continue
+		}
+		notes = append(notes, cloneNote(n))
+	}
+	return notes
+}
+
+// UpdateNote обновляет заметку и её ссылки и записывает новую ревизию. tags == nil — теги не меняются, format == "" — формат не меняется.
+// expectedVersion > 0 — обновить, только если версия заметки совпадает
+func (m *MemoryStorage) UpdateNote(ctx context.Context, noteID int, title, content, format string, tags []string, expectedVersion int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.notes[noteID]
+	if !ok || n.DeletedAt != nil {
+		return nil, models.ErrNoteNotFound
+	}
+	if expectedVersion > 0 && n.Version != expectedVersion {
+		return nil, models.ErrVersionMismatch
+	}
+	n.Title = title
+	n.Content = content
+	if format != "" {
+		n.Format = format
+	}
+	if tags != nil {
+		n.Tags = copyTags(tags)
+	}
+	n.Version++
+	n.UpdatedAt = time.Now()
+	m.addRevision(n)
+	m.setNoteLinks(n)
+
+	return cloneNote(n), nil
+}
+
+// PatchNote частично обновляет заметку: меняются только поля, заданные в patch
+func (m *MemoryStorage) PatchNote(ctx context.Context, noteID int, patch models.NotePatch, expectedVersion int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.notes[noteID]
+	if !ok || n.DeletedAt != nil {
+		return nil, models.ErrNoteNotFound
+	}
+	if expectedVersion > 0 && n.Version != expectedVersion {
+		return nil, models.ErrVersionMismatch
+	}
+	if patch.Title != nil {
+		n.Title = *patch.Title
+	}
+	if patch.Content != nil {
+		n.Content = *patch.Content
+	}
+	if patch.Format != nil {
+		n.Format = *patch.Format
+	}
+	if patch.Tags != nil {
+		n.Tags = copyTags(*patch.Tags)
+	}
+	n.Version++
+	n.UpdatedAt = time.Now()
+	m.addRevision(n)
+	if patch.Content != nil {
+		m.setNoteLinks(n)
+	}
+
+	return cloneNote(n), nil
+}
+
+// DeleteNote переносит заметку в корзину (soft delete).
+// expectedVersion > 0 — только если версия заметки совпадает
+func (m *MemoryStorage) DeleteNote(ctx context.Context, noteID, expectedVersion int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.notes[noteID]
+	if !ok || n.DeletedAt != nil {
+		return models.ErrNoteNotFound
+	}
+	if expectedVersion > 0 && n.Version != expectedVersion {
+		return models.ErrVersionMismatch
+	}
+	now := time.Now()
+	n.DeletedAt = &now
+	return nil
+}
+
+// GetUserTags возвращает теги пользователя с количеством заметок
+func (m *MemoryStorage) GetUserTags(ctx context.Context, userID int) ([]*models.TagCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, n := range m.notes {
+		if n.UserID != userID || n.DeletedAt != nil {
+			continue
+		}
+		for _, tag := range n.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := []*models.TagCount{}
+	for name, count := range counts {
+		tags = append(tags, &models.TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// matchTags проверяет фильтр по тегам: and — есть все теги, or — есть хотя бы один
+func matchTags(noteTags, filter []string, mode string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+
+	found := 0
+	for _, want := range filter {
+		for _, tag := range noteTags {
+			if tag == want {
+				found++
+				break
+			}
+		}
+	}
+
+	if mode == "or" {
+		return found > 0
+	}
+	return found == len(filter)
+}
+
+// cloneNote копирует заметку, чтобы вызывающий код не менял данные хранилища
+func cloneNote(n *models.Note) *models.Note {
+	note := *n
+	note.NotebookID = copyID(n.NotebookID)
+	note.Tags = copyTags(n.Tags)
+	if n.DeletedAt != nil {
+		deletedAt := *n.DeletedAt
+		note.DeletedAt = &deletedAt
+	}
+	return &note
+}
+
+func copyTags(tags []string) []string {
+	result := make([]string, len(tags))
+	copy(result, tags)
+	return result
+}
+
+// Проверяем на этапе компиляции, что обе реализации удовлетворяют Store
+var (
+	_ Store = (*Storage)(nil)
+	_ Store = (*MemoryStorage)(nil)
+)
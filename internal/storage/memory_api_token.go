@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// memoryAPIToken - personal access токен вместе с хешем
+type memoryAPIToken struct {
+	hash  string
+	token *models.APIToken
+}
+
+// CreateAPIToken сохраняет personal access токен (только хеш)
+func (m *MemoryStorage) CreateAPIToken(ctx context.Context, userID int, name, tokenHash string, scopes []string, expiresAt *time.Time) (*models.APIToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, models.ErrUserNotFound
+	}
+
+	token := &models.APIToken{
+		ID:        m.nextAPITokenID,
+		UserID:    userID,
+		Name:      name,
+		Scopes:    copyTags(scopes),
+		CreatedAt: time.Now(),
+	}
+	if expiresAt != nil {
+		expires := *expiresAt
+		token.ExpiresAt = &expires
+	}
+	m.apiTokens[token.ID] = &memoryAPIToken{hash: tokenHash, token: token}
+	m.nextAPITokenID++
+
+	return cloneAPIToken(token), nil
+}
+
+// GetUserAPITokens возвращает токены пользователя, новые первыми
+func (m *MemoryStorage) GetUserAPITokens(ctx context.Context, userID int) ([]*models.APIToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tokens := []*models.APIToken{}
+	for _, t := range m.apiTokens {
+		if t.token.UserID == userID {
+			tokens = append(tokens, cloneAPIToken(t.token))
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID > tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// UseAPIToken находит действующий токен по хешу и отмечает время использования
+func (m *MemoryStorage) UseAPIToken(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, t := range m.apiTokens {
+		if t.hash == tokenHash && !t.token.Expired(now) {
+			t.token.LastUsedAt = &now
+			return cloneAPIToken(t.token), nil
+		}
+	}
+	return nil, models.ErrAPITokenNotFound
+}
+
+// DeleteAPIToken отзывает токен пользователя
+func (m *MemoryStorage) DeleteAPIToken(ctx context.Context, userID, tokenID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.apiTokens[tokenID]
+	if !ok || t.token.UserID != userID {
+		return models.ErrAPITokenNotFound
+	}
+	delete(m.apiTokens, tokenID)
+	return nil
+}
+
+func cloneAPIToken(t *models.APIToken) *models.APIToken {
+	token := *t
+	token.Scopes = copyTags(t.Scopes)
+	if t.ExpiresAt != nil {
+		expires := *t.ExpiresAt
+		token.ExpiresAt = &expires
+	}
+	if t.LastUsedAt != nil {
+		used := *t.LastUsedAt
+		token.LastUsedAt = &used
+	}
+	return &token
+}
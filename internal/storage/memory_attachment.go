@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// CreateAttachment сохраняет метаданные вложения с проверкой квоты (0 — без ограничения)
+func (m *MemoryStorage) CreateAttachment(ctx context.Context, attachment *models.Attachment, quota int64) (*models.Attachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[attachment.UserID]; !ok {
+		return nil, models.ErrUserNotFound
+	}
+
+	note, ok := m.notes[attachment.NoteID]
+	if !ok || note.UserID != attachment.UserID || note.DeletedAt != nil {
+		return nil, models.ErrNoteNotFound
+	}
+
+	if quota > 0 && m.attachmentUsage(attachment.UserID).Used+attachment.Size > quota {
+		return nil, models.ErrQuotaExceeded
+	}
+
+	created := *attachment
+	created.ID = m.nextAttachmentID
+	created.CreatedAt = time.Now()
+	m.attachments[created.ID] = &created
+	m.nextAttachmentID++
+
+	result := created
+	return &result, nil
+}
+
+// GetNoteAttachments возвращает вложения заметки в порядке загрузки
+func (m *MemoryStorage) GetNoteAttachments(ctx context.Context, noteID int) ([]*models.Attachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	attachments := []*models.Attachment{}
+	for _, a := range m.attachments {
+		if a.NoteID == noteID {
+			attachment := *a
+			attachments = append(attachments, &attachment)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].ID < attachments[j].ID
+	})
+	return attachments, nil
+}
+
+// GetAttachment возвращает вложение заметки по ID
+func (m *MemoryStorage) GetAttachment(ctx context.Context, noteID, attachmentID int) (*models.Attachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.attachments[attachmentID]
+	if !ok || a.NoteID != noteID {
+		return nil, models.ErrAttachmentNotFound
+	}
+	attachment := *a
+	return &attachment, nil
+}
+
+// DeleteAttachment удаляет метаданные вложения и ставит blob в очередь на удаление
+func (m *MemoryStorage) DeleteAttachment(ctx context.Context, noteID, attachmentID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.attachments[attachmentID]
+	if !ok || a.NoteID != noteID {
+		return models.ErrAttachmentNotFound
+	}
+	m.deleteAttachment(attachmentID)
+	return nil
+}
+
+// GetAttachmentUsage возвращает занятое вложениями пользователя место
+func (m *MemoryStorage) GetAttachmentUsage(ctx context.Context, userID int) (*models.AttachmentUsage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.attachmentUsage(userID), nil
+}
+
+// ClaimImageAttachments забирает в обработку до limit ожидающих (или зависших дольше staleBefore) изображений
+func (m *MemoryStorage) ClaimImageAttachments(ctx context.Context, limit int, now, staleBefore time.Time) ([]*models.Attachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := []int{}
+	for id, a := range m.attachments {
+		stale := a.ImageStatus == models.ImageStatusProcessing && m.imageClaims[id].Before(staleBefore)
+		if a.ImageStatus == models.ImageStatusPending || stale {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	attachments := []*models.Attachment{}
+	for _, id := range ids {
+		a := m.attachments[id]
+		a.ImageStatus = models.ImageStatusProcessing
+		m.imageClaims[id] = now
+		attachment := *a
+		attachments = append(attachments, &attachment)
+	}
+	return attachments, nil
+}
+
+// FinishImageAttachment сохраняет результат обработки изображения (status — ready или failed)
+func (m *MemoryStorage) FinishImageAttachment(ctx context.Context, attachmentID int, status string, result *models.ImageResult) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.attachments[attachmentID]
+	if !ok || a.ImageStatus != models.ImageStatusProcessing {
+		return models.ErrAttachmentNotFound
+	}
+
+	a.ImageStatus = status
+	a.Width = result.Width
+	a.Height = result.Height
+	a.ThumbnailKey = result.ThumbnailKey
+	a.ThumbnailContentType = result.ThumbnailContentType
+	a.ThumbnailSize = result.ThumbnailSize
+	if result.Size > 0 {
+		a.Size = result.Size
+	}
+	if result.SHA256 != "" {
+		a.SHA256 = result.SHA256
+	}
+	if result.StorageKey != "" && result.StorageKey != a.StorageKey {
+		m.queueOrphanedBlob(a.StorageKey)
+		a.StorageKey = result.StorageKey
+	}
+	delete(m.imageClaims, attachmentID)
+	return nil
+}
+
+// GetOrphanedBlobs возвращает до limit blob'ов из очереди на удаление
+func (m *MemoryStorage) GetOrphanedBlobs(ctx context.Context, limit int) ([]*models.OrphanedBlob, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blobs := []*models.OrphanedBlob{}
+	for _, b := range m.orphanedBlobs {
+		if len(blobs) == limit {
+			break
+		}
+		blob := *b
+		blobs = append(blobs, &blob)
+	}
+	return blobs, nil
+}
+
+// DeleteOrphanedBlobs убирает из очереди стёртые blob'ы
+func (m *MemoryStorage) DeleteOrphanedBlobs(ctx context.Context, ids []int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+
+	kept := m.orphanedBlobs[:0]
+	for _, b := range m.orphanedBlobs {
+		if !deleted[b.ID] {
+			kept = append(kept, b)
+		}
+	}
+	m.orphanedBlobs = kept
+	return nil
+}
+
+// deleteAttachment удаляет вложение и ставит его blob'ы в очередь (аналог триггера в Postgres)
+func (m *MemoryStorage) deleteAttachment(attachmentID int) {
+	a := m.attachments[attachmentID]
+	delete(m.attachments, attachmentID)
+	delete(m.imageClaims, attachmentID)
+
+	m.queueOrphanedBlob(a.StorageKey)
+	if a.ThumbnailKey != "" {
+		m.queueOrphanedBlob(a.ThumbnailKey)
+	}
+}
+
+func (m *MemoryStorage) queueOrphanedBlob(key string) {
+	m.orphanedBlobs = append(m.orphanedBlobs, &models.OrphanedBlob{ID: m.nextOrphanedBlobID, StorageKey: key})
+	m.nextOrphanedBlobID++
+}
+
+func (m *MemoryStorage) attachmentUsage(userID int) *models.AttachmentUsage {
+	usage := &models.AttachmentUsage{}
+	for _, a := range m.attachments {
+		if a.UserID == userID {
+			usage.Used += a.Size
+			usage.Count++
+		}
+	}
+	return usage
+}
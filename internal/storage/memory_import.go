@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// CreateImport ставит импорт архива в очередь фоновой задачи
+func (m *MemoryStorage) CreateImport(ctx context.Context, imp *models.Import) (*models.Import, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[imp.UserID]; !ok {
+		return nil, models.ErrUserNotFound
+	}
+
+	created := &models.Import{
+		ID:         m.nextImportID,
+		UserID:     imp.UserID,
+		Filename:   imp.Filename,
+		Status:     models.ImportStatusPending,
+		TotalFiles: imp.TotalFiles,
+		StorageKey: imp.StorageKey,
+		CreatedAt:  time.Now(),
+	}
+	m.imports[created.ID] = created
+	m.nextImportID++
+
+	return cloneImport(created, false), nil
+}
+
+// GetImport возвращает импорт пользователя вместе с отчётом об ошибках по файлам
+func (m *MemoryStorage) GetImport(ctx context.Context, userID, importID int) (*models.Import, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	imp, ok := m.imports[importID]
+	if !ok || imp.UserID != userID {
+		return nil, models.ErrImportNotFound
+	}
+	return cloneImport(imp, true), nil
+}
+
+// GetUserImports возвращает импорты пользователя, новые первыми (без отчётов об ошибках)
+func (m *MemoryStorage) GetUserImports(ctx context.Context, userID int) ([]*models.Import, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	imports := []*models.Import{}
+	for _, imp := range m.imports {
+		if imp.UserID == userID {
+			imports = append(imports, cloneImport(imp, false))
+		}
+	}
+	sort.Slice(imports, func(i, j int) bool { return imports[i].ID > imports[j].ID })
+	return imports, nil
+}
+
+// ClaimImports забирает в обработку до limit ожидающих (или без прогресса с staleBefore) импортов
+func (m *MemoryStorage) ClaimImports(ctx context.Context, limit int, now, staleBefore time.Time) ([]*models.Import, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := []int{}
+	for id, imp := range m.imports {
+		stale := imp.Status == models.ImportStatusProcessing && m.importClaims[id].Before(staleBefore)
+		if imp.Status == models.ImportStatusPending || stale {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	imports := []*models.Import{}
+	for _, id := range ids {
+		imp := m.imports[id]
+		imp.Status = models.ImportStatusProcessing
+		imp.Attempts++
+		m.importClaims[id] = now
+		imports = append(imports, cloneImport(imp, false))
+	}
+	return imports, nil
+}
+
+// SaveImportBatch создаёт заметки порции и недостающие блокноты и записывает ошибки и прогресс импорта.
+// Если импорт удалён или порцию уже записал другой обработчик — models.ErrImportNotFound
+func (m *MemoryStorage) SaveImportBatch(ctx context.Context, importID int, batch *models.ImportBatch) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	imp, ok := m.imports[importID]
+	if !ok || imp.Status != models.ImportStatusProcessing || imp.ProcessedFiles != batch.Offset {
+		return models.ErrImportNotFound
+	}
+
+	now := time.Now()
+	for _, n := range batch.Notes {
+		createdAt, updatedAt := now, now
+		if n.CreatedAt != nil {
+			createdAt, updatedAt = *n.CreatedAt, *n.CreatedAt
+		}
+		if n.UpdatedAt != nil {
+			updatedAt = *n.UpdatedAt
+		}
+
+		note := &models.Note{
+			ID:         m.nextNoteID,
+			UserID:     imp.UserID,
+			NotebookID: m.importNotebook(imp, n.Folders),
+			Title:      n.Title,
+			Content:    n.Content,
+			Format:     models.FormatMarkdown,
+			Tags:       copyTags(n.Tags),
+			Version:    1,
+			CreatedAt:  createdAt,
+			UpdatedAt:  updatedAt,
+		}
+		m.notes[note.ID] = note
+		m.nextNoteID++
+		m.addRevision(note)
+		m.setNoteLinks(note)
+	}
+
+	imp.Errors = append(imp.Errors, batch.Errors...)
+	imp.ProcessedFiles += batch.Files
+	imp.ImportedNotes += len(batch.Notes)
+	imp.FailedFiles += len(batch.Errors)
+	imp.Attempts = 0
+	m.importClaims[importID] = now
+	return nil
+}
+
+// FinishImport завершает импорт (status — completed или failed) и ставит архив в очередь на удаление
+func (m *MemoryStorage) FinishImport(ctx context.Context, importID int, status, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	imp, ok := m.imports[importID]
+	if !ok || imp.Status != models.ImportStatusProcessing {
+		return models.ErrImportNotFound
+	}
+
+	now := time.Now()
+	imp.Status = status
+	imp.Error = message
+	imp.FinishedAt = &now
+	if imp.StorageKey != "" {
+		m.queueOrphanedBlob(imp.StorageKey)
+		imp.StorageKey = ""
+	}
+	delete(m.importClaims, importID)
+	return nil
+}
+
+// importNotebook находит блокнот по пути папок архива, создавая недостающие (вызывается под m.mu)
+func (m *MemoryStorage) importNotebook(imp *models.Import, folders []string) *int {
+	var parentID *int
+	for _, name := range folders {
+		var found *models.Notebook
+		for _, nb := range m.notebooks {
+			if nb.UserID == imp.UserID && sameID(nb.ParentID, parentID) && nb.Name == name &&
+				(found == nil || nb.ID < found.ID) {
+				found = nb
+			}
+		}
+
+		if found == nil {
+			now := time.Now()
+			found = &models.Notebook{
+				ID:        m.nextNotebookID,
+				UserID:    imp.UserID,
+				ParentID:  copyID(parentID),
+				Name:      name,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			m.notebooks[found.ID] = found
+			m.nextNotebookID++
+			imp.CreatedNotebooks++
+		}
+
+		parentID = copyID(&found.ID)
+	}
+	return parentID
+}
+
+// deleteUserImports удаляет импорты пользователя, архивы незавершённых ставит в очередь (вызывается под m.mu)
+func (m *MemoryStorage) deleteUserImports(userID int) {
+	for id, imp := range m.imports {
+		if imp.UserID != userID {
+			continue
+		}
+		if imp.StorageKey != "" {
+			m.queueOrphanedBlob(imp.StorageKey)
+		}
+		delete(m.imports, id)
+		delete(m.importClaims, id)
+	}
+}
+
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// cloneImport копирует импорт; withErrors — вместе с отчётом об ошибках (как GetImport в Postgres)
+func cloneImport(imp *models.Import, withErrors bool) *models.Import {
+	clone := *imp
+	clone.Errors = nil
+	if withErrors && len(imp.Errors) > 0 {
+		clone.Errors = append([]models.ImportFileError(nil), imp.Errors...)
+	}
+	if imp.FinishedAt != nil {
+		finishedAt := *imp.FinishedAt
+		clone.FinishedAt = &finishedAt
+	}
+	return &clone
+}
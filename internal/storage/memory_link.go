@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/Balyshev/notes-api/pkg/wikilink"
+)
+
+// GetOutgoingLinks возвращает ссылки из заметки в порядке их появления в тексте
+func (m *MemoryStorage) GetOutgoingLinks(ctx context.Context, userID, noteID int) ([]*models.NoteLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	links := []*models.NoteLink{}
+	source, ok := m.notes[noteID]
+	if !ok || source.UserID != userID || source.DeletedAt != nil {
+		return links, nil
+	}
+	for _, target := range m.links[noteID] {
+		links = append(links, m.resolveLink(source, target))
+	}
+	return links, nil
+}
+
+// GetBacklinks возвращает ссылки других заметок пользователя (и самой заметки) на заметку noteID
+func (m *MemoryStorage) GetBacklinks(ctx context.Context, userID, noteID int) ([]*models.NoteLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.filterLinks(userID, func(link *models.NoteLink) bool {
+		return link.TargetID != nil && *link.TargetID == noteID
+	}), nil
+}
+
+// GetUnresolvedLinks возвращает ссылки из заметок пользователя, для которых не нашлось заметки
+func (m *MemoryStorage) GetUnresolvedLinks(ctx context.Context, userID int) ([]*models.NoteLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.filterLinks(userID, func(link *models.NoteLink) bool {
+		return link.TargetID == nil
+	}), nil
+}
+
+// GetLinkGraph возвращает граф ссылок пользователя: заметки вне корзины и ссылки между ними
+func (m *MemoryStorage) GetLinkGraph(ctx context.Context, userID int) (*models.LinkGraph, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	graph := &models.LinkGraph{Nodes: []*models.GraphNode{}, Edges: []*models.GraphEdge{}}
+	for _, note := range m.userLinkSources(userID) {
+		graph.Nodes = append(graph.Nodes, &models.GraphNode{ID: note.ID, Title: note.Title, NotebookID: copyID(note.NotebookID)})
+	}
+
+	seen := make(map[models.GraphEdge]bool)
+	for _, link := range m.filterLinks(userID, func(link *models.NoteLink) bool { return link.TargetID != nil }) {
+		edge := models.GraphEdge{Source: link.SourceID, Target: *link.TargetID}
+		if !seen[edge] {
+			seen[edge] = true
+			graph.Edges = append(graph.Edges, &edge)
+		}
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].Source != graph.Edges[j].Source {
+			return graph.Edges[i].Source < graph.Edges[j].Source
+		}
+		return graph.Edges[i].Target < graph.Edges[j].Target
+	})
+
+	return graph, nil
+}
+
+// filterLinks возвращает разрешённые ссылки из заметок пользователя, подходящие под keep (вызывается под m.mu)
+func (m *MemoryStorage) filterLinks(userID int, keep func(*models.NoteLink) bool) []*models.NoteLink {
+	links := []*models.NoteLink{}
+	for _, source := range m.userLinkSources(userID) {
+		for _, target := range m.links[source.ID] {
+			if link := m.resolveLink(source, target); keep(link) {
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// userLinkSources возвращает заметки пользователя вне корзины по возрастанию id (вызывается под m.mu)
+func (m *MemoryStorage) userLinkSources(userID int) []*models.Note {
+	var notes []*models.Note
+	for _, n := range m.notes {
+		if n.UserID == userID && n.DeletedAt == nil {
+			notes = append(notes, n)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].ID < notes[j].ID })
+	return notes
+}
+
+// resolveLink ищет цель ссылки так же, как Storage: [[Заголовок]] — самая старая заметка владельца
+// с таким заголовком без учёта регистра, note:42 — заметка 42 того же владельца (вызывается под m.mu)
+func (m *MemoryStorage) resolveLink(source *models.Note, target wikilink.Target) *models.NoteLink {
+	link := &models.NoteLink{SourceID: source.ID, SourceTitle: source.Title, Link: target.String()}
+
+	var found *models.Note
+	if target.NoteID > 0 {
+		if n, ok := m.notes[target.NoteID]; ok && n.UserID == source.UserID && n.DeletedAt == nil {
+			found = n
+		}
+	} else {
+		title := strings.ToLower(target.Title)
+		for _, n := range m.notes {
+			if n.UserID == source.UserID && n.DeletedAt == nil && strings.ToLower(n.Title) == title &&
+				(found == nil || n.ID < found.ID) {
+				found = n
+			}
+		}
+	}
+
+	if found != nil {
+		id, title := found.ID, found.Title
+		link.TargetID = &id
+		link.TargetTitle = &title
+	}
+	return link
+}
+
+// setNoteLinks заменяет ссылки заметки ссылками из её текущего содержимого (вызывается под m.mu)
+func (m *MemoryStorage) setNoteLinks(note *models.Note) {
+	targets := wikilink.Parse(note.Content)
+	if len(targets) == 0 {
+		delete(m.links, note.ID)
+		return
+	}
+	m.links[note.ID] = targets
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// GetLoginAttempts возвращает счётчик неудачных входов по ключу
+func (m *MemoryStorage) GetLoginAttempts(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.loginAttempts[key]
+	if !ok {
+		return &models.LoginAttempts{Key: key}, nil
+	}
+	attempts := *a
+	if a.LockedUntil != nil {
+		until := *a.LockedUntil
+		attempts.LockedUntil = &until
+	}
+	return &attempts, nil
+}
+
+// RecordLoginFailure увеличивает счётчик неудач, начиная заново после windowStart
+func (m *MemoryStorage) RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.loginAttempts[key]
+	if !ok {
+		a = &models.LoginAttempts{Key: key}
+		m.loginAttempts[key] = a
+	}
+	if a.LastFailureAt.Before(windowStart) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	return a.Failures, nil
+}
+
+// LockLogin блокирует вход по ключу до until
+func (m *MemoryStorage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a, ok := m.loginAttempts[key]; ok {
+		a.LockedUntil = &until
+	}
+	return nil
+}
+
+// ResetLoginAttempts сбрасывает счётчик после успешного входа
+func (m *MemoryStorage) ResetLoginAttempts(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.loginAttempts, key)
+	return nil
+}
+
+// CreateLockoutEvent записывает блокировку входа в журнал
+func (m *MemoryStorage) CreateLockoutEvent(ctx context.Context, event *models.LockoutEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	event.ID = len(m.lockoutEvents) + 1
+	event.CreatedAt = time.Now()
+	stored := *event
+	m.lockoutEvents = append(m.lockoutEvents, &stored)
+	return nil
+}
+
+// GetLockoutEvents возвращает журнал блокировок, новые первыми
+func (m *MemoryStorage) GetLockoutEvents(ctx context.Context, limit, offset int) ([]*models.LockoutEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := []*models.LockoutEvent{}
+	for i := len(m.lockoutEvents) - 1 - offset; i >= 0 && len(events) < limit; i-- {
+		event := *m.lockoutEvents[i]
+		events = append(events, &event)
+	}
+	return events, nil
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// CreateNotebook создаёт блокнот. parentID должен быть блокнотом того же пользователя
+func (m *MemoryStorage) CreateNotebook(ctx context.Context, userID int, parentID *int, name string) (*models.Notebook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, models.ErrUserNotFound
+	}
+	if parentID != nil {
+		if parent, ok := m.notebooks[*parentID]; !ok || parent.UserID != userID {
+			return nil, models.ErrNotebookNotFound
+		}
+	}
+
+	now := time.Now()
+	notebook := &models.Notebook{
+		ID:        m.nextNotebookID,
+		UserID:    userID,
+		ParentID:  copyID(parentID),
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.notebooks[notebook.ID] = notebook
+	m.nextNotebookID++
+
+	return cloneNotebook(notebook), nil
+}
+
+// GetNotebook получает блокнот по ID
+func (m *MemoryStorage) GetNotebook(ctx context.Context, notebookID int) (*models.Notebook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	nb, ok := m.notebooks[notebookID]
+	if !ok {
+		return nil, models.ErrNotebookNotFound
+	}
+	return cloneNotebook(nb), nil
+}
+
+// GetUserNotebooks возвращает все блокноты пользователя плоским списком
+func (m *MemoryStorage) GetUserNotebooks(ctx context.Context, userID int) ([]*models.Notebook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	notebooks := []*models.Notebook{}
+	for _, nb := range m.notebooks {
+		if nb.UserID == userID {
+			notebooks = append(notebooks, cloneNotebook(nb))
+		}
+	}
+	sort.Slice(notebooks, func(i, j int) bool {
+		if notebooks[i].Name != notebooks[j].Name {
+			return notebooks[i].Name < notebooks[j].Name
+		}
+		return notebooks[i].ID < notebooks[j].ID
+	})
+	return notebooks, nil
+}
+
+// RenameNotebook меняет имя блокнота
+func (m *MemoryStorage) RenameNotebook(ctx context.Context, notebookID int, name string) (*models.Notebook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nb, ok := m.notebooks[notebookID]
+	if !ok {
+		return nil, models.ErrNotebookNotFound
+	}
+	nb.Name = name
+	nb.UpdatedAt = time.Now()
+	return cloneNotebook(nb), nil
+}
+
+// MoveNotebook переносит блокнот со всем содержимым в parentID (nil — на верхний уровень)
+func (m *MemoryStorage) MoveNotebook(ctx context.Context, notebookID int, parentID *int) (*models.Notebook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nb, ok := m.notebooks[notebookID]
+	if !ok {
+		return nil, models.ErrNotebookNotFound
+	}
+	if parentID != nil {
+		if parent, ok := m.notebooks[*parentID]; !ok || parent.UserID != nb.UserID {
+			return nil, models.ErrNotebookNotFound
+		}
+		if m.notebookSubtree(notebookID)[*parentID] {
+			return nil, models.ErrNotebookCycle
+		}
+	}
+	nb.ParentID = copyID(parentID)
+	nb.UpdatedAt = time.Now()
+	return cloneNotebook(nb), nil
+}
+
+// DeleteNotebook удаляет блокнот; с cascade — вместе с вложенными блокнотами, их заметки уходят в корзину
+func (m *MemoryStorage) DeleteNotebook(ctx context.Context, notebookID int, cascade bool) (*models.DeleteNotebookResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.notebooks[notebookID]; !ok {
+		return nil, models.ErrNotebookNotFound
+	}
+
+	subtree := m.notebookSubtree(notebookID)
+	if !cascade {
+		if len(subtree) > 1 {
+			return nil, models.ErrNotebookNotEmpty
+		}
+		for _, n := range m.notes {
+			if n.DeletedAt == nil && n.NotebookID != nil && *n.NotebookID == notebookID {
+				return nil, models.ErrNotebookNotEmpty
+			}
+		}
+	}
+
+	result := &models.DeleteNotebookResponse{DeletedNotebooks: len(subtree)}
+	now := time.Now()
+	for _, n := range m.notes {
+		if n.NotebookID == nil || !subtree[*n.NotebookID] {
+			continue
+		}
+		// Как ON DELETE SET NULL в Postgres
+		n.NotebookID = nil
+		if n.DeletedAt == nil {
+			deletedAt := now
+			n.DeletedAt = &deletedAt
+			result.TrashedNotes++
+		}
+	}
+	for id := range subtree {
+		delete(m.notebooks, id)
+	}
+	return result, nil
+}
+
+// MoveNote переносит заметку в блокнот её владельца (nil — вне блокнотов)
+func (m *MemoryStorage) MoveNote(ctx context.Context, noteID int, notebookID *int, expectedVersion int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.notes[noteID]
+	if !ok || n.DeletedAt != nil {
+		return nil, models.ErrNoteNotFound
+	}
+	if notebookID != nil {
+		if nb, ok := m.notebooks[*notebookID]; !ok || nb.UserID != n.UserID {
+			return nil, models.ErrNotebookNotFound
+		}
+	}
+	if expectedVersion > 0 && n.Version != expectedVersion {
+		return nil, models.ErrVersionMismatch
+	}
+	n.NotebookID = copyID(notebookID)
+	n.Version++
+	n.UpdatedAt = time.Now()
+
+	return cloneNote(n), nil
+}
+
+// notebookSubtree возвращает id блокнота и всех вложенных в него блокнотов (вызывается под m.mu)
+func (m *MemoryStorage) notebookSubtree(notebookID int) map[int]bool {
+	subtree := map[int]bool{notebookID: true}
+	queue := []int{notebookID}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for id, nb := range m.notebooks {
+			if nb.ParentID != nil && *nb.ParentID == parent && !subtree[id] {
+				subtree[id] = true
+				queue = append(queue, id)
+			}
+		}
+	}
+	return subtree
+}
+
+func cloneNotebook(nb *models.Notebook) *models.Notebook {
+	notebook := *nb
+	notebook.ParentID = copyID(nb.ParentID)
+	return &notebook
+}
+
+func copyID(id *int) *int {
+	if id == nil {
+		return nil
+	}
+	value := *id
+	return &value
+}
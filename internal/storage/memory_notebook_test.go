@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestMemoryNotebookTree(t *testing.T) {
+	m := newMemoryWithUsers(t, 2)
+	ctx := t.Context()
+
+	root, err := m.CreateNotebook(ctx, 1, nil, "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, _ := m.CreateNotebook(ctx, 1, &root.ID, "child")
+	grandchild, _ := m.CreateNotebook(ctx, 1, &child.ID, "grandchild")
+	other, _ := m.CreateNotebook(ctx, 2, nil, "other")
+
+	if _, err := m.CreateNotebook(ctx, 1, &other.ID, "foreign parent"); err != models.ErrNotebookNotFound {
+		t.Errorf("create under foreign notebook: %v", err)
+	}
+
+	moves := []struct {
+		name     string
+		notebook int
+		parent   *int
+		want     error
+	}{
+		{"into itself", root.ID, &root.ID, models.ErrNotebookCycle},
+		{"into descendant", root.ID, &grandchild.ID, models.ErrNotebookCycle},
+		{"into foreign notebook", child.ID, &other.ID, models.ErrNotebookNotFound},
+		{"to top level", grandchild.ID, nil, nil},
+		{"back under root", grandchild.ID, &root.ID, nil},
+	}
+	for _, tt := range moves {
+		if _, err := m.MoveNotebook(ctx, tt.notebook, tt.parent); err != tt.want {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	nb, err := m.GetNotebook(ctx, grandchild.ID)
+	if err != nil || nb.ParentID == nil || *nb.ParentID != root.ID {
+		t.Errorf("moved notebook: %+v, %v", nb, err)
+	}
+	notebooks, _ := m.GetUserNotebooks(ctx, 1)
+	if len(notebooks) != 3 {
+		t.Errorf("GetUserNotebooks: %d notebooks", len(notebooks))
+	}
+}
+
+func TestMemoryDeleteNotebook(t *testing.T) {
+	m := newMemoryWithUsers(t, 1)
+	ctx := t.Context()
+
+	root, _ := m.CreateNotebook(ctx, 1, nil, "root")
+	child, _ := m.CreateNotebook(ctx, 1, &root.ID, "child")
+	empty, _ := m.CreateNotebook(ctx, 1, nil, "empty")
+	note := createNote(t, m, 1, "in child", "content")
+	unfiled := createNote(t, m, 1, "unfiled", "content")
+	if _, err := m.MoveNote(ctx, note.ID, &child.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.DeleteNotebook(ctx, root.ID, false); err != models.ErrNotebookNotEmpty {
+		t.Errorf("delete non-empty without cascade: %v", err)
+	}
+	if _, err := m.DeleteNotebook(ctx, child.ID, false); err != models.ErrNotebookNotEmpty {
+		t.Errorf("delete notebook with notes without cascade: %v", err)
+	}
+	if res, err := m.DeleteNotebook(ctx, empty.ID, false); err != nil || res.DeletedNotebooks != 1 {
+		t.Errorf("delete empty notebook: %+v, %v", res, err)
+	}
+
+	res, err := m.DeleteNotebook(ctx, root.ID, true)
+	if err != nil || res.DeletedNotebooks != 2 || res.TrashedNotes != 1 {
+		t.Fatalf("cascade delete: %+v, %v", res, err)
+	}
+	if _, err := m.GetNotebook(ctx, child.ID); err != models.ErrNotebookNotFound {
+		t.Errorf("child after cascade: %v", err)
+	}
+	trashed, err := m.GetTrashedNote(ctx, note.ID)
+	if err != nil || trashed.NotebookID != nil {
+		t.Errorf("note after cascade: %+v, %v", trashed, err)
+	}
+	if _, err := m.GetNoteByID(ctx, unfiled.ID); err != nil {
+		t.Errorf("unfiled note: %v", err)
+	}
+}
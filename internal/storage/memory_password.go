@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// memoryPasswordReset - токен сброса пароля в in-memory хранилище
+type memoryPasswordReset struct {
+	userID    int
+	expiresAt time.Time
+	used      bool
+}
+
+// UpdatePassword меняет хеш пароля пользователя и отзывает все его сессии и токены сброса
+func (m *MemoryStorage) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return models.ErrUserNotFound
+	}
+	u.PasswordHash = passwordHash
+	m.revokeCredentials(u.ID)
+	return nil
+}
+
+// DeleteUser удаляет пользователя вместе с заметками, сессиями и токенами
+func (m *MemoryStorage) DeleteUser(ctx context.Context, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return models.ErrUserNotFound
+	}
+	delete(m.users, userID)
+
+	for id, n := range m.notes {
+		if n.UserID == userID {
+			m.purgeNote(id)
+		}
+	}
+	for hash, t := range m.refreshTokens {
+		if s := m.sessions[t.sessionID]; s != nil && s.UserID == userID {
+			delete(m.refreshTokens, hash)
+		}
+	}
+	for id, s := range m.sessions {
+		if s.UserID == userID {
+			delete(m.sessions, id)
+		}
+	}
+	for id, t := range m.apiTokens {
+		if t.token.UserID == userID {
+			delete(m.apiTokens, id)
+		}
+	}
+	for hash, r := range m.passwordResets {
+		if r.userID == userID {
+			delete(m.passwordResets, hash)
+		}
+	}
+	delete(m.twoFactor, userID)
+	for _, shares := range m.shares {
+		delete(shares, userID)
+	}
+	for id, nb := range m.notebooks {
+		if nb.UserID == userID {
+			delete(m.notebooks, id)
+		}
+	}
+	m.deleteUserImports(userID)
+	return nil
+}
+
+// CreatePasswordReset сохраняет токен сброса пароля
+func (m *MemoryStorage) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return models.ErrUserNotFound
+	}
+	m.passwordResets[tokenHash] = &memoryPasswordReset{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+// ResetPassword по одноразовому токену меняет пароль и отзывает все сессии пользователя
+func (m *MemoryStorage) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reset, ok := m.passwordResets[tokenHash]
+	if !ok || reset.used || time.Now().After(reset.expiresAt) {
+		return 0, models.ErrInvalidResetToken
+	}
+	u, ok := m.users[reset.userID]
+	if !ok {
+		return 0, models.ErrInvalidResetToken
+	}
+	u.PasswordHash = passwordHash
+	m.revokeCredentials(u.ID)
+	return u.ID, nil
+}
+
+// revokeCredentials после смены пароля делает недействительными токены сброса и все сессии (вызывается под m.mu)
+func (m *MemoryStorage) revokeCredentials(userID int) {
+	now := time.Now()
+	for _, r := range m.passwordResets {
+		if r.userID == userID {
+			r.used = true
+		}
+	}
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			s.RevokedAt = &now
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestMemoryPasswordReset(t *testing.T) {
+	m := newMemoryWithUsers(t, 1)
+	hour := time.Now().Add(time.Hour)
+
+	if err := m.CreatePasswordReset(t.Context(), 1, "expired", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CreatePasswordReset(t.Context(), 1, "valid", hour); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		token string
+		err   error
+	}{
+		{"expired", models.ErrInvalidResetToken},
+		{"unknown", models.ErrInvalidResetToken},
+		{"valid", nil},
+		{"valid", models.ErrInvalidResetToken}, // токен одноразовый
+	}
+	for _, tt := range tests {
+		userID, err := m.ResetPassword(t.Context(), tt.token, "new-hash")
+		if err != tt.err || (err == nil && userID != 1) {
+			t.Errorf("ResetPassword(%s): %d, %v, want %v", tt.token, userID, err, tt.err)
+		}
+	}
+
+	user, _ := m.GetUserByID(t.Context(), 1)
+	if user.PasswordHash != "new-hash" {
+		t.Errorf("password hash %q", user.PasswordHash)
+	}
+}
+
+func TestMemoryDeleteUser(t *testing.T) {
+	m := newMemoryWithUsers(t, 2)
+	note := createNote(t, m, 1, "Title", "content")
+	other := createNote(t, m, 2, "Other", "content")
+
+	if err := m.DeleteUser(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetUserByID(t.Context(), 1); err != models.ErrUserNotFound {
+		t.Errorf("GetUserByID: %v", err)
+	}
+	if _, err := m.GetNoteByID(t.Context(), note.ID); err != models.ErrNoteNotFound {
+		t.Errorf("note of deleted user: %v", err)
+	}
+	if _, err := m.GetNoteByID(t.Context(), other.ID); err != nil {
+		t.Errorf("note of other user: %v", err)
+	}
+	if err := m.DeleteUser(t.Context(), 1); err != models.ErrUserNotFound {
+		t.Errorf("DeleteUser twice: %v", err)
+	}
+	if err := m.UpdatePassword(t.Context(), 1, "hash"); err != models.ErrUserNotFound {
+		t.Errorf("UpdatePassword of deleted user: %v", err)
+	}
+	// Имя освободилось
+	if _, err := m.CreateUser(t.Context(), "user1", "", "hash"); err != nil {
+		t.Errorf("CreateUser with freed name: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// MemoryRateLimits хранит корзины rate limit в памяти процесса. Лимит считается отдельно
+// в каждом экземпляре API, зато запрос не ждёт базу данных
+type MemoryRateLimits struct {
+	mu      sync.Mutex
+	buckets map[string]*models.RateLimitBucket
+}
+
+// NewMemoryRateLimits создаёт пустое хранилище корзин
+func NewMemoryRateLimits() *MemoryRateLimits {
+	return &MemoryRateLimits{
+		buckets: make(map[string]*models.RateLimitBucket),
+	}
+}
+
+// TakeRateLimitToken забирает токен из корзины key
+func (l *MemoryRateLimits) TakeRateLimitToken(ctx context.Context, key string, policy *models.RateLimitPolicy, now time.Time) (*models.RateLimitBucket, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &models.RateLimitBucket{}
+		l.buckets[key] = bucket
+	}
+	allowed := bucket.Take(policy, now)
+
+	state := *bucket
+	return &state, allowed, nil
+}
+
+// PurgeRateLimitBuckets удаляет корзины, не менявшиеся с before
+func (l *MemoryRateLimits) PurgeRateLimitBuckets(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	purged := 0
+	for key, bucket := range l.buckets {
+		if bucket.UpdatedAt.Before(before) {
+			delete(l.buckets, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// TakeRateLimitToken забирает токен из корзины key
+func (m *MemoryStorage) TakeRateLimitToken(ctx context.Context, key string, policy *models.RateLimitPolicy, now time.Time) (*models.RateLimitBucket, bool, error) {
+	return m.rateLimits.TakeRateLimitToken(ctx, key, policy, now)
+}
+
+// PurgeRateLimitBuckets удаляет корзины, не менявшиеся с before
+func (m *MemoryStorage) PurgeRateLimitBuckets(ctx context.Context, before time.Time) (int, error) {
+	return m.rateLimits.PurgeRateLimitBuckets(ctx, before)
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestMemoryRateLimits(t *testing.T) {
+	l := NewMemoryRateLimits()
+	ctx := t.Context()
+	policy := &models.RateLimitPolicy{Name: "api", Limit: 2, Period: time.Minute, Burst: 2}
+	now := time.Now()
+
+	for i := 1; i <= 2; i++ {
+		if _, allowed, err := l.TakeRateLimitToken(ctx, "ip:1", policy, now); err != nil || !allowed {
+			t.Fatalf("request %d: allowed %v, %v", i, allowed, err)
+		}
+	}
+	bucket, allowed, _ := l.TakeRateLimitToken(ctx, "ip:1", policy, now)
+	if allowed || bucket.Tokens >= 1 {
+		t.Fatalf("over the limit: allowed %v, tokens %v", allowed, bucket.Tokens)
+	}
+	// Корзины разных ключей независимы
+	if _, allowed, _ := l.TakeRateLimitToken(ctx, "ip:2", policy, now); !allowed {
+		t.Error("other key is limited")
+	}
+	// Через 30 секунд корзина пополняется на один токен
+	if _, allowed, _ := l.TakeRateLimitToken(ctx, "ip:1", policy, now.Add(30*time.Second)); !allowed {
+		t.Error("bucket is not refilled")
+	}
+
+	purged, err := l.PurgeRateLimitBuckets(ctx, now.Add(time.Second))
+	if err != nil || purged != 1 {
+		t.Fatalf("purge: %d, %v", purged, err)
+	}
+	if len(l.buckets) != 1 || l.buckets["ip:1"] == nil {
+		t.Errorf("buckets after purge: %v", l.buckets)
+	}
+}
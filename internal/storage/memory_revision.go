@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// GetNoteRevisions возвращает все ревизии заметки, новые первыми
+func (m *MemoryStorage) GetNoteRevisions(ctx context.Context, noteID int) ([]*models.NoteRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored := m.revisions[noteID]
+	revisions := make([]*models.NoteRevision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		rev := *stored[i]
+		revisions = append(revisions, &rev)
+	}
+	return revisions, nil
+}
+
+// GetNoteRevision получает одну ревизию заметки по номеру
+func (m *MemoryStorage) GetNoteRevision(ctx context.Context, noteID, revision int) (*models.NoteRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, r := range m.revisions[noteID] {
+		if r.Revision == revision {
+			rev := *r
+			return &rev, nil
+		}
+	}
+	return nil, models.ErrRevisionNotFound
+}
+
+// GetLatestRevision получает последнюю ревизию заметки вместе с кэшем HTML
+func (m *MemoryStorage) GetLatestRevision(ctx context.Context, noteID int) (*models.NoteRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revisions := m.revisions[noteID]
+	if len(revisions) == 0 {
+		return nil, models.ErrRevisionNotFound
+	}
+	rev := *revisions[len(revisions)-1]
+	return &rev, nil
+}
+
+// SaveRevisionHTML сохраняет HTML ревизии, отрисованный рендерером версии renderer
+func (m *MemoryStorage) SaveRevisionHTML(ctx context.Context, revisionID int, renderer, html string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, revisions := range m.revisions {
+		for _, r := range revisions {
+			if r.ID == revisionID {
+				r.HTML = html
+				r.HTMLRenderer = renderer
+				return nil
+			}
+		}
+	}
+	return models.ErrRevisionNotFound
+}
+
+// addRevision записывает текущее состояние заметки как новую ревизию (вызывается под m.mu)
+func (m *MemoryStorage) addRevision(note *models.Note) {
+	revisions := m.revisions[note.ID]
+	m.revisions[note.ID] = append(revisions, &models.NoteRevision{
+		ID:        m.nextRevisionID,
+		NoteID:    note.ID,
+		Revision:  len(revisions) + 1,
+		Title:     note.Title,
+		Content:   note.Content,
+		Format:    note.Format,
+		CreatedAt: time.Now(),
+	})
+	m.nextRevisionID++
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// SearchNotes ищет заметки пользователя по словам (упрощённый аналог tsquery)
+func (m *MemoryStorage) SearchNotes(ctx context.Context, userID int, q *models.SearchQuery, limit, offset int) ([]*models.NoteSearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var all []*models.NoteSearchResult
+	for _, n := range m.notes {
+		if n.UserID != userID || n.DeletedAt != nil {
+			continue
+		}
+		rank, ok := matchNote(n, q)
+		if !ok {
+			continue
+		}
+		all = append(all, &models.NoteSearchResult{
+			Note:           *cloneNote(n),
+			Rank:           rank,
+			TitleSnippet:   highlight(n.Title, q, 0),
+			ContentSnippet: highlight(n.Content, q, 30),
+		})
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Rank != all[j].Rank {
+			return all[i].Rank > all[j].Rank
+		}
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
+
+	var results []*models.NoteSearchResult
+	for i := offset; i < len(all) && len(results) < limit; i++ {
+		results = append(results, all[i])
+	}
+	return results, nil
+}
+
+// matchNote проверяет заметку на соответствие запросу и считает ранг
+// (совпадения в заголовке весят больше, как вес 'A' в tsvector)
+func matchNote(n *models.Note, q *models.SearchQuery) (float64, bool) {
+	titleWords := models.SearchWords(n.Title)
+	contentWords := models.SearchWords(n.Content)
+
+	rank := 0.0
+	for _, term := range q.Terms {
+		hits := countTerm(titleWords, term) + countTerm(contentWords, term)
+		if term.Negate {
+			if hits > 0 {
+				return 0, false
+			}
+			continue
+		}
+		if hits == 0 {
+			return 0, false
+		}
+		rank += float64(countTerm(titleWords, term)) + 0.4*float64(countTerm(contentWords, term))
+	}
+	return rank / (rank + 1), true
+}
+
+// countTerm считает вхождения слова или фразы в последовательность слов
+func countTerm(words []string, term models.SearchTerm) int {
+	count := 0
+	for i := 0; i+len(term.Words) <= len(words); i++ {
+		matched := true
+		for j, w := range term.Words {
+			last := j == len(term.Words)-1
+			if !wordMatches(words[i+j], w, term.Prefix && last) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			count++
+		}
+	}
+	return count
+}
+
+func wordMatches(word, pattern string, prefix bool) bool {
+	if prefix {
+		return strings.HasPrefix(word, pattern)
+	}
+	return word == pattern
+}
+
+// highlight экранирует текст как HTML и оборачивает найденные слова в <mark>.
+// Если maxWords > 0, возвращает фрагмент из maxWords слов вокруг первого совпадения
+func highlight(text string, q *models.SearchQuery, maxWords int) string {
+	type span struct{ start, end int }
+
+	runes := []rune(text)
+	var spans []span
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+			i++
+		}
+		spans = append(spans, span{start, i})
+	}
+
+	isHit := func(s span) bool {
+		word := strings.ToLower(string(runes[s.start:s.end]))
+		for _, term := range q.Terms {
+			if term.Negate {
+				continue
+			}
+			for j, w := range term.Words {
+				if wordMatches(word, w, term.Prefix && j == len(term.Words)-1) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	from, to := 0, len(spans)
+	if maxWords > 0 && len(spans) > maxWords {
+		first := 0
+		for i, s := range spans {
+			if isHit(s) {
+				first = i
+				break
+			}
+		}
+		from = first - maxWords/3
+		if from < 0 {
+			from = 0
+		}
+		to = from + maxWords
+		if to > len(spans) {
+			to = len(spans)
+			from = to - maxWords
+		}
+	}
+	if len(spans) == 0 {
+		return html.EscapeString(text)
+	}
+
+	textStart, textEnd := 0, len(runes)
+	if from > 0 {
+		textStart = spans[from].start
+	}
+	if to < len(spans) {
+		textEnd = spans[to-1].end
+	}
+
+	var b strings.Builder
+	pos := textStart
+	for _, s := range spans[from:to] {
+		b.WriteString(html.EscapeString(string(runes[pos:s.start])))
+		if isHit(s) {
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(string(runes[s.start:s.end])))
+			b.WriteString("</mark>")
+		} else {
+			b.WriteString(html.EscapeString(string(runes[s.start:s.end])))
+		}
+		pos = s.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:textEnd])))
+	return b.String()
+}
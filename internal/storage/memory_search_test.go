@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestMemorySearchNotes(t *testing.T) {
+	m := newMemoryWithUsers(t, 2)
+	notes := []struct {
+		user           int
+		title, content string
+	}{
+		{1, "Shopping list", "milk, bread and eggs"},
+		{1, "Plan", "buy milk tomorrow"},
+		{1, "Programming", "go programs and programmers"},
+		{1, "Draft plan", "draft of the shopping plan"},
+		{2, "Shopping list", "milk"},
+	}
+	for _, n := range notes {
+		createNote(t, m, n.user, n.title, n.content)
+	}
+
+	tests := []struct {
+		query string
+		want  []string // заголовки в порядке ранга
+	}{
+		{"milk", []string{"Plan", "Shopping list"}}, // одинаковый ранг — сначала новые
+		{"shopping", []string{"Shopping list", "Draft plan"}},
+		{`"shopping list"`, []string{"Shopping list"}},
+		{`"list shopping"`, nil},
+		{"program*", []string{"Programming"}},
+		{"program", nil},
+		{"plan -draft", []string{"Plan"}},
+		{"milk tomorrow", []string{"Plan"}},
+	}
+	for _, tt := range tests {
+		q, err := models.ParseSearchQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := m.SearchNotes(t.Context(), 1, q, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range results {
+			if r.UserID != 1 {
+				t.Errorf("%q: found a note of user %d", tt.query, r.UserID)
+			}
+			got = append(got, r.Title)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: %q, want %q", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: %q, want %q", tt.query, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text, query string
+		maxWords    int
+		want        string
+	}{
+		{"Shopping list", "shopping", 0, "<mark>Shopping</mark> list"},
+		{"go programs, programmers!", "program*", 0, "go <mark>programs</mark>, <mark>programmers</mark>!"},
+		{"plan without draft", "plan -draft", 0, "<mark>plan</mark> without draft"},
+		{"nothing here", "milk", 0, "nothing here"},
+		{"one two three four five six seven eight nine", "eight", 3, "seven <mark>eight</mark> nine"},
+		{"one two three four five six", "one", 2, "<mark>one</mark> two"},
+		{"", "milk", 0, ""},
+	}
+	for _, tt := range tests {
+		q, err := models.ParseSearchQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := highlight(tt.text, q, tt.maxWords); got != tt.want {
+			t.Errorf("highlight(%q, %q, %d) = %q, want %q", tt.text, tt.query, tt.maxWords, got, tt.want)
+		}
+	}
+}
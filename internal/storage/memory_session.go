@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// memoryRefreshToken - refresh токен в in-memory хранилище
+type memoryRefreshToken struct {
+	sessionID int
+	expiresAt time.Time
+	used      bool
+}
+
+// CreateSession создаёт сессию входа с первым refresh токеном
+func (m *MemoryStorage) CreateSession(ctx context.Context, userID int, refreshHash string, expiresAt time.Time) (*models.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session := &models.Session{
+		ID:        m.nextSessionID,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+	m.sessions[session.ID] = session
+	m.nextSessionID++
+	m.refreshTokens[refreshHash] = &memoryRefreshToken{sessionID: session.ID, expiresAt: expiresAt}
+
+	created := *session
+	return &created, nil
+}
+
+// RotateRefreshToken обменивает refresh токен на новый; повторное использование отзывает сессию
+func (m *MemoryStorage) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.refreshTokens[oldHash]
+	if !ok {
+		return nil, models.ErrInvalidRefreshToken
+	}
+	session := m.sessions[token.sessionID]
+	if session == nil || session.RevokedAt != nil {
+		return nil, models.ErrInvalidRefreshToken
+	}
+	if token.used {
+		now := time.Now()
+		session.RevokedAt = &now
+		return nil, models.ErrRefreshTokenReused
+	}
+	if time.Now().After(token.expiresAt) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	token.used = true
+	m.refreshTokens[newHash] = &memoryRefreshToken{sessionID: session.ID, expiresAt: expiresAt}
+
+	rotated := *session
+	return &rotated, nil
+}
+
+// IsSessionActive проверяет, что сессия существует и не отозвана
+func (m *MemoryStorage) IsSessionActive(ctx context.Context, sessionID int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[sessionID]
+	return ok && session.RevokedAt == nil, nil
+}
+
+// RevokeSession отзывает сессию (logout)
+func (m *MemoryStorage) RevokeSession(ctx context.Context, sessionID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[sessionID]
+	if !ok || session.RevokedAt != nil {
+		return models.ErrSessionNotFound
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	return nil
+}
+
+// RevokeUserSessions отзывает все сессии пользователя (logout everywhere)
+func (m *MemoryStorage) RevokeUserSessions(ctx context.Context, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, session := range m.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// ShareNote выдаёт пользователю доступ к заметке или меняет уже выданное право
+func (m *MemoryStorage) ShareNote(ctx context.Context, noteID, userID int, permission string) (*models.NoteShare, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.notes[noteID]; !ok {
+		return nil, models.ErrNoteNotFound
+	}
+	if _, ok := m.users[userID]; !ok {
+		return nil, models.ErrUserNotFound
+	}
+
+	shares := m.shares[noteID]
+	if shares == nil {
+		shares = make(map[int]*models.NoteShare)
+		m.shares[noteID] = shares
+	}
+	share, ok := shares[userID]
+	if !ok {
+		share = &models.NoteShare{NoteID: noteID, UserID: userID, CreatedAt: time.Now()}
+		shares[userID] = share
+	}
+	share.Permission = permission
+
+	return m.cloneNoteShare(share), nil
+}
+
+// GetNoteShares возвращает всех, с кем поделились заметкой, в порядке выдачи доступа
+func (m *MemoryStorage) GetNoteShares(ctx context.Context, noteID int) ([]*models.NoteShare, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	shares := []*models.NoteShare{}
+	for _, share := range m.shares[noteID] {
+		shares = append(shares, m.cloneNoteShare(share))
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if !shares[i].CreatedAt.Equal(shares[j].CreatedAt) {
+			return shares[i].CreatedAt.Before(shares[j].CreatedAt)
+		}
+		return shares[i].UserID < shares[j].UserID
+	})
+	return shares, nil
+}
+
+// GetNotePermission возвращает право пользователя на чужую заметку
+func (m *MemoryStorage) GetNotePermission(ctx context.Context, noteID, userID int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	share, ok := m.shares[noteID][userID]
+	if !ok {
+		return "", models.ErrShareNotFound
+	}
+	return share.Permission, nil
+}
+
+// DeleteNoteShare отзывает доступ пользователя к заметке
+func (m *MemoryStorage) DeleteNoteShare(ctx context.Context, noteID, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.shares[noteID][userID]; !ok {
+		return models.ErrShareNotFound
+	}
+	delete(m.shares[noteID], userID)
+	return nil
+}
+
+// GetSharedNotes возвращает заметки, которыми поделились с пользователем, новые доступы первыми
+func (m *MemoryStorage) GetSharedNotes(ctx context.Context, userID, limit, offset int) ([]*models.SharedNote, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var all []*models.SharedNote
+	for noteID, shares := range m.shares {
+		share, ok := shares[userID]
+		if !ok {
+			continue
+		}
+		n, ok := m.notes[noteID]
+		if !ok || n.DeletedAt != nil {
+			continue
+		}
+		item := &models.SharedNote{
+			Note:       cloneNote(n),
+			Permission: share.Permission,
+			SharedAt:   share.CreatedAt,
+		}
+		if owner, ok := m.users[n.UserID]; ok {
+			item.OwnerUsername = owner.Username
+		}
+		all = append(all, item)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].SharedAt.Equal(all[j].SharedAt) {
+			return all[i].SharedAt.After(all[j].SharedAt)
+		}
+		return all[i].ID > all[j].ID
+	})
+
+	shared := []*models.SharedNote{}
+	for i := offset; i < len(all) && len(shared) < limit; i++ {
+		shared = append(shared, all[i])
+	}
+	return shared, nil
+}
+
+// cloneNoteShare копирует доступ и подставляет имя пользователя (вызывается под m.mu)
+func (m *MemoryStorage) cloneNoteShare(s *models.NoteShare) *models.NoteShare {
+	share := *s
+	if u, ok := m.users[s.UserID]; ok {
+		share.Username = u.Username
+	}
+	return &share
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// memoryShareLink - публичная ссылка вместе с хешем токена
+type memoryShareLink struct {
+	hash string
+	link *models.ShareLink
+}
+
+// CreateShareLink сохраняет публичную ссылку (только хеш токена)
+func (m *MemoryStorage) CreateShareLink(ctx context.Context, noteID, userID int, tokenHash, passwordHash string, expiresAt *time.Time) (*models.ShareLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.notes[noteID]; !ok {
+		return nil, models.ErrNoteNotFound
+	}
+
+	link := &models.ShareLink{
+		ID:           m.nextShareLinkID,
+		NoteID:       noteID,
+		UserID:       userID,
+		PasswordHash: passwordHash,
+		HasPassword:  passwordHash != "",
+		CreatedAt:    time.Now(),
+	}
+	if expiresAt != nil {
+		expires := *expiresAt
+		link.ExpiresAt = &expires
+	}
+	m.shareLinks[link.ID] = &memoryShareLink{hash: tokenHash, link: link}
+	m.nextShareLinkID++
+
+	return cloneShareLink(link), nil
+}
+
+// GetNoteShareLinks возвращает ссылки на заметку (включая истёкшие), новые первыми
+func (m *MemoryStorage) GetNoteShareLinks(ctx context.Context, noteID int) ([]*models.ShareLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	links := []*models.ShareLink{}
+	for _, l := range m.shareLinks {
+		if l.link.NoteID == noteID {
+			links = append(links, cloneShareLink(l.link))
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ID > links[j].ID
+	})
+	return links, nil
+}
+
+// GetShareLink находит действующую ссылку по хешу токена
+func (m *MemoryStorage) GetShareLink(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	for _, l := range m.shareLinks {
+		if l.hash == tokenHash && !l.link.Expired(now) {
+			return cloneShareLink(l.link), nil
+		}
+	}
+	return nil, models.ErrShareLinkNotFound
+}
+
+// DeleteShareLink отзывает ссылку на заметку
+func (m *MemoryStorage) DeleteShareLink(ctx context.Context, noteID, linkID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.shareLinks[linkID]
+	if !ok || l.link.NoteID != noteID {
+		return models.ErrShareLinkNotFound
+	}
+	delete(m.shareLinks, linkID)
+	return nil
+}
+
+func cloneShareLink(l *models.ShareLink) *models.ShareLink {
+	link := *l
+	if l.ExpiresAt != nil {
+		expires := *l.ExpiresAt
+		link.ExpiresAt = &expires
+	}
+	return &link
+}
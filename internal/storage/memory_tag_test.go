@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestMemoryTags(t *testing.T) {
+	m := newMemoryWithUsers(t, 2)
+	for _, n := range []struct {
+		user  int
+		title string
+		tags  []string
+	}{
+		{1, "a", []string{"go", "work"}},
+		{1, "b", []string{"go"}},
+		{1, "c", []string{"home"}},
+		{1, "d", nil},
+		{2, "e", []string{"go", "other"}},
+	} {
+		if _, err := m.CreateNote(t.Context(), n.user, nil, n.title, "content", "plain", n.tags); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tags, err := m.GetUserTags(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ""
+	for _, tag := range tags {
+		got += fmt.Sprintf("%s:%d ", tag.Name, tag.Count)
+	}
+	// Сначала по количеству, при равенстве — по имени
+	if want := "go:2 home:1 work:1 "; got != want {
+		t.Errorf("GetUserTags: %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		tags []string
+		mode string
+		want string
+	}{
+		{nil, "and", "abcd"},
+		{[]string{"go"}, "and", "ab"},
+		{[]string{"go", "work"}, "and", "a"},
+		{[]string{"go", "home"}, "and", ""},
+		{[]string{"work", "home"}, "or", "ac"},
+		{[]string{"other"}, "or", ""},
+	}
+	for _, tt := range tests {
+		notes, err := m.GetUserNotes(t.Context(), 1, models.NoteListOptions{
+			Limit: 10, Sort: []models.SortKey{{Field: models.SortCreatedAt}}, Tags: tt.tags, TagMode: tt.mode,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		for _, n := range notes {
+			got += n.Title
+		}
+		if got != tt.want {
+			t.Errorf("tags=%v mode=%s: %q, want %q", tt.tags, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryUpdateNoteTags(t *testing.T) {
+	m := newMemoryWithUsers(t, 1)
+	note, err := m.CreateNote(t.Context(), 1, nil, "Title", "content", "plain", []string{"go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nil — теги не меняются
+	updated, err := m.UpdateNote(t.Context(), note.ID, "Title", "content", "", nil, 0)
+	if err != nil || len(updated.Tags) != 1 {
+		t.Fatalf("UpdateNote with nil tags: %v, %v", updated.Tags, err)
+	}
+	// [] — теги удаляются
+	updated, err = m.UpdateNote(t.Context(), note.ID, "Title", "content", "", []string{}, 0)
+	if err != nil || len(updated.Tags) != 0 {
+		t.Fatalf("UpdateNote with empty tags: %v, %v", updated.Tags, err)
+	}
+}
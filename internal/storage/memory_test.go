@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestMemoryUsers(t *testing.T) {
+	m := NewMemory()
+
+	user, err := m.CreateUser(t.Context(), "alice", "", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.PasswordHash != "" {
+		t.Error("CreateUser returned the password hash")
+	}
+	if _, err := m.CreateUser(t.Context(), "alice", "", "hash"); err != models.ErrUsernameExists {
+		t.Errorf("duplicate username: %v", err)
+	}
+
+	got, err := m.GetUserByUsername(t.Context(), "alice")
+	if err != nil || got.ID != user.ID || got.PasswordHash != "hash" {
+		t.Errorf("GetUserByUsername: %+v, %v", got, err)
+	}
+	if _, err := m.GetUserByUsername(t.Context(), "bob"); err != models.ErrUserNotFound {
+		t.Errorf("unknown username: %v", err)
+	}
+	if _, err := m.GetUserByID(t.Context(), user.ID+1); err != models.ErrUserNotFound {
+		t.Errorf("unknown id: %v", err)
+	}
+}
+
+// newMemoryWithUsers создаёт хранилище с пользователями id 1..n
+func newMemoryWithUsers(t *testing.T, n int) *MemoryStorage {
+	t.Helper()
+	m := NewMemory()
+	for i := 1; i <= n; i++ {
+		if _, err := m.CreateUser(t.Context(), "user"+strconv.Itoa(i), "", "hash"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+// createNote создаёт заметку и останавливает тест при ошибке
+func createNote(t *testing.T, m *MemoryStorage, userID int, title, content string) *models.Note {
+	t.Helper()
+	note, err := m.CreateNote(t.Context(), userID, nil, title, content, "plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return note
+}
+
+func TestMemoryNotes(t *testing.T) {
+	m := newMemoryWithUsers(t, 2)
+
+	note := createNote(t, m, 1, "Title", "content")
+	createNote(t, m, 2, "Other", "content")
+
+	// Изменение возвращённой копии не меняет хранилище
+	note.Title = "Changed"
+	got, err := m.GetNoteByID(t.Context(), note.ID)
+	if err != nil || got.Title != "Title" {
+		t.Fatalf("GetNoteByID: %+v, %v", got, err)
+	}
+
+	notes, err := m.GetUserNotes(t.Context(), 1, models.NoteListOptions{Limit: 10, Sort: models.DefaultNoteSort})
+	if err != nil || len(notes) != 1 || notes[0].ID != note.ID {
+		t.Fatalf("GetUserNotes: %v, %v", notes, err)
+	}
+
+	updated, err := m.UpdateNote(t.Context(), note.ID, "New", "changed", "", nil, 0)
+	if err != nil || updated.Title != "New" || updated.Content != "changed" {
+		t.Fatalf("UpdateNote: %+v, %v", updated, err)
+	}
+
+	if err := m.DeleteNote(t.Context(), note.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetNoteByID(t.Context(), note.ID); err != models.ErrNoteNotFound {
+		t.Errorf("GetNoteByID after delete: %v", err)
+	}
+	if _, err := m.UpdateNote(t.Context(), note.ID, "T", "c", "", nil, 0); err != models.ErrNoteNotFound {
+		t.Errorf("UpdateNote after delete: %v", err)
+	}
+	if err := m.DeleteNote(t.Context(), note.ID, 0); err != models.ErrNoteNotFound {
+		t.Errorf("DeleteNote twice: %v", err)
+	}
+}
+
+func TestMemoryGetUserNotesOrder(t *testing.T) {
+	m := newMemoryWithUsers(t, 1)
+	for _, title := range []string{"a", "b", "c", "d"} {
+		createNote(t, m, 1, title, "content")
+	}
+
+	tests := []struct {
+		sort          string
+		limit, offset int
+		want          string
+	}{
+		{"asc", 10, 0, "abcd"},
+		{"desc", 10, 0, "dcba"},
+		{"asc", 2, 1, "bc"},
+		{"desc", 2, 3, "a"},
+		{"asc", 2, 10, ""},
+		{"-title", 10, 0, "dcba"},
+		{"title:asc,created_at:desc", 2, 0, "ab"},
+	}
+	for _, tt := range tests {
+		sort, err := models.ParseNoteSort(tt.sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		notes, err := m.GetUserNotes(t.Context(), 1, models.NoteListOptions{Limit: tt.limit, Offset: tt.offset, Sort: sort})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		for _, n := range notes {
+			got += n.Title
+		}
+		if got != tt.want {
+			t.Errorf("sort=%s limit=%d offset=%d: %q, want %q", tt.sort, tt.limit, tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryNoteVersion(t *testing.T) {
+	m := newMemoryWithUsers(t, 1)
+	note := createNote(t, m, 1, "Title", "content")
+	if note.Version != 1 {
+		t.Fatalf("new note version %d", note.Version)
+	}
+
+	tests := []struct {
+		expected int
+		err      error
+		version  int
+	}{
+		{0, nil, 2}, // 0 — без проверки версии
+		{2, nil, 3},
+		{2, models.ErrVersionMismatch, 3},
+		{4, models.ErrVersionMismatch, 3},
+	}
+	for _, tt := range tests {
+		updated, err := m.UpdateNote(t.Context(), note.ID, "Title", "content", "", nil, tt.expected)
+		if err != tt.err {
+			t.Fatalf("expected version %d: %v, want %v", tt.expected, err, tt.err)
+		}
+		current, _ := m.GetNoteByID(t.Context(), note.ID)
+		if current.Version != tt.version || (err == nil && updated.Version != tt.version) {
+			t.Errorf("expected version %d: version %d, want %d", tt.expected, current.Version, tt.version)
+		}
+	}
+
+	if err := m.DeleteNote(t.Context(), note.ID, 1); err != models.ErrVersionMismatch {
+		t.Errorf("DeleteNote with stale version: %v", err)
+	}
+	if err := m.DeleteNote(t.Context(), note.ID, 3); err != nil {
+		t.Errorf("DeleteNote with current version: %v", err)
+	}
+}
+
+func TestMemoryCanceledContext(t *testing.T) {
+	m := newMemoryWithUsers(t, 1)
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, err := m.CreateNote(ctx, 1, nil, "Title", "content", "plain", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("CreateNote: %v", err)
+	}
+	if _, err := m.GetUserNotes(ctx, 1, models.NoteListOptions{Limit: 10, Sort: models.DefaultNoteSort}); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetUserNotes: %v", err)
+	}
+	if notes, _ := m.GetUserNotes(t.Context(), 1, models.NoteListOptions{Limit: 10, Sort: models.DefaultNoteSort}); len(notes) != 0 {
+		t.Errorf("note created with canceled context: %v", notes)
+	}
+}
+
+func TestCtxError(t *testing.T) {
+	driverErr := errors.New("pq: canceling statement due to user request")
+
+	expired, cancel := context.WithTimeout(t.Context(), -1)
+	defer cancel()
+	canceled, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want error
+	}{
+		{"live context", t.Context(), driverErr, driverErr},
+		{"deadline", expired, driverErr, context.DeadlineExceeded},
+		{"canceled", canceled, driverErr, context.Canceled},
+	}
+	for _, tt := range tests {
+		if got := ctxError(tt.ctx, tt.err); got != tt.want {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// GetTrashedNotes возвращает заметки пользователя из корзины, недавно удалённые первыми
+func (m *MemoryStorage) GetTrashedNotes(ctx context.Context, userID, limit, offset int) ([]*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var all []*models.Note
+	for _, n := range m.notes {
+		if n.UserID == userID && n.DeletedAt != nil {
+			all = append(all, cloneNote(n))
+		}
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].DeletedAt.After(*all[j].DeletedAt)
+	})
+
+	notes := []*models.Note{}
+	for i := offset; i < len(all) && len(notes) < limit; i++ {
+		notes = append(notes, all[i])
+	}
+	return notes, nil
+}
+
+// GetTrashedNote получает заметку из корзины по ID
+func (m *MemoryStorage) GetTrashedNote(ctx context.Context, noteID int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n, ok := m.notes[noteID]
+	if !ok || n.DeletedAt == nil {
+		return nil, models.ErrNoteNotFound
+	}
+	return cloneNote(n), nil
+}
+
+// RestoreNote возвращает заметку из корзины
+func (m *MemoryStorage) RestoreNote(ctx context.Context, noteID int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.notes[noteID]
+	if !ok || n.DeletedAt == nil {
+		return nil, models.ErrNoteNotFound
+	}
+	n.DeletedAt = nil
+	return cloneNote(n), nil
+}
+
+// PurgeNote окончательно удаляет заметку из корзины
+func (m *MemoryStorage) PurgeNote(ctx context.Context, noteID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.notes[noteID]
+	if !ok || n.DeletedAt == nil {
+		return models.ErrNoteNotFound
+	}
+	m.purgeNote(noteID)
+	return nil
+}
+
+// PurgeDeletedNotes окончательно удаляет заметки, попавшие в корзину раньше before
+func (m *MemoryStorage) PurgeDeletedNotes(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	for id, n := range m.notes {
+		if n.DeletedAt != nil && n.DeletedAt.Before(before) {
+			m.purgeNote(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// purgeNote удаляет заметку и всё, что с ней связано (вызывается под m.mu)
+func (m *MemoryStorage) purgeNote(noteID int) {
+	delete(m.notes, noteID)
+	delete(m.revisions, noteID)
+	delete(m.links, noteID)
+	delete(m.shares, noteID)
+	for id, l := range m.shareLinks {
+		if l.link.NoteID == noteID {
+			delete(m.shareLinks, id)
+		}
+	}
+	for id, a := range m.attachments {
+		if a.NoteID == noteID {
+			m.deleteAttachment(id)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+func TestMemoryTrash(t *testing.T) {
+	m := newMemoryWithUsers(t, 1)
+	kept := createNote(t, m, 1, "kept", "content")
+	note := createNote(t, m, 1, "trashed", "content")
+
+	if err := m.DeleteNote(t.Context(), note.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Удалённая заметка видна только в корзине
+	notes, _ := m.GetUserNotes(t.Context(), 1, models.NoteListOptions{Limit: 10})
+	if len(notes) != 1 || notes[0].ID != kept.ID {
+		t.Errorf("GetUserNotes: %v", notes)
+	}
+	trash, err := m.GetTrashedNotes(t.Context(), 1, 10, 0)
+	if err != nil || len(trash) != 1 || trash[0].ID != note.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("GetTrashedNotes: %v, %v", trash, err)
+	}
+	if _, err := m.GetTrashedNote(t.Context(), kept.ID); err != models.ErrNoteNotFound {
+		t.Errorf("GetTrashedNote for a live note: %v", err)
+	}
+
+	restored, err := m.RestoreNote(t.Context(), note.ID)
+	if err != nil || restored.DeletedAt != nil {
+		t.Fatalf("RestoreNote: %+v, %v", restored, err)
+	}
+	if _, err := m.RestoreNote(t.Context(), note.ID); err != models.ErrNoteNotFound {
+		t.Errorf("RestoreNote twice: %v", err)
+	}
+	if err := m.PurgeNote(t.Context(), note.ID); err != models.ErrNoteNotFound {
+		t.Errorf("PurgeNote for a live note: %v", err)
+	}
+
+	m.DeleteNote(t.Context(), note.ID, 0)
+	if err := m.PurgeNote(t.Context(), note.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetTrashedNote(t.Context(), note.ID); err != models.ErrNoteNotFound {
+		t.Errorf("GetTrashedNote after purge: %v", err)
+	}
+}
+
+func TestMemoryPurgeDeletedNotes(t *testing.T) {
+	m := newMemoryWithUsers(t, 1)
+	live := createNote(t, m, 1, "live", "content")
+	old := createNote(t, m, 1, "old", "content")
+	m.DeleteNote(t.Context(), old.ID, 0)
+
+	// Срок ещё не вышел
+	purged, err := m.PurgeDeletedNotes(t.Context(), time.Now().Add(-time.Hour))
+	if err != nil || purged != 0 {
+		t.Fatalf("PurgeDeletedNotes before retention: %d, %v", purged, err)
+	}
+
+	purged, err = m.PurgeDeletedNotes(t.Context(), time.Now().Add(time.Second))
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeDeletedNotes: %d, %v", purged, err)
+	}
+	if _, err := m.GetTrashedNote(t.Context(), old.ID); err != models.ErrNoteNotFound {
+		t.Errorf("purged note: %v", err)
+	}
+	if _, err := m.GetNoteByID(t.Context(), live.ID); err != nil {
+		t.Errorf("live note: %v", err)
+	}
+}
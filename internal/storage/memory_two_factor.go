@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// memoryTwoFactor - состояние TOTP пользователя в in-memory хранилище
+type memoryTwoFactor struct {
+	models.TwoFactor
+	recoveryCodes map[string]bool // хеш -> уже использован
+}
+
+// GetTwoFactor возвращает состояние TOTP пользователя
+func (m *MemoryStorage) GetTwoFactor(ctx context.Context, userID int) (*models.TwoFactor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, models.ErrUserNotFound
+	}
+	tf := &models.TwoFactor{}
+	if state, ok := m.twoFactor[userID]; ok {
+		*tf = state.TwoFactor
+	}
+	return tf, nil
+}
+
+// SetTOTPSecret сохраняет секрет до подтверждения; включённую 2FA не трогает
+func (m *MemoryStorage) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return models.ErrUserNotFound
+	}
+	if state, ok := m.twoFactor[userID]; ok && state.Enabled {
+		return models.ErrTwoFactorAlreadyEnabled
+	}
+	m.twoFactor[userID] = &memoryTwoFactor{TwoFactor: models.TwoFactor{Secret: secret}}
+	return nil
+}
+
+// EnableTwoFactor включает 2FA и заменяет коды восстановления
+func (m *MemoryStorage) EnableTwoFactor(ctx context.Context, userID int, counter int64, recoveryHashes []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.twoFactor[userID]
+	if !ok || state.Enabled {
+		return models.ErrTwoFactorAlreadyEnabled
+	}
+	state.Enabled = true
+	state.LastCounter = counter
+	state.recoveryCodes = make(map[string]bool)
+	for _, hash := range recoveryHashes {
+		state.recoveryCodes[hash] = false
+	}
+	return nil
+}
+
+// DisableTwoFactor отключает 2FA, удаляет секрет и коды восстановления
+func (m *MemoryStorage) DisableTwoFactor(ctx context.Context, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.twoFactor, userID)
+	return nil
+}
+
+// UseTOTPCounter запоминает принятый интервал TOTP, повторный код отклоняется
+func (m *MemoryStorage) UseTOTPCounter(ctx context.Context, userID int, counter int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.twoFactor[userID]
+	if !ok || state.LastCounter >= counter {
+		return models.ErrTwoFactorCodeReused
+	}
+	state.LastCounter = counter
+	return nil
+}
+
+// UseRecoveryCode погашает код восстановления
+func (m *MemoryStorage) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.twoFactor[userID]
+	if !ok {
+		return models.ErrInvalidTwoFactorCode
+	}
+	used, ok := state.recoveryCodes[codeHash]
+	if !ok || used {
+		return models.ErrInvalidTwoFactorCode
+	}
+	state.recoveryCodes[codeHash] = true
+	return nil
+}
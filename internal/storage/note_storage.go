@@ -1,157 +1,489 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/lib/pq"
 )
 
-// CreateNote создаёт новую заметку
-func (s *Storage) CreateNote(userID int, title, content string) (*models.Note, error) {
-	query := `
-		INSERT INTO notes (user_id, title, content, created_at, updated_at)
-		VALUES ($1, $2, $3, NOW(), NOW())
-		RETURNING id, user_id, title, content, created_at, updated_at
-	`
+// noteColumns - колонки заметки в том порядке, в котором их читает scanNote
+const noteColumns = "id, user_id, notebook_id, title, content, content_format, version, created_at, updated_at, deleted_at"
+
+// rowScanner - общий метод *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+// scanNote читает заметку, выбранную с noteColumns
+func scanNote(row rowScanner) (*models.Note, error) {
 	note := &models.Note{}
-	err := s.db.QueryRow(query, userID, title, content).Scan(
+	err := row.Scan(
 		&note.ID,
 		&note.UserID,
+		&note.NotebookID,
 		&note.Title,
 		&note.Content,
+		&note.Format,
+		&note.Version,
 		&note.CreatedAt,
 		&note.UpdatedAt,
+		&note.DeletedAt,
 	)
-
 	if err != nil {
 		return nil, err
 	}
+	return note, nil
+}
+
+// CreateNote создаёт новую заметку вместе с тегами, ссылками и первой ревизией.
+// notebookID должен быть блокнотом того же пользователя, иначе models.ErrNotebookNotFound
+func (s *Storage) CreateNote(ctx context.Context, userID int, notebookID *int, title, content, format string, tags []string) (*models.Note, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO notes (user_id, notebook_id, title, content, content_format, created_at, updated_at)
+		SELECT $1, $2, $3, $4, $5, NOW(), NOW()
+		WHERE $2::integer IS NULL OR EXISTS (SELECT 1 FROM notebooks WHERE id = $2 AND user_id = $1)
+		RETURNING ` + noteColumns
+
+	note, err := scanNote(tx.QueryRowContext(ctx, query, userID, notebookID, title, content, format))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || isForeignKeyViolation(err) {
+			return nil, models.ErrNotebookNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := setNoteTags(ctx, tx, userID, note.ID, tags); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := insertRevision(ctx, tx, note); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := setNoteLinks(ctx, tx, note); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := loadTags(ctx, tx, []*models.Note{note}); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
 
 	return note, nil
 }
 
 // GetNoteByID получает заметку по ID
-func (s *Storage) GetNoteByID(noteID int) (*models.Note, error) {
+func (s *Storage) GetNoteByID(ctx context.Context, noteID int) (*models.Note, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, user_id, title, content, created_at, updated_at
+		SELECT ` + noteColumns + `
 		FROM notes
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	note := &models.Note{}
-	err := s.db.QueryRow(query, noteID).Scan(
-		&note.ID,
-		&note.UserID,
-		&note.Title,
-		&note.Content,
-		&note.CreatedAt,
-		&note.UpdatedAt,
-	)
-
+	note, err := scanNote(s.db.QueryRowContext(ctx, query, noteID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrNoteNotFound
 		}
-		return nil, err
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := loadTags(ctx, s.db, []*models.Note{note}); err != nil {
+		return nil, ctxError(ctx, err)
 	}
 
 	return note, nil
 }
 
-// GetUserNotes получает все заметки пользователя с пагинацией и сортировкой
-func (s *Storage) GetUserNotes(userID, limit, offset int, sortOrder string) ([]*models.Note, error) {
-	// Проверяем sortOrder (защита от SQL injection)
-	if sortOrder != "asc" && sortOrder != "desc" {
-		sortOrder = "desc" // по умолчанию
+// GetUserNotes получает заметки пользователя с пагинацией, сортировкой и фильтрами.
+// С opts.Cursor вместо OFFSET используется keyset условие по полям сортировки и id
+func (s *Storage) GetUserNotes(ctx context.Context, userID int, opts models.NoteListOptions) ([]*models.Note, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	keys := opts.Sort
+	if len(keys) == 0 {
+		keys = models.DefaultNoteSort
 	}
 
+	where, args := noteFilter(userID, opts)
+
+	// Страница «до курсора» читается в обратном порядке и затем разворачивается
+	backward := opts.Cursor != nil && opts.Cursor.Before
+	if opts.Cursor != nil {
+		var cond string
+		cond, args = keysetCondition(keys, opts.Cursor, backward, args)
+		where += " AND " + cond
+	}
+
+	offset := opts.Offset
+	if opts.Cursor != nil {
+		offset = 0
+	}
+
+	args = append(args, opts.Limit, offset)
 	query := fmt.Sprintf(`
-		SELECT id, user_id, title, content, created_at, updated_at
+		SELECT %s
 		FROM notes
-		WHERE user_id = $1
-		ORDER BY created_at %s
-		LIMIT $2 OFFSET $3
-	`, sortOrder)
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, noteColumns, where, orderBy(keys, backward), len(args)-1, len(args))
 
-	rows, err := s.db.Query(query, userID, limit, offset)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, ctxError(ctx, err)
 	}
 	defer rows.Close()
 
 	var notes []*models.Note
 	for rows.Next() {
-		note := &models.Note{}
-		err := rows.Scan(
-			&note.ID,
-			&note.UserID,
-			&note.Title,
-			&note.Content,
-			&note.CreatedAt,
-			&note.UpdatedAt,
-		)
+		note, err := scanNote(rows)
 		if err != nil {
-			return nil, err
+			return nil, ctxError(ctx, err)
 		}
 		notes = append(notes, note)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, ctxError(ctx, err)
+	}
+
+	if backward {
+		for i, j := 0, len(notes)-1; i < j; i, j = i+1, j-1 {
+			notes[i], notes[j] = notes[j], notes[i]
+		}
+	}
+
+	if err := loadTags(ctx, s.db, notes); err != nil {
+		return nil, ctxError(ctx, err)
 	}
 
 	return notes, nil
 }
 
-// UpdateNote обновляет заметку
-func (s *Storage) UpdateNote(noteID int, title, content string) (*models.Note, error) {
+// sortColumn возвращает колонку для поля сортировки (защита от SQL injection)
+func sortColumn(field string) string {
+	switch field {
+	case models.SortUpdatedAt:
+		return "updated_at"
+	case models.SortTitle:
+		return "title"
+	default:
+		return "created_at"
+	}
+}
+
+// sortDirection возвращает направление ключа; reverse — для чтения страницы назад
+func sortDirection(desc, reverse bool) string {
+	if desc != reverse {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// orderBy строит ORDER BY по ключам сортировки; id идёт последним в направлении последнего ключа
+func orderBy(keys []models.SortKey, reverse bool) string {
+	parts := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		parts = append(parts, sortColumn(key.Field)+" "+sortDirection(key.Desc, reverse))
+	}
+	parts = append(parts, "id "+sortDirection(keys[len(keys)-1].Desc, reverse))
+	return strings.Join(parts, ", ")
+}
+
+// keysetCondition строит условие «строго после курсора» для ключей с разными направлениями:
+// (k1 > v1) OR (k1 = v1 AND k2 < v2) OR ... OR (k1 = v1 AND ... AND id > cursor_id)
+func keysetCondition(keys []models.SortKey, cursor *models.NoteCursor, reverse bool, args []interface{}) (string, []interface{}) {
+	values := cursor.TypedValues()
+
+	columns := make([]string, 0, len(keys)+1)
+	descs := make([]bool, 0, len(keys)+1)
+	params := make([]string, 0, len(keys)+1)
+	for i, key := range keys {
+		args = append(args, values[i])
+		columns = append(columns, sortColumn(key.Field))
+		descs = append(descs, key.Desc)
+		params = append(params, fmt.Sprintf("$%d", len(args)))
+	}
+	args = append(args, cursor.ID)
+	columns = append(columns, "id")
+	descs = append(descs, keys[len(keys)-1].Desc)
+	params = append(params, fmt.Sprintf("$%d", len(args)))
+
+	var or []string
+	for i := range columns {
+		var and []string
+		for j := 0; j < i; j++ {
+			and = append(and, columns[j]+" = "+params[j])
+		}
+		op := ">"
+		if sortDirection(descs[i], reverse) == "DESC" {
+			op = "<"
+		}
+		and = append(and, columns[i]+" "+op+" "+params[i])
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return "(" + strings.Join(or, " OR ") + ")", args
+}
+
+// CountUserNotes считает заметки пользователя с теми же фильтрами, что и GetUserNotes
+// (без учёта пагинации)
+func (s *Storage) CountUserNotes(ctx context.Context, userID int, opts models.NoteListOptions) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	where, args := noteFilter(userID, opts)
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM notes WHERE %s`, where)
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	return total, nil
+}
+
+// noteFilter строит параметризованный WHERE для списка заметок пользователя (без пагинации)
+func noteFilter(userID int, opts models.NoteListOptions) (string, []interface{}) {
+	args := []interface{}{userID}
+	where := "user_id = $1 AND deleted_at IS NULL"
+
+	if len(opts.Tags) > 0 {
+		args = append(args, pq.Array(opts.Tags))
+		tagFilter := fmt.Sprintf(`
+			SELECT COUNT(DISTINCT t.name)
+			FROM note_tags nt
+			JOIN tags t ON t.id = nt.tag_id
+			WHERE nt.note_id = notes.id AND t.name = ANY($%d)
+		`, len(args))
+
+		if opts.TagMode == "or" {
+			where += fmt.Sprintf(" AND (%s) > 0", tagFilter)
+		} else {
+			args = append(args, len(opts.Tags))
+			where += fmt.Sprintf(" AND (%s) = $%d", tagFilter, len(args))
+		}
+	}
+
+	addCond := func(cond string, value interface{}) {
+		args = append(args, value)
+		where += fmt.Sprintf(" AND "+cond, len(args))
+	}
+
+	if opts.CreatedFrom != nil {
+		addCond("created_at >= $%d", *opts.CreatedFrom)
+	}
+	if opts.CreatedTo != nil {
+		addCond("created_at <= $%d", *opts.CreatedTo)
+	}
+	if opts.UpdatedFrom != nil {
+		addCond("updated_at >= $%d", *opts.UpdatedFrom)
+	}
+	if opts.UpdatedTo != nil {
+		addCond("updated_at <= $%d", *opts.UpdatedTo)
+	}
+	if opts.TitlePrefix != "" {
+		addCond(`title ILIKE $%d ESCAPE '\'`, escapeLike(opts.TitlePrefix)+"%")
+	}
+	if opts.MinLength > 0 {
+		addCond("char_length(content) >= $%d", opts.MinLength)
+	}
+	if opts.MaxLength > 0 {
+		addCond("char_length(content) <= $%d", opts.MaxLength)
+	}
+
+	if opts.Unfiled {
+		where += " AND notebook_id IS NULL"
+	}
+	if opts.NotebookID != nil {
+		args = append(args, *opts.NotebookID)
+		if opts.Recursive {
+			where += fmt.Sprintf(" AND notebook_id IN (%s)", notebookSubtree(len(args)))
+		} else {
+			where += fmt.Sprintf(" AND notebook_id = $%d", len(args))
+		}
+	}
+
+	return where, args
+}
+
+// UpdateNote обновляет заметку и её ссылки и записывает новую ревизию. tags == nil — теги не меняются, format == "" — формат не меняется.
+// expectedVersion > 0 — обновить, только если версия заметки совпадает (If-Match),
+// иначе models.ErrVersionMismatch
+func (s *Storage) UpdateNote(ctx context.Context, noteID int, title, content, format string, tags []string, expectedVersion int) (*models.Note, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE notes
-		SET title = $1, content = $2, updated_at = NOW()
-		WHERE id = $3
-		RETURNING id, user_id, title, content, created_at, updated_at
-	`
-
-	note := &models.Note{}
-	err := s.db.QueryRow(query, title, content, noteID).Scan(
-		&note.ID,
-		&note.UserID,
-		&note.Title,
-		&note.Content,
-		&note.CreatedAt,
-		&note.UpdatedAt,
-	)
+		SET title = $1, content = $2, content_format = COALESCE(NULLIF($5, ''), content_format),
+			version = version + 1, updated_at = NOW()
+		WHERE id = $3 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)
+		RETURNING ` + noteColumns
 
+	note, err := scanNote(tx.QueryRowContext(ctx, query, title, content, noteID, expectedVersion, format))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, models.ErrNoteNotFound
+			return nil, noteMissingOrChanged(ctx, tx, noteID)
 		}
-		return nil, err
+		return nil, ctxError(ctx, err)
+	}
+
+	if tags != nil {
+		if err := setNoteTags(ctx, tx, note.UserID, note.ID, tags); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	if err := insertRevision(ctx, tx, note); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := setNoteLinks(ctx, tx, note); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := loadTags(ctx, tx, []*models.Note{note}); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ctxError(ctx, err)
 	}
 
 	return note, nil
 }
 
-// DeleteNote удаляет заметку
-func (s *Storage) DeleteNote(noteID int) error {
-	query := `DELETE FROM notes WHERE id = $1`
+// DeleteNote переносит заметку в корзину (soft delete).
+// expectedVersion > 0 — только если версия заметки совпадает
+func (s *Storage) DeleteNote(ctx context.Context, noteID, expectedVersion int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE notes
+		SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+	`
 
-	result, err := s.db.Exec(query, noteID)
+	result, err := s.db.ExecContext(ctx, query, noteID, expectedVersion)
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	if rowsAffected == 0 {
-		return models.ErrNoteNotFound
+		return noteMissingOrChanged(ctx, s.db, noteID)
 	}
 
 	return nil
 }
+
+// noteMissingOrChanged объясняет, почему условный UPDATE не затронул строк:
+// заметки нет (или она в корзине) — ErrNoteNotFound, иначе не совпала версия
+func noteMissingOrChanged(ctx context.Context, q querier, noteID int) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM notes WHERE id = $1 AND deleted_at IS NULL)
+	`, noteID).Scan(&exists)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	if !exists {
+		return models.ErrNoteNotFound
+	}
+	return models.ErrVersionMismatch
+}
+
+// PatchNote частично обновляет заметку одним UPDATE: меняются только поля, заданные в patch.
+// expectedVersion работает так же, как в UpdateNote
+func (s *Storage) PatchNote(ctx context.Context, noteID int, patch models.NotePatch, expectedVersion int) (*models.Note, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE notes
+		SET title = COALESCE($1, title),
+			content = COALESCE($2, content),
+			content_format = COALESCE($5, content_format),
+			version = version + 1,
+			updated_at = NOW()
+		WHERE id = $3 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)
+		RETURNING ` + noteColumns
+
+	note, err := scanNote(tx.QueryRowContext(ctx, query, patch.Title, patch.Content, noteID, expectedVersion, patch.Format))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, noteMissingOrChanged(ctx, tx, noteID)
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	if patch.Tags != nil {
+		if err := setNoteTags(ctx, tx, note.UserID, note.ID, *patch.Tags); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	if err := insertRevision(ctx, tx, note); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if patch.Content != nil {
+		if err := setNoteLinks(ctx, tx, note); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	if err := loadTags(ctx, tx, []*models.Note{note}); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return note, nil
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы префикс искался буквально
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/lib/pq"
+)
+
+const notebookColumns = "id, user_id, parent_id, name, created_at, updated_at"
+
+// notebookSubtree возвращает подзапрос с id блокнота $param и всех вложенных в него блокнотов
+func notebookSubtree(param int) string {
+	return fmt.Sprintf(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM notebooks WHERE id = $%d
+			UNION ALL
+			SELECT nb.id FROM notebooks nb JOIN subtree ON nb.parent_id = subtree.id
+		)
+		SELECT id FROM subtree`, param)
+}
+
+// CreateNotebook создаёт блокнот. parentID должен быть блокнотом того же пользователя,
+// иначе models.ErrNotebookNotFound
+func (s *Storage) CreateNotebook(ctx context.Context, userID int, parentID *int, name string) (*models.Notebook, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO notebooks (user_id, parent_id, name, created_at, updated_at)
+		SELECT $1, $2, $3, NOW(), NOW()
+		WHERE $2::integer IS NULL OR EXISTS (SELECT 1 FROM notebooks WHERE id = $2 AND user_id = $1)
+		RETURNING ` + notebookColumns
+
+	notebook, err := scanNotebook(s.db.QueryRowContext(ctx, query, userID, parentID, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || isForeignKeyViolation(err) {
+			return nil, models.ErrNotebookNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return notebook, nil
+}
+
+// GetNotebook получает блокнот по ID
+func (s *Storage) GetNotebook(ctx context.Context, notebookID int) (*models.Notebook, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + notebookColumns + ` FROM notebooks WHERE id = $1`
+
+	notebook, err := scanNotebook(s.db.QueryRowContext(ctx, query, notebookID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNotebookNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return notebook, nil
+}
+
+// GetUserNotebooks возвращает все блокноты пользователя плоским списком (дерево строится по parent_id)
+func (s *Storage) GetUserNotebooks(ctx context.Context, userID int) ([]*models.Notebook, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + notebookColumns + `
+		FROM notebooks
+		WHERE user_id = $1
+		ORDER BY name, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	notebooks := []*models.Notebook{}
+	for rows.Next() {
+		notebook, err := scanNotebook(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		notebooks = append(notebooks, notebook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return notebooks, nil
+}
+
+// RenameNotebook меняет имя блокнота
+func (s *Storage) RenameNotebook(ctx context.Context, notebookID int, name string) (*models.Notebook, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE notebooks SET name = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + notebookColumns
+
+	notebook, err := scanNotebook(s.db.QueryRowContext(ctx, query, notebookID, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNotebookNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return notebook, nil
+}
+
+// MoveNotebook переносит блокнот со всем содержимым в parentID (nil — на верхний уровень).
+// Перенос в самого себя или во вложенный блокнот — models.ErrNotebookCycle
+func (s *Storage) MoveNotebook(ctx context.Context, notebookID int, parentID *int) (*models.Notebook, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	userID, err := lockNotebooks(ctx, tx, notebookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		var ownParent, cycle bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT
+				EXISTS (SELECT 1 FROM notebooks WHERE id = $2 AND user_id = $3),
+				EXISTS (SELECT 1 FROM (`+notebookSubtree(1)+`) s WHERE s.id = $2)
+		`, notebookID, *parentID, userID).Scan(&ownParent, &cycle)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		if !ownParent {
+			return nil, models.ErrNotebookNotFound
+		}
+		if cycle {
+			return nil, models.ErrNotebookCycle
+		}
+	}
+
+	query := `
+		UPDATE notebooks SET parent_id = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + notebookColumns
+
+	notebook, err := scanNotebook(tx.QueryRowContext(ctx, query, notebookID, parentID))
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return notebook, nil
+}
+
+// DeleteNotebook удаляет блокнот. Непустой блокнот (есть вложенные блокноты или заметки)
+// без cascade не удаляется — models.ErrNotebookNotEmpty. С cascade удаляются все вложенные
+// блокноты, а их заметки переносятся в корзину
+func (s *Storage) DeleteNotebook(ctx context.Context, notebookID int, cascade bool) (*models.DeleteNotebookResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockNotebooks(ctx, tx, notebookID); err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	if err := tx.QueryRowContext(ctx, `SELECT array_agg(id) FROM (`+notebookSubtree(1)+`) s`, notebookID).Scan(pq.Array(&ids)); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if !cascade {
+		var hasNotes bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM notes WHERE notebook_id = $1 AND deleted_at IS NULL)
+		`, notebookID).Scan(&hasNotes)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		if hasNotes || len(ids) > 1 {
+			return nil, models.ErrNotebookNotEmpty
+		}
+	}
+
+	// notebook_id заметок обнуляется внешним ключом (ON DELETE SET NULL)
+	result, err := tx.ExecContext(ctx, `
+		UPDATE notes SET deleted_at = NOW()
+		WHERE notebook_id = ANY($1) AND deleted_at IS NULL
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	trashed, err := result.RowsAffected()
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	// Вложенные блокноты удаляются каскадно по parent_id
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notebooks WHERE id = $1`, notebookID); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return &models.DeleteNotebookResponse{
+		DeletedNotebooks: len(ids),
+		TrashedNotes:     int(trashed),
+	}, nil
+}
+
+// MoveNote переносит заметку в блокнот её владельца (nil — вне блокнотов).
+// expectedVersion работает так же, как в UpdateNote
+func (s *Storage) MoveNote(ctx context.Context, noteID int, notebookID *int, expectedVersion int) (*models.Note, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if notebookID != nil {
+		var ownNotebook bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM notebooks nb JOIN notes n ON n.user_id = nb.user_id
+				WHERE nb.id = $1 AND n.id = $2
+			)
+		`, *notebookID, noteID).Scan(&ownNotebook)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		if !ownNotebook {
+			if err := noteMissingOrChanged(ctx, s.db, noteID); err == models.ErrNoteNotFound {
+				return nil, err
+			}
+			return nil, models.ErrNotebookNotFound
+		}
+	}
+
+	query := `
+		UPDATE notes
+		SET notebook_id = $2, version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
+		RETURNING ` + noteColumns
+
+	note, err := scanNote(s.db.QueryRowContext(ctx, query, noteID, notebookID, expectedVersion))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, noteMissingOrChanged(ctx, s.db, noteID)
+		}
+		if isForeignKeyViolation(err) {
+			return nil, models.ErrNotebookNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := loadTags(ctx, s.db, []*models.Note{note}); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return note, nil
+}
+
+// lockNotebooks блокирует блокноты владельца notebookID до конца транзакции,
+// чтобы параллельные переносы не создали цикл. Возвращает id владельца
+func lockNotebooks(ctx context.Context, tx *sql.Tx, notebookID int) (int, error) {
+	var userID int
+	err := tx.QueryRowContext(ctx, `SELECT user_id FROM notebooks WHERE id = $1`, notebookID).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, models.ErrNotebookNotFound
+		}
+		return 0, ctxError(ctx, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM notebooks WHERE user_id = $1 FOR UPDATE`, userID); err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	return userID, nil
+}
+
+// isForeignKeyViolation сообщает, что запись ссылается на уже удалённую строку
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23503" // foreign_key_violation
+}
+
+func scanNotebook(row rowScanner) (*models.Notebook, error) {
+	notebook := &models.Notebook{}
+	err := row.Scan(
+		&notebook.ID,
+		&notebook.UserID,
+		&notebook.ParentID,
+		&notebook.Name,
+		&notebook.CreatedAt,
+		&notebook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return notebook, nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// CreatePasswordReset сохраняет токен сброса пароля (только хеш)
+func (s *Storage) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO password_resets (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, userID, tokenHash, expiresAt.UTC()); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return nil
+}
+
+// ResetPassword по одноразовому токену меняет пароль и отзывает все сессии пользователя.
+// Возвращает id пользователя; неизвестный, использованный или истёкший токен — models.ErrInvalidResetToken
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE password_resets SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING user_id
+	`, tokenHash, time.Now().UTC()).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, models.ErrInvalidResetToken
+		}
+		return 0, ctxError(ctx, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = $1 WHERE id = $2`, passwordHash, userID); err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	if err := revokeCredentials(ctx, tx, userID); err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	return userID, nil
+}
+
+// revokeCredentials после смены пароля делает недействительными остальные токены сброса и все сессии
+func revokeCredentials(ctx context.Context, q querier, userID int) error {
+	if _, err := q.ExecContext(ctx, `UPDATE password_resets SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		return err
+	}
+	_, err := q.ExecContext(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	return err
+}
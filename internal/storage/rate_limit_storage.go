@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// TakeRateLimitToken забирает токен из корзины key (одна транзакция с блокировкой строки,
+// поэтому лимит общий для всех экземпляров API). Возвращает состояние корзины и решение
+func (s *Storage) TakeRateLimitToken(ctx context.Context, key string, policy *models.RateLimitPolicy, now time.Time) (*models.RateLimitBucket, bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Новая корзина создаётся полной; ON CONFLICT DO NOTHING делает вставку безопасной
+	// при одновременных первых запросах, а FOR UPDATE ниже их упорядочивает
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING
+	`, key, float64(policy.Burst), now.UTC())
+	if err != nil {
+		return nil, false, ctxError(ctx, err)
+	}
+
+	bucket := &models.RateLimitBucket{}
+	err = tx.QueryRowContext(ctx, `
+		SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = $1 FOR UPDATE
+	`, key).Scan(&bucket.Tokens, &bucket.UpdatedAt)
+	if err != nil {
+		return nil, false, ctxError(ctx, err)
+	}
+
+	allowed := bucket.Take(policy, now)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE rate_limit_buckets SET tokens = $1, updated_at = $2 WHERE key = $3
+	`, bucket.Tokens, now.UTC(), key)
+	if err != nil {
+		return nil, false, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, ctxError(ctx, err)
+	}
+
+	return bucket, allowed, nil
+}
+
+// PurgeRateLimitBuckets удаляет корзины, не менявшиеся с before (они уже снова полные)
+func (s *Storage) PurgeRateLimitBuckets(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < $1`, before.UTC())
+	if err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	return int(rowsAffected), nil
+}
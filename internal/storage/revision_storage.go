@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// GetNoteRevisions возвращает все ревизии заметки, новые первыми
+func (s *Storage) GetNoteRevisions(ctx context.Context, noteID int) ([]*models.NoteRevision, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, note_id, revision, title, content, content_format, created_at
+		FROM note_revisions
+		WHERE note_id = $1
+		ORDER BY revision DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	revisions := []*models.NoteRevision{}
+	for rows.Next() {
+		rev := &models.NoteRevision{}
+		err := rows.Scan(
+			&rev.ID,
+			&rev.NoteID,
+			&rev.Revision,
+			&rev.Title,
+			&rev.Content,
+			&rev.Format,
+			&rev.CreatedAt,
+		)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return revisions, nil
+}
+
+// GetNoteRevision получает одну ревизию заметки по номеру
+func (s *Storage) GetNoteRevision(ctx context.Context, noteID, revision int) (*models.NoteRevision, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, note_id, revision, title, content, content_format, created_at
+		FROM note_revisions
+		WHERE note_id = $1 AND revision = $2
+	`
+
+	rev := &models.NoteRevision{}
+	err := s.db.QueryRowContext(ctx, query, noteID, revision).Scan(
+		&rev.ID,
+		&rev.NoteID,
+		&rev.Revision,
+		&rev.Title,
+		&rev.Content,
+		&rev.Format,
+		&rev.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrRevisionNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return rev, nil
+}
+
+// GetLatestRevision получает последнюю ревизию заметки (её текущее состояние) вместе с кэшем HTML
+func (s *Storage) GetLatestRevision(ctx context.Context, noteID int) (*models.NoteRevision, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, note_id, revision, title, content, content_format, created_at,
+			COALESCE(content_html, ''), COALESCE(html_renderer, '')
+		FROM note_revisions
+		WHERE note_id = $1
+		ORDER BY revision DESC
+		LIMIT 1
+	`
+
+	rev := &models.NoteRevision{}
+	err := s.db.QueryRowContext(ctx, query, noteID).Scan(
+		&rev.ID,
+		&rev.NoteID,
+		&rev.Revision,
+		&rev.Title,
+		&rev.Content,
+		&rev.Format,
+		&rev.CreatedAt,
+		&rev.HTML,
+		&rev.HTMLRenderer,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrRevisionNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return rev, nil
+}
+
+// SaveRevisionHTML сохраняет HTML ревизии, отрисованный рендерером версии renderer
+func (s *Storage) SaveRevisionHTML(ctx context.Context, revisionID int, renderer, html string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE note_revisions
+		SET content_html = $1, html_renderer = $2
+		WHERE id = $3
+	`
+
+	result, err := s.db.ExecContext(ctx, query, html, renderer, revisionID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrRevisionNotFound
+	}
+
+	return nil
+}
+
+// insertRevision записывает текущее состояние заметки как новую ревизию
+func insertRevision(ctx context.Context, q querier, note *models.Note) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO note_revisions (note_id, revision, title, content, content_format, created_at)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, NOW()
+		FROM note_revisions
+		WHERE note_id = $1
+	`, note.ID, note.Title, note.Content, note.Format)
+	return err
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// SearchNotes ищет заметки пользователя по словам (tsvector + GIN индекс),
+// результаты отсортированы по релевантности
+func (s *Storage) SearchNotes(ctx context.Context, userID int, q *models.SearchQuery, limit, offset int) ([]*models.NoteSearchResult, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, notebook_id, title, content, content_format, version, created_at, updated_at,
+			ts_rank(search_vector, q) AS rank,
+			ts_headline('simple', translate(title, chr(2) || chr(3), ''), q, 'StartSel=` + snippetStart + `, StopSel=` + snippetStop + `, HighlightAll=true'),
+			ts_headline('simple', translate(content, chr(2) || chr(3), ''), q, 'StartSel=` + snippetStart + `, StopSel=` + snippetStop + `, MaxFragments=2, MaxWords=30, MinWords=10')
+		FROM notes, to_tsquery('simple', $2) AS q
+		WHERE user_id = $1 AND deleted_at IS NULL AND search_vector @@ q
+		ORDER BY rank DESC, created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, buildTSQuery(q), limit, offset)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	var results []*models.NoteSearchResult
+	for rows.Next() {
+		r := &models.NoteSearchResult{}
+		err := rows.Scan(
+			&r.ID,
+			&r.UserID,
+			&r.NotebookID,
+			&r.Title,
+			&r.Content,
+			&r.Format,
+			&r.Version,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+			&r.Rank,
+			&r.TitleSnippet,
+			&r.ContentSnippet,
+		)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		r.TitleSnippet = markSnippet(r.TitleSnippet)
+		r.ContentSnippet = markSnippet(r.ContentSnippet)
+		results = append(results, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	notes := make([]*models.Note, len(results))
+	for i, r := range results {
+		notes[i] = &r.Note
+	}
+	if err := loadTags(ctx, s.db, notes); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return results, nil
+}
+
+// Границы совпадений, которые ставит ts_headline (из текста заметки эти символы убираются).
+// Управляющие символы вместо <mark>: текст сначала экранируется, и только потом границы заменяются разметкой
+const (
+	snippetStart = "\x02"
+	snippetStop  = "\x03"
+)
+
+var snippetMarks = strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>")
+
+// markSnippet экранирует фрагмент как HTML и заменяет границы совпадений на <mark>
+func markSnippet(s string) string {
+	return snippetMarks.Replace(html.EscapeString(s))
+}
+
+// buildTSQuery собирает строку для to_tsquery.
+// Слова уже очищены ParseSearchQuery (только буквы и цифры), поэтому
+// операторы tsquery в них попасть не могут
+func buildTSQuery(q *models.SearchQuery) string {
+	parts := make([]string, 0, len(q.Terms))
+	for _, term := range q.Terms {
+		words := make([]string, len(term.Words))
+		copy(words, term.Words)
+		if term.Prefix {
+			words[len(words)-1] += ":*"
+		}
+
+		part := strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			part = "(" + part + ")"
+		}
+		if term.Negate {
+			part = "!" + part
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " & ")
+}
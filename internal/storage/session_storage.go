@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// CreateSession создаёт сессию входа с первым refresh токеном (хранится только хеш)
+func (s *Storage) CreateSession(ctx context.Context, userID int, refreshHash string, expiresAt time.Time) (*models.Session, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	session := &models.Session{}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO sessions (user_id, created_at)
+		VALUES ($1, NOW())
+		RETURNING id, user_id, created_at
+	`, userID).Scan(&session.ID, &session.UserID, &session.CreatedAt)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := insertRefreshToken(ctx, tx, session.ID, refreshHash, expiresAt); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return session, nil
+}
+
+// RotateRefreshToken обменивает refresh токен на новый в той же сессии.
+// Повторное использование уже обменянного токена означает его кражу:
+// сессия отзывается целиком и возвращается models.ErrRefreshTokenReused
+func (s *Storage) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var (
+		tokenID        int
+		tokenExpiresAt time.Time
+		usedAt         *time.Time
+		session        = &models.Session{}
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT rt.id, rt.expires_at, rt.used_at, s.id, s.user_id, s.created_at, s.revoked_at
+		FROM refresh_tokens rt
+		JOIN sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt, s
+	`, oldHash).Scan(
+		&tokenID,
+		&tokenExpiresAt,
+		&usedAt,
+		&session.ID,
+		&session.UserID,
+		&session.CreatedAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrInvalidRefreshToken
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	if session.RevokedAt != nil {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	if usedAt != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE id = $1`, session.ID); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		return nil, models.ErrRefreshTokenReused
+	}
+
+	if time.Now().After(tokenExpiresAt) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1`, tokenID); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := insertRefreshToken(ctx, tx, session.ID, newHash, expiresAt); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return session, nil
+}
+
+// IsSessionActive проверяет, что сессия существует и не отозвана
+func (s *Storage) IsSessionActive(ctx context.Context, sessionID int) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var active bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL)
+	`, sessionID).Scan(&active)
+	if err != nil {
+		return false, ctxError(ctx, err)
+	}
+
+	return active, nil
+}
+
+// RevokeSession отзывает сессию (logout)
+func (s *Storage) RevokeSession(ctx context.Context, sessionID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := s.db.ExecContext(ctx, query, sessionID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeUserSessions отзывает все сессии пользователя (logout everywhere)
+func (s *Storage) RevokeUserSessions(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := s.db.ExecContext(ctx, query, userID); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return nil
+}
+
+func insertRefreshToken(ctx context.Context, q querier, sessionID int, hash string, expiresAt time.Time) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, sessionID, hash, expiresAt.UTC())
+	return err
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+const shareLinkColumns = "id, note_id, user_id, password_hash, expires_at, created_at"
+
+// CreateShareLink сохраняет публичную ссылку (только хеш токена).
+// passwordHash пустой — ссылка без пароля
+func (s *Storage) CreateShareLink(ctx context.Context, noteID, userID int, tokenHash, passwordHash string, expiresAt *time.Time) (*models.ShareLink, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO share_links (note_id, user_id, token_hash, password_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING ` + shareLinkColumns
+
+	var password, expires interface{}
+	if passwordHash != "" {
+		password = passwordHash
+	}
+	if expiresAt != nil {
+		expires = expiresAt.UTC()
+	}
+
+	link, err := scanShareLink(s.db.QueryRowContext(ctx, query, noteID, userID, tokenHash, password, expires))
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return link, nil
+}
+
+// GetNoteShareLinks возвращает ссылки на заметку (включая истёкшие), новые первыми
+func (s *Storage) GetNoteShareLinks(ctx context.Context, noteID int) ([]*models.ShareLink, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + shareLinkColumns + `
+		FROM share_links
+		WHERE note_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	links := []*models.ShareLink{}
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return links, nil
+}
+
+// GetShareLink находит действующую ссылку по хешу токена.
+// Неизвестная или истёкшая ссылка — models.ErrShareLinkNotFound
+func (s *Storage) GetShareLink(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + shareLinkColumns + `
+		FROM share_links
+		WHERE token_hash = $1 AND (expires_at IS NULL OR expires_at > $2)
+	`
+
+	link, err := scanShareLink(s.db.QueryRowContext(ctx, query, tokenHash, time.Now().UTC()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrShareLinkNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return link, nil
+}
+
+// DeleteShareLink отзывает ссылку на заметку
+func (s *Storage) DeleteShareLink(ctx context.Context, noteID, linkID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM share_links WHERE id = $1 AND note_id = $2`
+
+	result, err := s.db.ExecContext(ctx, query, linkID, noteID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrShareLinkNotFound
+	}
+
+	return nil
+}
+
+func scanShareLink(row rowScanner) (*models.ShareLink, error) {
+	link := &models.ShareLink{}
+	var passwordHash sql.NullString
+	err := row.Scan(
+		&link.ID,
+		&link.NoteID,
+		&link.UserID,
+		&passwordHash,
+		&link.ExpiresAt,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	link.PasswordHash = passwordHash.String
+	link.HasPassword = passwordHash.Valid
+	return link, nil
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// ShareNote выдаёт пользователю доступ к заметке или меняет уже выданное право
+func (s *Storage) ShareNote(ctx context.Context, noteID, userID int, permission string) (*models.NoteShare, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH share AS (
+			INSERT INTO note_shares (note_id, user_id, permission, created_at)
+			VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (note_id, user_id) DO UPDATE SET permission = EXCLUDED.permission
+			RETURNING note_id, user_id, permission, created_at
+		)
+		SELECT share.note_id, share.user_id, u.username, share.permission, share.created_at
+		FROM share
+		JOIN users u ON u.id = share.user_id
+	`
+
+	share, err := scanNoteShare(s.db.QueryRowContext(ctx, query, noteID, userID, permission))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, models.ErrNoteNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return share, nil
+}
+
+// GetNoteShares возвращает всех, с кем поделились заметкой, в порядке выдачи доступа
+func (s *Storage) GetNoteShares(ctx context.Context, noteID int) ([]*models.NoteShare, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ns.note_id, ns.user_id, u.username, ns.permission, ns.created_at
+		FROM note_shares ns
+		JOIN users u ON u.id = ns.user_id
+		WHERE ns.note_id = $1
+		ORDER BY ns.created_at, ns.user_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	shares := []*models.NoteShare{}
+	for rows.Next() {
+		share, err := scanNoteShare(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		shares = append(shares, share)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return shares, nil
+}
+
+// GetNotePermission возвращает право пользователя на чужую заметку.
+// Нет доступа — models.ErrShareNotFound
+func (s *Storage) GetNotePermission(ctx context.Context, noteID, userID int) (string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT permission FROM note_shares WHERE note_id = $1 AND user_id = $2`
+
+	var permission string
+	err := s.db.QueryRowContext(ctx, query, noteID, userID).Scan(&permission)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", models.ErrShareNotFound
+		}
+		return "", ctxError(ctx, err)
+	}
+
+	return permission, nil
+}
+
+// DeleteNoteShare отзывает доступ пользователя к заметке
+func (s *Storage) DeleteNoteShare(ctx context.Context, noteID, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM note_shares WHERE note_id = $1 AND user_id = $2`
+
+	result, err := s.db.ExecContext(ctx, query, noteID, userID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrShareNotFound
+	}
+
+	return nil
+}
+
+// GetSharedNotes возвращает заметки, которыми поделились с пользователем, новые доступы первыми.
+// Заметки в корзине владельца не показываются
+func (s *Storage) GetSharedNotes(ctx context.Context, userID, limit, offset int) ([]*models.SharedNote, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT n.id, n.user_id, n.notebook_id, n.title, n.content, n.content_format, n.version, n.created_at, n.updated_at, n.deleted_at,
+			u.username, ns.permission, ns.created_at
+		FROM note_shares ns
+		JOIN notes n ON n.id = ns.note_id
+		JOIN users u ON u.id = n.user_id
+		WHERE ns.user_id = $1 AND n.deleted_at IS NULL
+		ORDER BY ns.created_at DESC, n.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	shared := []*models.SharedNote{}
+	notes := []*models.Note{}
+	for rows.Next() {
+		item := &models.SharedNote{Note: &models.Note{}}
+		err := rows.Scan(
+			&item.ID,
+			&item.UserID,
+			&item.NotebookID,
+			&item.Title,
+			&item.Content,
+			&item.Format,
+			&item.Version,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&item.DeletedAt,
+			&item.OwnerUsername,
+			&item.Permission,
+			&item.SharedAt,
+		)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		shared = append(shared, item)
+		notes = append(notes, item.Note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := loadTags(ctx, s.db, notes); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return shared, nil
+}
+
+func scanNoteShare(row rowScanner) (*models.NoteShare, error) {
+	share := &models.NoteShare{}
+	err := row.Scan(
+		&share.NoteID,
+		&share.UserID,
+		&share.Username,
+		&share.Permission,
+		&share.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return share, nil
+}
@@ -1,17 +1,245 @@
 package storage
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// NoteStore описывает операции с заметками
+type NoteStore interface {
+	CreateNote(ctx context.Context, userID int, notebookID *int, title, content, format string, tags []string) (*models.Note, error)
+	GetNoteByID(ctx context.Context, noteID int) (*models.Note, error)
+	GetUserNotes(ctx context.Context, userID int, opts models.NoteListOptions) ([]*models.Note, error)
+	CountUserNotes(ctx context.Context, userID int, opts models.NoteListOptions) (int, error)
+	UpdateNote(ctx context.Context, noteID int, title, content, format string, tags []string, expectedVersion int) (*models.Note, error)
+	PatchNote(ctx context.Context, noteID int, patch models.NotePatch, expectedVersion int) (*models.Note, error)
+	DeleteNote(ctx context.Context, noteID, expectedVersion int) error
+	SearchNotes(ctx context.Context, userID int, q *models.SearchQuery, limit, offset int) ([]*models.NoteSearchResult, error)
+	GetUserTags(ctx context.Context, userID int) ([]*models.TagCount, error)
+	GetNoteRevisions(ctx context.Context, noteID int) ([]*models.NoteRevision, error)
+	GetNoteRevision(ctx context.Context, noteID, revision int) (*models.NoteRevision, error)
+	GetLatestRevision(ctx context.Context, noteID int) (*models.NoteRevision, error)
+	SaveRevisionHTML(ctx context.Context, revisionID int, renderer, html string) error
+	GetTrashedNotes(ctx context.Context, userID, limit, offset int) ([]*models.Note, error)
+	GetTrashedNote(ctx context.Context, noteID int) (*models.Note, error)
+	RestoreNote(ctx context.Context, noteID int) (*models.Note, error)
+	PurgeNote(ctx context.Context, noteID int) error
+	PurgeDeletedNotes(ctx context.Context, before time.Time) (int, error)
+}
+
+// UserStore описывает операции с пользователями
+type UserStore interface {
+	CreateUser(ctx context.Context, username, email, passwordHash string) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	DeleteUser(ctx context.Context, userID int) error
+}
+
+// SessionStore описывает сессии входа и refresh токены
+type SessionStore interface {
+	CreateSession(ctx context.Context, userID int, refreshHash string, expiresAt time.Time) (*models.Session, error)
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*models.Session, error)
+	IsSessionActive(ctx context.Context, sessionID int) (bool, error)
+	RevokeSession(ctx context.Context, sessionID int) error
+	RevokeUserSessions(ctx context.Context, userID int) error
+}
+
+// APITokenStore описывает personal access токены
+type APITokenStore interface {
+	CreateAPIToken(ctx context.Context, userID int, name, tokenHash string, scopes []string, expiresAt *time.Time) (*models.APIToken, error)
+	GetUserAPITokens(ctx context.Context, userID int) ([]*models.APIToken, error)
+	UseAPIToken(ctx context.Context, tokenHash string) (*models.APIToken, error)
+	DeleteAPIToken(ctx context.Context, userID, tokenID int) error
+}
+
+// PasswordResetStore описывает токены сброса пароля
+type PasswordResetStore interface {
+	CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error)
+}
+
+// TwoFactorStore описывает TOTP и коды восстановления
+type TwoFactorStore interface {
+	GetTwoFactor(ctx context.Context, userID int) (*models.TwoFactor, error)
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	EnableTwoFactor(ctx context.Context, userID int, counter int64, recoveryHashes []string) error
+	DisableTwoFactor(ctx context.Context, userID int) error
+	UseTOTPCounter(ctx context.Context, userID int, counter int64) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+}
+
+// LoginAttemptStore описывает счётчики неудачных входов и журнал блокировок
+type LoginAttemptStore interface {
+	GetLoginAttempts(ctx context.Context, key string) (*models.LoginAttempts, error)
+	RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	CreateLockoutEvent(ctx context.Context, event *models.LockoutEvent) error
+	GetLockoutEvents(ctx context.Context, limit, offset int) ([]*models.LockoutEvent, error)
+}
+
+// RateLimitStore хранит состояние token bucket для rate limiting
+type RateLimitStore interface {
+	TakeRateLimitToken(ctx context.Context, key string, policy *models.RateLimitPolicy, now time.Time) (*models.RateLimitBucket, bool, error)
+	PurgeRateLimitBuckets(ctx context.Context, before time.Time) (int, error)
+}
+
+// NotebookStore описывает блокноты и перенос заметок между ними
+type NotebookStore interface {
+	CreateNotebook(ctx context.Context, userID int, parentID *int, name string) (*models.Notebook, error)
+	GetNotebook(ctx context.Context, notebookID int) (*models.Notebook, error)
+	GetUserNotebooks(ctx context.Context, userID int) ([]*models.Notebook, error)
+	RenameNotebook(ctx context.Context, notebookID int, name string) (*models.Notebook, error)
+	MoveNotebook(ctx context.Context, notebookID int, parentID *int) (*models.Notebook, error)
+	DeleteNotebook(ctx context.Context, notebookID int, cascade bool) (*models.DeleteNotebookResponse, error)
+	MoveNote(ctx context.Context, noteID int, notebookID *int, expectedVersion int) (*models.Note, error)
+}
+
+// ShareStore описывает доступ пользователей к чужим заметкам
+type ShareStore interface {
+	ShareNote(ctx context.Context, noteID, userID int, permission string) (*models.NoteShare, error)
+	GetNoteShares(ctx context.Context, noteID int) ([]*models.NoteShare, error)
+	GetNotePermission(ctx context.Context, noteID, userID int) (string, error)
+	DeleteNoteShare(ctx context.Context, noteID, userID int) error
+	GetSharedNotes(ctx context.Context, userID, limit, offset int) ([]*models.SharedNote, error)
+}
+
+// ShareLinkStore описывает публичные ссылки на заметки
+type ShareLinkStore interface {
+	CreateShareLink(ctx context.Context, noteID, userID int, tokenHash, passwordHash string, expiresAt *time.Time) (*models.ShareLink, error)
+	GetNoteShareLinks(ctx context.Context, noteID int) ([]*models.ShareLink, error)
+	GetShareLink(ctx context.Context, tokenHash string) (*models.ShareLink, error)
+	DeleteShareLink(ctx context.Context, noteID, linkID int) error
+}
+
+// AttachmentStore описывает метаданные вложений, обработку изображений и очередь blob'ов на удаление
+type AttachmentStore interface {
+	CreateAttachment(ctx context.Context, attachment *models.Attachment, quota int64) (*models.Attachment, error)
+	GetNoteAttachments(ctx context.Context, noteID int) ([]*models.Attachment, error)
+	GetAttachment(ctx context.Context, noteID, attachmentID int) (*models.Attachment, error)
+	DeleteAttachment(ctx context.Context, noteID, attachmentID int) error
+	GetAttachmentUsage(ctx context.Context, userID int) (*models.AttachmentUsage, error)
+	ClaimImageAttachments(ctx context.Context, limit int, now, staleBefore time.Time) ([]*models.Attachment, error)
+	FinishImageAttachment(ctx context.Context, attachmentID int, status string, result *models.ImageResult) error
+	GetOrphanedBlobs(ctx context.Context, limit int) ([]*models.OrphanedBlob, error)
+	DeleteOrphanedBlobs(ctx context.Context, ids []int) error
+}
+
+// LinkStore описывает ссылки между заметками пользователя
+type LinkStore interface {
+	GetOutgoingLinks(ctx context.Context, userID, noteID int) ([]*models.NoteLink, error)
+	GetBacklinks(ctx context.Context, userID, noteID int) ([]*models.NoteLink, error)
+	GetUnresolvedLinks(ctx context.Context, userID int) ([]*models.NoteLink, error)
+	GetLinkGraph(ctx context.Context, userID int) (*models.LinkGraph, error)
+}
+
+// ImportStore описывает импорт архивов заметок фоновой задачей
+type ImportStore interface {
+	CreateImport(ctx context.Context, imp *models.Import) (*models.Import, error)
+	GetImport(ctx context.Context, userID, importID int) (*models.Import, error)
+	GetUserImports(ctx context.Context, userID int) ([]*models.Import, error)
+	ClaimImports(ctx context.Context, limit int, now, staleBefore time.Time) ([]*models.Import, error)
+	SaveImportBatch(ctx context.Context, importID int, batch *models.ImportBatch) error
+	FinishImport(ctx context.Context, importID int, status, message string) error
+}
+
+// Составные хранилища обработчиков называются <Основа><Возможность>Store: основа — хранилище,
+// вокруг которого строится обработчик (NoteStore или UserStore), к ней добавляются хранилища возможности
+
+// NoteLinkStore - заметки и ссылки между ними
+type NoteLinkStore interface {
+	NoteStore
+	LinkStore
+}
+
+// NoteAttachmentStore - заметки, доступы к ним и их вложения
+type NoteAttachmentStore interface {
+	NoteStore
+	ShareStore
+	AttachmentStore
+}
+
+// NoteShareStore - заметки, их доступы, публичные ссылки и пользователи, которым доступ выдаётся
+type NoteShareStore interface {
+	NoteStore
+	UserStore
+	ShareStore
+	ShareLinkStore
+}
+
+// UserAuthStore - всё, что нужно для входа: пользователи и их сессии
+type UserAuthStore interface {
+	UserStore
+	SessionStore
+	PasswordResetStore
+	TwoFactorStore
+	LoginAttemptStore
+}
+
+// Store объединяет все хранилища (реализуется Storage и MemoryStorage)
+type Store interface {
+	NoteStore
+	UserStore
+	SessionStore
+	APITokenStore
+	PasswordResetStore
+	TwoFactorStore
+	LoginAttemptStore
+	RateLimitStore
+	ShareStore
+	ShareLinkStore
+	NotebookStore
+	AttachmentStore
+	LinkStore
+	ImportStore
+}
 
 //Storage содержит подключение к БД
 type Storage struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
 //создаёт новое подключение к БД
-func New(db *sql.DB) *Storage {
+//queryTimeout ограничивает время одного запроса (0 — без ограничения)
+func New(db *sql.DB, queryTimeout time.Duration) *Storage {
 	return &Storage{
-		db: db,
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// withTimeout добавляет к контексту запроса таймаут на один SQL запрос
+func (s *Storage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+// withBatchTimeout даёт транзакции из statements шагов по таймауту запроса на каждый:
+// общий таймаут одного запроса оборвал бы большую, но исправную транзакцию
+func (s *Storage) withBatchTimeout(ctx context.Context, statements int) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.queryTimeout*time.Duration(statements))
+}
+
+// ctxError возвращает ошибку контекста, если запрос прерван по таймауту или отменён.
+// Драйвер в этом случае отдаёт свою ошибку (query_canceled), а хендлерам нужна context.DeadlineExceeded
+func ctxError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
 	}
+	return err
 }
 
 //Закрывает подключение к БД
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithBatchTimeout(t *testing.T) {
+	s := New(nil, time.Second)
+	ctx, cancel := s.withBatchTimeout(t.Context(), 52)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if left := time.Until(deadline); !ok || left <= 51*time.Second || left > 52*time.Second {
+		t.Errorf("batch deadline in %s", left)
+	}
+
+	// Без DB_QUERY_TIMEOUT у транзакции нет и общего таймаута
+	ctx, cancel = New(nil, 0).withBatchTimeout(t.Context(), 52)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("deadline without a query timeout")
+	}
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/lib/pq"
+)
+
+// querier - общие методы *sql.DB и *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// GetUserTags возвращает теги пользователя с количеством заметок
+func (s *Storage) GetUserTags(ctx context.Context, userID int) ([]*models.TagCount, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT t.name, COUNT(nt.note_id)
+		FROM tags t
+		JOIN note_tags nt ON nt.tag_id = t.id
+		JOIN notes n ON n.id = nt.note_id AND n.deleted_at IS NULL
+		WHERE t.user_id = $1
+		GROUP BY t.name
+		ORDER BY COUNT(nt.note_id) DESC, t.name
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	tags := []*models.TagCount{}
+	for rows.Next() {
+		tag := &models.TagCount{}
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return tags, nil
+}
+
+// setNoteTags заменяет теги заметки, создавая недостающие теги пользователя.
+// Теги, отвязанные от заметки и больше нигде не используемые, удаляются
+func setNoteTags(ctx context.Context, q querier, userID, noteID int, tags []string) error {
+	removed, err := queryIDs(ctx, q, `DELETE FROM note_tags WHERE note_id = $1 RETURNING tag_id`, noteID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range tags {
+		var tagID int
+		err := q.QueryRowContext(ctx, `
+			INSERT INTO tags (user_id, name)
+			VALUES ($1, $2)
+			ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		`, userID, name).Scan(&tagID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.ExecContext(ctx, `INSERT INTO note_tags (note_id, tag_id) VALUES ($1, $2)`, noteID, tagID); err != nil {
+			return err
+		}
+	}
+
+	return deleteOrphanTags(ctx, q, removed)
+}
+
+// deleteOrphanTags удаляет из tagIDs теги, которые больше не привязаны ни к одной заметке.
+// Сначала теги блокируются: тег, который другая транзакция сейчас привязывает к заметке
+// (upsert в setNoteTags держит его строку до коммита), пропускается. Проверка привязок идёт
+// отдельным запросом, поэтому видит всё, что закоммичено до блокировки
+func deleteOrphanTags(ctx context.Context, q querier, tagIDs []int64) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	locked, err := queryIDs(ctx, q, `
+		SELECT id FROM tags WHERE id = ANY($1) ORDER BY id FOR UPDATE SKIP LOCKED
+	`, pq.Array(tagIDs))
+	if err != nil || len(locked) == 0 {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, `
+		DELETE FROM tags
+		WHERE id = ANY($1)
+			AND NOT EXISTS (SELECT 1 FROM note_tags WHERE note_tags.tag_id = tags.id)
+	`, pq.Array(locked))
+	return err
+}
+
+// queryIDs выполняет запрос, возвращающий один столбец идентификаторов
+func queryIDs(ctx context.Context, q querier, query string, args ...interface{}) ([]int64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// loadTags заполняет Tags у заметок одним запросом
+func loadTags(ctx context.Context, q querier, notes []*models.Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(notes))
+	byID := make(map[int]*models.Note, len(notes))
+	for i, note := range notes {
+		note.Tags = []string{}
+		ids[i] = int64(note.ID)
+		byID[note.ID] = note
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT nt.note_id, t.name
+		FROM note_tags nt
+		JOIN tags t ON t.id = nt.tag_id
+		WHERE nt.note_id = ANY($1)
+		ORDER BY t.name
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var noteID int
+		var name string
+		if err := rows.Scan(&noteID, &name); err != nil {
+			return err
+		}
+		if note, ok := byID[noteID]; ok {
+			note.Tags = append(note.Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Balyshev/notes-api/internal/models"
+	"github.com/lib/pq"
+)
+
+// GetTrashedNotes возвращает заметки пользователя из корзины, недавно удалённые первыми
+func (s *Storage) GetTrashedNotes(ctx context.Context, userID, limit, offset int) ([]*models.Note, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + noteColumns + `
+		FROM notes
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	notes := []*models.Note{}
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := loadTags(ctx, s.db, notes); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return notes, nil
+}
+
+// GetTrashedNote получает заметку из корзины по ID
+func (s *Storage) GetTrashedNote(ctx context.Context, noteID int) (*models.Note, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + noteColumns + `
+		FROM notes
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	note, err := scanNote(s.db.QueryRowContext(ctx, query, noteID))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoteNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := loadTags(ctx, s.db, []*models.Note{note}); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return note, nil
+}
+
+// RestoreNote возвращает заметку из корзины
+func (s *Storage) RestoreNote(ctx context.Context, noteID int) (*models.Note, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE notes
+		SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + noteColumns
+
+	note, err := scanNote(s.db.QueryRowContext(ctx, query, noteID))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoteNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	if err := loadTags(ctx, s.db, []*models.Note{note}); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return note, nil
+}
+
+// PurgeNote окончательно удаляет заметку из корзины (вместе с ревизиями и ненужными тегами)
+func (s *Storage) PurgeNote(ctx context.Context, noteID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Привязки удалятся каскадно вместе с заметкой, поэтому её теги запоминаются заранее
+	tagIDs, err := queryIDs(ctx, tx, `SELECT tag_id FROM note_tags WHERE note_id = $1`, noteID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM notes WHERE id = $1 AND deleted_at IS NOT NULL`, noteID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return ctxError(ctx, err)
+	} else if n == 0 {
+		return models.ErrNoteNotFound
+	}
+
+	if err := deleteOrphanTags(ctx, tx, tagIDs); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return ctxError(ctx, tx.Commit())
+}
+
+// PurgeDeletedNotes окончательно удаляет заметки, попавшие в корзину раньше before.
+// Возвращает количество удалённых заметок
+func (s *Storage) PurgeDeletedNotes(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Все части запроса видят один снимок, поэтому теги удаляемых заметок читаются до каскада
+	var purged int
+	var tagIDs pq.Int64Array
+	err = tx.QueryRowContext(ctx, `
+		WITH purged AS (
+			DELETE FROM notes
+			WHERE deleted_at IS NOT NULL AND deleted_at < $1
+			RETURNING id
+		)
+		SELECT
+			(SELECT COUNT(*) FROM purged),
+			COALESCE((SELECT array_agg(DISTINCT nt.tag_id) FROM note_tags nt JOIN purged p ON p.id = nt.note_id), '{}')
+	`, before).Scan(&purged, &tagIDs)
+	if err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	if err := deleteOrphanTags(ctx, tx, tagIDs); err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	return purged, nil
+}
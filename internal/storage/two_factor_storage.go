@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Balyshev/notes-api/internal/models"
+)
+
+// GetTwoFactor возвращает состояние TOTP пользователя
+func (s *Storage) GetTwoFactor(ctx context.Context, userID int) (*models.TwoFactor, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT COALESCE(totp_secret, ''), totp_enabled, totp_last_counter
+		FROM users
+		WHERE id = $1
+	`
+
+	tf := &models.TwoFactor{}
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&tf.Secret, &tf.Enabled, &tf.LastCounter)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, ctxError(ctx, err)
+	}
+
+	return tf, nil
+}
+
+// SetTOTPSecret сохраняет секрет до подтверждения; включённую 2FA не трогает
+func (s *Storage) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET totp_secret = $1 WHERE id = $2 AND NOT totp_enabled`
+
+	result, err := s.db.ExecContext(ctx, query, secret, userID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTwoFactorAlreadyEnabled
+	}
+
+	return nil
+}
+
+// EnableTwoFactor включает 2FA и заменяет коды восстановления (хранятся только хеши)
+func (s *Storage) EnableTwoFactor(ctx context.Context, userID int, counter int64, recoveryHashes []string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users SET totp_enabled = TRUE, totp_last_counter = $1
+		WHERE id = $2 AND totp_secret IS NOT NULL AND NOT totp_enabled
+	`, counter, userID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTwoFactorAlreadyEnabled
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryHashes); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return nil
+}
+
+// DisableTwoFactor отключает 2FA, удаляет секрет и коды восстановления
+func (s *Storage) DisableTwoFactor(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_counter = 0
+		WHERE id = $1
+	`, userID); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return nil
+}
+
+// UseTOTPCounter запоминает принятый интервал TOTP.
+// Код того же или более раннего интервала — models.ErrTwoFactorCodeReused
+func (s *Storage) UseTOTPCounter(ctx context.Context, userID int, counter int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET totp_last_counter = $1 WHERE id = $2 AND totp_last_counter < $1`
+
+	result, err := s.db.ExecContext(ctx, query, counter, userID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrTwoFactorCodeReused
+	}
+
+	return nil
+}
+
+// UseRecoveryCode погашает код восстановления; неизвестный или использованный — models.ErrInvalidTwoFactorCode
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+
+	result, err := s.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, q querier, userID int, hashes []string) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := q.ExecContext(ctx, `
+			INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
@@ -9,17 +10,21 @@ import (
 )
 
 // CreateUser создаёт нового пользователя
-func (s *Storage) CreateUser(username, passwordHash string) (*models.User, error) {
+func (s *Storage) CreateUser(ctx context.Context, username, email, passwordHash string) (*models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-		INSERT INTO users (username, password_hash, created_at)
-		VALUES ($1, $2, NOW())
-		RETURNING id, username, created_at
+		INSERT INTO users (username, email, password_hash, created_at)
+		VALUES ($1, NULLIF($2, ''), $3, NOW())
+		RETURNING id, username, COALESCE(email, ''), created_at
 	`
 
 	user := &models.User{}
-	err := s.db.QueryRow(query, username, passwordHash).Scan(
+	err := s.db.QueryRowContext(ctx, query, username, email, passwordHash).Scan(
 		&user.ID,
 		&user.Username,
+		&user.Email,
 		&user.CreatedAt,
 	)
 
@@ -30,24 +35,28 @@ func (s *Storage) CreateUser(username, passwordHash string) (*models.User, error
 				return nil, models.ErrUsernameExists
 			}
 		}
-		return nil, err
+		return nil, ctxError(ctx, err)
 	}
 
 	return user, nil
 }
 
 // GetUserByUsername получает пользователя по username (для логина)
-func (s *Storage) GetUserByUsername(username string) (*models.User, error) {
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, username, password_hash, created_at
+		SELECT id, username, COALESCE(email, ''), password_hash, created_at
 		FROM users
 		WHERE username = $1
 	`
 
 	user := &models.User{}
-	err := s.db.QueryRow(query, username).Scan(
+	err := s.db.QueryRowContext(ctx, query, username).Scan(
 		&user.ID,
 		&user.Username,
+		&user.Email,
 		&user.PasswordHash, // Теперь получаем хеш пароля
 		&user.CreatedAt,
 	)
@@ -56,24 +65,29 @@ func (s *Storage) GetUserByUsername(username string) (*models.User, error) {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrUserNotFound
 		}
-		return nil, err
+		return nil, ctxError(ctx, err)
 	}
 
 	return user, nil
 }
 
-// GetUserByID получает пользователя по ID
-func (s *Storage) GetUserByID(id int) (*models.User, error) {
+// GetUserByID получает пользователя по ID (с хешем пароля — для его проверки при смене)
+func (s *Storage) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-		SELECT id, username, created_at
+		SELECT id, username, COALESCE(email, ''), password_hash, created_at
 		FROM users
 		WHERE id = $1
 	`
 
 	user := &models.User{}
-	err := s.db.QueryRow(query, id).Scan(
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
 		&user.CreatedAt,
 	)
 
@@ -81,8 +95,65 @@ func (s *Storage) GetUserByID(id int) (*models.User, error) {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrUserNotFound
 		}
-		return nil, err
+		return nil, ctxError(ctx, err)
 	}
 
 	return user, nil
 }
+
+// UpdatePassword меняет хеш пароля пользователя и в той же транзакции отзывает
+// все его сессии и неиспользованные токены сброса
+func (s *Storage) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = $1 WHERE id = $2`, passwordHash, userID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	if err := revokeCredentials(ctx, tx, userID); err != nil {
+		return ctxError(ctx, err)
+	}
+
+	return ctxError(ctx, tx.Commit())
+}
+
+// DeleteUser удаляет пользователя; заметки, сессии и токены удаляются каскадно
+func (s *Storage) DeleteUser(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM users WHERE id = $1`
+
+	result, err := s.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(content, '')), 'B')
+    ) STORED;
+
+CREATE INDEX idx_notes_search_vector ON notes USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notes_search_vector;
+ALTER TABLE notes DROP COLUMN search_vector;
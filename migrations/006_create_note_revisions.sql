@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS note_revisions (
+    id SERIAL PRIMARY KEY,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (note_id, revision)
+);
+
+-- Текущее состояние существующих заметок становится первой ревизией
+INSERT INTO note_revisions (note_id, revision, title, content, created_at)
+SELECT id, 1, title, content, updated_at FROM notes;
+
+-- +goose Down
+DROP TABLE IF EXISTS note_revisions;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN deleted_at TIMESTAMP NULL;
+
+CREATE INDEX idx_notes_deleted_at ON notes(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notes_deleted_at;
+ALTER TABLE notes DROP COLUMN deleted_at;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN email VARCHAR(255) NULL;
+
+CREATE TABLE IF NOT EXISTS password_resets (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_resets_user_id ON password_resets(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS password_resets;
+ALTER TABLE users DROP COLUMN email;
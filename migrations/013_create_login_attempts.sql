@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key VARCHAR(300) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP NULL
+);
+
+CREATE TABLE IF NOT EXISTS lockout_events (
+    id SERIAL PRIMARY KEY,
+    key VARCHAR(300) NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    failures INTEGER NOT NULL,
+    locked_until TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_lockout_events_created_at ON lockout_events(created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS lockout_events;
+DROP TABLE IF EXISTS login_attempts;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notebooks (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id INTEGER NULL REFERENCES notebooks(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (parent_id <> id)
+);
+
+CREATE INDEX idx_notebooks_user_id ON notebooks(user_id);
+CREATE INDEX idx_notebooks_parent_id ON notebooks(parent_id);
+
+-- Удалённый блокнот не удаляет заметки: они остаются вне блокнотов
+ALTER TABLE notes ADD COLUMN notebook_id INTEGER NULL REFERENCES notebooks(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_notes_notebook_id ON notes(notebook_id);
+
+-- +goose Down
+ALTER TABLE notes DROP COLUMN IF EXISTS notebook_id;
+DROP TABLE IF EXISTS notebooks;
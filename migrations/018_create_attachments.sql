@@ -0,0 +1,42 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    sha256 CHAR(64) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attachments_note_id ON attachments(note_id);
+CREATE INDEX idx_attachments_user_id ON attachments(user_id);
+
+-- Очередь blob'ов на удаление: метаданные удаляются вместе с заметкой (ON DELETE CASCADE),
+-- а сами файлы стирает фоновая задача
+CREATE TABLE IF NOT EXISTS orphaned_blobs (
+    id SERIAL PRIMARY KEY,
+    storage_key VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION queue_orphaned_blob() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO orphaned_blobs (storage_key) VALUES (OLD.storage_key);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER attachments_queue_orphaned_blob
+    AFTER DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION queue_orphaned_blob();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS attachments_queue_orphaned_blob ON attachments;
+DROP FUNCTION IF EXISTS queue_orphaned_blob();
+DROP TABLE IF EXISTS orphaned_blobs;
+DROP TABLE IF EXISTS attachments;
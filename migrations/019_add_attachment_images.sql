@@ -0,0 +1,43 @@
+-- +goose Up
+-- Обработка изображений фоновой задачей: pending -> processing -> ready / failed (NULL — не изображение)
+ALTER TABLE attachments ADD COLUMN image_status VARCHAR(20) NULL;
+ALTER TABLE attachments ADD COLUMN image_claimed_at TIMESTAMP NULL;
+ALTER TABLE attachments ADD COLUMN width INTEGER NULL;
+ALTER TABLE attachments ADD COLUMN height INTEGER NULL;
+ALTER TABLE attachments ADD COLUMN thumbnail_key VARCHAR(255) NULL;
+ALTER TABLE attachments ADD COLUMN thumbnail_content_type VARCHAR(50) NULL;
+ALTER TABLE attachments ADD COLUMN thumbnail_size BIGINT NULL;
+
+CREATE INDEX idx_attachments_image_pending ON attachments(id) WHERE image_status IN ('pending', 'processing');
+
+-- Вместе с вложением удаляется и его миниатюра
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION queue_orphaned_blob() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO orphaned_blobs (storage_key) VALUES (OLD.storage_key);
+    IF OLD.thumbnail_key IS NOT NULL THEN
+        INSERT INTO orphaned_blobs (storage_key) VALUES (OLD.thumbnail_key);
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION queue_orphaned_blob() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO orphaned_blobs (storage_key) VALUES (OLD.storage_key);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS idx_attachments_image_pending;
+ALTER TABLE attachments DROP COLUMN IF EXISTS thumbnail_size;
+ALTER TABLE attachments DROP COLUMN IF EXISTS thumbnail_content_type;
+ALTER TABLE attachments DROP COLUMN IF EXISTS thumbnail_key;
+ALTER TABLE attachments DROP COLUMN IF EXISTS height;
+ALTER TABLE attachments DROP COLUMN IF EXISTS width;
+ALTER TABLE attachments DROP COLUMN IF EXISTS image_claimed_at;
+ALTER TABLE attachments DROP COLUMN IF EXISTS image_status;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN content_format VARCHAR(20) NOT NULL DEFAULT 'plain'
+    CHECK (content_format IN ('plain', 'markdown'));
+
+ALTER TABLE note_revisions ADD COLUMN content_format VARCHAR(20) NOT NULL DEFAULT 'plain';
+
+-- Кэш HTML ревизии: ревизии неизменяемы, поэтому HTML строится один раз.
+-- html_renderer — версия рендерера, при её смене HTML строится заново
+ALTER TABLE note_revisions ADD COLUMN content_html TEXT NULL;
+ALTER TABLE note_revisions ADD COLUMN html_renderer VARCHAR(20) NULL;
+
+-- +goose Down
+ALTER TABLE note_revisions DROP COLUMN IF EXISTS html_renderer;
+ALTER TABLE note_revisions DROP COLUMN IF EXISTS content_html;
+ALTER TABLE note_revisions DROP COLUMN IF EXISTS content_format;
+ALTER TABLE notes DROP COLUMN IF EXISTS content_format;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Ссылки между заметками: [[Заголовок]] (target_title) или note:42 (target_id).
+-- Цель ищется при чтении, поэтому созданная позже или переименованная заметка подхватывается без пересохранения
+CREATE TABLE IF NOT EXISTS note_links (
+    id SERIAL PRIMARY KEY,
+    source_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    target_id INTEGER NULL,
+    target_title VARCHAR(255) NULL,
+    CHECK ((target_id IS NULL) <> (target_title IS NULL))
+);
+
+CREATE INDEX idx_note_links_source_id ON note_links(source_id);
+CREATE INDEX idx_note_links_target_id ON note_links(target_id) WHERE target_id IS NOT NULL;
+CREATE INDEX idx_notes_user_lower_title ON notes(user_id, LOWER(title)) WHERE deleted_at IS NULL;
+
+-- Ссылки существующих заметок. Ссылки внутри блоков кода здесь не отбрасываются — это сделает первое сохранение заметки
+INSERT INTO note_links (source_id, target_title)
+SELECT DISTINCT ON (n.id, LOWER(t.title)) n.id, t.title
+FROM notes n,
+    LATERAL (
+        SELECT BTRIM(SPLIT_PART(SPLIT_PART(m[1], '|', 1), '#', 1)) AS title
+        FROM REGEXP_MATCHES(n.content, '\[\[([^][\n]+)\]\]', 'g') AS m
+    ) t
+WHERE t.title <> '' AND LENGTH(t.title) <= 255;
+
+INSERT INTO note_links (source_id, target_id)
+SELECT DISTINCT n.id, m[1]::integer
+FROM notes n, REGEXP_MATCHES(n.content, '\mnote:([1-9][0-9]{0,8})\M', 'g') AS m;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notes_user_lower_title;
+DROP TABLE IF EXISTS note_links;
@@ -0,0 +1,54 @@
+-- +goose Up
+-- Импорт zip архива заметок фоновой задачей: pending -> processing -> completed / failed.
+-- Прогресс пишется в той же транзакции, что и заметки, поэтому прерванный импорт продолжается с processed_files
+CREATE TABLE IF NOT EXISTS imports (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    storage_key VARCHAR(255) NULL, -- архив; после завершения уходит в очередь orphaned_blobs
+    total_files INTEGER NOT NULL DEFAULT 0,
+    processed_files INTEGER NOT NULL DEFAULT 0,
+    imported_notes INTEGER NOT NULL DEFAULT 0,
+    failed_files INTEGER NOT NULL DEFAULT 0,
+    created_notebooks INTEGER NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    claimed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_imports_user_id ON imports(user_id);
+CREATE INDEX idx_imports_pending ON imports(id) WHERE status IN ('pending', 'processing');
+
+-- Отчёт по файлам, которые не удалось импортировать
+CREATE TABLE IF NOT EXISTS import_errors (
+    id SERIAL PRIMARY KEY,
+    import_id INTEGER NOT NULL REFERENCES imports(id) ON DELETE CASCADE,
+    file TEXT NOT NULL,
+    error TEXT NOT NULL
+);
+
+CREATE INDEX idx_import_errors_import_id ON import_errors(import_id);
+
+-- Архив незавершённого импорта удалённого пользователя тоже нужно стереть
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION queue_import_blob() RETURNS trigger AS $$
+BEGIN
+    IF OLD.storage_key IS NOT NULL THEN
+        INSERT INTO orphaned_blobs (storage_key) VALUES (OLD.storage_key);
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER imports_queue_orphaned_blob
+    AFTER DELETE ON imports
+    FOR EACH ROW EXECUTE FUNCTION queue_import_blob();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS imports_queue_orphaned_blob ON imports;
+DROP FUNCTION IF EXISTS queue_import_blob();
+DROP TABLE IF EXISTS import_errors;
+DROP TABLE IF EXISTS imports;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Сколько раз подряд импорт забирали в обработку без прогресса. Обнуляется записанной порцией,
+-- после нескольких неудачных попыток импорт завершается со статусом failed
+ALTER TABLE imports ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE imports DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ChallengeTTL - сколько действует challenge токен между паролем и кодом 2FA
+var ChallengeTTL = 5 * time.Minute
+
+// TwoFactorIssuer - имя сервиса в приложении-аутентификаторе
+var TwoFactorIssuer = "Notes API"
+
+const challengePurpose = "2fa"
+
+// RecoveryCodeCount - сколько кодов восстановления выдаётся при включении 2FA
+const RecoveryCodeCount = 10
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateChallengeToken выдаёт короткоживущий токен после проверки пароля;
+// он обменивается на access токен только вместе с кодом 2FA
+func GenerateChallengeToken(userID int, username string) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:   userID,
+		Username: username,
+		Purpose:  challengePurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return signClaims(claims)
+}
+
+// ValidateChallengeToken проверяет challenge токен
+func ValidateChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != challengePurpose {
+		return nil, errors.New("not a challenge token")
+	}
+	return claims, nil
+}
+
+// GenerateRecoveryCodes создаёт одноразовые коды восстановления вида xxxxx-xxxxx и их хеши
+func GenerateRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode хеширует код восстановления без учёта регистра и дефисов
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return HashToken(code)
+}
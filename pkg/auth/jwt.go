@@ -7,44 +7,78 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte("minetik-secretik")
+// keys - ключи подписи JWT, задаются при старте через SetKeys
+var keys *KeySet
+
+// SetKeys задаёт набор ключей для выдачи и проверки токенов
+func SetKeys(ks *KeySet) {
+	keys = ks
+}
+
+// AccessTokenTTL - время жизни access токена (продлевается через refresh токен)
+var AccessTokenTTL = 15 * time.Minute
 
 // данные Claims  которые хранятся в JWT
 type Claims struct {
-	UserID   int    `json:"user_id"`
-	Username string `json:"username"`
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID int    `json:"sid"`
+	Purpose   string `json:"purpose,omitempty"` // пусто — access токен, иначе служебный (например 2FA challenge)
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int, username string) (string, error) {
-	//токен действителен 24 часа
-	expirationTime := time.Now().Add(24 * time.Hour)
+func GenerateToken(userID int, username string, sessionID int) (string, error) {
+	//токен действителен AccessTokenTTL
+	expirationTime := time.Now().Add(AccessTokenTTL)
 
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecret)
+	return signClaims(claims)
+}
+
+func ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return tokenString, nil
+	// Служебные токены (2FA challenge) не дают доступа к API
+	if claims.Purpose != "" {
+		return nil, errors.New("invalid token!")
+	}
 
+	return claims, nil
 }
 
-func ValidateToken(tokenString string) (*Claims, error) {
+// signClaims подписывает claims текущим ключом из набора
+func signClaims(claims *Claims) (string, error) {
+	if keys == nil {
+		return "", ErrNoSigningKey
+	}
+	key := keys.SigningKey()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// parseClaims проверяет подпись и срок действия токена
+func parseClaims(tokenString string) (*Claims, error) {
+	if keys == nil {
+		return nil, ErrNoSigningKey
+	}
+
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.lookup)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey   = errors.New("jwt signing key is not configured")
+	ErrUnknownKeyID   = errors.New("unknown jwt key id")
+	ErrUnsupportedKey = errors.New("unsupported jwt key: expected RSA or Ed25519 PEM")
+)
+
+// Key - ключ подписи JWT. Ключ без приватной части (только публичный)
+// используется лишь для проверки токенов во время ротации
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// CanSign сообщает, есть ли у ключа приватная часть
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// NewHMACKey создаёт симметричный HS256 ключ
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// ParseKeyPEM разбирает PEM ключ: приватный RSA (PKCS#1/PKCS#8) или Ed25519 (PKCS#8),
+// либо публичный (PKIX) — тогда ключ годится только для проверки
+func ParseKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM block found", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %q: %w", id, ErrUnsupportedKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, verifyKey: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, verifyKey: k}, nil
+	default:
+		return nil, fmt.Errorf("key %q: %w", id, ErrUnsupportedKey)
+	}
+}
+
+// KeySet - набор ключей: одним подписываются новые токены,
+// все остальные принимаются при проверке (выбираются по заголовку kid)
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	order   []string
+}
+
+// NewKeySet создаёт набор ключей; signingID — ключ для подписи (пусто — первый в списке)
+func NewKeySet(keys []*Key, signingID string) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoSigningKey
+	}
+
+	ks := &KeySet{keys: make(map[string]*Key)}
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("jwt key id must not be empty")
+		}
+		if _, ok := ks.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", k.ID)
+		}
+		ks.keys[k.ID] = k
+		ks.order = append(ks.order, k.ID)
+	}
+
+	if signingID == "" {
+		signingID = keys[0].ID
+	}
+	signing, ok := ks.keys[signingID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q: %w", signingID, ErrUnknownKeyID)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("signing key %q has no private part", signingID)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+// SigningKey возвращает ключ, которым подписываются новые токены
+func (ks *KeySet) SigningKey() *Key {
+	return ks.signing
+}
+
+// lookup подбирает ключ для проверки токена по kid и алгоритму из заголовка
+func (ks *KeySet) lookup(token *jwt.Token) (interface{}, error) {
+	key := ks.signing
+	if kid, ok := token.Header["kid"].(string); ok {
+		if key, ok = ks.keys[kid]; !ok {
+			return nil, ErrUnknownKeyID
+		}
+	}
+	// Алгоритм токена должен совпадать с типом ключа (защита от подмены alg)
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// LoadKeySetFromEnv собирает ключи из переменных окружения:
+//
+//	JWT_KEYS        — список "kid=путь/к/ключу.pem" через запятую (RSA или Ed25519)
+//	JWT_SIGNING_KEY — kid ключа для подписи (по умолчанию первый)
+//	JWT_SECRET      — секрет HS256 (kid из JWT_SECRET_KID, по умолчанию "hs256")
+//
+// Без ключей создаётся случайный HS256 секрет: токены не переживут перезапуск
+func LoadKeySetFromEnv() (*KeySet, error) {
+	var keys []*Key
+
+	if spec := strings.TrimSpace(os.Getenv("JWT_KEYS")); spec != "" {
+		for _, item := range strings.Split(spec, ",") {
+			id, path, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if !ok || id == "" || path == "" {
+				return nil, fmt.Errorf("invalid JWT_KEYS entry %q, expected kid=path", item)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", id, err)
+			}
+			key, err := ParseKeyPEM(id, data)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		id := os.Getenv("JWT_SECRET_KID")
+		if id == "" {
+			id = "hs256"
+		}
+		keys = append(keys, NewHMACKey(id, []byte(secret)))
+	}
+
+	if len(keys) == 0 {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		keys = append(keys, NewHMACKey("ephemeral", secret))
+	}
+
+	return NewKeySet(keys, os.Getenv("JWT_SIGNING_KEY"))
+}
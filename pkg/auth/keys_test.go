@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// pemBlock кодирует ключ в PEM
+func pemBlock(t *testing.T, typ string, der []byte, err error) []byte {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8 := func(key interface{}) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		return pemBlock(t, "PRIVATE KEY", der, err)
+	}
+	pkix := func(key interface{}) []byte {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		return pemBlock(t, "PUBLIC KEY", der, err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		alg     string
+		canSign bool
+	}{
+		{"RSA PKCS#1", pemBlock(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), nil), "RS256", true},
+		{"RSA PKCS#8", pkcs8(rsaKey), "RS256", true},
+		{"RSA public", pkix(&rsaKey.PublicKey), "RS256", false},
+		{"Ed25519", pkcs8(edPriv), "EdDSA", true},
+		{"Ed25519 public", pkix(edPub), "EdDSA", false},
+	}
+	for _, tt := range tests {
+		key, err := ParseKeyPEM("k", tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if key.Method.Alg() != tt.alg || key.CanSign() != tt.canSign {
+			t.Errorf("%s: alg %s, can sign %v", tt.name, key.Method.Alg(), key.CanSign())
+		}
+	}
+
+	if _, err := ParseKeyPEM("k", []byte("not a pem")); err == nil {
+		t.Error("garbage accepted")
+	}
+	if _, err := ParseKeyPEM("k", pemBlock(t, "CERTIFICATE", []byte{1}, nil)); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("certificate: %v", err)
+	}
+}
+
+func TestNewKeySet(t *testing.T) {
+	a := NewHMACKey("a", []byte("secret-a"))
+	b := NewHMACKey("b", []byte("secret-b"))
+	_, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKIXPublicKey(edPriv.Public())
+	public, err := ParseKeyPEM("public", pemBlock(t, "PUBLIC KEY", der, err))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    []*Key
+		signing string
+		want    string // kid ключа подписи, пусто — ошибка
+	}{
+		{"first by default", []*Key{a, b}, "", "a"},
+		{"explicit", []*Key{a, b}, "b", "b"},
+		{"no keys", nil, "", ""},
+		{"unknown signing key", []*Key{a}, "c", ""},
+		{"duplicate id", []*Key{a, a}, "", ""},
+		{"public key cannot sign", []*Key{public, a}, "", ""},
+	}
+	for _, tt := range tests {
+		ks, err := NewKeySet(tt.keys, tt.signing)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			continue
+		}
+		if err != nil || ks.SigningKey().ID != tt.want {
+			t.Errorf("%s: %v, %v", tt.name, ks, err)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old := NewHMACKey("old", []byte("old-secret"))
+	next := NewHMACKey("new", []byte("new-secret"))
+	t.Cleanup(func() { SetKeys(nil) })
+
+	ks, _ := NewKeySet([]*Key{old}, "")
+	SetKeys(ks)
+	oldToken, err := GenerateToken(1, "alice", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Новый ключ подписывает, старый ещё принимается
+	ks, _ = NewKeySet([]*Key{next, old}, "new")
+	SetKeys(ks)
+	if _, err := ValidateToken(oldToken); err != nil {
+		t.Errorf("token of the previous key: %v", err)
+	}
+	newToken, _ := GenerateToken(1, "alice", 1)
+	if claims, err := ValidateToken(newToken); err != nil || claims.UserID != 1 {
+		t.Errorf("token of the new key: %v, %v", claims, err)
+	}
+
+	// Старый ключ убран из набора
+	ks, _ = NewKeySet([]*Key{next}, "")
+	SetKeys(ks)
+	if _, err := ValidateToken(oldToken); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("token of a removed key: %v", err)
+	}
+
+	// Подмена алгоритма: kid указывает на HS256 ключ, а токен подписан "none"
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodNone, &Claims{UserID: 1}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := ValidateToken(forged); err == nil {
+		t.Error("unsigned token accepted")
+	}
+
+	SetKeys(nil)
+	if _, err := GenerateToken(1, "alice", 1); err != ErrNoSigningKey {
+		t.Errorf("GenerateToken without keys: %v", err)
+	}
+}
@@ -11,3 +11,12 @@ func CheckPassword(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// dummyHash считается при старте, чтобы первая проверка не была медленнее остальных
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// CheckDummyPassword тратит на проверку столько же времени, сколько CheckPassword.
+// Вызывается, когда пользователя нет, чтобы по времени ответа нельзя было это узнать
+func CheckDummyPassword(password string) {
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
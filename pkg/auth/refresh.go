@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// RefreshTokenTTL - время жизни refresh токена
+var RefreshTokenTTL = 30 * 24 * time.Hour
+
+// GenerateRefreshToken создаёт случайный refresh токен и его хеш для хранения в БД
+func GenerateRefreshToken() (token, hash string, err error) {
+	return generateOpaqueToken("")
+}
+
+// HashToken возвращает sha256 хеш токена (в БД хранится только он)
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateOpaqueToken создаёт 256-битный случайный токен с префиксом
+func generateOpaqueToken(prefix string) (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = prefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// APITokenPrefix отличает personal access токены от JWT в заголовке Authorization
+const APITokenPrefix = "nat_"
+
+// GenerateAPIToken создаёт personal access токен и его хеш для хранения в БД
+func GenerateAPIToken() (token, hash string, err error) {
+	return generateOpaqueToken(APITokenPrefix)
+}
+
+// IsAPIToken сообщает, является ли строка personal access токеном
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
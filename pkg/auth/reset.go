@@ -0,0 +1,11 @@
+package auth
+
+import "time"
+
+// PasswordResetTTL - время жизни токена сброса пароля
+var PasswordResetTTL = time.Hour
+
+// GenerateResetToken создаёт одноразовый токен сброса пароля и его хеш
+func GenerateResetToken() (token, hash string, err error) {
+	return generateOpaqueToken("")
+}
@@ -0,0 +1,9 @@
+package auth
+
+// ShareLinkPrefix отличает токены публичных ссылок от остальных токенов
+const ShareLinkPrefix = "nsl_"
+
+// GenerateShareLinkToken создаёт токен публичной ссылки на заметку и его хеш
+func GenerateShareLinkToken() (token, hash string, err error) {
+	return generateOpaqueToken(ShareLinkPrefix)
+}
@@ -0,0 +1,188 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxEdits - сколько строк можно удалить и добавить в сумме. Память на поиск растёт как квадрат
+// этого числа, поэтому тексты, отличающиеся сильнее, не сравниваются
+const MaxEdits = 1000
+
+var ErrTooLarge = errors.New("texts differ in too many lines to build a diff")
+
+// op - одна строка результата сравнения: ' ' (без изменений), '-' (удалена), '+' (добавлена)
+type op struct {
+	kind byte
+	line string
+}
+
+// Unified возвращает построчный diff двух текстов в формате unified diff
+// с context строками контекста вокруг изменений. Пустая строка — тексты совпадают.
+// Если тексты отличаются больше чем на MaxEdits строк — ErrTooLarge
+func Unified(from, to, fromName, toName string, context int) (string, error) {
+	ops, ok := diffLines(splitLines(from), splitLines(to), MaxEdits)
+	if !ok {
+		return "", ErrTooLarge
+	}
+
+	var changes []int
+	for i, o := range ops {
+		if o.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+
+	// Группируем изменения в hunk'и: если между изменениями не больше 2*context
+	// одинаковых строк, они попадают в один hunk
+	for i := 0; i < len(changes); {
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context+1 {
+			j++
+		}
+
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[j] + context + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		writeHunk(&b, ops, start, end)
+		i = j + 1
+	}
+
+	return b.String(), nil
+}
+
+func writeHunk(b *strings.Builder, ops []op, start, end int) {
+	// Номера строк до начала hunk'а
+	aPos, bPos := 0, 0
+	for _, o := range ops[:start] {
+		if o.kind != '+' {
+			aPos++
+		}
+		if o.kind != '-' {
+			bPos++
+		}
+	}
+
+	aLen, bLen := 0, 0
+	for _, o := range ops[start:end] {
+		if o.kind != '+' {
+			aLen++
+		}
+		if o.kind != '-' {
+			bLen++
+		}
+	}
+
+	aStart, bStart := aPos, bPos
+	if aLen > 0 {
+		aStart++
+	}
+	if bLen > 0 {
+		bStart++
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+	for _, o := range ops[start:end] {
+		b.WriteByte(o.kind)
+		b.WriteString(o.line)
+		b.WriteByte('\n')
+	}
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines находит кратчайший скрипт редактирования (алгоритм Майерса).
+// На шаге d сохраняется только диагонали -d..d, поэтому память — O(d²), а не O((n+m)·d).
+// Если скрипт длиннее maxEdits — false
+func diffLines(a, b []string, maxEdits int) ([]op, bool) {
+	n, m := len(a), len(b)
+	max := n + m
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	found := false
+search:
+	for d := 0; d <= max && d <= maxEdits; d++ {
+		// trace[d] - значения v перед шагом d, trace[d][i] соответствует диагонали i-d
+		trace = append(trace, append([]int(nil), v[max-d:max+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+				x = v[max+k+1]
+			} else {
+				x = v[max+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				found = true
+				break search
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	// Восстанавливаем путь с конца
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = v[d+prevK]
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, op{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, op{'+', b[y-1]})
+			} else {
+				ops = append(ops, op{'-', a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops, true
+}
@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		context  int
+		want     string
+	}{
+		{
+			name: "equal",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+			want: "",
+		},
+		{
+			name:    "changed line",
+			from:    "a\nb\nc\n",
+			to:      "a\nB\nc\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:    "from empty",
+			from:    "",
+			to:      "x\n",
+			context: 3,
+			want:    "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+x\n",
+		},
+		{
+			name:    "to empty",
+			from:    "x\n",
+			to:      "",
+			context: 3,
+			want:    "--- old\n+++ new\n@@ -1,1 +0,0 @@\n-x\n",
+		},
+		{
+			name:    "separate hunks",
+			from:    "1\n2\n3\n4\n5\n6\n7\n8\n",
+			to:      "1\nX\n3\n4\n5\n6\nY\n8\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,3 +1,3 @@\n 1\n-2\n+X\n 3\n@@ -6,3 +6,3 @@\n 6\n-7\n+Y\n 8\n",
+		},
+		{
+			name:    "merged hunk",
+			from:    "1\n2\n3\n4\n",
+			to:      "1\nX\n3\nY\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,4 +1,4 @@\n 1\n-2\n+X\n 3\n-4\n+Y\n",
+		},
+		{
+			name:    "no trailing newline",
+			from:    "a\nb",
+			to:      "a\nb\nc",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -2,1 +2,2 @@\n b\n+c\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unified(tt.from, tt.to, "old", "new", tt.context)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedTooLarge(t *testing.T) {
+	lines := func(n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteString(strconv.Itoa(i) + "\n")
+		}
+		return b.String()
+	}
+
+	if _, err := Unified("", lines(MaxEdits), "old", "new", 3); err != nil {
+		t.Errorf("%d edits: %v", MaxEdits, err)
+	}
+	if _, err := Unified("", lines(MaxEdits+1), "old", "new", 3); err != ErrTooLarge {
+		t.Errorf("%d edits: got %v, want ErrTooLarge", MaxEdits+1, err)
+	}
+	// Размер текстов не важен, если отличий мало
+	big := lines(20000)
+	if _, err := Unified(big, big+"x\n", "old", "new", 3); err != nil {
+		t.Errorf("one edit in a large text: %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+const (
+	tagOrientation = 0x0112
+	tagGPSInfo     = 0x8825
+)
+
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+
+	errMalformedExif = errors.New("imaging: malformed EXIF")
+)
+
+// exifBlock - EXIF (TIFF структура) внутри файла вместе с границами содержащего её сегмента JPEG или чанка PNG.
+// У чанка PNG после изменения нужно пересчитать CRC
+type exifBlock struct {
+	start, end int // границы TIFF данных
+	segStart   int // начало всего сегмента/чанка (для вырезания)
+	segEnd     int
+	png        bool
+}
+
+// StripLocation удаляет GPS данные из EXIF в JPEG или PNG, остальные теги (ориентация, камера) сохраняются.
+// Если EXIF не удаётся разобрать, он удаляется целиком. Возвращает новое содержимое и true, если файл изменился
+func StripLocation(data []byte) ([]byte, bool) {
+	blocks := findExif(data)
+	if len(blocks) == 0 {
+		return data, false
+	}
+
+	out := append([]byte(nil), data...)
+	changed := false
+	var broken []exifBlock
+	for _, b := range blocks {
+		stripped, err := stripGPS(out[b.start:b.end])
+		if err != nil {
+			broken = append(broken, b)
+			continue
+		}
+		if stripped {
+			changed = true
+			if b.png {
+				updatePNGChunkCRC(out, b)
+			}
+		}
+	}
+
+	// Нераспознанный EXIF вырезается целиком, с конца, чтобы не сдвигать оставшиеся смещения
+	for i := len(broken) - 1; i >= 0; i-- {
+		out = append(out[:broken[i].segStart], out[broken[i].segEnd:]...)
+		changed = true
+	}
+
+	if !changed {
+		return data, false
+	}
+	return out, true
+}
+
+// Orientation возвращает EXIF ориентацию изображения (1–8), 1 — если её нет
+func Orientation(data []byte) int {
+	for _, b := range findExif(data) {
+		tiff := data[b.start:b.end]
+		order, ifd, err := tiffHeader(tiff)
+		if err != nil {
+			continue
+		}
+		n, err := ifdCount(tiff, order, ifd)
+		if err != nil {
+			continue
+		}
+		for i := 0; i < n; i++ {
+			entry := ifd + 2 + 12*i
+			if order.Uint16(tiff[entry:]) == tagOrientation {
+				if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+					return o
+				}
+			}
+		}
+	}
+	return 1
+}
+
+// findExif находит EXIF блоки: сегменты APP1 в JPEG и чанки eXIf в PNG
+func findExif(data []byte) []exifBlock {
+	switch {
+	case len(data) > 2 && data[0] == 0xFF && data[1] == 0xD8:
+		return findJPEGExif(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return findPNGExif(data)
+	}
+	return nil
+}
+
+func findJPEGExif(data []byte) []exifBlock {
+	var blocks []exifBlock
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			break
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++ // заполнитель
+			continue
+		}
+		// SOS — дальше сжатые данные, EOI — конец файла
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			pos += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+
+		payload := pos + 4
+		if marker == 0xE1 && bytes.HasPrefix(data[payload:end], jpegExifHeader) {
+			blocks = append(blocks, exifBlock{
+				start:    payload + len(jpegExifHeader),
+				end:      end,
+				segStart: pos,
+				segEnd:   end,
+			})
+		}
+		pos = end
+	}
+	return blocks
+}
+
+func findPNGExif(data []byte) []exifBlock {
+	var blocks []exifBlock
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if end > len(data) {
+			break
+		}
+
+		chunkType := string(data[pos+4 : pos+8])
+		if chunkType == "eXIf" {
+			blocks = append(blocks, exifBlock{
+				start:    pos + 8,
+				end:      pos + 8 + length,
+				segStart: pos,
+				segEnd:   end,
+				png:      true,
+			})
+		}
+		if chunkType == "IEND" {
+			break
+		}
+		pos = end
+	}
+	return blocks
+}
+
+func updatePNGChunkCRC(data []byte, b exifBlock) {
+	crc := crc32.ChecksumIEEE(data[b.segStart+4 : b.end])
+	binary.BigEndian.PutUint32(data[b.end:], crc)
+}
+
+// stripGPS удаляет из TIFF структуры ссылку на GPS IFD и затирает нулями сам GPS IFD со значениями.
+// Размер и остальные смещения не меняются: запись убирается из IFD0 сдвигом, освободившиеся байты обнуляются
+func stripGPS(tiff []byte) (bool, error) {
+	order, ifd, err := tiffHeader(tiff)
+	if err != nil {
+		return false, err
+	}
+	n, err := ifdCount(tiff, order, ifd)
+	if err != nil {
+		return false, err
+	}
+
+	gpsEntry := -1
+	for i := 0; i < n; i++ {
+		if order.Uint16(tiff[ifd+2+12*i:]) == tagGPSInfo {
+			gpsEntry = i
+			break
+		}
+	}
+	if gpsEntry < 0 {
+		return false, nil
+	}
+
+	gps := int(order.Uint32(tiff[ifd+2+12*gpsEntry+8:]))
+	if err := zeroIFD(tiff, order, gps); err != nil {
+		return false, err
+	}
+
+	// Записи после GPS и указатель на следующий IFD сдвигаются на одну запись вверх
+	entries := ifd + 2
+	tail := entries + 12*n + 4
+	copy(tiff[entries+12*gpsEntry:], tiff[entries+12*(gpsEntry+1):tail])
+	clear(tiff[tail-12 : tail])
+	order.PutUint16(tiff[ifd:], uint16(n-1))
+	return true, nil
+}
+
+// zeroIFD обнуляет IFD по смещению offset и значения его записей, хранящиеся вне записей
+func zeroIFD(tiff []byte, order binary.ByteOrder, offset int) error {
+	n, err := ifdCount(tiff, order, offset)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		entry := offset + 2 + 12*i
+		size := typeSize(order.Uint16(tiff[entry+2:]))
+		if size == 0 {
+			return errMalformedExif
+		}
+		total := uint64(size) * uint64(order.Uint32(tiff[entry+4:]))
+		if total <= 4 {
+			continue
+		}
+		value := uint64(order.Uint32(tiff[entry+8:]))
+		if value+total > uint64(len(tiff)) {
+			return errMalformedExif
+		}
+		clear(tiff[value : value+total])
+	}
+
+	clear(tiff[offset : offset+2+12*n+4])
+	return nil
+}
+
+func tiffHeader(tiff []byte) (binary.ByteOrder, int, error) {
+	if len(tiff) < 8 {
+		return nil, 0, errMalformedExif
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0, errMalformedExif
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return nil, 0, errMalformedExif
+	}
+
+	return order, int(order.Uint32(tiff[4:])), nil
+}
+
+// ifdCount возвращает число записей IFD, проверяя, что IFD целиком лежит внутри данных
+func ifdCount(tiff []byte, order binary.ByteOrder, offset int) (int, error) {
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0, errMalformedExif
+	}
+	n := int(order.Uint16(tiff[offset:]))
+	if offset+2+12*n+4 > len(tiff) {
+		return 0, errMalformedExif
+	}
+	return n, nil
+}
+
+// typeSize - размер одного значения TIFF типа в байтах, 0 — неизвестный тип
+func typeSize(t uint16) int {
+	switch t {
+	case 1, 2, 6, 7: // BYTE, ASCII, SBYTE, UNDEFINED
+		return 1
+	case 3, 8: // SHORT, SSHORT
+		return 2
+	case 4, 9, 11: // LONG, SLONG, FLOAT
+		return 4
+	case 5, 10, 12: // RATIONAL, SRATIONAL, DOUBLE
+		return 8
+	}
+	return 0
+}
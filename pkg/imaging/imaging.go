@@ -0,0 +1,156 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // регистрирует декодер GIF
+	"image/jpeg"
+	"image/png"
+)
+
+// MaxPixels ограничивает размер декодируемого изображения: маленький файл может распаковаться в гигабайты
+const MaxPixels = 40_000_000
+
+var (
+	ErrUnsupported = errors.New("imaging: unsupported image format")
+	ErrTooLarge    = errors.New("imaging: image is too large")
+)
+
+// Result - миниатюра и размеры исходного изображения
+type Result struct {
+	Width       int // с учётом EXIF ориентации, то есть как изображение показывается
+	Height      int
+	Thumbnail   []byte
+	ContentType string // image/jpeg для JPEG, image/png для остальных (сохраняется прозрачность)
+}
+
+// Thumbnail декодирует JPEG, PNG или GIF (первый кадр), поворачивает по EXIF ориентации
+// и уменьшает так, чтобы изображение помещалось в квадрат size×size. Маленькие изображения не увеличиваются
+func Thumbnail(data []byte, size int) (*Result, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	width, height := fit(src.Bounds().Dx(), src.Bounds().Dy(), size)
+	orientation := Orientation(data)
+	thumb := orient(resize(src, width, height), orientation)
+
+	result := &Result{Width: config.Width, Height: config.Height}
+	if orientation >= 5 {
+		result.Width, result.Height = config.Height, config.Width
+	}
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80})
+		result.ContentType = "image/jpeg"
+	} else {
+		err = png.Encode(&buf, thumb)
+		result.ContentType = "image/png"
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Thumbnail = buf.Bytes()
+	return result, nil
+}
+
+// fit возвращает размеры, в которые вписывается width×height внутри квадрата size×size
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, (height*size+width/2)/width)
+	}
+	return max(1, (width*size+height/2)/height), size
+}
+
+// resize уменьшает изображение усреднением: каждый пиксель результата — среднее покрываемого им блока исходных
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw == width && sh == height {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					count++
+				}
+			}
+
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0] = uint8(r / count)
+			d[1] = uint8(g / count)
+			d[2] = uint8(b / count)
+			d[3] = uint8(a / count)
+		}
+	}
+	return dst
+}
+
+// orient поворачивает и отражает изображение по EXIF ориентации (1 — без изменений)
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		for dx := 0; dx < dw; dx++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // отражение по горизонтали
+				sx, sy = w-1-dx, dy
+			case 3: // поворот на 180°
+				sx, sy = w-1-dx, h-1-dy
+			case 4: // отражение по вертикали
+				sx, sy = dx, h-1-dy
+			case 5: // транспонирование
+				sx, sy = dy, dx
+			case 6: // поворот на 90° по часовой
+				sx, sy = dy, h-1-dx
+			case 7: // транспонирование относительно побочной диагонали
+				sx, sy = w-1-dy, h-1-dx
+			case 8: // поворот на 90° против часовой
+				sx, sy = w-1-dy, dx
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], src.Pix[sy*src.Stride+sx*4:])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,256 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// byteOrder - порядок байт TIFF, в который можно и писать по смещению, и дописывать
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// gpsMarker - числитель широты в тестовом GPS IFD, по нему проверяется, что координаты стёрты
+const gpsMarker = 0x5EC2E7
+
+// testTIFF собирает EXIF (TIFF) с ориентацией и, если gps, со ссылкой на GPS IFD (GPSLatitudeRef и GPSLatitude)
+func testTIFF(order byteOrder, orientation int, gps bool) []byte {
+	var entries [][3]uint32 // tag, type, value
+	if orientation > 0 {
+		entries = append(entries, [3]uint32{tagOrientation, 3, uint32(orientation)})
+	}
+	n := len(entries)
+	if gps {
+		n++
+	}
+	gpsOffset := 8 + 2 + 12*n + 4
+
+	tiff := make([]byte, 8, 128)
+	if order.String() == binary.LittleEndian.String() {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+
+	tiff = order.AppendUint16(tiff, uint16(n))
+	if gps {
+		// Записи IFD идут по возрастанию тега, GPSInfo — последней
+		entries = append(entries, [3]uint32{tagGPSInfo, 4, uint32(gpsOffset)})
+	}
+	for _, e := range entries {
+		tiff = order.AppendUint16(tiff, uint16(e[0]))
+		tiff = order.AppendUint16(tiff, uint16(e[1]))
+		tiff = order.AppendUint32(tiff, 1)
+		if e[1] == 3 {
+			tiff = order.AppendUint16(tiff, uint16(e[2]))
+			tiff = append(tiff, 0, 0)
+		} else {
+			tiff = order.AppendUint32(tiff, e[2])
+		}
+	}
+	tiff = order.AppendUint32(tiff, 0)
+
+	if gps {
+		values := gpsOffset + 2 + 2*12 + 4
+		tiff = order.AppendUint16(tiff, 2)
+		// GPSLatitudeRef: ASCII "N\0" хранится в самой записи
+		tiff = order.AppendUint16(tiff, 1)
+		tiff = order.AppendUint16(tiff, 2)
+		tiff = order.AppendUint32(tiff, 2)
+		tiff = append(tiff, 'N', 0, 0, 0)
+		// GPSLatitude: 3 RATIONAL за пределами записи
+		tiff = order.AppendUint16(tiff, 2)
+		tiff = order.AppendUint16(tiff, 5)
+		tiff = order.AppendUint32(tiff, 3)
+		tiff = order.AppendUint32(tiff, uint32(values))
+		tiff = order.AppendUint32(tiff, 0)
+		for _, v := range []uint32{gpsMarker, 1000, 45, 1, 0, 1} {
+			tiff = order.AppendUint32(tiff, v)
+		}
+	}
+	return tiff
+}
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+// testJPEG кодирует изображение и вставляет сразу после SOI сегмент APP1 с EXIF
+func testJPEG(t *testing.T, width, height int, tiff []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(width, height), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if tiff == nil {
+		return data
+	}
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(jpegExifHeader)+len(tiff)))
+	segment = append(segment, jpegExifHeader...)
+	segment = append(segment, tiff...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// testPNG кодирует изображение и вставляет после IHDR чанк eXIf
+func testPNG(t *testing.T, width, height int, tiff []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(width, height)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if tiff == nil {
+		return data
+	}
+
+	ihdrEnd := len(pngSignature) + 12 + 13
+	out := append([]byte{}, data[:ihdrEnd]...)
+	out = append(out, pngChunk("eXIf", tiff)...)
+	return append(out, data[ihdrEnd:]...)
+}
+
+func pngChunk(chunkType string, payload []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func hasGPS(data []byte) bool {
+	return bytes.Contains(data, binary.LittleEndian.AppendUint32(nil, gpsMarker)) ||
+		bytes.Contains(data, binary.BigEndian.AppendUint32(nil, gpsMarker))
+}
+
+func TestStripLocation(t *testing.T) {
+	broken := testTIFF(binary.LittleEndian, 6, true)
+	binary.LittleEndian.PutUint32(broken[4:], 1<<20) // IFD0 за пределами данных
+
+	tests := []struct {
+		name        string
+		data        []byte
+		changed     bool
+		orientation int // ожидаемая ориентация после очистки
+	}{
+		{"jpeg little endian", testJPEG(t, 8, 4, testTIFF(binary.LittleEndian, 6, true)), true, 6},
+		{"jpeg big endian", testJPEG(t, 8, 4, testTIFF(binary.BigEndian, 3, true)), true, 3},
+		{"jpeg gps only", testJPEG(t, 8, 4, testTIFF(binary.LittleEndian, 0, true)), true, 1},
+		{"png", testPNG(t, 8, 4, testTIFF(binary.BigEndian, 8, true)), true, 8},
+		{"jpeg without gps", testJPEG(t, 8, 4, testTIFF(binary.LittleEndian, 6, false)), false, 6},
+		{"jpeg without exif", testJPEG(t, 8, 4, nil), false, 1},
+		{"png without exif", testPNG(t, 8, 4, nil), false, 1},
+		{"malformed exif is dropped", testJPEG(t, 8, 4, broken), true, 1},
+		{"not an image", []byte("plain text"), false, 1},
+	}
+	for _, tt := range tests {
+		out, changed := StripLocation(tt.data)
+		if changed != tt.changed {
+			t.Errorf("%s: changed = %v, want %v", tt.name, changed, tt.changed)
+		}
+		if !changed && !bytes.Equal(out, tt.data) {
+			t.Errorf("%s: unchanged data differs", tt.name)
+		}
+		if hasGPS(out) {
+			t.Errorf("%s: GPS data left in output", tt.name)
+		}
+		if o := Orientation(out); o != tt.orientation {
+			t.Errorf("%s: orientation = %d, want %d", tt.name, o, tt.orientation)
+		}
+		if bytes.HasPrefix(tt.data, pngSignature) || bytes.HasPrefix(tt.data, []byte{0xFF, 0xD8}) {
+			if _, _, err := image.Decode(bytes.NewReader(out)); err != nil {
+				t.Errorf("%s: stripped image does not decode: %v", tt.name, err)
+			}
+		}
+	}
+
+	// Повторная очистка ничего не меняет
+	once, _ := StripLocation(tests[0].data)
+	if _, changed := StripLocation(once); changed {
+		t.Error("second StripLocation changed data")
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		size          int
+		width, height int // размеры исходного изображения с учётом ориентации
+		thumbW        int
+		thumbH        int
+		contentType   string
+	}{
+		{"landscape jpeg", testJPEG(t, 200, 100, nil), 50, 200, 100, 50, 25, "image/jpeg"},
+		{"portrait png", testPNG(t, 30, 90, nil), 45, 30, 90, 15, 45, "image/png"},
+		{"small image is not enlarged", testPNG(t, 10, 20, nil), 100, 10, 20, 10, 20, "image/png"},
+		{"rotated jpeg", testJPEG(t, 200, 100, testTIFF(binary.LittleEndian, 6, false)), 50, 100, 200, 25, 50, "image/jpeg"},
+		{"mirrored jpeg keeps size", testJPEG(t, 200, 100, testTIFF(binary.BigEndian, 2, false)), 50, 200, 100, 50, 25, "image/jpeg"},
+	}
+	for _, tt := range tests {
+		res, err := Thumbnail(tt.data, tt.size)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if res.Width != tt.width || res.Height != tt.height || res.ContentType != tt.contentType {
+			t.Errorf("%s: %dx%d %s, want %dx%d %s", tt.name, res.Width, res.Height, res.ContentType, tt.width, tt.height, tt.contentType)
+		}
+		thumb, _, err := image.DecodeConfig(bytes.NewReader(res.Thumbnail))
+		if err != nil || thumb.Width != tt.thumbW || thumb.Height != tt.thumbH {
+			t.Errorf("%s: thumbnail %dx%d, %v, want %dx%d", tt.name, thumb.Width, thumb.Height, err, tt.thumbW, tt.thumbH)
+		}
+	}
+}
+
+func TestThumbnailLimits(t *testing.T) {
+	// PNG с IHDR, заявляющим 8000×6000 (48 Мпикс): распаковывать его не нужно, хватает заголовка
+	huge := testPNG(t, 1, 1, nil)
+	ihdr := huge[len(pngSignature):]
+	binary.BigEndian.PutUint32(ihdr[8:], 8000)
+	binary.BigEndian.PutUint32(ihdr[12:], 6000)
+	binary.BigEndian.PutUint32(ihdr[21:], crc32.ChecksumIEEE(ihdr[4:21]))
+
+	// Ровно MaxPixels ещё допустим по заголовку
+	limit := testPNG(t, 1, 1, nil)
+	ihdr = limit[len(pngSignature):]
+	binary.BigEndian.PutUint32(ihdr[8:], 8000)
+	binary.BigEndian.PutUint32(ihdr[12:], MaxPixels/8000)
+	binary.BigEndian.PutUint32(ihdr[21:], crc32.ChecksumIEEE(ihdr[4:21]))
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"over MaxPixels", huge, ErrTooLarge},
+		{"at MaxPixels passes the size check", limit, ErrUnsupported}, // данных пикселей нет — ошибка декодирования
+		{"not an image", []byte("plain text"), ErrUnsupported},
+		{"truncated jpeg", testJPEG(t, 20, 20, nil)[:40], ErrUnsupported},
+	}
+	for _, tt := range tests {
+		if _, err := Thumbnail(tt.data, 64); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,614 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type inlineKind int
+
+const (
+	inlineText inlineKind = iota
+	inlineCode
+	inlineHTML
+	inlineHardBreak
+	inlineSoftBreak
+	inlineEmphasis
+	inlineStrong
+	inlineStrikethrough
+	inlineLink
+	inlineImage
+	inlineDelimiter
+)
+
+type inline struct {
+	kind     inlineKind
+	text     string
+	dest     string
+	title    string
+	children []*inline
+
+	// Поля серии разделителей *, _ или ~
+	char     byte
+	count    int
+	origLen  int
+	canOpen  bool
+	canClose bool
+}
+
+// bracket - открывающая скобка [ или ![ в ожидании закрывающей
+type bracket struct {
+	idx   int // индекс узла скобки в nodes
+	pos   int // позиция в исходной строке сразу после скобки
+	image bool
+}
+
+const (
+	maxLabelLength = 999 // длина метки ссылки по CommonMark
+	maxLinkParens  = 32  // вложенность скобок в адресе ссылки, как в cmark
+)
+
+var (
+	attrPattern   = `(?:\s+[a-zA-Z_:][a-zA-Z0-9_.:-]*(?:\s*=\s*(?:[^"'=<>` + "`" + `\x00-\x20]+|'[^']*'|"[^"]*"))?)`
+	reInlineHTML  = regexp.MustCompile(`^(?:<[A-Za-z][A-Za-z0-9-]*` + attrPattern + `*\s*/?>|</[A-Za-z][A-Za-z0-9-]*\s*>|<!--(?s:.*?)-->)`)
+	reAutolinkURI = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^<>\x00-\x20]*)>`)
+	reAutolinkEml = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+	reBareURL     = regexp.MustCompile(`^(?:https?://|www\.)[a-zA-Z0-9_-]+(?:\.[a-zA-Z0-9_-]+)+[^\s<]*`)
+	reEntity      = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	reTrailEntity = regexp.MustCompile(`&[a-zA-Z0-9]+;$`)
+)
+
+// inlineParser разбирает inline содержимое одного блока
+type inlineParser struct {
+	refs     map[string]linkRef
+	src      string
+	nodes    []*inline
+	brackets []*bracket
+	text     strings.Builder
+
+	// Скобки ниже linkFloor в стеке неактивны: ссылки не могут быть вложены в ссылки
+	linkFloor int
+	// noCloser[c] - позиция, начиная с которой в строке нет незаэкранированного c
+	noCloser map[byte]int
+}
+
+func (p *parser) parseInline(src string) []*inline {
+	ip := &inlineParser{refs: p.refs, src: src, noCloser: make(map[byte]int)}
+	ip.parse()
+	return processEmphasis(ip.nodes)
+}
+
+func (ip *inlineParser) flush() {
+	if ip.text.Len() > 0 {
+		ip.nodes = append(ip.nodes, &inline{kind: inlineText, text: ip.text.String()})
+		ip.text.Reset()
+	}
+}
+
+func (ip *inlineParser) add(node *inline) {
+	ip.flush()
+	ip.nodes = append(ip.nodes, node)
+}
+
+func (ip *inlineParser) parse() {
+	s := ip.src
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				ip.add(&inline{kind: inlineHardBreak})
+				i += 2
+				i = skipLeadingSpaces(s, i)
+				continue
+			}
+			if i+1 < len(s) && isASCIIPunct(s[i+1]) {
+				ip.text.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+			ip.text.WriteByte(c)
+			i++
+
+		case c == '`':
+			i = ip.parseCodeSpan(i)
+
+		case c == '*' || c == '_' || c == '~':
+			i = ip.parseDelimiterRun(i)
+
+		case c == '[':
+			ip.add(&inline{kind: inlineText, text: "["})
+			ip.brackets = append(ip.brackets, &bracket{idx: len(ip.nodes) - 1, pos: i + 1})
+			i++
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			ip.add(&inline{kind: inlineText, text: "!["})
+			ip.brackets = append(ip.brackets, &bracket{idx: len(ip.nodes) - 1, pos: i + 2, image: true})
+			i += 2
+
+		case c == ']':
+			i = ip.closeBracket(i)
+
+		case c == '<':
+			i = ip.parseAngle(i)
+
+		case c == '&':
+			if m := reEntity.FindString(s[i:]); m != "" {
+				ip.text.WriteString(html.UnescapeString(m))
+				i += len(m)
+				continue
+			}
+			ip.text.WriteByte(c)
+			i++
+
+		case c == '\n':
+			ip.lineBreak()
+			i = skipLeadingSpaces(s, i+1)
+
+		case (c == 'h' || c == 'w') && ip.autolinkBoundary(i):
+			if n := ip.parseBareURL(i); n > 0 {
+				i += n
+				continue
+			}
+			ip.text.WriteByte(c)
+			i++
+
+		default:
+			ip.text.WriteByte(c)
+			i++
+		}
+	}
+	ip.flush()
+}
+
+// lineBreak добавляет перевод строки: два пробела в конце строки дают жёсткий перенос
+func (ip *inlineParser) lineBreak() {
+	text := ip.text.String()
+	trimmed := strings.TrimRight(text, " ")
+	ip.text.Reset()
+	ip.text.WriteString(trimmed)
+	if len(text)-len(trimmed) >= 2 {
+		ip.add(&inline{kind: inlineHardBreak})
+		return
+	}
+	ip.add(&inline{kind: inlineSoftBreak})
+}
+
+func (ip *inlineParser) parseCodeSpan(i int) int {
+	s := ip.src
+	n := 0
+	for i+n < len(s) && s[i+n] == '`' {
+		n++
+	}
+	start := i + n
+
+	for j := start; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := 0
+		for j+m < len(s) && s[j+m] == '`' {
+			m++
+		}
+		if m == n {
+			code := strings.ReplaceAll(s[start:j], "\n", " ")
+			if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			ip.add(&inline{kind: inlineCode, text: code})
+			return j + m
+		}
+		j += m
+	}
+
+	// Нет закрывающей серии той же длины — обратные кавычки остаются текстом
+	ip.text.WriteString(s[i:start])
+	return start
+}
+
+func (ip *inlineParser) parseDelimiterRun(i int) int {
+	s := ip.src
+	c := s[i]
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+
+	before, after := ' ', ' '
+	if i > 0 {
+		before, _ = utf8.DecodeLastRuneInString(s[:i])
+	}
+	if i+n < len(s) {
+		after, _ = utf8.DecodeRuneInString(s[i+n:])
+	}
+
+	leftFlanking := !unicode.IsSpace(after) &&
+		(!isPunct(after) || unicode.IsSpace(before) || isPunct(before))
+	rightFlanking := !unicode.IsSpace(before) &&
+		(!isPunct(before) || unicode.IsSpace(after) || isPunct(after))
+
+	node := &inline{kind: inlineDelimiter, char: c, count: n, origLen: n}
+	switch c {
+	case '*':
+		node.canOpen = leftFlanking
+		node.canClose = rightFlanking
+	case '_':
+		node.canOpen = leftFlanking && (!rightFlanking || isPunct(before))
+		node.canClose = rightFlanking && (!leftFlanking || isPunct(after))
+	case '~':
+		// GFM: зачёркивание — одна или две тильды
+		node.canOpen = leftFlanking && n <= 2
+		node.canClose = rightFlanking && n <= 2
+	}
+
+	ip.add(node)
+	return i + n
+}
+
+func (ip *inlineParser) closeBracket(i int) int {
+	if len(ip.brackets) == 0 {
+		ip.text.WriteByte(']')
+		return i + 1
+	}
+
+	opener := ip.brackets[len(ip.brackets)-1]
+	ip.brackets = ip.brackets[:len(ip.brackets)-1]
+	active := opener.image || len(ip.brackets) >= ip.linkFloor
+	if ip.linkFloor > len(ip.brackets) {
+		ip.linkFloor = len(ip.brackets)
+	}
+	if !active {
+		ip.text.WriteByte(']')
+		return i + 1
+	}
+
+	dest, title, end, ok := ip.linkTarget(opener, i)
+	if !ok {
+		ip.text.WriteByte(']')
+		return i + 1
+	}
+
+	ip.flush()
+	children := processEmphasis(append([]*inline(nil), ip.nodes[opener.idx+1:]...))
+
+	kind := inlineLink
+	if opener.image {
+		kind = inlineImage
+	}
+	ip.nodes = append(ip.nodes[:opener.idx], &inline{kind: kind, dest: dest, title: title, children: children})
+
+	// Ссылки не могут быть вложены в ссылки: все открытые скобки [ становятся неактивными
+	if !opener.image {
+		ip.linkFloor = len(ip.brackets)
+	}
+	return end
+}
+
+// linkTarget разбирает то, что идёт после ']': (адрес "заголовок"), [метка], [] или ничего
+func (ip *inlineParser) linkTarget(opener *bracket, i int) (string, string, int, bool) {
+	s := ip.src
+	if i+1 < len(s) && s[i+1] == '(' {
+		if dest, title, end, ok := ip.parseLinkTail(i + 2); ok {
+			return dest, title, end, true
+		}
+	}
+
+	label := s[opener.pos:i]
+	end := i + 1
+	if i+1 < len(s) && s[i+1] == '[' {
+		if j := strings.IndexAny(s[i+2:], "[]"); j >= 0 && s[i+2+j] == ']' {
+			if j > 0 {
+				label = s[i+2 : i+2+j]
+			}
+			end = i + 3 + j
+		}
+	}
+
+	if len(label) > maxLabelLength {
+		return "", "", 0, false
+	}
+	ref, ok := ip.refs[normalizeLabel(label)]
+	if !ok || strings.TrimSpace(label) == "" {
+		return "", "", 0, false
+	}
+	return ref.dest, ref.title, end, true
+}
+
+// parseLinkTail разбирает адрес и заголовок inline ссылки, i указывает сразу после '('
+func (ip *inlineParser) parseLinkTail(i int) (string, string, int, bool) {
+	s := ip.src
+	i = skipWhitespace(s, i)
+	if i >= len(s) {
+		return "", "", 0, false
+	}
+
+	var dest string
+	if s[i] == '<' {
+		j := i + 1
+		for ; j < len(s) && s[j] != '>'; j++ {
+			if s[j] == '\n' || s[j] == '<' {
+				return "", "", 0, false
+			}
+			if s[j] == '\\' && j+1 < len(s) {
+				j++
+			}
+		}
+		if j >= len(s) {
+			return "", "", 0, false
+		}
+		dest = s[i+1 : j]
+		i = j + 1
+	} else {
+		depth := 0
+		j := i
+		for ; j < len(s); j++ {
+			c := s[j]
+			if c == '\\' && j+1 < len(s) && isASCIIPunct(s[j+1]) {
+				j++
+				continue
+			}
+			if c <= ' ' {
+				break
+			}
+			if c == '(' {
+				depth++
+				if depth > maxLinkParens {
+					return "", "", 0, false
+				}
+			}
+			if c == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+		if depth != 0 {
+			return "", "", 0, false
+		}
+		dest = s[i:j]
+		i = j
+	}
+
+	title := ""
+	j := skipWhitespace(s, i)
+	if j < len(s) && j > i && (s[j] == '"' || s[j] == '\'' || s[j] == '(') {
+		closer := s[j]
+		if closer == '(' {
+			closer = ')'
+		}
+		k := ip.findCloser(j+1, closer)
+		if k < 0 {
+			return "", "", 0, false
+		}
+		title = s[j+1 : k]
+		j = skipWhitespace(s, k+1)
+	}
+
+	if j >= len(s) || s[j] != ')' {
+		return "", "", 0, false
+	}
+	return unescapeString(dest), unescapeString(title), j + 1, true
+}
+
+// findCloser ищет незаэкранированный символ c начиная с позиции i. Если до конца строки его нет,
+// это запоминается, и следующие поиски с более дальних позиций сразу завершаются неудачей
+func (ip *inlineParser) findCloser(i int, c byte) int {
+	s := ip.src
+	if from, ok := ip.noCloser[c]; ok && i >= from {
+		return -1
+	}
+	for k := i; k < len(s); k++ {
+		if s[k] == '\\' && k+1 < len(s) {
+			k++
+			continue
+		}
+		if s[k] == c {
+			return k
+		}
+	}
+	ip.noCloser[c] = i
+	return -1
+}
+
+// parseAngle разбирает автоссылку <адрес> или сырой HTML тег
+func (ip *inlineParser) parseAngle(i int) int {
+	s := ip.src[i:]
+	if m := reAutolinkURI.FindStringSubmatch(s); m != nil {
+		ip.add(&inline{kind: inlineLink, dest: m[1], children: []*inline{{kind: inlineText, text: m[1]}}})
+		return i + len(m[0])
+	}
+	if m := reAutolinkEml.FindStringSubmatch(s); m != nil {
+		ip.add(&inline{kind: inlineLink, dest: "mailto:" + m[1], children: []*inline{{kind: inlineText, text: m[1]}}})
+		return i + len(m[0])
+	}
+	if m := reInlineHTML.FindString(s); m != "" {
+		ip.add(&inline{kind: inlineHTML, text: m})
+		return i + len(m)
+	}
+	ip.text.WriteByte('<')
+	return i + 1
+}
+
+// autolinkBoundary проверяет, может ли с позиции i начинаться автоссылка без угловых скобок (GFM)
+func (ip *inlineParser) autolinkBoundary(i int) bool {
+	if i == 0 {
+		return true
+	}
+	before, _ := utf8.DecodeLastRuneInString(ip.src[:i])
+	return unicode.IsSpace(before) || strings.ContainsRune("*_~(", before)
+}
+
+func (ip *inlineParser) parseBareURL(i int) int {
+	url := reBareURL.FindString(ip.src[i:])
+	if url == "" {
+		return 0
+	}
+
+	// Завершающая пунктуация не входит в ссылку
+	for url != "" {
+		last := url[len(url)-1]
+		switch {
+		case strings.IndexByte("?!.,:*_~'\"", last) >= 0:
+			url = url[:len(url)-1]
+		case last == ')' && strings.Count(url, ")") > strings.Count(url, "("):
+			url = url[:len(url)-1]
+		case last == ';' && reTrailEntity.MatchString(url):
+			url = url[:strings.LastIndexByte(url, '&')]
+		default:
+			goto done
+		}
+	}
+done:
+	if !strings.Contains(url, ".") {
+		return 0
+	}
+
+	dest := url
+	if strings.HasPrefix(url, "www.") {
+		dest = "http://" + url
+	}
+	ip.add(&inline{kind: inlineLink, dest: dest, children: []*inline{{kind: inlineText, text: url}}})
+	return len(url)
+}
+
+// delimKey - вид закрывающего разделителя для нижних границ поиска открывающих (openers_bottom в CommonMark)
+type delimKey struct {
+	char    byte
+	canOpen bool
+	mod     int
+}
+
+// processEmphasis сопоставляет разделители *, _ и ~ и оборачивает текст между ними (алгоритм из CommonMark).
+// Узлы складываются в стек, закрывающий разделитель ищет пару среди открывающих в стеке. Если пары нет,
+// поиск для того же вида разделителя дальше не спускается ниже этого места, поэтому разбор линейный
+func processEmphasis(nodes []*inline) []*inline {
+	out := make([]*inline, 0, len(nodes))
+	var openers []int // индексы в out открывающих разделителей
+	bottoms := make(map[delimKey]int)
+
+	for _, closer := range nodes {
+		if closer.kind != inlineDelimiter {
+			out = append(out, closer)
+			continue
+		}
+
+		key := delimKey{char: closer.char, canOpen: closer.canOpen, mod: closer.origLen % 3}
+		if closer.char == '~' {
+			key.mod = closer.origLen
+		}
+		for closer.canClose && closer.count > 0 {
+			oi := -1
+			for k := len(openers) - 1; k >= bottoms[key]; k-- {
+				opener := out[openers[k]]
+				if opener.char != closer.char {
+					continue
+				}
+				if closer.char == '~' {
+					if opener.count != closer.count {
+						continue
+					}
+				} else if (opener.canClose || closer.canOpen) && (opener.origLen+closer.origLen)%3 == 0 &&
+					!(opener.origLen%3 == 0 && closer.origLen%3 == 0) {
+					continue
+				}
+				oi = k
+				break
+			}
+			if oi < 0 {
+				bottoms[key] = len(openers)
+				break
+			}
+
+			o := openers[oi]
+			opener := out[o]
+			use, kind := 1, inlineEmphasis
+			switch {
+			case closer.char == '~':
+				use, kind = closer.count, inlineStrikethrough
+			case opener.count >= 2 && closer.count >= 2:
+				use, kind = 2, inlineStrong
+			}
+
+			// Разделители между парой остаются текстом
+			wrapped := &inline{kind: kind, children: append([]*inline(nil), out[o+1:]...)}
+			out = out[:o+1]
+			openers = openers[:oi+1]
+
+			opener.count -= use
+			closer.count -= use
+			if opener.count == 0 {
+				out = out[:o]
+				openers = openers[:oi]
+			}
+			out = append(out, wrapped)
+
+			for k, b := range bottoms {
+				if b > len(openers) {
+					bottoms[k] = len(openers)
+				}
+			}
+		}
+
+		if closer.count > 0 {
+			out = append(out, closer)
+			if closer.canOpen {
+				openers = append(openers, len(out)-1)
+			}
+		}
+	}
+	return out
+}
+
+func skipLeadingSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// skipWhitespace пропускает пробелы и не больше одного перевода строки
+func skipWhitespace(s string, i int) int {
+	newline := false
+	for i < len(s) {
+		switch s[i] {
+		case ' ', '\t':
+		case '\n':
+			if newline {
+				return i
+			}
+			newline = true
+		default:
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// unescapeString раскрывает экранирование \x и HTML сущности в адресах и заголовках ссылок
+func unescapeString(s string) string {
+	if strings.IndexByte(s, '\\') >= 0 {
+		var b strings.Builder
+		for i := 0; i < len(s); i++ {
+			if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+				i++
+			}
+			b.WriteByte(s[i])
+		}
+		s = b.String()
+	}
+	return html.UnescapeString(s)
+}
@@ -0,0 +1,684 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockThematicBreak
+	blockCode
+	blockHTML
+	blockQuote
+	blockList
+	blockTable
+)
+
+// block - блочный элемент документа. Текст абзацев и заголовков разбирается на inline элементы
+// только при выводе, когда уже собраны все определения ссылок
+type block struct {
+	kind  blockKind
+	level int    // уровень заголовка
+	text  string // текст абзаца/заголовка, содержимое кода или HTML
+	lang  string // язык блока кода из info строки
+
+	children []*block // цитата
+
+	items   []*listItem
+	ordered bool
+	start   int
+	tight   bool
+
+	align  []string // выравнивание колонок таблицы: left, center, right или ""
+	header []string
+	rows   [][]string
+}
+
+type listItem struct {
+	blocks []*block
+	task   int // 0 — не задача, 1 — [ ], 2 — [x]
+}
+
+type linkRef struct {
+	dest  string
+	title string
+}
+
+type parser struct {
+	refs  map[string]linkRef
+	depth int // вложенность цитат и списков
+}
+
+// Ограничения, которые не дают небольшому тексту раздуть результат или время разбора
+const (
+	maxNesting      = 32      // глубже цитаты и списки разбираются как обычный текст
+	maxTableColumns = 128     // строка с большим числом колонок не считается заголовком таблицы
+	maxTableFill    = 1 << 14 // сколько недостающих ячеек можно дописать в строки одной таблицы
+)
+
+// Render преобразует markdown в HTML: CommonMark и расширения GFM — таблицы, списки задач,
+// зачёркивание ~~текст~~ и автоссылки. Блоки кода получают class="language-<язык>".
+// Сырой HTML из текста попадает в результат как есть, поэтому результат нужно пропускать через санитайзер
+func Render(src string) string {
+	p := &parser{refs: make(map[string]linkRef)}
+	blocks := p.parseBlocks(splitLines(src))
+
+	var b strings.Builder
+	p.renderBlocks(&b, blocks, false)
+	return b.String()
+}
+
+var (
+	reATXHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))??(?:[ \t]+#+)?[ \t]*$`)
+	reThematicBreak = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	reFenceOpen     = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+	reSetextH1      = regexp.MustCompile(`^ {0,3}=+[ \t]*$`)
+	reSetextH2      = regexp.MustCompile(`^ {0,3}-+[ \t]*$`)
+	reListMarker    = regexp.MustCompile(`^( {0,3})([-+*]|[0-9]{1,9}[.)])( +|$)`)
+	reTableDelim    = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	reLinkRefDef    = regexp.MustCompile(`^ {0,3}\[((?:[^\[\]\\]|\\.){1,999})\]:[ \t]*(<[^<>\n]*>|\S+)(?:[ \t]+("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|\((?:[^()\\]|\\.)*\)))?[ \t]*$`)
+	reHTMLBlockTag  = regexp.MustCompile(`^ {0,3}</?([a-zA-Z][a-zA-Z0-9-]*)(?:[ \t/>]|$)`)
+	reHTMLRawOpen   = regexp.MustCompile(`(?i)^ {0,3}<(script|pre|style|textarea)(?:[ \t>]|$)`)
+	reHTMLRawClose  = regexp.MustCompile(`(?i)</(script|pre|style|textarea)>`)
+)
+
+// htmlBlockTags - теги, с которых начинается HTML блок (условие 6 из CommonMark)
+var htmlBlockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "base": true, "basefont": true, "blockquote": true,
+	"body": true, "caption": true, "center": true, "col": true, "colgroup": true, "dd": true,
+	"details": true, "dialog": true, "dir": true, "div": true, "dl": true, "dt": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true, "frame": true,
+	"frameset": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"head": true, "header": true, "hr": true, "html": true, "iframe": true, "legend": true,
+	"li": true, "link": true, "main": true, "menu": true, "menuitem": true, "nav": true,
+	"noframes": true, "ol": true, "optgroup": true, "option": true, "p": true, "param": true,
+	"search": true, "section": true, "summary": true, "table": true, "tbody": true, "td": true,
+	"tfoot": true, "th": true, "thead": true, "title": true, "tr": true, "track": true, "ul": true,
+}
+
+// parseBlocks разбирает строки в блоки одного уровня вложенности
+func (p *parser) parseBlocks(lines []string) []*block {
+	var blocks []*block
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if isBlank(line) {
+			i++
+			continue
+		}
+
+		if indentOf(line) >= 4 {
+			b, next := parseIndentedCode(lines, i)
+			blocks = append(blocks, b)
+			i = next
+			continue
+		}
+
+		if m := reFenceOpen.FindStringSubmatch(line); m != nil && !(m[2][0] == '`' && strings.Contains(m[3], "`")) {
+			b, next := parseFencedCode(lines, i, m)
+			blocks = append(blocks, b)
+			i = next
+			continue
+		}
+
+		if m := reATXHeading.FindStringSubmatch(line); m != nil {
+			blocks = append(blocks, &block{kind: blockHeading, level: len(m[1]), text: strings.TrimSpace(m[2])})
+			i++
+			continue
+		}
+
+		if reThematicBreak.MatchString(line) {
+			blocks = append(blocks, &block{kind: blockThematicBreak})
+			i++
+			continue
+		}
+
+		if _, ok := stripQuoteMarker(line); ok && p.depth < maxNesting {
+			b, next := p.parseQuote(lines, i)
+			blocks = append(blocks, b)
+			i = next
+			continue
+		}
+
+		if _, ok := parseListMarker(line); ok && p.depth < maxNesting {
+			b, next := p.parseList(lines, i)
+			blocks = append(blocks, b)
+			i = next
+			continue
+		}
+
+		if htmlBlockStart(line, true) {
+			b, next := parseHTMLBlock(lines, i)
+			blocks = append(blocks, b)
+			i = next
+			continue
+		}
+
+		if b, next, ok := parseTable(lines, i); ok {
+			blocks = append(blocks, b)
+			i = next
+			continue
+		}
+
+		b, next := p.parseParagraph(lines, i)
+		if b != nil {
+			blocks = append(blocks, b)
+		}
+		i = next
+	}
+	return blocks
+}
+
+func parseIndentedCode(lines []string, i int) (*block, int) {
+	var code []string
+	end := i
+	for j := i; j < len(lines); j++ {
+		if isBlank(lines[j]) {
+			code = append(code, removeIndent(lines[j], 4))
+			continue
+		}
+		if indentOf(lines[j]) < 4 {
+			break
+		}
+		code = append(code, removeIndent(lines[j], 4))
+		end = j + 1
+	}
+	// Пустые строки в конце не входят в блок кода
+	code = code[:end-i]
+	return &block{kind: blockCode, text: strings.Join(code, "\n") + "\n"}, end
+}
+
+func parseFencedCode(lines []string, i int, m []string) (*block, int) {
+	indent := len(m[1])
+	fence := m[2]
+	info := strings.TrimSpace(m[3])
+
+	lang := ""
+	if fields := strings.Fields(info); len(fields) > 0 {
+		lang = unescapeString(fields[0])
+	}
+
+	var code []string
+	j := i + 1
+	for ; j < len(lines); j++ {
+		line := lines[j]
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) <= 3 && strings.HasPrefix(trimmed, fence) &&
+			strings.TrimRight(strings.TrimLeft(trimmed, fence[:1]), " \t") == "" {
+			j++
+			break
+		}
+		code = append(code, removeIndent(line, indent))
+	}
+
+	text := strings.Join(code, "\n")
+	if len(code) > 0 {
+		text += "\n"
+	}
+	return &block{kind: blockCode, text: text, lang: lang}, j
+}
+
+func (p *parser) parseQuote(lines []string, i int) (*block, int) {
+	var quoted []string
+	j := i
+	for ; j < len(lines); j++ {
+		if rest, ok := stripQuoteMarker(lines[j]); ok {
+			quoted = append(quoted, rest)
+			continue
+		}
+		// Ленивое продолжение: строка абзаца без '>'
+		if isBlank(lines[j]) || isBlank(quoted[len(quoted)-1]) || interruptsParagraph(lines[j]) {
+			break
+		}
+		quoted = append(quoted, lines[j])
+	}
+	return &block{kind: blockQuote, children: p.parseNested(quoted)}, j
+}
+
+// parseNested разбирает содержимое цитаты или элемента списка на следующем уровне вложенности
+func (p *parser) parseNested(lines []string) []*block {
+	p.depth++
+	defer func() { p.depth-- }()
+	return p.parseBlocks(lines)
+}
+
+// listMarker - маркер элемента списка
+type listMarker struct {
+	ordered bool
+	marker  byte // '-', '+', '*' или разделитель '.', ')' нумерованного списка
+	start   int
+	indent  int    // отступ содержимого элемента
+	content string // первая строка содержимого
+}
+
+func parseListMarker(line string) (listMarker, bool) {
+	m := reListMarker.FindStringSubmatch(line)
+	if m == nil {
+		return listMarker{}, false
+	}
+
+	marker := listMarker{marker: m[2][len(m[2])-1]}
+	if len(m[2]) > 1 || (m[2][0] >= '0' && m[2][0] <= '9') {
+		marker.ordered = true
+		marker.start, _ = strconv.Atoi(m[2][:len(m[2])-1])
+	}
+
+	width := len(m[1]) + len(m[2])
+	spaces := len(m[3])
+	rest := line[width+spaces:]
+	switch {
+	case isBlank(rest):
+		marker.indent = width + 1
+	case spaces > 4:
+		// Содержимое с большим отступом — блок кода внутри элемента
+		marker.indent = width + 1
+		rest = line[width+1:]
+	default:
+		marker.indent = width + spaces
+	}
+	marker.content = rest
+	return marker, true
+}
+
+func (p *parser) parseList(lines []string, i int) (*block, int) {
+	first, _ := parseListMarker(lines[i])
+	list := &block{kind: blockList, ordered: first.ordered, start: first.start, tight: true}
+
+	j := i
+	for j < len(lines) {
+		marker, ok := parseListMarker(lines[j])
+		if !ok || marker.ordered != first.ordered || marker.marker != first.marker || reThematicBreak.MatchString(lines[j]) {
+			break
+		}
+
+		itemLines := []string{marker.content}
+		k := j + 1
+		for k < len(lines) {
+			line := lines[k]
+			if isBlank(line) {
+				// Элемент может начинаться не больше чем с одной пустой строки
+				if len(itemLines) == 1 && isBlank(itemLines[0]) {
+					break
+				}
+				itemLines = append(itemLines, "")
+				k++
+				continue
+			}
+			if indentOf(line) >= marker.indent {
+				itemLines = append(itemLines, removeIndent(line, marker.indent))
+				k++
+				continue
+			}
+			// Ленивое продолжение абзаца
+			if !isBlank(itemLines[len(itemLines)-1]) && !interruptsParagraph(line) && !isListItem(line) {
+				itemLines = append(itemLines, line)
+				k++
+				continue
+			}
+			break
+		}
+
+		// Пустые строки в конце элемента относятся к промежутку между элементами
+		trailing := 0
+		for len(itemLines) > 1 && isBlank(itemLines[len(itemLines)-1]) {
+			itemLines = itemLines[:len(itemLines)-1]
+			trailing++
+		}
+
+		if hasBlankBetweenBlocks(itemLines) {
+			list.tight = false
+		}
+
+		item := &listItem{blocks: p.parseNested(itemLines)}
+		if len(item.blocks) > 0 && item.blocks[0].kind == blockParagraph {
+			item.task, item.blocks[0].text = taskMarker(item.blocks[0].text)
+		}
+		list.items = append(list.items, item)
+
+		j = k
+		if trailing > 0 {
+			if next, ok := parseListMarker(safeLine(lines, k)); ok && next.ordered == first.ordered && next.marker == first.marker {
+				list.tight = false
+				continue
+			}
+			// Пустые строки после последнего элемента остаются внешнему уровню
+			j = k - trailing
+			break
+		}
+	}
+	return list, j
+}
+
+// hasBlankBetweenBlocks проверяет, разделены ли блоки элемента пустой строкой (такой список «свободный»).
+// Пустые строки внутри блоков кода не считаются
+func hasBlankBetweenBlocks(lines []string) bool {
+	fence := ""
+	for i, line := range lines {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimLeft(line, " "), fence) {
+				fence = ""
+			}
+			continue
+		}
+		if m := reFenceOpen.FindStringSubmatch(line); m != nil {
+			fence = m[2]
+			continue
+		}
+		if isBlank(line) && i > 0 && i < len(lines)-1 && indentOf(lines[i+1]) < 4 {
+			return true
+		}
+	}
+	return false
+}
+
+// taskMarker распознаёт "[ ] " / "[x] " в начале элемента списка
+func taskMarker(text string) (int, string) {
+	if len(text) < 3 || text[0] != '[' || text[2] != ']' {
+		return 0, text
+	}
+	rest := text[3:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' && rest[0] != '\n' {
+		return 0, text
+	}
+	switch text[1] {
+	case ' ':
+		return 1, strings.TrimLeft(rest, " \t")
+	case 'x', 'X':
+		return 2, strings.TrimLeft(rest, " \t")
+	}
+	return 0, text
+}
+
+func parseHTMLBlock(lines []string, i int) (*block, int) {
+	j := i
+	switch {
+	case reHTMLRawOpen.MatchString(lines[i]):
+		for ; j < len(lines); j++ {
+			if reHTMLRawClose.MatchString(lines[j]) {
+				j++
+				break
+			}
+		}
+	case strings.HasPrefix(strings.TrimLeft(lines[i], " "), "<!--"):
+		for ; j < len(lines); j++ {
+			if strings.Contains(lines[j], "-->") {
+				j++
+				break
+			}
+		}
+	default:
+		for j < len(lines) && !isBlank(lines[j]) {
+			j++
+		}
+	}
+	return &block{kind: blockHTML, text: strings.Join(lines[i:j], "\n") + "\n"}, j
+}
+
+// htmlBlockStart проверяет, начинается ли со строки HTML блок.
+// Одиночный произвольный тег (условие 7) не может прерывать абзац, поэтому учитывается только с standalone
+func htmlBlockStart(line string, standalone bool) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || !strings.HasPrefix(trimmed, "<") {
+		return false
+	}
+	if reHTMLRawOpen.MatchString(line) || strings.HasPrefix(trimmed, "<!--") {
+		return true
+	}
+	if m := reHTMLBlockTag.FindStringSubmatch(line); m != nil && htmlBlockTags[strings.ToLower(m[1])] {
+		return true
+	}
+	if !standalone {
+		return false
+	}
+	tag := reInlineHTML.FindString(trimmed)
+	return tag != "" && !strings.HasPrefix(tag, "<!--") && isBlank(trimmed[len(tag):])
+}
+
+func parseTable(lines []string, i int) (*block, int, bool) {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !reTableDelim.MatchString(lines[i+1]) {
+		return nil, i, false
+	}
+
+	header := splitTableRow(lines[i])
+	delims := splitTableRow(lines[i+1])
+	if len(header) != len(delims) || len(header) > maxTableColumns {
+		return nil, i, false
+	}
+
+	table := &block{kind: blockTable, header: header}
+	for _, d := range delims {
+		d = strings.TrimSpace(d)
+		switch {
+		case strings.HasPrefix(d, ":") && strings.HasSuffix(d, ":"):
+			table.align = append(table.align, "center")
+		case strings.HasPrefix(d, ":"):
+			table.align = append(table.align, "left")
+		case strings.HasSuffix(d, ":"):
+			table.align = append(table.align, "right")
+		default:
+			table.align = append(table.align, "")
+		}
+	}
+
+	// Строки хранят только свои ячейки, недостающие дописываются при выводе. Как и в cmark-gfm,
+	// их число ограничено: таблица заканчивается на строке, которая превысила бы лимит
+	fill := 0
+	j := i + 2
+	for ; j < len(lines); j++ {
+		if isBlank(lines[j]) || interruptsParagraph(lines[j]) {
+			break
+		}
+		cells := splitTableRow(lines[j])
+		if len(cells) > len(header) {
+			cells = cells[:len(header)]
+		}
+		fill += len(header) - len(cells)
+		if fill > maxTableFill {
+			break
+		}
+		table.rows = append(table.rows, cells)
+	}
+	return table, j, true
+}
+
+// splitTableRow делит строку таблицы на ячейки по '|' (кроме экранированных \|)
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// parseParagraph собирает абзац (или setext заголовок). Определения ссылок в начале абзаца
+// запоминаются и в вывод не попадают
+func (p *parser) parseParagraph(lines []string, i int) (*block, int) {
+	var para []string
+	j := i
+	for ; j < len(lines); j++ {
+		line := lines[j]
+		if isBlank(line) {
+			break
+		}
+		if len(para) > 0 {
+			level := 0
+			if reSetextH1.MatchString(line) {
+				level = 1
+			} else if reSetextH2.MatchString(line) {
+				level = 2
+			}
+			if level > 0 {
+				para = p.takeLinkRefs(para)
+				if len(para) == 0 {
+					// От абзаца остались только определения ссылок — строка не заголовок
+					return &block{kind: blockParagraph, text: strings.TrimSpace(line)}, j + 1
+				}
+				return &block{kind: blockHeading, level: level, text: joinParagraph(para)}, j + 1
+			}
+			if interruptsParagraph(line) {
+				break
+			}
+		}
+		para = append(para, line)
+	}
+
+	para = p.takeLinkRefs(para)
+	if len(para) == 0 {
+		return nil, j
+	}
+	return &block{kind: blockParagraph, text: joinParagraph(para)}, j
+}
+
+func (p *parser) takeLinkRefs(lines []string) []string {
+	for len(lines) > 0 {
+		m := reLinkRefDef.FindStringSubmatch(lines[0])
+		if m == nil || strings.TrimSpace(m[1]) == "" {
+			break
+		}
+		label := normalizeLabel(m[1])
+		if _, exists := p.refs[label]; !exists {
+			dest := m[2]
+			if strings.HasPrefix(dest, "<") {
+				dest = dest[1 : len(dest)-1]
+			}
+			title := ""
+			if len(m[3]) >= 2 {
+				title = m[3][1 : len(m[3])-1]
+			}
+			p.refs[label] = linkRef{dest: unescapeString(dest), title: unescapeString(title)}
+		}
+		lines = lines[1:]
+	}
+	return lines
+}
+
+func joinParagraph(lines []string) string {
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimLeft(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(trimmed, "\n"), " \t")
+}
+
+// interruptsParagraph проверяет, начинается ли со строки блок, прерывающий абзац
+func interruptsParagraph(line string) bool {
+	if indentOf(line) >= 4 {
+		return false
+	}
+	if reATXHeading.MatchString(line) || reThematicBreak.MatchString(line) || reFenceOpen.MatchString(line) {
+		return true
+	}
+	if _, ok := stripQuoteMarker(line); ok {
+		return true
+	}
+	if htmlBlockStart(line, false) {
+		return true
+	}
+	// Прервать абзац может только непустой элемент списка, нумерованный — только с 1
+	if marker, ok := parseListMarker(line); ok && !isBlank(marker.content) && (!marker.ordered || marker.start == 1) {
+		return true
+	}
+	return false
+}
+
+func isListItem(line string) bool {
+	_, ok := parseListMarker(line)
+	return ok
+}
+
+func stripQuoteMarker(line string) (string, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || !strings.HasPrefix(trimmed, ">") {
+		return "", false
+	}
+	rest := trimmed[1:]
+	return strings.TrimPrefix(rest, " "), true
+}
+
+// splitLines делит текст на строки, нормализуя переводы строк и раскрывая табы в отступах
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\x00", "�")
+	src = strings.TrimSuffix(src, "\n")
+	if src == "" {
+		return nil
+	}
+
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = expandIndentTabs(line)
+	}
+	return lines
+}
+
+// expandIndentTabs заменяет табы в начальном отступе пробелами до позиции, кратной 4
+func expandIndentTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			b.WriteByte(' ')
+			col++
+		case '\t':
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		default:
+			b.WriteString(line[i:])
+			return b.String()
+		}
+	}
+	return b.String()
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func removeIndent(line string, n int) string {
+	indent := indentOf(line)
+	if indent > n {
+		indent = n
+	}
+	return line[indent:]
+}
+
+func safeLine(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
@@ -0,0 +1,112 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"atx heading", "# Title", "<h1>Title</h1>\n"},
+		{"closed atx heading", "## Sub ##", "<h2>Sub</h2>\n"},
+		{"setext h1", "Title\n=====", "<h1>Title</h1>\n"},
+		{"setext h2", "Sub\n---", "<h2>Sub</h2>\n"},
+		{"thematic break", "---", "<hr />\n"},
+		{"soft break", "line one\nline two", "<p>line one\nline two</p>\n"},
+		{"hard break", "hard  \nbreak", "<p>hard<br />\nbreak</p>\n"},
+		{"fenced code", "```go\nfmt.Println(\"<hi>\")\n```",
+			"<pre><code class=\"language-go\">fmt.Println(&quot;&lt;hi&gt;&quot;)\n</code></pre>\n"},
+		{"indented code", "    indented\n    code", "<pre><code>indented\ncode\n</code></pre>\n"},
+		{"blockquote", "> quote\n> more", "<blockquote>\n<p>quote\nmore</p>\n</blockquote>\n"},
+		{"tight list", "- a\n- b", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"loose list", "- a\n\n- b", "<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ul>\n"},
+		{"ordered list start", "3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n"},
+		{"task list", "- [ ] todo\n- [x] done",
+			"<ul>\n<li class=\"task-list-item\"><input type=\"checkbox\" disabled=\"\" /> todo</li>\n" +
+				"<li class=\"task-list-item\"><input type=\"checkbox\" disabled=\"\" checked=\"\" /> done</li>\n</ul>\n"},
+		{"table", "| a | b |\n|:--|--:|\n| 1 | 2 |",
+			"<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n" +
+				"<tbody>\n<tr>\n<td align=\"left\">1</td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"html block", "<div>\nblock\n</div>", "<div>\nblock\n</div>\n"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.in); got != tt.want {
+			t.Errorf("%s: Render(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderInline(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"emphasis", "plain *em* **strong** ~~del~~ `code`",
+			"<p>plain <em>em</em> <strong>strong</strong> <del>del</del> <code>code</code></p>\n"},
+		{"unclosed emphasis", "**unclosed", "<p>**unclosed</p>\n"},
+		{"escaped emphasis", `\*not em\*`, "<p>*not em*</p>\n"},
+		{"text is escaped", "5 < 6 & 7", "<p>5 &lt; 6 &amp; 7</p>\n"},
+		{"link with title", `[link](https://example.com "T")`, "<p><a href=\"https://example.com\" title=\"T\">link</a></p>\n"},
+		{"reference link", "[ref][r]\n\n[r]: /url", "<p><a href=\"/url\">ref</a></p>\n"},
+		{"image", "![alt](/img.png)", "<p><img src=\"/img.png\" alt=\"alt\" /></p>\n"},
+		{"angle autolink", "<https://example.com>", "<p><a href=\"https://example.com\">https://example.com</a></p>\n"},
+		{"bare url", "visit https://example.com now", "<p>visit <a href=\"https://example.com\">https://example.com</a> now</p>\n"},
+		{"inline html is kept for the sanitizer", "a <b>raw</b> html", "<p>a <b>raw</b> html</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.in); got != tt.want {
+			t.Errorf("%s: Render(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderLimits(t *testing.T) {
+	deep := strings.Repeat(">", maxNesting+10) + " deep"
+	out := Render(deep)
+	if n := strings.Count(out, "<blockquote>"); n != maxNesting {
+		t.Errorf("nesting: %d blockquotes, want %d", n, maxNesting)
+	}
+
+	// Слишком широкая таблица остаётся абзацем
+	wide := strings.Repeat("| a ", maxTableColumns+1) + "|\n" + strings.Repeat("|---", maxTableColumns+1) + "|"
+	if out := Render(wide); strings.Contains(out, "<table>") {
+		t.Error("table over the column limit is rendered")
+	}
+
+	// Короткие строки дополняются пустыми ячейками, пока не исчерпан лимит
+	header := strings.Repeat("| h ", maxTableColumns) + "|\n" + strings.Repeat("|---", maxTableColumns) + "|\n"
+	rows := maxTableFill/(maxTableColumns-1) + 10
+	out = Render(header + strings.Repeat("x\n", rows))
+	if got, want := strings.Count(out, "<tr>"), 1+maxTableFill/(maxTableColumns-1); got != want {
+		t.Errorf("table fill: %d rows, want %d", got, want)
+	}
+	if out := Render("| a | b |\n|---|---|\n| 1 |"); !strings.Contains(out, "<td>1</td>\n<td></td>") {
+		t.Errorf("short row is not padded: %q", out)
+	}
+}
+
+func TestRenderLinear(t *testing.T) {
+	// Входы, на которых наивный разбор строк работает за квадрат от длины
+	inputs := map[string]string{
+		"brackets":   strings.Repeat("[", 100000),
+		"link tails": strings.Repeat("[a](", 50000),
+		"emphasis":   strings.Repeat("*a", 50000),
+		"code spans": strings.Repeat("`a``", 50000),
+		"angles":     strings.Repeat("<a", 50000),
+		"nested":     strings.Repeat("> - ", 50000) + "x",
+	}
+	for name, in := range inputs {
+		start := time.Now()
+		Render(in)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: %s", name, elapsed)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package markdown
+
+import (
+	"strconv"
+	"strings"
+)
+
+func (p *parser) renderBlocks(b *strings.Builder, blocks []*block, tight bool) {
+	for i, bl := range blocks {
+		switch bl.kind {
+		case blockParagraph:
+			if tight {
+				p.renderInline(b, bl.text)
+				if i < len(blocks)-1 {
+					b.WriteString("\n")
+				}
+				continue
+			}
+			b.WriteString("<p>")
+			p.renderInline(b, bl.text)
+			b.WriteString("</p>\n")
+
+		case blockHeading:
+			level := strconv.Itoa(bl.level)
+			b.WriteString("<h" + level + ">")
+			p.renderInline(b, bl.text)
+			b.WriteString("</h" + level + ">\n")
+
+		case blockThematicBreak:
+			b.WriteString("<hr />\n")
+
+		case blockCode:
+			b.WriteString("<pre><code")
+			if bl.lang != "" {
+				b.WriteString(` class="language-` + escapeHTML(bl.lang) + `"`)
+			}
+			b.WriteString(">" + escapeHTML(bl.text) + "</code></pre>\n")
+
+		case blockHTML:
+			b.WriteString(bl.text)
+
+		case blockQuote:
+			b.WriteString("<blockquote>\n")
+			p.renderBlocks(b, bl.children, false)
+			b.WriteString("</blockquote>\n")
+
+		case blockList:
+			p.renderList(b, bl)
+
+		case blockTable:
+			p.renderTable(b, bl)
+		}
+	}
+}
+
+func (p *parser) renderList(b *strings.Builder, list *block) {
+	tag := "ul"
+	if list.ordered {
+		tag = "ol"
+	}
+
+	b.WriteString("<" + tag)
+	if list.ordered && list.start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(list.start) + `"`)
+	}
+	b.WriteString(">\n")
+
+	for _, item := range list.items {
+		if item.task > 0 {
+			b.WriteString(`<li class="task-list-item"><input type="checkbox" disabled=""`)
+			if item.task == 2 {
+				b.WriteString(` checked=""`)
+			}
+			b.WriteString(" /> ")
+		} else {
+			b.WriteString("<li>")
+		}
+
+		if len(item.blocks) > 0 && (!list.tight || item.blocks[0].kind != blockParagraph) {
+			b.WriteString("\n")
+		}
+		p.renderBlocks(b, item.blocks, list.tight)
+		b.WriteString("</li>\n")
+	}
+
+	b.WriteString("</" + tag + ">\n")
+}
+
+func (p *parser) renderTable(b *strings.Builder, table *block) {
+	cell := func(tag, align, text string) {
+		b.WriteString("<" + tag)
+		if align != "" {
+			b.WriteString(` align="` + align + `"`)
+		}
+		b.WriteString(">")
+		p.renderInline(b, text)
+		b.WriteString("</" + tag + ">\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n<tr>\n")
+	for i, text := range table.header {
+		cell("th", table.align[i], text)
+	}
+	b.WriteString("</tr>\n</thead>\n")
+
+	if len(table.rows) > 0 {
+		b.WriteString("<tbody>\n")
+		for _, row := range table.rows {
+			b.WriteString("<tr>\n")
+			for i, align := range table.align {
+				text := ""
+				if i < len(row) {
+					text = row[i]
+				}
+				cell("td", align, text)
+			}
+			b.WriteString("</tr>\n")
+		}
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+func (p *parser) renderInline(b *strings.Builder, text string) {
+	renderNodes(b, p.parseInline(text))
+}
+
+func renderNodes(b *strings.Builder, nodes []*inline) {
+	for _, n := range nodes {
+		switch n.kind {
+		case inlineText:
+			b.WriteString(escapeHTML(n.text))
+		case inlineCode:
+			b.WriteString("<code>" + escapeHTML(n.text) + "</code>")
+		case inlineHTML:
+			b.WriteString(n.text)
+		case inlineHardBreak:
+			b.WriteString("<br />\n")
+		case inlineSoftBreak:
+			b.WriteString("\n")
+		case inlineEmphasis:
+			b.WriteString("<em>")
+			renderNodes(b, n.children)
+			b.WriteString("</em>")
+		case inlineStrong:
+			b.WriteString("<strong>")
+			renderNodes(b, n.children)
+			b.WriteString("</strong>")
+		case inlineStrikethrough:
+			b.WriteString("<del>")
+			renderNodes(b, n.children)
+			b.WriteString("</del>")
+		case inlineLink:
+			b.WriteString(`<a href="` + escapeHTML(encodeURL(n.dest)) + `"`)
+			if n.title != "" {
+				b.WriteString(` title="` + escapeHTML(n.title) + `"`)
+			}
+			b.WriteString(">")
+			renderNodes(b, n.children)
+			b.WriteString("</a>")
+		case inlineImage:
+			b.WriteString(`<img src="` + escapeHTML(encodeURL(n.dest)) + `" alt="` + escapeHTML(plainText(n.children)) + `"`)
+			if n.title != "" {
+				b.WriteString(` title="` + escapeHTML(n.title) + `"`)
+			}
+			b.WriteString(" />")
+		case inlineDelimiter:
+			b.WriteString(escapeHTML(strings.Repeat(string(n.char), n.count)))
+		}
+	}
+}
+
+// plainText - текст inline элементов без разметки (для alt картинок)
+func plainText(nodes []*inline) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n.kind {
+		case inlineText, inlineCode:
+			b.WriteString(n.text)
+		case inlineSoftBreak, inlineHardBreak:
+			b.WriteString(" ")
+		case inlineDelimiter:
+			b.WriteString(strings.Repeat(string(n.char), n.count))
+		default:
+			b.WriteString(plainText(n.children))
+		}
+	}
+	return b.String()
+}
+
+var htmlEscaper = strings.NewReplacer(`&`, "&amp;", `<`, "&lt;", `>`, "&gt;", `"`, "&quot;")
+
+func escapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
+
+// encodeURL кодирует в адресе символы, которые нельзя оставить как есть (пробелы, не-ASCII)
+func encodeURL(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			strings.IndexByte("-._~:/?#[]@!$&'()*+,;=%", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&15])
+	}
+	return b.String()
+}
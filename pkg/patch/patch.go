@@ -0,0 +1,294 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch   = errors.New("invalid patch document")
+	ErrPathNotFound   = errors.New("patch path not found")
+	ErrTestFailed     = errors.New("patch test operation failed")
+	ErrUnsupportedOp  = errors.New("unsupported patch operation")
+	ErrInvalidPointer = errors.New("invalid JSON pointer")
+)
+
+// MergePatch применяет JSON Merge Patch (RFC 7396) к документу
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, ErrInvalidPatch
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// Operation - одна операция JSON Patch
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch применяет JSON Patch (RFC 6902) к документу.
+// Операции применяются по очереди; при любой ошибке документ не меняется
+func JSONPatch(doc, patch []byte) ([]byte, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		var err error
+		target, err = apply(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func apply(doc interface{}, op Operation) (interface{}, error) {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, ErrInvalidPatch
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, ErrInvalidPatch
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, op.Path, value)
+		case "replace":
+			if _, err := get(doc, op.Path); err != nil {
+				return nil, err
+			}
+			doc, err := remove(doc, op.Path)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, op.Path, value)
+		default:
+			current, err := get(doc, op.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, op.Path)
+	case "move", "copy":
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, ErrInvalidPatch
+			}
+			if doc, err = remove(doc, op.From); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return add(doc, op.Path, value)
+	default:
+		return nil, ErrUnsupportedOp
+	}
+}
+
+// parsePointer разбирает JSON Pointer (RFC 6901)
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, ErrInvalidPointer
+	}
+	parts := strings.Split(path[1:], "/")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+func get(doc interface{}, path string) (interface{}, error) {
+	parts, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, part := range parts {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			current = value
+		case []interface{}:
+			i, err := arrayIndex(part, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[i]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return current, nil
+}
+
+func add(doc interface{}, path string, value interface{}) (interface{}, error) {
+	parts, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return value, nil
+	}
+	return setIn(doc, parts, value, true)
+}
+
+func remove(doc interface{}, path string) (interface{}, error) {
+	parts, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	return setIn(doc, parts, nil, false)
+}
+
+// setIn добавляет (insert == true) или удаляет значение по пути и возвращает обновлённый узел
+func setIn(node interface{}, parts []string, value interface{}, insert bool) (interface{}, error) {
+	key := parts[0]
+	last := len(parts) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if last {
+			if insert {
+				n[key] = value
+				return n, nil
+			}
+			if _, ok := n[key]; !ok {
+				return nil, ErrPathNotFound
+			}
+			delete(n, key)
+			return n, nil
+		}
+		child, ok := n[key]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		updated, err := setIn(child, parts[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = updated
+		return n, nil
+
+	case []interface{}:
+		if last {
+			if insert {
+				i := len(n)
+				if key != "-" {
+					var err error
+					if i, err = arrayIndex(key, len(n)); err != nil {
+						return nil, err
+					}
+				}
+				result := make([]interface{}, 0, len(n)+1)
+				result = append(result, n[:i]...)
+				result = append(result, value)
+				return append(result, n[i:]...), nil
+			}
+			i, err := arrayIndex(key, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			result := make([]interface{}, 0, len(n)-1)
+			result = append(result, n[:i]...)
+			return append(result, n[i+1:]...), nil
+		}
+		i, err := arrayIndex(key, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := setIn(n[i], parts[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+
+	default:
+		return nil, ErrPathNotFound
+	}
+}
+
+// arrayIndex разбирает индекс массива в диапазоне [0, max]
+func arrayIndex(part string, max int) (int, error) {
+	if part == "" || (len(part) > 1 && part[0] == '0') {
+		return 0, ErrInvalidPointer
+	}
+	i, err := strconv.Atoi(part)
+	if err != nil || i < 0 {
+		return 0, ErrInvalidPointer
+	}
+	if i > max {
+		return 0, ErrPathNotFound
+	}
+	return i, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+	data, _ := json.Marshal(value)
+	var result interface{}
+	json.Unmarshal(data, &result)
+	return result
+}
@@ -0,0 +1,103 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// jsonEqual сравнивает документы без учёта порядка ключей
+func jsonEqual(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result %q: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want %q: %v", want, err)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		{"replace field", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`, nil},
+		{"add field", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`, nil},
+		{"null removes", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`, nil},
+		{"array replaced", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`, nil},
+		{"nested merge", `{"a":{"b":"c","d":"e"}}`, `{"a":{"d":null,"f":"g"}}`, `{"a":{"b":"c","f":"g"}}`, nil},
+		{"non-object patch", `{"a":"b"}`, `["c"]`, `["c"]`, nil},
+		{"empty patch", `{"a":"b"}`, `{}`, `{"a":"b"}`, nil},
+		{"invalid patch", `{"a":"b"}`, `{`, "", ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	doc := `{"title":"a","tags":["x","y"],"meta":{"k":"v"}}`
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{"replace", `[{"op":"replace","path":"/title","value":"b"}]`, `{"title":"b","tags":["x","y"],"meta":{"k":"v"}}`, nil},
+		{"add to array", `[{"op":"add","path":"/tags/1","value":"z"}]`, `{"title":"a","tags":["x","z","y"],"meta":{"k":"v"}}`, nil},
+		{"append to array", `[{"op":"add","path":"/tags/-","value":"z"}]`, `{"title":"a","tags":["x","y","z"],"meta":{"k":"v"}}`, nil},
+		{"remove", `[{"op":"remove","path":"/tags/0"}]`, `{"title":"a","tags":["y"],"meta":{"k":"v"}}`, nil},
+		{"move", `[{"op":"move","from":"/meta/k","path":"/title"}]`, `{"title":"v","tags":["x","y"],"meta":{}}`, nil},
+		{"copy", `[{"op":"copy","from":"/tags","path":"/meta/tags"}]`, `{"title":"a","tags":["x","y"],"meta":{"k":"v","tags":["x","y"]}}`, nil},
+		{"test passes", `[{"op":"test","path":"/title","value":"a"},{"op":"replace","path":"/title","value":"b"}]`, `{"title":"b","tags":["x","y"],"meta":{"k":"v"}}`, nil},
+		{"escaped pointer", `[{"op":"add","path":"/meta/a~1b~0c","value":1}]`, `{"title":"a","tags":["x","y"],"meta":{"k":"v","a/b~c":1}}`, nil},
+		{"empty patch", `[]`, doc, nil},
+		{"test fails", `[{"op":"test","path":"/title","value":"b"}]`, "", ErrTestFailed},
+		{"replace missing", `[{"op":"replace","path":"/missing","value":1}]`, "", ErrPathNotFound},
+		{"move into itself", `[{"op":"move","from":"/meta","path":"/meta/inner"}]`, "", ErrInvalidPatch},
+		{"unknown op", `[{"op":"merge","path":"/title","value":1}]`, "", ErrUnsupportedOp},
+		{"missing value", `[{"op":"add","path":"/title"}]`, "", ErrInvalidPatch},
+		{"bad pointer", `[{"op":"remove","path":"title"}]`, "", ErrInvalidPointer},
+		{"not an array", `{"op":"remove","path":"/title"}`, "", ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(doc), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package sanitize
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// allowedTags - разрешённые теги и их атрибуты. Всё остальное удаляется, текст внутри сохраняется
+var allowedTags = map[string]map[string]bool{
+	"a":          {"href": true, "title": true},
+	"abbr":       {"title": true},
+	"b":          {},
+	"blockquote": {"cite": true},
+	"br":         {},
+	"caption":    {},
+	"code":       {"class": true},
+	"dd":         {},
+	"del":        {"cite": true},
+	"details":    {"open": true},
+	"div":        {},
+	"dl":         {},
+	"dt":         {},
+	"em":         {},
+	"figcaption": {},
+	"figure":     {},
+	"h1":         {},
+	"h2":         {},
+	"h3":         {},
+	"h4":         {},
+	"h5":         {},
+	"h6":         {},
+	"hr":         {},
+	"i":          {},
+	"img":        {"src": true, "alt": true, "title": true, "width": true, "height": true},
+	"input":      {"type": true, "checked": true, "disabled": true},
+	"ins":        {"cite": true},
+	"kbd":        {},
+	"li":         {"class": true},
+	"mark":       {},
+	"ol":         {"start": true},
+	"p":          {},
+	"pre":        {},
+	"q":          {"cite": true},
+	"s":          {},
+	"samp":       {},
+	"small":      {},
+	"span":       {},
+	"strike":     {},
+	"strong":     {},
+	"sub":        {},
+	"summary":    {},
+	"sup":        {},
+	"table":      {},
+	"tbody":      {},
+	"td":         {"align": true, "colspan": true, "rowspan": true},
+	"tfoot":      {},
+	"th":         {"align": true, "colspan": true, "rowspan": true},
+	"thead":      {},
+	"tr":         {},
+	"u":          {},
+	"ul":         {},
+	"var":        {},
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true, "input": true}
+
+// droppedTags удаляются вместе с содержимым
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true,
+	"template": true, "textarea": true, "title": true, "svg": true, "math": true, "select": true,
+	"xmp": true, "noembed": true, "noframes": true, "frameset": true, "plaintext": true,
+}
+
+// urlAttrs - атрибуты со ссылками, в них допустимы только безопасные схемы
+var urlAttrs = map[string]bool{"href": true, "src": true, "cite": true}
+
+var (
+	reTagName       = regexp.MustCompile(`^</?([a-zA-Z][a-zA-Z0-9-]*)`)
+	reLanguageClass = regexp.MustCompile(`^language-[A-Za-z0-9_+#.-]+$`)
+	reNumber        = regexp.MustCompile(`^[0-9]{1,4}$`)
+)
+
+// HTML оставляет в html только разрешённые теги и атрибуты: скрипты, обработчики событий, стили
+// и ссылки с опасными схемами (javascript:, data: и т.п.) удаляются, незакрытые теги закрываются
+func HTML(src string) string {
+	s := &sanitizer{src: src}
+	s.run()
+	return s.out.String()
+}
+
+type sanitizer struct {
+	src   string
+	out   strings.Builder
+	stack []string
+}
+
+type attribute struct {
+	name  string
+	value string
+}
+
+func (s *sanitizer) run() {
+	src := s.src
+	for i := 0; i < len(src); {
+		lt := strings.IndexByte(src[i:], '<')
+		if lt < 0 {
+			s.text(src[i:])
+			break
+		}
+		s.text(src[i : i+lt])
+		i += lt
+
+		switch {
+		case strings.HasPrefix(src[i:], "<!--"):
+			// Комментарии удаляются
+			end := strings.Index(src[i+4:], "-->")
+			if end < 0 {
+				i = len(src)
+				continue
+			}
+			i += 4 + end + 3
+
+		case strings.HasPrefix(src[i:], "<!") || strings.HasPrefix(src[i:], "<?"):
+			i = skipTag(src, i)
+
+		case reTagName.MatchString(src[i:]):
+			i = s.tag(i)
+
+		default:
+			s.out.WriteString("&lt;")
+			i++
+		}
+	}
+
+	for len(s.stack) > 0 {
+		s.closeTop()
+	}
+}
+
+// text выводит текст, заново экранируя его
+func (s *sanitizer) text(t string) {
+	if t != "" {
+		s.out.WriteString(escape(html.UnescapeString(t)))
+	}
+}
+
+// tag разбирает тег, начинающийся с позиции i, и возвращает позицию после него
+func (s *sanitizer) tag(i int) int {
+	src := s.src
+	end := skipTag(src, i)
+	raw := src[i:end]
+	closing := strings.HasPrefix(raw, "</")
+	name := strings.ToLower(reTagName.FindStringSubmatch(raw)[1])
+
+	if closing {
+		s.closeTag(name)
+		return end
+	}
+
+	if droppedTags[name] {
+		if strings.HasSuffix(raw, "/>") {
+			return end
+		}
+		return skipElement(src, end, name)
+	}
+
+	allowed, ok := allowedTags[name]
+	if !ok {
+		return end
+	}
+
+	attrs := s.filterAttrs(name, parseAttrs(raw[len(name)+1:]), allowed)
+	if name == "input" {
+		// Из полей ввода остаётся только неактивный флажок списка задач
+		if !hasAttr(attrs, "type", "checkbox") {
+			return end
+		}
+		attrs = append(removeAttr(attrs, "disabled"), attribute{name: "disabled"})
+	}
+
+	s.out.WriteString("<" + name)
+	for _, a := range attrs {
+		s.out.WriteString(" " + a.name + `="` + escape(a.value) + `"`)
+	}
+	if name == "a" {
+		s.out.WriteString(` rel="nofollow noopener noreferrer"`)
+	}
+
+	if voidTags[name] {
+		s.out.WriteString(" />")
+		return end
+	}
+	s.out.WriteString(">")
+	s.stack = append(s.stack, name)
+	return end
+}
+
+func (s *sanitizer) filterAttrs(tag string, attrs []attribute, allowed map[string]bool) []attribute {
+	var result []attribute
+	seen := make(map[string]bool)
+	for _, a := range attrs {
+		if !allowed[a.name] || seen[a.name] {
+			continue
+		}
+		if urlAttrs[a.name] && !safeURL(a.value, tag == "img") {
+			continue
+		}
+
+		switch a.name {
+		case "class":
+			// У кода допустим только класс языка, у элемента списка — класс задачи
+			if tag == "code" && !reLanguageClass.MatchString(a.value) ||
+				tag == "li" && a.value != "task-list-item" {
+				continue
+			}
+		case "align":
+			if a.value != "left" && a.value != "center" && a.value != "right" {
+				continue
+			}
+		case "start", "colspan", "rowspan", "width", "height":
+			if !reNumber.MatchString(a.value) {
+				continue
+			}
+		case "type":
+			a.value = strings.ToLower(a.value)
+		case "checked", "disabled", "open":
+			a.value = ""
+		}
+
+		seen[a.name] = true
+		result = append(result, a)
+	}
+	return result
+}
+
+// closeTag закрывает тег name вместе со всеми незакрытыми тегами внутри него
+func (s *sanitizer) closeTag(name string) {
+	for i := len(s.stack) - 1; i >= 0; i-- {
+		if s.stack[i] == name {
+			for len(s.stack) > i {
+				s.closeTop()
+			}
+			return
+		}
+	}
+}
+
+func (s *sanitizer) closeTop() {
+	name := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	s.out.WriteString("</" + name + ">")
+}
+
+// skipTag возвращает позицию после '>' тега, учитывая кавычки в значениях атрибутов
+func skipTag(src string, i int) int {
+	var quote byte
+	for j := i + 1; j < len(src); j++ {
+		c := src[j]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return j + 1
+		}
+	}
+	return len(src)
+}
+
+// skipElement пропускает содержимое тега name до его закрывающего тега включительно
+func skipElement(src string, i int, name string) int {
+	for j := i; j+2+len(name) <= len(src); j++ {
+		if src[j] == '<' && src[j+1] == '/' && strings.EqualFold(src[j+2:j+2+len(name)], name) {
+			return skipTag(src, j)
+		}
+	}
+	return len(src)
+}
+
+// parseAttrs разбирает атрибуты из тела тега (после имени)
+func parseAttrs(s string) []attribute {
+	s = strings.TrimSuffix(s, ">")
+	var attrs []attribute
+	i := 0
+	for i < len(s) {
+		for i < len(s) && (isSpace(s[i]) || s[i] == '/') {
+			i++
+		}
+		start := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '/' && s[i] != '>' {
+			i++
+		}
+		if start == i {
+			i++
+			continue
+		}
+		a := attribute{name: strings.ToLower(s[start:i])}
+
+		j := i
+		for j < len(s) && isSpace(s[j]) {
+			j++
+		}
+		if j < len(s) && s[j] == '=' {
+			j++
+			for j < len(s) && isSpace(s[j]) {
+				j++
+			}
+			if j < len(s) && (s[j] == '"' || s[j] == '\'') {
+				q := s[j]
+				end := strings.IndexByte(s[j+1:], q)
+				if end < 0 {
+					end = len(s) - j - 1
+				}
+				a.value = s[j+1 : j+1+end]
+				i = j + 1 + end + 1
+			} else {
+				start := j
+				for j < len(s) && !isSpace(s[j]) && s[j] != '>' {
+					j++
+				}
+				a.value = s[start:j]
+				i = j
+			}
+			a.value = html.UnescapeString(a.value)
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// safeURL разрешает относительные ссылки и схемы http, https, mailto (у картинок — только http и https)
+func safeURL(raw string, image bool) bool {
+	// Браузеры игнорируют управляющие символы и пробелы внутри схемы ("java\tscript:")
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+
+	u, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https":
+		return true
+	case "mailto":
+		return !image
+	}
+	return false
+}
+
+func hasAttr(attrs []attribute, name, value string) bool {
+	for _, a := range attrs {
+		if a.name == name && a.value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeAttr(attrs []attribute, name string) []attribute {
+	result := attrs[:0]
+	for _, a := range attrs {
+		if a.name != name {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+var escaper = strings.NewReplacer(`&`, "&amp;", `<`, "&lt;", `>`, "&gt;", `"`, "&quot;", `'`, "&#39;")
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
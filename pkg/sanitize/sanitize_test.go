@@ -0,0 +1,91 @@
+package sanitize
+
+import "testing"
+
+const rel = ` rel="nofollow noopener noreferrer"`
+
+func TestHTMLURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"javascript", `<a href="javascript:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"mixed case scheme", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"tab inside scheme", "<a href=\"java\tscript:alert(1)\">x</a>", `<a` + rel + `>x</a>`},
+		{"newline inside scheme", "<a href=\"java\nscript:alert(1)\">x</a>", `<a` + rel + `>x</a>`},
+		{"leading space", `<a href=" javascript:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"decimal entity", `<a href="&#106;avascript:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"hex entities", `<a href="&#x6A;avascript&#x3A;alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"named colon entity", `<a href="javascript&colon;alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"encoded tab", `<a href="jav&#x09;ascript:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"unquoted", `<a href=javascript:alert(1)>x</a>`, `<a` + rel + `>x</a>`},
+		{"vbscript", `<a href="vbscript:msgbox(1)">x</a>`, `<a` + rel + `>x</a>`},
+		{"data link", `<a href="data:text/html,<script>alert(1)</script>">x</a>`, `<a` + rel + `>x</a>`},
+		{"data image", `<img src="data:image/png;base64,AAAA">`, `<img />`},
+		{"mailto image", `<img src="mailto:a@example.com">`, `<img />`},
+		{"https", `<a href="https://example.com">x</a>`, `<a href="https://example.com"` + rel + `>x</a>`},
+		{"relative", `<a href="/relative">x</a>`, `<a href="/relative"` + rel + `>x</a>`},
+		{"mailto", `<a href="mailto:a@example.com">x</a>`, `<a href="mailto:a@example.com"` + rel + `>x</a>`},
+	}
+	for _, tt := range tests {
+		if got := HTML(tt.in); got != tt.want {
+			t.Errorf("%s: HTML(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLTags(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"script dropped with content", `<script>alert(1)</script>after`, `after`},
+		{"uppercase script", `<SCRIPT>alert(1)</SCRIPT>after`, `after`},
+		{"unclosed script", `<script>alert(1)`, ``},
+		{"style", `<style>body{}</style>text`, `text`},
+		{"self-closing svg", `<svg/onload=alert(1)>`, ``},
+		{"iframe", `<iframe src="https://evil"></iframe>ok`, `ok`},
+		{"unclosed tag is closed", `<b>bold`, `<b>bold</b>`},
+		{"misnested tags", `<p><em>text</p>`, `<p><em>text</em></p>`},
+		{"stray closing tag", `</div>stray`, `stray`},
+		{"unknown tag keeps text", `<unknown>kept text</unknown>`, `kept text`},
+		{"tag inside tag", `<b <script>>x`, `<b>&gt;x</b>`},
+		{"comment", `<!-- comment -->x`, `x`},
+		{"unclosed comment", `<!-- unclosed comment`, ``},
+		{"text is escaped", `a < b && c > d`, `a &lt; b &amp;&amp; c &gt; d`},
+		{"entities stay escaped", `&lt;script&gt;`, `&lt;script&gt;`},
+	}
+	for _, tt := range tests {
+		if got := HTML(tt.in); got != tt.want {
+			t.Errorf("%s: HTML(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLAttributes(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"event handler", `<img src="x" onerror="alert(1)">`, `<img src="x" />`},
+		{"onclick on link", `<a href="https://example.com" onclick="alert(1)">x</a>`, `<a href="https://example.com"` + rel + `>x</a>`},
+		{"style and class", `<div style="color:red" class="x">x</div>`, `<div>x</div>`},
+		{"language class", `<code class="language-go">x</code>`, `<code class="language-go">x</code>`},
+		{"invalid language class", `<code class="language-go onmouseover">x</code>`, `<code>x</code>`},
+		{"task checkbox", `<li class="task-list-item"><input type="checkbox" checked>x</li>`,
+			`<li class="task-list-item"><input type="checkbox" checked="" disabled="" />x</li>`},
+		{"text input", `<input type="text" value="x">`, ``},
+		{"quotes in value", `<a href="x" title='a"b'>x</a>`, `<a href="x" title="a&quot;b"` + rel + `>x</a>`},
+		{"gt in value", `<a title="x>y" href="/">x</a>`, `<a title="x&gt;y" href="/"` + rel + `>x</a>`},
+		{"table cell", `<td align="center" colspan="2" rowspan="abc">x</td>`, `<td align="center" colspan="2">x</td>`},
+		{"image size", `<img src=x alt="a" title="t" width="10" height="1e3">`, `<img src="x" alt="a" title="t" width="10" />`},
+	}
+	for _, tt := range tests {
+		if got := HTML(tt.in); got != tt.want {
+			t.Errorf("%s: HTML(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret - ключ "12345678901234567890" из тестовых векторов RFC 4226 и RFC 6238 в base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC4226(t *testing.T) {
+	// RFC 4226, приложение D: HOTP для счётчиков 0..9
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		got, err := Code(rfcSecret, int64(counter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != code {
+			t.Errorf("counter %d: %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	// RFC 6238, приложение B (SHA1); в векторах 8 цифр, у нас 6 — последние 6 цифр
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.want[len(tt.want)-Digits:]; got != want {
+			t.Errorf("T=%d: %s, want %s", tt.unix, got, want)
+		}
+	}
+
+	// Секрет принимается в нижнем регистре и с пробелами по краям
+	if got, _ := Code(" "+strings.ToLower(rfcSecret)+" ", 1); got != "287082" {
+		t.Errorf("lowercase secret: %s", got)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	counter := Counter(now)
+	code := func(delta int64) string {
+		c, _ := Code(rfcSecret, counter+delta)
+		return c
+	}
+
+	tests := []struct {
+		name string
+		code string
+		ok   bool
+		want int64
+	}{
+		{"current", code(0), true, counter},
+		{"previous interval", code(-1), true, counter - 1},
+		{"next interval", code(1), true, counter + 1},
+		{"two intervals ago", code(-2), false, 0},
+		{"two intervals ahead", code(2), false, 0},
+		{"with spaces", " " + code(0) + " ", true, counter},
+		{"short", code(0)[1:], false, 0},
+		{"wrong", "000000", false, 0},
+	}
+	for _, tt := range tests {
+		got, ok := Validate(rfcSecret, tt.code, now)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: %d, %v; want %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if a == b || len(a) != 32 {
+		t.Errorf("secrets %q, %q", a, b)
+	}
+	if _, err := Code(a, 0); err != nil {
+		t.Errorf("generated secret: %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Notes API", "alice@example.com", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Notes API:alice@example.com" {
+		t.Errorf("uri: %s", u)
+	}
+	q := u.Query()
+	if q.Get("secret") != rfcSecret || q.Get("issuer") != "Notes API" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("query: %v", q)
+	}
+}
@@ -0,0 +1,147 @@
+package vault
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// field - поле front matter: одно значение у скаляра, несколько — у списка
+type field struct {
+	key    string
+	values []string
+}
+
+// splitFrontMatter отделяет YAML front matter (между строками "---" в начале файла) от текста.
+// Поддерживается подмножество YAML, которого хватает заметкам: скаляры, списки [a, b] и "- a",
+// многострочные значения "|" и ">". Вложенные объекты пропускаются
+func splitFrontMatter(text string) ([]field, string, error) {
+	lines := strings.Split(text, "\n")
+	if len(lines) == 0 || strings.TrimRight(lines[0], " \t") != "---" {
+		return nil, text, nil
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if line := strings.TrimRight(lines[i], " \t"); line == "---" || line == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, text, nil // нет закрывающей строки — это не front matter
+	}
+
+	var fields []field
+	var current *field
+	block := false // current - многострочное значение "|" или ">"
+	for i, line := range lines[1:end] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(line, "- ") || trimmed == "-" {
+			switch {
+			case current == nil:
+				return nil, "", fmt.Errorf("%w: line %d", ErrInvalidFrontMatter, i+2)
+			case block:
+				if len(current.values) == 0 {
+					current.values = []string{trimmed}
+				} else {
+					current.values[0] += " " + trimmed
+				}
+			case trimmed == "-" || strings.HasPrefix(trimmed, "- "):
+				current.values = append(current.values, scalar(strings.TrimPrefix(trimmed, "-")))
+			}
+			continue
+		}
+
+		colon := strings.Index(line, ":")
+		if colon <= 0 {
+			return nil, "", fmt.Errorf("%w: line %d", ErrInvalidFrontMatter, i+2)
+		}
+		fields = append(fields, field{key: strings.TrimSpace(line[:colon])})
+		current = &fields[len(fields)-1]
+		block = false
+
+		value := strings.TrimSpace(line[colon+1:])
+		switch {
+		case value == "":
+		case value[0] == '|' || value[0] == '>':
+			block = true
+		case value[0] == '[':
+			if !strings.HasSuffix(value, "]") {
+				return nil, "", fmt.Errorf("%w: line %d", ErrInvalidFrontMatter, i+2)
+			}
+			for _, item := range splitFlow(value[1 : len(value)-1]) {
+				if item = scalar(item); item != "" {
+					current.values = append(current.values, item)
+				}
+			}
+		default:
+			current.values = []string{scalar(value)}
+		}
+	}
+
+	return fields, strings.Join(lines[end+1:], "\n"), nil
+}
+
+// splitFlow делит содержимое [a, "b, c"] по запятым вне кавычек
+func splitFlow(s string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
+
+// scalar снимает с YAML скаляра кавычки, а с незакавыченного — комментарий " # ..."
+func scalar(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				if unquoted, err := strconv.Unquote(s[:i+1]); err == nil {
+					return unquoted
+				}
+				return s[1:i]
+			}
+		}
+	case '\'':
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return strings.ReplaceAll(s[1:i], "''", "'")
+		}
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
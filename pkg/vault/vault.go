@@ -0,0 +1,223 @@
+package vault
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxTitleLength - заголовок длиннее обрезается до этого числа байт (как у заметок)
+const MaxTitleLength = 255
+
+var (
+	ErrNotUTF8            = errors.New("file is not valid UTF-8 text")
+	ErrNULByte            = errors.New("file contains NUL bytes")
+	ErrInvalidFrontMatter = errors.New("invalid front matter")
+)
+
+// Note - заметка, прочитанная из markdown файла архива
+type Note struct {
+	Folders   []string // папки от корня архива до файла
+	Title     string
+	Content   string // текст без front matter и пустых строк в начале и конце
+	Tags      []string
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+}
+
+// Files возвращает markdown файлы архива, отсортированные по пути. Скрытые файлы и папки
+// (.obsidian, .trash), служебная папка macOS и файлы других типов пропускаются
+func Files(r *zip.Reader) []*zip.File {
+	var files []*zip.File
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(f.Name), ".md") {
+			continue
+		}
+		parts, ok := splitPath(f.Name)
+		if !ok {
+			continue
+		}
+		hidden := false
+		for _, part := range parts {
+			if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+				hidden = true
+				break
+			}
+		}
+		if !hidden {
+			files = append(files, f)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files
+}
+
+// UnpackedSize возвращает суммарный размер файлов после распаковки. Берётся из заголовков архива:
+// archive/zip не даёт прочитать из файла больше заявленного размера
+func UnpackedSize(files []*zip.File) uint64 {
+	var size uint64
+	for _, f := range files {
+		size += f.UncompressedSize64
+	}
+	return size
+}
+
+// splitPath разбивает путь файла в архиве на части; пути с ".." не принимаются
+func splitPath(name string) ([]string, bool) {
+	var parts []string
+	for _, part := range strings.Split(strings.ReplaceAll(name, "\\", "/"), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			return nil, false
+		}
+		parts = append(parts, part)
+	}
+	return parts, len(parts) > 0
+}
+
+// Parse читает заметку из файла name. Заголовок берётся из title во front matter,
+// затем из первого заголовка "# ..." вне блоков кода, затем из имени файла
+func Parse(name string, data []byte) (*Note, error) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	if !utf8.ValidString(text) {
+		return nil, ErrNotUTF8
+	}
+	if strings.IndexByte(text, 0) >= 0 {
+		return nil, ErrNULByte
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	// Имена файлов zip без флага UTF-8 приходят в кодировке архиватора
+	parts, ok := splitPath(name)
+	if !ok || !utf8.ValidString(name) || strings.IndexByte(name, 0) >= 0 {
+		return nil, fmt.Errorf("invalid file name %q", name)
+	}
+	note := &Note{Folders: parts[:len(parts)-1]}
+
+	fields, body, err := splitFrontMatter(text)
+	if err != nil {
+		return nil, err
+	}
+	note.Content = strings.TrimRight(strings.TrimLeft(body, "\n"), " \t\n")
+
+	if err := note.applyFrontMatter(fields); err != nil {
+		return nil, err
+	}
+
+	if note.Title == "" {
+		note.Title = firstHeading(note.Content)
+	}
+	if note.Title == "" {
+		file := parts[len(parts)-1]
+		note.Title = strings.TrimSpace(file[:len(file)-len(path.Ext(file))])
+	}
+	note.Title = truncate(note.Title, MaxTitleLength)
+
+	return note, nil
+}
+
+// applyFrontMatter переносит в заметку известные поля front matter, остальные игнорируются
+func (n *Note) applyFrontMatter(fields []field) error {
+	for _, f := range fields {
+		var err error
+		switch strings.ToLower(f.key) {
+		case "title":
+			if len(f.values) > 0 {
+				n.Title = strings.TrimSpace(f.values[0])
+			}
+		case "tags", "tag":
+			n.Tags = append(n.Tags, splitTags(f.values)...)
+		case "created", "created_at", "date":
+			n.CreatedAt, err = parseDate(f.key, f.values)
+		case "updated", "updated_at", "modified":
+			n.UpdatedAt, err = parseDate(f.key, f.values)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitTags разбирает теги Obsidian: список или строку "a, b" / "a b", с # в начале или без
+func splitTags(values []string) []string {
+	var tags []string
+	for _, v := range values {
+		for _, tag := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			if tag = strings.TrimLeft(tag, "#"); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// dateLayouts - форматы дат во front matter; даты без часового пояса считаются UTC
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseDate(key string, values []string) (*time.Time, error) {
+	if len(values) != 1 || values[0] == "" {
+		return nil, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, values[0]); err == nil {
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date in front matter field %q: %q", key, values[0])
+}
+
+var (
+	reHeading = regexp.MustCompile(`^ {0,3}#{1,6}[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	reFence   = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+)
+
+// firstHeading возвращает текст первого ATX заголовка вне блоков кода
+func firstHeading(content string) string {
+	fence := ""
+	for _, line := range strings.Split(content, "\n") {
+		if m := reFence.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case m[1][0] == fence[0] && len(m[1]) >= len(fence):
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		if m := reHeading.FindStringSubmatch(line); m != nil {
+			if title := strings.TrimSpace(m[1]); title != "" {
+				return title
+			}
+		}
+	}
+	return ""
+}
+
+// truncate обрезает строку до max байт, не разрывая символы
+func truncate(s string, max int) string {
+	for len(s) > max {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+	return strings.TrimSpace(s)
+}
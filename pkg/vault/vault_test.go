@@ -0,0 +1,172 @@
+package vault
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	t = t.UTC()
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		want *Note
+		err  error
+	}{
+		{
+			name: "title from file name",
+			file: "Ideas.md",
+			data: "just text\n",
+			want: &Note{Title: "Ideas", Content: "just text"},
+		},
+		{
+			name: "title from heading",
+			file: "work/projects/plan.md",
+			data: "\n# Project Plan #\n\ntext",
+			want: &Note{Folders: []string{"work", "projects"}, Title: "Project Plan", Content: "# Project Plan #\n\ntext"},
+		},
+		{
+			name: "heading in code ignored",
+			file: "a.md",
+			data: "```\n# not a title\n```\n## Real",
+			want: &Note{Title: "Real", Content: "```\n# not a title\n```\n## Real"},
+		},
+		{
+			name: "front matter",
+			file: "a.md",
+			data: "---\ntitle: From Front Matter\ntags: [go, \"#notes\"]\ncreated: 2023-01-02\nmodified: 2023-01-03T10:00:00+03:00\n---\n# Heading\nbody\n",
+			want: &Note{
+				Title:     "From Front Matter",
+				Content:   "# Heading\nbody",
+				Tags:      []string{"go", "notes"},
+				CreatedAt: date("2023-01-02T00:00:00Z"),
+				UpdatedAt: date("2023-01-03T07:00:00Z"),
+			},
+		},
+		{
+			name: "tag list and string",
+			file: "a.md",
+			data: "---\ntags:\n  - one\n  - two\ntag: \"three, #four\"\n---\ntext",
+			want: &Note{Title: "a", Content: "text", Tags: []string{"one", "two", "three", "four"}},
+		},
+		{
+			name: "unclosed front matter is text",
+			file: "a.md",
+			data: "---\ntitle: x\ntext",
+			want: &Note{Title: "a", Content: "---\ntitle: x\ntext"},
+		},
+		{
+			name: "bom and crlf",
+			file: "a.md",
+			data: "\ufeff# Title\r\ntext\r\n",
+			want: &Note{Title: "Title", Content: "# Title\ntext"},
+		},
+		{
+			name: "invalid date",
+			file: "a.md",
+			data: "---\ncreated: yesterday\n---\ntext",
+			err:  errors.New(`invalid date in front matter field "created": "yesterday"`),
+		},
+		{
+			name: "invalid front matter",
+			file: "a.md",
+			data: "---\n  - orphan\n---\ntext",
+			err:  ErrInvalidFrontMatter,
+		},
+		{
+			name: "not utf-8",
+			file: "a.md",
+			data: "text \xff",
+			err:  ErrNotUTF8,
+		},
+		{
+			name: "nul byte",
+			file: "a.md",
+			data: "te\x00xt",
+			err:  ErrNULByte,
+		},
+		{
+			name: "file name not utf-8",
+			file: "caf\xe9.md",
+			data: "text",
+			err:  errors.New(`invalid file name "caf\xe9.md"`),
+		},
+		{
+			name: "parent directory",
+			file: "../a.md",
+			data: "text",
+			err:  errors.New(`invalid file name "../a.md"`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.file, []byte(tt.data))
+			if tt.err != nil {
+				if err == nil || (!errors.Is(err, tt.err) && err.Error() != tt.err.Error()) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Файл в корне архива: пустой, но не nil список папок
+			if len(got.Folders) == 0 {
+				got.Folders = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{
+		"b.md", "a/c.MD", "a/", "image.png", ".obsidian/workspace.md",
+		"notes/.trash/old.md", "__MACOSX/a/._c.md", "../escape.md", "a/b.md",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("0123456789"))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := Files(reader)
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	want := []string{"a/b.md", "a/c.MD", "b.md"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Files = %v, want %v", names, want)
+	}
+	if size := UnpackedSize(files); size != 30 {
+		t.Errorf("UnpackedSize = %d, want 30", size)
+	}
+}
@@ -0,0 +1,102 @@
+package wikilink
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxTitleLength - ссылка длиннее максимального заголовка заметки ни на что указывать не может
+const MaxTitleLength = 255
+
+// Target - цель ссылки: заголовок заметки ([[Заголовок]]) или её id (note:42)
+type Target struct {
+	Title  string
+	NoteID int
+}
+
+// String возвращает ссылку в том виде, в каком она записывается в тексте
+func (t Target) String() string {
+	if t.NoteID > 0 {
+		return "note:" + strconv.Itoa(t.NoteID)
+	}
+	return "[[" + t.Title + "]]"
+}
+
+var (
+	reWikiLink = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+	reNoteID   = regexp.MustCompile(`\bnote:([1-9][0-9]{0,8})\b`)
+	reFence    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	reCodeSpan = regexp.MustCompile("`[^`\n]+`")
+)
+
+// Parse находит ссылки в тексте заметки: [[Заголовок]] (а также [[Заголовок|подпись]] и [[Заголовок#раздел]]
+// в стиле Obsidian) и note:42, в том числе в markdown ссылке [текст](note:42).
+// Ссылки внутри кода не учитываются. Повторы (заголовки без учёта регистра) убираются, порядок — по первому появлению
+func Parse(content string) []Target {
+	var targets []Target
+	seen := make(map[string]bool)
+	add := func(t Target) {
+		key := strings.ToLower(t.String())
+		if !seen[key] {
+			seen[key] = true
+			targets = append(targets, t)
+		}
+	}
+
+	for _, line := range stripCode(content) {
+		// Обе разновидности ищутся в одной строке, порядок восстанавливается по позиции
+		titles := reWikiLink.FindAllStringSubmatchIndex(line, -1)
+		ids := reNoteID.FindAllStringSubmatchIndex(line, -1)
+		for len(titles) > 0 || len(ids) > 0 {
+			if len(ids) == 0 || (len(titles) > 0 && titles[0][0] < ids[0][0]) {
+				m := titles[0]
+				titles = titles[1:]
+				if title := linkTitle(line[m[2]:m[3]]); title != "" {
+					add(Target{Title: title})
+				}
+				continue
+			}
+			m := ids[0]
+			ids = ids[1:]
+			id, _ := strconv.Atoi(line[m[2]:m[3]])
+			add(Target{NoteID: id})
+		}
+	}
+	return targets
+}
+
+// linkTitle выделяет заголовок из текста [[...]]: без подписи после '|' и раздела после '#'
+func linkTitle(text string) string {
+	if i := strings.IndexByte(text, '|'); i >= 0 {
+		text = text[:i]
+	}
+	if i := strings.IndexByte(text, '#'); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.TrimSpace(text)
+	if len(text) > MaxTitleLength {
+		return ""
+	}
+	return text
+}
+
+// stripCode возвращает строки текста без блоков кода ``` / ~~~ и с вырезанным `inline кодом`
+func stripCode(content string) []string {
+	var lines []string
+	fence := ""
+	for _, line := range strings.Split(content, "\n") {
+		if fence != "" {
+			if m := reFence.FindStringSubmatch(line); m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) {
+				fence = ""
+			}
+			continue
+		}
+		if m := reFence.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			continue
+		}
+		lines = append(lines, reCodeSpan.ReplaceAllString(line, " "))
+	}
+	return lines
+}
@@ -0,0 +1,54 @@
+package wikilink
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Target
+	}{
+		{"no links", "plain text", nil},
+		{"title", "see [[Project Plan]]", []Target{{Title: "Project Plan"}}},
+		{"alias and section", "[[Plan|the plan]] and [[Plan#Goals]]", []Target{{Title: "Plan"}}},
+		{"note id", "note:42 and [text](note:7)", []Target{{NoteID: 42}, {NoteID: 7}}},
+		{"order by position", "note:3 [[B]] note:1 [[A]]", []Target{{NoteID: 3}, {Title: "B"}, {NoteID: 1}, {Title: "A"}}},
+		{"duplicates ignore case", "[[Plan]] [[plan]] note:5 note:5", []Target{{Title: "Plan"}, {NoteID: 5}}},
+		{"inline code skipped", "`[[Code]]` [[Text]]", []Target{{Title: "Text"}}},
+		{"fenced code skipped", "```\n[[Code]]\nnote:1\n```\n[[After]]", []Target{{Title: "After"}}},
+		{"tilde fence", "~~~\n[[Code]]\n~~~\n[[After]]", []Target{{Title: "After"}}},
+		{"not a note id", "keynote:42 note:0 note:abc", nil},
+		{"empty title", "[[ ]] [[|alias]]", nil},
+		{"title too long", "[[" + strings.Repeat("a", MaxTitleLength+1) + "]]", nil},
+		{"no line breaks in title", "[[a\nb]]", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetString(t *testing.T) {
+	tests := []struct {
+		target Target
+		want   string
+	}{
+		{Target{Title: "Plan"}, "[[Plan]]"},
+		{Target{NoteID: 42}, "note:42"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.target.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}